@@ -27,9 +27,11 @@ var entryOptsPool = sync.Pool{
 			entryType:    base.Outbound,
 			batchCount:   1,
 			flag:         0,
+			origin:       "",
 			slotChain:    nil,
 			args:         nil,
 			attachments:  nil,
+			retainEntry:  false,
 		}
 	},
 }
@@ -40,9 +42,11 @@ type EntryOptions struct {
 	entryType    base.TrafficType
 	batchCount   uint32
 	flag         int32
+	origin       string
 	slotChain    *base.SlotChain
 	args         []interface{}
 	attachments  map[interface{}]interface{}
+	retainEntry  bool
 }
 
 func (o *EntryOptions) Reset() {
@@ -50,9 +54,11 @@ func (o *EntryOptions) Reset() {
 	o.entryType = base.Outbound
 	o.batchCount = 1
 	o.flag = 0
+	o.origin = ""
 	o.slotChain = nil
 	o.args = nil
 	o.attachments = nil
+	o.retainEntry = false
 }
 
 type EntryOption func(*EntryOptions)
@@ -93,6 +99,13 @@ func WithFlag(flag int32) EntryOption {
 	}
 }
 
+// WithOrigin sets the resource entry with the given origin, which identifies the caller of the resource.
+func WithOrigin(origin string) EntryOption {
+	return func(opts *EntryOptions) {
+		opts.origin = origin
+	}
+}
+
 // WithArgs sets the resource entry with the given additional parameters.
 func WithArgs(args ...interface{}) EntryOption {
 	return func(opts *EntryOptions) {
@@ -129,8 +142,25 @@ func WithAttachments(data map[interface{}]interface{}) EntryOption {
 	}
 }
 
+// WithRetainedEntry creates the resource entry without the pool, so that the entry could be retained and
+// exited any times after Exit(), at the cost of the allocations of the entry.
+func WithRetainedEntry() EntryOption {
+	return func(opts *EntryOptions) {
+		opts.retainEntry = true
+	}
+}
+
 // Entry is the basic API of Sentinel.
+//
+// The entry is taken from a pool and recycled once exited, so that the pass path allocates nothing. Exiting
+// the entry again is a no-op until it's reused by another Entry call, so it must not be used or retained after
+// Exit() returns. Use WithRetainedEntry if the entry outlives its Exit().
 func Entry(resource string, opts ...EntryOption) (*base.SentinelEntry, *base.BlockError) {
+	return entryWithDefaultChain(resource, GlobalSlotChain(), opts)
+}
+
+// entryWithDefaultChain performs the entry with the given slot chain if no slot chain is specified by the options.
+func entryWithDefaultChain(resource string, sc *base.SlotChain, opts []EntryOption) (*base.SentinelEntry, *base.BlockError) {
 	options := entryOptsPool.Get().(*EntryOptions)
 	defer func() {
 		options.Reset()
@@ -141,30 +171,35 @@ func Entry(resource string, opts ...EntryOption) (*base.SentinelEntry, *base.Blo
 		opt(options)
 	}
 	if options.slotChain == nil {
-		options.slotChain = GlobalSlotChain()
+		options.slotChain = sc
 	}
 	return entry(resource, options)
 }
 
 func entry(resource string, options *EntryOptions) (*base.SentinelEntry, *base.BlockError) {
-	rw := base.NewResourceWrapper(resource, options.resourceType, options.entryType)
 	sc := options.slotChain
 
 	if sc == nil {
-		return base.NewSentinelEntry(nil, rw, nil), nil
+		return base.NewSentinelEntry(nil, base.NewResourceWrapper(resource, options.resourceType, options.entryType), nil), nil
 	}
-	// Get context from pool.
+	// Get context from pool, the context (and the entry unless retained) is put back to pool in calling Exit().
 	ctx := sc.GetPooledContext()
-	ctx.Resource = rw
+	var e *base.SentinelEntry
+	if options.retainEntry {
+		e = base.NewSentinelEntry(ctx, base.NewResourceWrapper(resource, options.resourceType, options.entryType), sc)
+	} else {
+		e = base.NewPooledSentinelEntry(ctx, resource, options.resourceType, options.entryType, sc)
+	}
+	ctx.Resource = e.Resource()
 	ctx.Input.BatchCount = options.batchCount
 	ctx.Input.Flag = options.flag
+	ctx.Input.Origin = options.origin
 	if len(options.args) != 0 {
 		ctx.Input.Args = options.args
 	}
 	if len(options.attachments) != 0 {
 		ctx.Input.Attachments = options.attachments
 	}
-	e := base.NewSentinelEntry(ctx, rw, sc)
 	ctx.SetEntry(e)
 	r := sc.Entry(ctx)
 	if r == nil {
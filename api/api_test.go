@@ -109,6 +109,9 @@ func Test_entryWithArgsAndChainPass(t *testing.T) {
 	assert.Nil(t, b, "the entry should not be blocked")
 	assert.Equal(t, "abc", entry.Resource().Name())
 
+	entry.Exit()
+	// The entry is pooled by default, it's recycled once exited and exiting it again is a no-op.
+	assert.Nil(t, entry.Context())
 	entry.Exit()
 
 	ps1.AssertNumberOfCalls(t, "Prepare", 1)
@@ -119,6 +122,24 @@ func Test_entryWithArgsAndChainPass(t *testing.T) {
 	ssm.AssertNumberOfCalls(t, "OnCompleted", 1)
 }
 
+func Test_entryWithRetainedEntry(t *testing.T) {
+	sc := base.NewSlotChain()
+	ssm := &statisticSlotMock{}
+	sc.AddStatSlot(ssm)
+	ssm.On("OnEntryPassed", mock.Anything).Return()
+	ssm.On("OnCompleted", mock.Anything).Return()
+
+	entry, b := Entry("abc", WithSlotChain(sc), WithRetainedEntry())
+	assert.Nil(t, b, "the entry should not be blocked")
+	assert.Equal(t, "abc", entry.Resource().Name())
+
+	entry.Exit()
+	// The retained entry is not recycled, so it's still readable and exiting it again is a no-op.
+	assert.Equal(t, "abc", entry.Resource().Name())
+	entry.Exit()
+	ssm.AssertNumberOfCalls(t, "OnCompleted", 1)
+}
+
 func Test_entryWithArgsAndChainBlock(t *testing.T) {
 	sc := base.NewSlotChain()
 	ps1 := &prepareSlotMock{}
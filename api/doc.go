@@ -19,6 +19,17 @@
 //  2. api.InitWithConfig(confEntity *config.Entity), using customized config Entity to initialize.
 //  3. api.InitWithConfigFile(configPath string), using yaml file to initialize.
 //
+// api.Shutdown(ctx) stops all the background tasks and closes the data sources, after which Sentinel could be initialized again.
+//
+// The package-level APIs operate on the default instance. An independent instance owning its own rules,
+// statistics and slot chain could be created by api.New(confEntity), and used through instance.Entry(resource)
+// and the rule managers of the instance (e.g. instance.FlowRuleManager().LoadRules(rules)). The statistics of such
+// an instance aren't reported by the metric log, the transport or the metrics collector, and the instance should be
+// closed by instance.Close() once it's no longer used.
+//
+// The entry returned by api.Entry is taken from a pool and recycled once exited, so it must not be used or retained
+// after e.Exit(). The option api.WithRetainedEntry() creates an entry outliving its Exit() at the cost of the allocations.
+//
 // Here is the example code to use Sentinel:
 //
 //  import sentinel "github.com/alibaba/sentinel-golang/api"
@@ -15,13 +15,16 @@
 package api
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/alibaba/sentinel-golang/core/config"
 	"github.com/alibaba/sentinel-golang/core/log/metric"
 	"github.com/alibaba/sentinel-golang/core/system_metric"
+	"github.com/alibaba/sentinel-golang/ext/datasource"
 	"github.com/alibaba/sentinel-golang/util"
 	"github.com/pkg/errors"
+	"go.uber.org/multierr"
 )
 
 // Initialization func initialize the Sentinel's runtime environment, including:
@@ -116,6 +119,32 @@ func initCoreComponents() error {
 	return nil
 }
 
+// Shutdown stops all the background tasks started by the initialization (metric log, system statistic collectors
+// and the time ticker), flushes and closes the metric log writer, and closes all the initialized data sources.
+// Sentinel could be initialized again after Shutdown.
+// If ctx is done before the shutdown completes, ctx.Err() is returned and the shutdown goes on in background.
+func Shutdown(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- shutdownCoreComponents()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func shutdownCoreComponents() error {
+	// The data sources are closed first, so that no rules are updated during the shutdown.
+	err := datasource.CloseAll()
+	err = multierr.Append(err, metric.StopTask())
+	system_metric.StopCollectors()
+	util.StopTimeTicker()
+	return err
+}
+
 func initSentinel(configPath string) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -0,0 +1,61 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/alibaba/sentinel-golang/core/config"
+	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/alibaba/sentinel-golang/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShutdown(t *testing.T) {
+	logDir, err := ioutil.TempDir("", "sentinel-shutdown")
+	assert.Nil(t, err)
+	defer os.RemoveAll(logDir)
+	defer config.ResetGlobalConfig(config.NewDefaultConfig())
+
+	newConfig := func() *config.Entity {
+		conf := config.NewDefaultConfig()
+		conf.Sentinel.Log.Logger = logging.NewConsoleLogger()
+		conf.Sentinel.Log.Dir = logDir
+		conf.Sentinel.Log.Metric.FlushIntervalSec = 1
+		conf.Sentinel.Stat.System.CollectIntervalMs = 100
+		conf.Sentinel.UseCacheTime = true
+		return conf
+	}
+	goroutines := runtime.NumGoroutine()
+
+	for i := 0; i < 2; i++ {
+		assert.Nil(t, InitWithConfig(newConfig()))
+		assert.True(t, util.CurrentTimeMillsWithTicker() > 0)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		assert.Nil(t, Shutdown(ctx))
+		cancel()
+		assert.Equal(t, uint64(0), util.CurrentTimeMillsWithTicker())
+		// All the background goroutines should have exited.
+		assert.True(t, runtime.NumGoroutine() <= goroutines, "goroutines before: %d, after: %d", goroutines, runtime.NumGoroutine())
+	}
+	// Shutdown without initialization is a no-op.
+	assert.Nil(t, Shutdown(context.Background()))
+}
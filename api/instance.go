@@ -0,0 +1,163 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/circuitbreaker"
+	"github.com/alibaba/sentinel-golang/core/config"
+	"github.com/alibaba/sentinel-golang/core/flow"
+	"github.com/alibaba/sentinel-golang/core/hotspot"
+	"github.com/alibaba/sentinel-golang/core/isolation"
+	"github.com/alibaba/sentinel-golang/core/log"
+	"github.com/alibaba/sentinel-golang/core/stat"
+	"github.com/alibaba/sentinel-golang/core/system"
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+)
+
+// Instance is an independent Sentinel, which owns its own rule managers, statistic nodes and slot chain.
+// The rules loaded to an instance only take effect on the entries of the same instance.
+//
+// The package-level APIs (e.g. api.Entry, flow.LoadRules) operate on the default instance.
+// The statistic window, the logging and the metric log settings are process-wide,
+// which are still governed by the global config initialized through api.InitXXX.
+type Instance struct {
+	nodes *stat.NodeStorage
+
+	flowManager      *flow.RuleManager
+	cbManager        *circuitbreaker.RuleManager
+	isolationManager *isolation.RuleManager
+	hotspotManager   *hotspot.RuleManager
+	systemManager    *system.RuleManager
+
+	slotChain *base.SlotChain
+}
+
+var defaultInstance = newInstance(stat.DefaultNodeStorage(), flow.DefaultRuleManager(), circuitbreaker.DefaultRuleManager(),
+	isolation.DefaultRuleManager(), hotspot.DefaultRuleManager(), system.DefaultRuleManager())
+
+// New creates an independent Sentinel instance. The resource stat config of conf (e.g. the max amount of resources)
+// applies to the statistic nodes of the instance. If conf is nil, the instance follows the global config.
+//
+// The statistics of the instance are only visible through its NodeStorage: the metric log, the transport command
+// handlers and the metrics collector only report the statistics of the default instance.
+// The instance should be closed by Close once it's no longer used.
+func New(conf *config.Entity) (*Instance, error) {
+	if conf != nil {
+		if err := config.CheckValid(conf); err != nil {
+			return nil, errors.Wrap(err, "invalid config of Sentinel instance")
+		}
+	}
+	nodes := stat.NewNodeStorage(conf)
+	return newInstance(nodes, flow.NewRuleManager(nodes), circuitbreaker.NewRuleManager(nodes),
+		isolation.NewRuleManager(nodes), hotspot.NewRuleManager(nodes), system.NewRuleManager(nodes)), nil
+}
+
+func newInstance(nodes *stat.NodeStorage, flowManager *flow.RuleManager, cbManager *circuitbreaker.RuleManager,
+	isolationManager *isolation.RuleManager, hotspotManager *hotspot.RuleManager, systemManager *system.RuleManager) *Instance {
+	s := &Instance{
+		nodes:            nodes,
+		flowManager:      flowManager,
+		cbManager:        cbManager,
+		isolationManager: isolationManager,
+		hotspotManager:   hotspotManager,
+		systemManager:    systemManager,
+	}
+	s.slotChain = s.buildSlotChain()
+	return s
+}
+
+// Default returns the default instance, which the package-level APIs operate on.
+func Default() *Instance {
+	return defaultInstance
+}
+
+func (s *Instance) buildSlotChain() *base.SlotChain {
+	sc := base.NewSlotChain()
+	sc.AddStatPrepareSlot(stat.NewResourceNodePrepareSlot(s.nodes))
+
+	sc.AddRuleCheckSlot(system.NewAdaptiveSlot(s.systemManager))
+	sc.AddRuleCheckSlot(flow.NewSlot(s.flowManager))
+	sc.AddRuleCheckSlot(isolation.NewSlot(s.isolationManager))
+	sc.AddRuleCheckSlot(hotspot.NewSlot(s.hotspotManager))
+	sc.AddRuleCheckSlot(circuitbreaker.NewSlot(s.cbManager))
+
+	sc.AddStatSlot(stat.NewSlot(s.nodes))
+	sc.AddStatSlot(log.DefaultSlot)
+	sc.AddStatSlot(flow.NewStandaloneStatSlot(s.flowManager))
+	sc.AddStatSlot(hotspot.NewConcurrencyStatSlot(s.hotspotManager))
+	sc.AddStatSlot(circuitbreaker.NewMetricStatSlot(s.cbManager))
+	return sc
+}
+
+// Entry is the basic API of the instance, the same as api.Entry except that the slot chain of the instance is used
+// if no slot chain is specified by the options.
+func (s *Instance) Entry(resource string, opts ...EntryOption) (*base.SentinelEntry, *base.BlockError) {
+	return entryWithDefaultChain(resource, s.slotChain, opts)
+}
+
+// Close stops refreshing the rules of the instance in background, clears all the rules of its rule managers and
+// drops its statistic nodes. The instance must not be used any more then. The default instance can't be closed,
+// see api.Shutdown instead.
+func (s *Instance) Close() error {
+	if s == defaultInstance {
+		return errors.New("the default Sentinel instance can't be closed")
+	}
+	err := multierr.Combine(
+		s.flowManager.Close(),
+		s.cbManager.Close(),
+		s.isolationManager.Close(),
+		s.hotspotManager.Close(),
+		s.systemManager.Close(),
+	)
+	s.nodes.Reset()
+	return err
+}
+
+// SlotChain returns the slot chain of the instance.
+func (s *Instance) SlotChain() *base.SlotChain {
+	return s.slotChain
+}
+
+// NodeStorage returns the statistic nodes of the instance.
+func (s *Instance) NodeStorage() *stat.NodeStorage {
+	return s.nodes
+}
+
+// FlowRuleManager returns the flow rule manager of the instance.
+func (s *Instance) FlowRuleManager() *flow.RuleManager {
+	return s.flowManager
+}
+
+// CircuitBreakerRuleManager returns the circuit breaker rule manager of the instance.
+func (s *Instance) CircuitBreakerRuleManager() *circuitbreaker.RuleManager {
+	return s.cbManager
+}
+
+// IsolationRuleManager returns the isolation rule manager of the instance.
+func (s *Instance) IsolationRuleManager() *isolation.RuleManager {
+	return s.isolationManager
+}
+
+// HotspotRuleManager returns the hotspot param flow rule manager of the instance.
+func (s *Instance) HotspotRuleManager() *hotspot.RuleManager {
+	return s.hotspotManager
+}
+
+// SystemRuleManager returns the system rule manager of the instance.
+func (s *Instance) SystemRuleManager() *system.RuleManager {
+	return s.systemManager
+}
@@ -0,0 +1,450 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/config"
+	"github.com/alibaba/sentinel-golang/core/flow"
+	"github.com/alibaba/sentinel-golang/core/isolation"
+	"github.com/alibaba/sentinel-golang/core/stat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("InvalidConfig", func(t *testing.T) {
+		conf := config.NewDefaultConfig()
+		conf.Sentinel.App.Name = ""
+		s, err := New(conf)
+		assert.Error(t, err)
+		assert.Nil(t, s)
+	})
+
+	t.Run("IndependentInstances", func(t *testing.T) {
+		const res = "abc-instance"
+		s1, err := New(nil)
+		assert.NoError(t, err)
+		s2, err := New(config.NewDefaultConfig())
+		assert.NoError(t, err)
+		assert.NotEqual(t, Default(), s1)
+		assert.Equal(t, GlobalSlotChain(), Default().SlotChain())
+
+		_, err = s1.FlowRuleManager().LoadRules([]*flow.Rule{
+			{
+				Resource:               res,
+				TokenCalculateStrategy: flow.Direct,
+				ControlBehavior:        flow.Reject,
+				Threshold:              0,
+				StatIntervalInMs:       1000,
+			},
+		})
+		assert.NoError(t, err)
+		defer s1.FlowRuleManager().ClearRules()
+		assert.Equal(t, 1, len(s1.FlowRuleManager().GetRules()))
+		assert.Equal(t, 0, len(s2.FlowRuleManager().GetRules()))
+		assert.Equal(t, 0, len(flow.GetRulesOfResource(res)))
+
+		_, b := s1.Entry(res)
+		assert.NotNil(t, b)
+		assert.Equal(t, base.BlockTypeFlow, b.BlockType())
+
+		e, b := s2.Entry(res)
+		assert.Nil(t, b)
+		e.Exit()
+		e, b = Entry(res)
+		assert.Nil(t, b)
+		e.Exit()
+
+		assert.Equal(t, int64(1), s1.NodeStorage().GetResourceNode(res).TotalCount(base.MetricEventBlock))
+		assert.Equal(t, int64(1), s2.NodeStorage().GetResourceNode(res).TotalCount(base.MetricEventPass))
+		assert.Equal(t, int64(0), s2.NodeStorage().GetResourceNode(res).TotalCount(base.MetricEventBlock))
+		assert.Equal(t, int64(1), stat.GetResourceNode(res).TotalCount(base.MetricEventPass))
+	})
+}
+
+func TestInstanceClose(t *testing.T) {
+	const res = "abc-instance-close"
+	s, err := New(nil)
+	assert.NoError(t, err)
+	_, err = s.FlowRuleManager().LoadRules([]*flow.Rule{{Resource: res, Threshold: 0}})
+	assert.NoError(t, err)
+	_, err = s.IsolationRuleManager().LoadRules([]*isolation.Rule{{Resource: res, MetricType: isolation.Concurrency, Threshold: 1}})
+	assert.NoError(t, err)
+	_, b := s.Entry(res)
+	assert.NotNil(t, b)
+
+	assert.NoError(t, s.Close())
+	assert.Equal(t, 0, len(s.FlowRuleManager().GetRules()))
+	assert.Equal(t, 0, len(s.IsolationRuleManager().GetRules()))
+	assert.Equal(t, 0, len(s.NodeStorage().ResourceNodeList()))
+
+	assert.Error(t, Default().Close())
+}
+
+func TestInstanceShadowMode(t *testing.T) {
+	const res = "abc-instance-shadow"
+	s, err := New(nil)
+	assert.NoError(t, err)
+	_, err = s.FlowRuleManager().LoadRules([]*flow.Rule{
+		{
+			Resource:               res,
+			TokenCalculateStrategy: flow.Direct,
+			ControlBehavior:        flow.Reject,
+			Threshold:              0,
+			Mode:                   base.RuleModeShadow,
+		},
+	})
+	assert.NoError(t, err)
+	_, err = s.IsolationRuleManager().LoadRules([]*isolation.Rule{
+		{
+			Resource:   res,
+			MetricType: isolation.Concurrency,
+			Threshold:  1,
+			Mode:       base.RuleModeShadow,
+		},
+	})
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		e, b := s.Entry(res, WithBatchCount(2))
+		assert.Nil(t, b)
+		assert.Equal(t, 2, len(e.Context().ShadowBlocks()))
+		e.Exit()
+	}
+
+	node := s.NodeStorage().GetResourceNode(res)
+	assert.Equal(t, int64(6), node.TotalCount(base.MetricEventPass))
+	assert.Equal(t, int64(0), node.TotalCount(base.MetricEventBlock))
+	assert.Equal(t, int64(6), node.TotalShadowBlockCount(base.BlockTypeFlow))
+	assert.Equal(t, int64(6), node.TotalShadowBlockCount(base.BlockTypeIsolation))
+}
+
+func TestInstancePatternRules(t *testing.T) {
+	s, err := New(nil)
+	assert.NoError(t, err)
+	_, err = s.FlowRuleManager().LoadRules([]*flow.Rule{
+		{
+			Resource:               "abc-pattern-",
+			ResourceMatchStrategy:  base.ResourceMatchPrefix,
+			TokenCalculateStrategy: flow.Direct,
+			ControlBehavior:        flow.Reject,
+			Threshold:              1,
+			StatIntervalInMs:       10000,
+		},
+		{
+			Resource:               "abc-shared-*",
+			ResourceMatchStrategy:  base.ResourceMatchGlob,
+			SharedStat:             true,
+			TokenCalculateStrategy: flow.Direct,
+			ControlBehavior:        flow.Reject,
+			Threshold:              1,
+			StatIntervalInMs:       10000,
+		},
+	})
+	assert.NoError(t, err)
+
+	// Each matched resource is limited separately.
+	for _, res := range []string{"abc-pattern-1", "abc-pattern-2"} {
+		e, b := s.Entry(res)
+		assert.Nil(t, b)
+		e.Exit()
+		_, b = s.Entry(res)
+		assert.NotNil(t, b)
+		assert.Equal(t, base.BlockTypeFlow, b.BlockType())
+	}
+
+	// All the matched resources share the same limit.
+	e, b := s.Entry("abc-shared-1")
+	assert.Nil(t, b)
+	e.Exit()
+	_, b = s.Entry("abc-shared-2")
+	assert.NotNil(t, b)
+	assert.Equal(t, base.BlockTypeFlow, b.BlockType())
+}
+
+func TestInstanceGroupRules(t *testing.T) {
+	s, err := New(nil)
+	assert.NoError(t, err)
+	_, err = s.FlowRuleManager().LoadRules([]*flow.Rule{
+		{
+			Resource:               "abc-group",
+			MemberResources:        []string{"abc-group-1", "abc-group-2"},
+			TokenCalculateStrategy: flow.Direct,
+			ControlBehavior:        flow.Reject,
+			Threshold:              3,
+			StatIntervalInMs:       10000,
+		},
+		{
+			Resource:               "abc-group-1",
+			TokenCalculateStrategy: flow.Direct,
+			ControlBehavior:        flow.Reject,
+			Threshold:              1,
+			StatIntervalInMs:       10000,
+		},
+	})
+	assert.NoError(t, err)
+
+	// The own rule of the member still takes effect.
+	e, b := s.Entry("abc-group-1")
+	assert.Nil(t, b)
+	e.Exit()
+	_, b = s.Entry("abc-group-1")
+	assert.NotNil(t, b)
+	assert.Equal(t, "abc-group-1", b.TriggeredRule().ResourceName())
+
+	// The members share the group limit.
+	for i := 0; i < 2; i++ {
+		e, b = s.Entry("abc-group-2")
+		assert.Nil(t, b)
+		e.Exit()
+	}
+	_, b = s.Entry("abc-group-2")
+	assert.NotNil(t, b)
+	assert.Equal(t, "abc-group", b.TriggeredRule().ResourceName())
+}
+
+func TestInstanceFairShare(t *testing.T) {
+	s, err := New(nil)
+	assert.NoError(t, err)
+	_, err = s.FlowRuleManager().LoadRules([]*flow.Rule{
+		{
+			Resource:               "abc-fair",
+			TokenCalculateStrategy: flow.Direct,
+			ControlBehavior:        flow.FairShare,
+			Threshold:              4,
+			StatIntervalInMs:       10000,
+		},
+	})
+	assert.NoError(t, err)
+
+	e, b := s.Entry("abc-fair", WithOrigin("quiet"))
+	assert.Nil(t, b)
+	e.Exit()
+	// The noisy caller gets its fair share and the remaining share of the quiet caller is reserved.
+	passed := 0
+	for i := 0; i < 10; i++ {
+		if e, b = s.Entry("abc-fair", WithOrigin("noisy")); b == nil {
+			passed++
+			e.Exit()
+		}
+	}
+	assert.Equal(t, 2, passed)
+	e, b = s.Entry("abc-fair", WithOrigin("quiet"))
+	assert.Nil(t, b)
+	e.Exit()
+	_, b = s.Entry("abc-fair", WithOrigin("quiet"))
+	assert.NotNil(t, b)
+
+	t.Run("RollbackBlocked", func(t *testing.T) {
+		_, err := s.FlowRuleManager().LoadRules([]*flow.Rule{
+			{
+				Resource:               "abc-fair-rollback",
+				TokenCalculateStrategy: flow.Direct,
+				ControlBehavior:        flow.FairShare,
+				Threshold:              2,
+				StatIntervalInMs:       10000,
+			},
+		})
+		assert.NoError(t, err)
+		_, err = s.IsolationRuleManager().LoadRules([]*isolation.Rule{
+			{Resource: "abc-fair-rollback", MetricType: isolation.Concurrency, Threshold: 1},
+		})
+		assert.NoError(t, err)
+
+		held, b := s.Entry("abc-fair-rollback", WithOrigin("a"))
+		assert.Nil(t, b)
+		// The entries blocked by the isolation rule don't consume the fair share.
+		for i := 0; i < 5; i++ {
+			_, b = s.Entry("abc-fair-rollback", WithOrigin("a"))
+			assert.NotNil(t, b)
+		}
+		held.Exit()
+		e, b := s.Entry("abc-fair-rollback", WithOrigin("a"))
+		assert.Nil(t, b)
+		e.Exit()
+	})
+}
+
+func TestInstanceReservations(t *testing.T) {
+	s, err := New(nil)
+	assert.NoError(t, err)
+
+	t.Run("Flow", func(t *testing.T) {
+		_, err := s.FlowRuleManager().LoadRules([]*flow.Rule{
+			{
+				Resource:               "abc-reserved",
+				TokenCalculateStrategy: flow.Direct,
+				ControlBehavior:        flow.Reject,
+				Threshold:              10,
+				StatIntervalInMs:       10000,
+				Reservations:           []*base.Reservation{{Flag: 1, Ratio: 0.2}},
+			},
+		})
+		assert.NoError(t, err)
+
+		passCount := func(opts ...EntryOption) int {
+			passed := 0
+			for i := 0; i < 20; i++ {
+				if e, b := s.Entry("abc-reserved", opts...); b == nil {
+					passed++
+					e.Exit()
+				}
+			}
+			return passed
+		}
+		// The normal traffic never consumes the reserve.
+		assert.Equal(t, 8, passCount())
+		assert.Equal(t, 2, passCount(WithFlag(1)))
+	})
+
+	t.Run("FlowRollbackBlocked", func(t *testing.T) {
+		_, err := s.FlowRuleManager().LoadRules([]*flow.Rule{
+			{
+				Resource:               "abc-reserved-rollback",
+				TokenCalculateStrategy: flow.Direct,
+				ControlBehavior:        flow.Reject,
+				Threshold:              10,
+				StatIntervalInMs:       10000,
+				Reservations:           []*base.Reservation{{Flag: 1, Ratio: 0.2}},
+			},
+		})
+		assert.NoError(t, err)
+		_, err = s.IsolationRuleManager().LoadRules([]*isolation.Rule{
+			{Resource: "abc-reserved-rollback", MetricType: isolation.Concurrency, Threshold: 1},
+		})
+		assert.NoError(t, err)
+
+		held, b := s.Entry("abc-reserved-rollback")
+		assert.Nil(t, b)
+		// The reserved entries blocked by the isolation rule don't consume the reserve.
+		for i := 0; i < 5; i++ {
+			_, b = s.Entry("abc-reserved-rollback", WithFlag(1))
+			assert.NotNil(t, b)
+		}
+		held.Exit()
+		passed := 0
+		for i := 0; i < 20; i++ {
+			if e, b := s.Entry("abc-reserved-rollback"); b == nil {
+				passed++
+				e.Exit()
+			}
+		}
+		assert.Equal(t, 7, passed)
+	})
+
+	t.Run("Isolation", func(t *testing.T) {
+		_, err := s.IsolationRuleManager().LoadRules([]*isolation.Rule{
+			{
+				Resource:     "abc-reserved-concurrency",
+				MetricType:   isolation.Concurrency,
+				Threshold:    5,
+				Reservations: []*base.Reservation{{Origin: "health", Ratio: 0.4}},
+			},
+		})
+		assert.NoError(t, err)
+
+		entries := make([]*base.SentinelEntry, 0)
+		for i := 0; i < 3; i++ {
+			e, b := s.Entry("abc-reserved-concurrency")
+			assert.Nil(t, b)
+			entries = append(entries, e)
+		}
+		_, b := s.Entry("abc-reserved-concurrency")
+		assert.NotNil(t, b)
+		for i := 0; i < 2; i++ {
+			e, b := s.Entry("abc-reserved-concurrency", WithOrigin("health"))
+			assert.Nil(t, b)
+			entries = append(entries, e)
+		}
+		_, b = s.Entry("abc-reserved-concurrency", WithOrigin("health"))
+		assert.NotNil(t, b)
+
+		// The reserve is released on exit.
+		entries[len(entries)-1].Exit()
+		_, b = s.Entry("abc-reserved-concurrency")
+		assert.NotNil(t, b)
+		e, b := s.Entry("abc-reserved-concurrency", WithOrigin("health"))
+		assert.Nil(t, b)
+		e.Exit()
+		for _, e := range entries[:len(entries)-1] {
+			e.Exit()
+		}
+	})
+}
+
+func TestInstanceConditionalRules(t *testing.T) {
+	s, err := New(nil)
+	assert.NoError(t, err)
+
+	t.Run("Flow", func(t *testing.T) {
+		_, err := s.FlowRuleManager().LoadRules([]*flow.Rule{
+			{
+				Resource:               "abc-conditional",
+				TokenCalculateStrategy: flow.Direct,
+				ControlBehavior:        flow.Reject,
+				Threshold:              5,
+				StatIntervalInMs:       10000,
+				Conditions: []*base.MatchCondition{
+					{Source: base.ConditionSourceAttachment, Key: "region", Operator: base.ConditionOpEquals, Values: []string{"eu"}},
+				},
+			},
+		})
+		assert.NoError(t, err)
+
+		passCount := func(opts ...EntryOption) int {
+			passed := 0
+			for i := 0; i < 10; i++ {
+				if e, b := s.Entry("abc-conditional", opts...); b == nil {
+					passed++
+					e.Exit()
+				}
+			}
+			return passed
+		}
+		// The traffic not matching the conditions is neither limited nor counted.
+		assert.Equal(t, 10, passCount(WithAttachment("region", "us")))
+		assert.Equal(t, 5, passCount(WithAttachment("region", "eu")))
+		assert.Equal(t, 10, passCount())
+	})
+
+	t.Run("Isolation", func(t *testing.T) {
+		_, err := s.IsolationRuleManager().LoadRules([]*isolation.Rule{
+			{
+				Resource:   "abc-conditional-concurrency",
+				MetricType: isolation.Concurrency,
+				Threshold:  1,
+				Conditions: []*base.MatchCondition{
+					{Source: base.ConditionSourceArg, Index: 0, Operator: base.ConditionOpIn, Values: []string{"free", "trial"}},
+				},
+			},
+		})
+		assert.NoError(t, err)
+
+		e1, b := s.Entry("abc-conditional-concurrency", WithArgs("free"))
+		assert.Nil(t, b)
+		e2, b := s.Entry("abc-conditional-concurrency", WithArgs("paid"))
+		assert.Nil(t, b)
+		_, b = s.Entry("abc-conditional-concurrency", WithArgs("trial"))
+		assert.NotNil(t, b)
+
+		e1.Exit()
+		e3, b := s.Entry("abc-conditional-concurrency", WithArgs("trial"))
+		assert.Nil(t, b)
+		e2.Exit()
+		e3.Exit()
+	})
+}
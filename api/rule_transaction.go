@@ -0,0 +1,167 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/circuitbreaker"
+	"github.com/alibaba/sentinel-golang/core/event"
+	"github.com/alibaba/sentinel-golang/core/flow"
+	"github.com/alibaba/sentinel-golang/core/hotspot"
+	"github.com/alibaba/sentinel-golang/core/isolation"
+	"github.com/alibaba/sentinel-golang/core/system"
+	"github.com/pkg/errors"
+)
+
+// The modules staged in a rule transaction are prepared and locked in this order,
+// so that the concurrent transactions never deadlock.
+const (
+	txFlow = iota
+	txIsolation
+	txHotspot
+	txCircuitBreaker
+	txSystem
+	txModuleSize
+)
+
+type stagedRules struct {
+	module   string
+	validate func() []base.InvalidRule
+	prepare  func() (base.PreparedRuleUpdate, error)
+}
+
+// RuleTransaction replaces the rules of multiple modules at once, e.g. the flow, circuit breaker and system rules
+// of a release, so the traffic never sees a mix of the old and the new rules.
+// The rules staged by the LoadXXXRules methods replace all the previous rules of their modules on Commit,
+// the modules not staged are left as they are.
+type RuleTransaction struct {
+	instance *Instance
+	staged   [txModuleSize]*stagedRules
+}
+
+// NewRuleTransaction creates a rule transaction on the default instance.
+func NewRuleTransaction() *RuleTransaction {
+	return defaultInstance.NewRuleTransaction()
+}
+
+// NewRuleTransaction creates a rule transaction on the rule managers of the instance.
+func (s *Instance) NewRuleTransaction() *RuleTransaction {
+	return &RuleTransaction{instance: s}
+}
+
+// LoadFlowRules stages the flow rules, which replace all the previous flow rules on Commit.
+func (t *RuleTransaction) LoadFlowRules(rules []*flow.Rule) *RuleTransaction {
+	rules = append([]*flow.Rule(nil), rules...)
+	t.staged[txFlow] = &stagedRules{
+		module: event.ModuleFlow,
+		validate: func() []base.InvalidRule {
+			return flow.ValidateRules(rules)
+		},
+		prepare: func() (base.PreparedRuleUpdate, error) {
+			return t.instance.flowManager.PrepareRules(rules)
+		},
+	}
+	return t
+}
+
+// LoadIsolationRules stages the isolation rules, which replace all the previous isolation rules on Commit.
+func (t *RuleTransaction) LoadIsolationRules(rules []*isolation.Rule) *RuleTransaction {
+	rules = append([]*isolation.Rule(nil), rules...)
+	t.staged[txIsolation] = &stagedRules{
+		module: event.ModuleIsolation,
+		validate: func() []base.InvalidRule {
+			return isolation.ValidateRules(rules)
+		},
+		prepare: func() (base.PreparedRuleUpdate, error) {
+			return t.instance.isolationManager.PrepareRules(rules)
+		},
+	}
+	return t
+}
+
+// LoadHotspotRules stages the hotspot param flow rules, which replace all the previous hotspot rules on Commit.
+func (t *RuleTransaction) LoadHotspotRules(rules []*hotspot.Rule) *RuleTransaction {
+	rules = append([]*hotspot.Rule(nil), rules...)
+	t.staged[txHotspot] = &stagedRules{
+		module: event.ModuleHotspot,
+		validate: func() []base.InvalidRule {
+			return hotspot.ValidateRules(rules)
+		},
+		prepare: func() (base.PreparedRuleUpdate, error) {
+			return t.instance.hotspotManager.PrepareRules(rules)
+		},
+	}
+	return t
+}
+
+// LoadCircuitBreakerRules stages the circuit breaker rules, which replace all the previous circuit breaker rules on Commit.
+func (t *RuleTransaction) LoadCircuitBreakerRules(rules []*circuitbreaker.Rule) *RuleTransaction {
+	rules = append([]*circuitbreaker.Rule(nil), rules...)
+	t.staged[txCircuitBreaker] = &stagedRules{
+		module: event.ModuleCircuitBreaker,
+		validate: func() []base.InvalidRule {
+			return circuitbreaker.ValidateRules(rules)
+		},
+		prepare: func() (base.PreparedRuleUpdate, error) {
+			return t.instance.cbManager.PrepareRules(rules)
+		},
+	}
+	return t
+}
+
+// LoadSystemRules stages the system rules, which replace all the previous system rules on Commit.
+func (t *RuleTransaction) LoadSystemRules(rules []*system.Rule) *RuleTransaction {
+	rules = append([]*system.Rule(nil), rules...)
+	t.staged[txSystem] = &stagedRules{
+		module: event.ModuleSystem,
+		validate: func() []base.InvalidRule {
+			return system.ValidateRules(rules)
+		},
+		prepare: func() (base.PreparedRuleUpdate, error) {
+			return t.instance.systemManager.PrepareRules(rules)
+		},
+	}
+	return t
+}
+
+// Commit validates all the staged rules, builds the traffic controllers of them and swaps them into the rule managers
+// in one step. If any rule is invalid, none of the rules is applied and *base.RuleValidationError reporting all
+// the invalid rules is returned.
+func (t *RuleTransaction) Commit() error {
+	var invalidRules []base.InvalidRule
+	for _, s := range t.staged {
+		if s != nil {
+			invalidRules = append(invalidRules, s.validate()...)
+		}
+	}
+	if len(invalidRules) > 0 {
+		return &base.RuleValidationError{InvalidRules: invalidRules}
+	}
+
+	updates := make([]base.PreparedRuleUpdate, 0, txModuleSize)
+	for _, s := range t.staged {
+		if s == nil {
+			continue
+		}
+		u, err := s.prepare()
+		if err != nil {
+			base.DiscardRuleUpdates(updates...)
+			return errors.Wrapf(err, "fail to build the %s rules", s.module)
+		}
+		updates = append(updates, u)
+	}
+	base.ApplyRuleUpdates(updates...)
+	return nil
+}
@@ -0,0 +1,118 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/circuitbreaker"
+	"github.com/alibaba/sentinel-golang/core/flow"
+	"github.com/alibaba/sentinel-golang/core/hotspot"
+	"github.com/alibaba/sentinel-golang/core/system"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuleTransaction(t *testing.T) {
+	const res = "abc-transaction"
+	flowRule := &flow.Rule{
+		Resource:               res,
+		TokenCalculateStrategy: flow.Direct,
+		ControlBehavior:        flow.Reject,
+		Threshold:              10,
+		StatIntervalInMs:       1000,
+	}
+	cbRule := &circuitbreaker.Rule{
+		Resource:         res,
+		Strategy:         circuitbreaker.ErrorCount,
+		RetryTimeoutMs:   3000,
+		MinRequestAmount: 10,
+		StatIntervalMs:   10000,
+		Threshold:        1.0,
+	}
+	hotspotRule := &hotspot.Rule{
+		Resource:        res,
+		MetricType:      hotspot.QPS,
+		ControlBehavior: hotspot.Reject,
+		ParamIndex:      0,
+		Threshold:       10,
+		DurationInSec:   1,
+	}
+	systemRule := &system.Rule{
+		MetricType:   system.InboundQPS,
+		TriggerCount: 100,
+		Strategy:     system.NoAdaptive,
+	}
+
+	t.Run("Commit", func(t *testing.T) {
+		s, err := New(nil)
+		assert.NoError(t, err)
+		err = s.NewRuleTransaction().
+			LoadFlowRules([]*flow.Rule{flowRule}).
+			LoadCircuitBreakerRules([]*circuitbreaker.Rule{cbRule}).
+			LoadHotspotRules([]*hotspot.Rule{hotspotRule}).
+			LoadSystemRules([]*system.Rule{systemRule}).
+			Commit()
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(s.FlowRuleManager().GetRules()))
+		assert.Equal(t, 1, len(s.CircuitBreakerRuleManager().GetRules()))
+		assert.Equal(t, 1, len(s.HotspotRuleManager().GetRules()))
+		assert.Equal(t, 1, len(s.SystemRuleManager().GetRules()))
+		assert.Equal(t, 0, len(flow.GetRulesOfResource(res)))
+
+		// The modules not staged are left as they are.
+		err = s.NewRuleTransaction().LoadFlowRules(nil).Commit()
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(s.FlowRuleManager().GetRules()))
+		assert.Equal(t, 1, len(s.CircuitBreakerRuleManager().GetRules()))
+		assert.Equal(t, 1, len(s.HotspotRuleManager().GetRules()))
+		assert.Equal(t, 1, len(s.SystemRuleManager().GetRules()))
+
+		// The rule managers are released after the transaction.
+		_, err = s.FlowRuleManager().LoadRules([]*flow.Rule{flowRule})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(s.FlowRuleManager().GetRules()))
+	})
+
+	t.Run("InvalidRules", func(t *testing.T) {
+		s, err := New(nil)
+		assert.NoError(t, err)
+		_, err = s.FlowRuleManager().LoadRules([]*flow.Rule{flowRule})
+		assert.NoError(t, err)
+
+		invalidCbRule := *cbRule
+		invalidCbRule.Threshold = -1
+		err = s.NewRuleTransaction().
+			LoadFlowRules([]*flow.Rule{flowRule, nil}).
+			LoadCircuitBreakerRules([]*circuitbreaker.Rule{cbRule, &invalidCbRule}).
+			LoadHotspotRules([]*hotspot.Rule{hotspotRule}).
+			Commit()
+		assert.Error(t, err)
+		validationErr, ok := err.(*base.RuleValidationError)
+		assert.True(t, ok)
+		assert.Equal(t, 2, len(validationErr.InvalidRules))
+		assert.Equal(t, "flow", validationErr.InvalidRules[0].Module)
+		assert.Equal(t, 1, validationErr.InvalidRules[0].Index)
+		assert.Nil(t, validationErr.InvalidRules[0].Rule)
+		assert.Equal(t, "circuitbreaker", validationErr.InvalidRules[1].Module)
+		assert.Equal(t, 1, validationErr.InvalidRules[1].Index)
+		assert.Equal(t, &invalidCbRule, validationErr.InvalidRules[1].Rule)
+
+		// Nothing is applied.
+		assert.Equal(t, 1, len(s.FlowRuleManager().GetRules()))
+		assert.Equal(t, 0, len(s.CircuitBreakerRuleManager().GetRules()))
+		assert.Equal(t, 0, len(s.HotspotRuleManager().GetRules()))
+	})
+}
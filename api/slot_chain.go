@@ -25,12 +25,13 @@ import (
 	"github.com/alibaba/sentinel-golang/core/system"
 )
 
-var globalSlotChain = BuildDefaultSlotChain()
+var globalSlotChain = defaultInstance.SlotChain()
 
 func GlobalSlotChain() *base.SlotChain {
 	return globalSlotChain
 }
 
+// BuildDefaultSlotChain builds a new slot chain of the default slots, which operate on the default instance.
 func BuildDefaultSlotChain() *base.SlotChain {
 	sc := base.NewSlotChain()
 	sc.AddStatPrepareSlot(stat.DefaultResourceNodePrepareSlot)
@@ -0,0 +1,273 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package base
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/alibaba/sentinel-golang/util"
+	"github.com/pkg/errors"
+)
+
+// ConditionSource indicates where the value checked by a MatchCondition comes from.
+type ConditionSource uint8
+
+const (
+	// ConditionSourceAttachment checks the attachment of the entry (see api.WithAttachment) with the Key.
+	ConditionSourceAttachment ConditionSource = iota
+	// ConditionSourceArg checks the argument of the entry (see api.WithArgs) at the Index.
+	ConditionSourceArg
+)
+
+func (s ConditionSource) String() string {
+	switch s {
+	case ConditionSourceAttachment:
+		return "Attachment"
+	case ConditionSourceArg:
+		return "Arg"
+	default:
+		return "Undefined"
+	}
+}
+
+var conditionSourceNames = map[string]int64{
+	"attachment": int64(ConditionSourceAttachment),
+	"arg":        int64(ConditionSourceArg),
+}
+
+// UnmarshalJSON accepts either the integer code or the name (e.g. "Attachment") of ConditionSource.
+func (s *ConditionSource) UnmarshalJSON(data []byte) error {
+	v, err := util.ParseEnumJSON(data, conditionSourceNames)
+	if err != nil {
+		return err
+	}
+	*s = ConditionSource(v)
+	return nil
+}
+
+// ConditionOperator indicates how a MatchCondition checks the value against its Values.
+type ConditionOperator uint8
+
+const (
+	// ConditionOpEquals matches the value equal to the only one of Values.
+	ConditionOpEquals ConditionOperator = iota
+	// ConditionOpNotEquals matches the value not equal to the only one of Values, including the absent value.
+	ConditionOpNotEquals
+	// ConditionOpIn matches the value equal to any of Values.
+	ConditionOpIn
+	// ConditionOpNotIn matches the value equal to none of Values, including the absent value.
+	ConditionOpNotIn
+	// ConditionOpRegex matches the value with the only one of Values as a regular expression (RE2 syntax),
+	// which must match the whole value.
+	ConditionOpRegex
+)
+
+func (o ConditionOperator) String() string {
+	switch o {
+	case ConditionOpEquals:
+		return "Equals"
+	case ConditionOpNotEquals:
+		return "NotEquals"
+	case ConditionOpIn:
+		return "In"
+	case ConditionOpNotIn:
+		return "NotIn"
+	case ConditionOpRegex:
+		return "Regex"
+	default:
+		return "Undefined"
+	}
+}
+
+var conditionOperatorNames = map[string]int64{
+	"equals":    int64(ConditionOpEquals),
+	"notequals": int64(ConditionOpNotEquals),
+	"in":        int64(ConditionOpIn),
+	"notin":     int64(ConditionOpNotIn),
+	"regex":     int64(ConditionOpRegex),
+}
+
+// UnmarshalJSON accepts either the integer code or the name (e.g. "NotIn") of ConditionOperator.
+func (o *ConditionOperator) UnmarshalJSON(data []byte) error {
+	v, err := util.ParseEnumJSON(data, conditionOperatorNames)
+	if err != nil {
+		return err
+	}
+	*o = ConditionOperator(v)
+	return nil
+}
+
+// MatchCondition restricts a rule to the entries whose attachment or argument matches,
+// e.g. {"source": "attachment", "key": "region", "operator": "equals", "values": ["eu"]}.
+// The values are compared in their string form, i.e. the string itself, or fmt.Sprint of the other types.
+type MatchCondition struct {
+	Source ConditionSource `json:"source"`
+	// Key is the key of the attachment, only for ConditionSourceAttachment.
+	Key string `json:"key,omitempty"`
+	// Index is the index of the argument, only for ConditionSourceArg.
+	Index    int               `json:"index,omitempty"`
+	Operator ConditionOperator `json:"operator"`
+	Values   []string          `json:"values"`
+}
+
+func (c *MatchCondition) String() string {
+	if c.Source == ConditionSourceArg {
+		return fmt.Sprintf("MatchCondition{Source=%s, Index=%d, Operator=%s, Values=%v}", c.Source, c.Index, c.Operator, c.Values)
+	}
+	return fmt.Sprintf("MatchCondition{Source=%s, Key=%s, Operator=%s, Values=%v}", c.Source, c.Key, c.Operator, c.Values)
+}
+
+type compiledCondition struct {
+	*MatchCondition
+	values map[string]struct{}
+	re     *regexp.Regexp
+}
+
+// ConditionMatcher matches the entries against the compiled conditions of a rule, all of which must be satisfied.
+// A nil ConditionMatcher matches all the entries.
+type ConditionMatcher struct {
+	conditions []compiledCondition
+}
+
+// NewConditionMatcher compiles the conditions, it returns nil if there is no condition.
+func NewConditionMatcher(conditions []*MatchCondition) (*ConditionMatcher, error) {
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+	m := &ConditionMatcher{conditions: make([]compiledCondition, 0, len(conditions))}
+	for i, c := range conditions {
+		if c == nil {
+			return nil, errors.Errorf("nil condition at index %d", i)
+		}
+		compiled, err := compileCondition(c)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid condition at index %d", i)
+		}
+		m.conditions = append(m.conditions, compiled)
+	}
+	return m, nil
+}
+
+func compileCondition(c *MatchCondition) (compiledCondition, error) {
+	ret := compiledCondition{MatchCondition: c}
+	switch c.Source {
+	case ConditionSourceAttachment:
+		if len(c.Key) == 0 {
+			return ret, errors.New("empty attachment key")
+		}
+	case ConditionSourceArg:
+		if c.Index < 0 {
+			return ret, errors.New("negative argument index")
+		}
+	default:
+		return ret, errors.Errorf("unknown condition source: %d", c.Source)
+	}
+	switch c.Operator {
+	case ConditionOpEquals, ConditionOpNotEquals:
+		if len(c.Values) != 1 {
+			return ret, errors.Errorf("%s condition requires exactly one value", c.Operator)
+		}
+	case ConditionOpIn, ConditionOpNotIn:
+		if len(c.Values) == 0 {
+			return ret, errors.Errorf("%s condition requires at least one value", c.Operator)
+		}
+		ret.values = make(map[string]struct{}, len(c.Values))
+		for _, v := range c.Values {
+			ret.values[v] = struct{}{}
+		}
+	case ConditionOpRegex:
+		if len(c.Values) != 1 {
+			return ret, errors.New("Regex condition requires exactly one value")
+		}
+		re, err := regexp.Compile("^(?:" + c.Values[0] + ")$")
+		if err != nil {
+			return ret, errors.Wrapf(err, "invalid regex: %s", c.Values[0])
+		}
+		ret.re = re
+	default:
+		return ret, errors.Errorf("unknown condition operator: %d", c.Operator)
+	}
+	return ret, nil
+}
+
+// IsValidConditions checks the match conditions of a rule.
+func IsValidConditions(conditions []*MatchCondition) error {
+	_, err := NewConditionMatcher(conditions)
+	return err
+}
+
+// Matches checks whether the entry of the input satisfies all the conditions. The input could be nil.
+func (m *ConditionMatcher) Matches(input *SentinelInput) bool {
+	if m == nil {
+		return true
+	}
+	for i := range m.conditions {
+		if !m.conditions[i].matches(input) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *compiledCondition) matches(input *SentinelInput) bool {
+	value, ok := c.valueOf(input)
+	switch c.Operator {
+	case ConditionOpEquals:
+		return ok && value == c.Values[0]
+	case ConditionOpNotEquals:
+		return !ok || value != c.Values[0]
+	case ConditionOpIn:
+		if !ok {
+			return false
+		}
+		_, in := c.values[value]
+		return in
+	case ConditionOpNotIn:
+		if !ok {
+			return true
+		}
+		_, in := c.values[value]
+		return !in
+	case ConditionOpRegex:
+		return ok && c.re.MatchString(value)
+	default:
+		return false
+	}
+}
+
+// valueOf returns the string form of the checked value of the input, false if absent.
+func (c *compiledCondition) valueOf(input *SentinelInput) (string, bool) {
+	if input == nil {
+		return "", false
+	}
+	var v interface{}
+	switch c.Source {
+	case ConditionSourceAttachment:
+		v = input.Attachments[c.Key]
+	case ConditionSourceArg:
+		if c.Index < len(input.Args) {
+			v = input.Args[c.Index]
+		}
+	}
+	switch val := v.(type) {
+	case nil:
+		return "", false
+	case string:
+		return val, true
+	default:
+		return fmt.Sprint(val), true
+	}
+}
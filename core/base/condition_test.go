@@ -0,0 +1,76 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package base
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConditionMatcher(t *testing.T) {
+	var conditions []*MatchCondition
+	err := json.Unmarshal([]byte(`[
+		{"source": "attachment", "key": "region", "operator": "equals", "values": ["eu"]},
+		{"source": "attachment", "key": "plan", "operator": "in", "values": ["free", "trial"]},
+		{"source": "arg", "index": 1, "operator": "regex", "values": ["user-[0-9]+"]},
+		{"source": "arg", "index": 0, "operator": "notIn", "values": ["1"]}
+	]`), &conditions)
+	assert.NoError(t, err)
+	assert.Equal(t, ConditionSourceArg, conditions[2].Source)
+	assert.Equal(t, ConditionOpNotIn, conditions[3].Operator)
+
+	m, err := NewConditionMatcher(conditions)
+	assert.NoError(t, err)
+	input := &SentinelInput{
+		Args:        []interface{}{2, "user-42"},
+		Attachments: map[interface{}]interface{}{"region": "eu", "plan": "trial"},
+	}
+	assert.True(t, m.Matches(input))
+
+	input.Args[0] = 1
+	assert.False(t, m.Matches(input))
+	input.Args = []interface{}{2, "admin"}
+	assert.False(t, m.Matches(input))
+	input.Args = []interface{}{2, "user-42"}
+	input.Attachments["plan"] = "paid"
+	assert.False(t, m.Matches(input))
+	delete(input.Attachments, "plan")
+	assert.False(t, m.Matches(input))
+	assert.False(t, m.Matches(nil))
+
+	// The absent value satisfies the negative operators only.
+	m, err = NewConditionMatcher([]*MatchCondition{{Key: "region", Operator: ConditionOpNotEquals, Values: []string{"eu"}}})
+	assert.NoError(t, err)
+	assert.True(t, m.Matches(&SentinelInput{}))
+
+	var nilMatcher *ConditionMatcher
+	assert.True(t, nilMatcher.Matches(nil))
+	m, err = NewConditionMatcher(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, m)
+}
+
+func TestIsValidConditions(t *testing.T) {
+	assert.NoError(t, IsValidConditions(nil))
+	assert.Error(t, IsValidConditions([]*MatchCondition{nil}))
+	assert.Error(t, IsValidConditions([]*MatchCondition{{Operator: ConditionOpEquals, Values: []string{"eu"}}}))
+	assert.Error(t, IsValidConditions([]*MatchCondition{{Source: ConditionSourceArg, Index: -1, Values: []string{"eu"}}}))
+	assert.Error(t, IsValidConditions([]*MatchCondition{{Key: "region", Operator: ConditionOpEquals, Values: []string{"eu", "us"}}}))
+	assert.Error(t, IsValidConditions([]*MatchCondition{{Key: "region", Operator: ConditionOpIn}}))
+	assert.Error(t, IsValidConditions([]*MatchCondition{{Key: "region", Operator: ConditionOpRegex, Values: []string{"("}}}))
+	assert.Error(t, IsValidConditions([]*MatchCondition{{Key: "region", Operator: 10, Values: []string{"eu"}}}))
+}
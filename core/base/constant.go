@@ -17,6 +17,8 @@ package base
 // global variable
 const (
 	TotalInBoundResourceName = "__total_inbound_traffic__"
+	// OverflowResourceName is the name of the shared node accounting the resources beyond the max amount of resources.
+	OverflowResourceName = "__overflow_resources__"
 
 	DefaultMaxResourceAmount uint32 = 10000
 
@@ -34,6 +34,8 @@ type EntryContext struct {
 	RuleCheckResult *TokenResult
 	// reserve for storing some intermediate data from the Entry execution process
 	Data map[interface{}]interface{}
+	// shadowBlocks are the blocks triggered by the rules in shadow mode, which never reject the entry.
+	shadowBlocks []*BlockError
 }
 
 func (ctx *EntryContext) SetEntry(entry *SentinelEntry) {
@@ -63,6 +65,20 @@ func (ctx *EntryContext) IsBlocked() bool {
 	return ctx.RuleCheckResult.IsBlocked()
 }
 
+// AddShadowBlock records a block triggered by a rule in shadow mode, the entry goes on as if it passed.
+// The given blockError must not be reused by the caller.
+func (ctx *EntryContext) AddShadowBlock(blockError *BlockError) {
+	if blockError == nil {
+		return
+	}
+	ctx.shadowBlocks = append(ctx.shadowBlocks, blockError)
+}
+
+// ShadowBlocks returns the blocks triggered by the rules in shadow mode, which must not be modified.
+func (ctx *EntryContext) ShadowBlocks() []*BlockError {
+	return ctx.shadowBlocks
+}
+
 func (ctx *EntryContext) PutRt(rt uint64) {
 	ctx.rt = rt
 }
@@ -83,7 +99,9 @@ func NewEmptyEntryContext() *EntryContext {
 type SentinelInput struct {
 	BatchCount uint32
 	Flag       int32
-	Args       []interface{}
+	// Origin is the identity of the caller of the entry, e.g. the upstream application.
+	Origin string
+	Args   []interface{}
 	// store some values in this context when calling context in slot.
 	Attachments map[interface{}]interface{}
 }
@@ -91,6 +109,7 @@ type SentinelInput struct {
 func (i *SentinelInput) reset() {
 	i.BatchCount = 1
 	i.Flag = 0
+	i.Origin = ""
 	if len(i.Args) != 0 {
 		i.Args = make([]interface{}, 0)
 	}
@@ -117,4 +136,8 @@ func (ctx *EntryContext) Reset() {
 	if len(ctx.Data) != 0 {
 		ctx.Data = make(map[interface{}]interface{})
 	}
+	for i := range ctx.shadowBlocks {
+		ctx.shadowBlocks[i] = nil
+	}
+	ctx.shadowBlocks = ctx.shadowBlocks[:0]
 }
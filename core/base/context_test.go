@@ -26,3 +26,19 @@ func TestEntryContext_IsBlocked(t *testing.T) {
 	ctx.RuleCheckResult = NewTokenResultBlocked(BlockTypeUnknown)
 	assert.True(t, ctx.IsBlocked(), "context with blocked request should indicate blocked")
 }
+
+func TestEntryContext_ShadowBlocks(t *testing.T) {
+	ctx := NewEmptyEntryContext()
+	ctx.Input = &SentinelInput{}
+	assert.Empty(t, ctx.ShadowBlocks())
+
+	ctx.AddShadowBlock(nil)
+	ctx.AddShadowBlock(NewBlockError(BlockTypeFlow))
+	ctx.AddShadowBlock(NewBlockError(BlockTypeSystemFlow))
+	assert.Equal(t, 2, len(ctx.ShadowBlocks()))
+	assert.Equal(t, BlockTypeFlow, ctx.ShadowBlocks()[0].BlockType())
+	assert.False(t, ctx.IsBlocked())
+
+	ctx.Reset()
+	assert.Empty(t, ctx.ShadowBlocks())
+}
@@ -18,6 +18,7 @@ import (
 	"sync"
 
 	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/alibaba/sentinel-golang/util"
 	"github.com/pkg/errors"
 )
 
@@ -33,16 +34,52 @@ type SentinelEntry struct {
 	// it means this entry will go through the sc
 	sc *SlotChain
 
-	exitCtl sync.Once
+	exited util.AtomicBool
+
+	// pooled indicates the entry is acquired from entryPool and will be put back once exited.
+	pooled bool
+	// resource holds the ResourceWrapper of the pooled entry, so that no extra allocation is needed.
+	resource ResourceWrapper
+}
+
+var entryPool = sync.Pool{
+	New: func() interface{} {
+		return &SentinelEntry{pooled: true}
+	},
 }
 
 func NewSentinelEntry(ctx *EntryContext, rw *ResourceWrapper, sc *SlotChain) *SentinelEntry {
 	return &SentinelEntry{
-		res:          rw,
-		ctx:          ctx,
-		exitHandlers: make([]ExitHandler, 0),
-		sc:           sc,
+		res: rw,
+		ctx: ctx,
+		sc:  sc,
+	}
+}
+
+// NewPooledSentinelEntry gets a SentinelEntry of the given resource from the pool, which is put back to the pool
+// once exited. Exiting the entry again is a no-op until it's reused, so that the entry, its resource and context
+// must not be used or retained after calling Exit(), since they might have been reused by another entry.
+func NewPooledSentinelEntry(ctx *EntryContext, name string, classification ResourceType, flowType TrafficType, sc *SlotChain) *SentinelEntry {
+	e := entryPool.Get().(*SentinelEntry)
+	e.resource = ResourceWrapper{name: name, classification: classification, flowType: flowType}
+	e.res = &e.resource
+	e.ctx = ctx
+	e.sc = sc
+	e.exited.Set(false)
+	return e
+}
+
+func (e *SentinelEntry) recycle() {
+	for i := range e.exitHandlers {
+		e.exitHandlers[i] = nil
 	}
+	// The capacity of exitHandlers is kept for reuse.
+	e.exitHandlers = e.exitHandlers[:0]
+	e.res = nil
+	e.ctx = nil
+	e.sc = nil
+	e.resource = ResourceWrapper{}
+	entryPool.Put(e)
 }
 
 func (e *SentinelEntry) WhenExit(exitHandler ExitHandler) {
@@ -74,36 +111,46 @@ func WithError(err error) ExitOption {
 	}
 }
 
+// Exit exits the entry, only the first call takes effect. It's safe to call Exit multiple times on the entry
+// created by NewSentinelEntry, while the pooled entry must not be exited again once reused, see NewPooledSentinelEntry.
 func (e *SentinelEntry) Exit(exitOps ...ExitOption) {
-	var options = ExitOptions{
-		err: nil,
-	}
-	for _, opt := range exitOps {
-		opt(&options)
-	}
 	ctx := e.ctx
 	if ctx == nil {
 		return
 	}
-	if options.err != nil {
-		ctx.SetError(options.err)
+	if len(exitOps) > 0 {
+		options := &ExitOptions{}
+		for _, opt := range exitOps {
+			opt(options)
+		}
+		if options.err != nil {
+			ctx.SetError(options.err)
+		}
+	}
+	if !e.exited.CompareAndSet(false, true) {
+		return
+	}
+	e.doExit(ctx)
+	if e.pooled {
+		e.recycle()
 	}
-	e.exitCtl.Do(func() {
-		defer func() {
-			if err := recover(); err != nil {
-				logging.Error(errors.Errorf("%+v", err), "Sentinel internal panic in SentinelEntry.Exit()")
-			}
-			if e.sc != nil {
-				e.sc.RefurbishContext(ctx)
-			}
-		}()
-		for _, handler := range e.exitHandlers {
-			if err := handler(e, ctx); err != nil {
-				logging.Error(err, "Fail to execute exitHandler in SentinelEntry.Exit()", "resource", e.Resource().Name())
-			}
+}
+
+func (e *SentinelEntry) doExit(ctx *EntryContext) {
+	defer func() {
+		if err := recover(); err != nil {
+			logging.Error(errors.Errorf("%+v", err), "Sentinel internal panic in SentinelEntry.Exit()")
 		}
 		if e.sc != nil {
-			e.sc.exit(ctx)
+			e.sc.RefurbishContext(ctx)
+		}
+	}()
+	for _, handler := range e.exitHandlers {
+		if err := handler(e, ctx); err != nil {
+			logging.Error(err, "Fail to execute exitHandler in SentinelEntry.Exit()", "resource", e.Resource().Name())
 		}
-	})
+	}
+	if e.sc != nil {
+		e.sc.exit(ctx)
+	}
 }
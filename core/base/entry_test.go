@@ -39,3 +39,20 @@ func TestSentinelEntry_WhenExit(t *testing.T) {
 	entry.Exit()
 	assert.True(t, flag == 1)
 }
+
+func TestSentinelEntry_Pooled(t *testing.T) {
+	flag = 0
+	sc := NewSlotChain()
+	ctx := sc.GetPooledContext()
+	entry := NewPooledSentinelEntry(ctx, "abc", ResTypeCommon, Inbound, sc)
+	ctx.SetEntry(entry)
+	assert.Equal(t, "abc", entry.Resource().Name())
+	assert.Equal(t, Inbound, entry.Resource().FlowType())
+	entry.WhenExit(exitHandlerMock)
+	entry.Exit()
+	assert.True(t, flag == 1)
+	// The exited entry is recycled, exiting it again is a no-op.
+	assert.Nil(t, entry.Context())
+	entry.Exit()
+	assert.True(t, flag == 1)
+}
@@ -38,6 +38,13 @@ type MetricItem struct {
 	AvgRt           uint64
 	OccupiedPassQps uint64
 	Concurrency     uint32
+
+	// P50Rt, P99Rt and MaxRt are appended to the end of the metric log line (see ToFatString),
+	// so that the lines are still readable by the parsers not aware of them.
+	// They're not in the thin format, which must keep the fields read by the Dashboard.
+	P50Rt uint64
+	P99Rt uint64
+	MaxRt uint64
 }
 
 type MetricItemRetriever interface {
@@ -49,16 +56,18 @@ func (m *MetricItem) ToFatString() (string, error) {
 	timeStr := util.FormatTimeMillis(m.Timestamp)
 	// All "|" in the resource name will be replaced with "_"
 	finalName := strings.ReplaceAll(m.Resource, "|", "_")
-	_, err := fmt.Fprintf(&b, "%d|%s|%s|%d|%d|%d|%d|%d|%d|%d|%d",
+	_, err := fmt.Fprintf(&b, "%d|%s|%s|%d|%d|%d|%d|%d|%d|%d|%d|%d|%d|%d",
 		m.Timestamp, timeStr, finalName, m.PassQps,
 		m.BlockQps, m.CompleteQps, m.ErrorQps, m.AvgRt,
-		m.OccupiedPassQps, m.Concurrency, m.Classification)
+		m.OccupiedPassQps, m.Concurrency, m.Classification,
+		m.P50Rt, m.P99Rt, m.MaxRt)
 	if err != nil {
 		return "", err
 	}
 	return b.String(), nil
 }
 
+// ToThinString formats the item in the thin format returned to the Dashboard, which is kept without the RT percentiles.
 func (m *MetricItem) ToThinString() (string, error) {
 	b := strings.Builder{}
 	finalName := strings.ReplaceAll(m.Resource, "|", "_")
@@ -134,5 +143,16 @@ func MetricItemFromFatString(line string) (*MetricItem, error) {
 		}
 		item.Classification = int32(cl)
 	}
+	if len(arr) >= 14 {
+		rts := make([]uint64, 3)
+		for i := range rts {
+			rt, err := strconv.ParseUint(arr[11+i], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			rts[i] = rt
+		}
+		item.P50Rt, item.P99Rt, item.MaxRt = rts[0], rts[1], rts[2]
+	}
 	return item, nil
 }
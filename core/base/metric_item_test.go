@@ -34,6 +34,35 @@ func TestMetricItemFromFatStringLegal(t *testing.T) {
 	assert.Equal(t, int32(1), item1.Classification)
 }
 
+func TestMetricItemWithRtPercentiles(t *testing.T) {
+	item := &MetricItem{
+		Resource:    "/foo/*",
+		Timestamp:   1564382218000,
+		PassQps:     4,
+		CompleteQps: 3,
+		AvgRt:       25,
+		Concurrency: 2,
+		P50Rt:       20,
+		P99Rt:       60,
+		MaxRt:       70,
+	}
+	line, err := item.ToFatString()
+	assert.NoError(t, err)
+	got, err := MetricItemFromFatString(line)
+	assert.NoError(t, err)
+	assert.Equal(t, item, got)
+
+	// The old lines without the RT percentiles are still readable.
+	got, err = MetricItemFromFatString("1564382218000|2019-07-29 14:36:58|/foo/*|4|9|3|0|25|0|2|1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), got.P99Rt)
+
+	thin, err := item.ToThinString()
+	assert.NoError(t, err)
+	// The thin format for the Dashboard is kept without the RT percentiles.
+	assert.Equal(t, "1564382218000|/foo/*|4|0|3|0|25|0|2|0", thin)
+}
+
 func TestMetricItemFromFatStringIllegal(t *testing.T) {
 	line1 := "1564382218000|2019-07-29 14:36:58|foo|baz|4|9|3|0|25|0|2|1"
 	_, err := MetricItemFromFatString(line1)
@@ -0,0 +1,143 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package base
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/alibaba/sentinel-golang/util"
+	"github.com/pkg/errors"
+)
+
+// ResourceMatchStrategy indicates how the Resource of a rule matches the resource names.
+type ResourceMatchStrategy uint8
+
+const (
+	// ResourceMatchExact is the default strategy, the Resource of rule is the exact resource name.
+	ResourceMatchExact ResourceMatchStrategy = iota
+	// ResourceMatchPrefix matches all the resources starting with the Resource of rule.
+	ResourceMatchPrefix
+	// ResourceMatchGlob matches the resources with the Resource of rule as a glob pattern,
+	// in which "*" matches any sequence of characters except "/", "**" matches any sequence of characters,
+	// and "?" matches any single character except "/", e.g. "/api/users/*" or "/api/**".
+	ResourceMatchGlob
+	// ResourceMatchRegex matches the resources with the Resource of rule as a regular expression (RE2 syntax),
+	// which must match the whole resource name.
+	ResourceMatchRegex
+)
+
+func (s ResourceMatchStrategy) String() string {
+	switch s {
+	case ResourceMatchExact:
+		return "Exact"
+	case ResourceMatchPrefix:
+		return "Prefix"
+	case ResourceMatchGlob:
+		return "Glob"
+	case ResourceMatchRegex:
+		return "Regex"
+	default:
+		return "Undefined"
+	}
+}
+
+var resourceMatchStrategyNames = map[string]int64{
+	"exact":  int64(ResourceMatchExact),
+	"prefix": int64(ResourceMatchPrefix),
+	"glob":   int64(ResourceMatchGlob),
+	"regex":  int64(ResourceMatchRegex),
+}
+
+// UnmarshalJSON accepts either the integer code or the name (e.g. "Glob") of ResourceMatchStrategy.
+func (s *ResourceMatchStrategy) UnmarshalJSON(data []byte) error {
+	v, err := util.ParseEnumJSON(data, resourceMatchStrategyNames)
+	if err != nil {
+		return err
+	}
+	*s = ResourceMatchStrategy(v)
+	return nil
+}
+
+// IsPattern indicates whether the Resource of rule is a pattern rather than an exact resource name.
+func (s ResourceMatchStrategy) IsPattern() bool {
+	return s != ResourceMatchExact
+}
+
+// ResourceMatcher matches the resource names against the Resource of a rule.
+type ResourceMatcher struct {
+	strategy ResourceMatchStrategy
+	pattern  string
+	re       *regexp.Regexp
+}
+
+// NewResourceMatcher compiles the pattern with the given strategy.
+func NewResourceMatcher(strategy ResourceMatchStrategy, pattern string) (*ResourceMatcher, error) {
+	if pattern == "" {
+		return nil, errors.New("empty resource pattern")
+	}
+	m := &ResourceMatcher{
+		strategy: strategy,
+		pattern:  pattern,
+	}
+	var err error
+	switch strategy {
+	case ResourceMatchExact, ResourceMatchPrefix:
+	case ResourceMatchGlob:
+		m.re, err = regexp.Compile(globToRegex(pattern))
+	case ResourceMatchRegex:
+		m.re, err = regexp.Compile("^(?:" + pattern + ")$")
+	default:
+		return nil, errors.Errorf("unknown resource match strategy: %d", strategy)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid %s resource pattern: %s", strategy, pattern)
+	}
+	return m, nil
+}
+
+// Match checks whether the resource matches the pattern.
+func (m *ResourceMatcher) Match(resource string) bool {
+	switch m.strategy {
+	case ResourceMatchExact:
+		return resource == m.pattern
+	case ResourceMatchPrefix:
+		return strings.HasPrefix(resource, m.pattern)
+	default:
+		return m.re.MatchString(resource)
+	}
+}
+
+func globToRegex(glob string) string {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				sb.WriteString(".*")
+				i++
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return sb.String()
+}
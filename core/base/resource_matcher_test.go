@@ -0,0 +1,54 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package base
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResourceMatcher(t *testing.T) {
+	tests := []struct {
+		strategy  ResourceMatchStrategy
+		pattern   string
+		matched   []string
+		unmatched []string
+	}{
+		{ResourceMatchExact, "/api/users", []string{"/api/users"}, []string{"/api/users/1", "/api"}},
+		{ResourceMatchPrefix, "/api/", []string{"/api/", "/api/users", "/api/users/1"}, []string{"/apix", "api/users"}},
+		{ResourceMatchGlob, "/api/users/*", []string{"/api/users/", "/api/users/1"}, []string{"/api/users/1/orders", "/api/users"}},
+		{ResourceMatchGlob, "/api/**", []string{"/api/users", "/api/users/1/orders"}, []string{"/apix/users"}},
+		{ResourceMatchGlob, "GET:/v?/items.json", []string{"GET:/v1/items.json"}, []string{"GET:/v1/itemsxjson", "GET://items.json"}},
+		{ResourceMatchRegex, `/api/users/\d+`, []string{"/api/users/42"}, []string{"/api/users/abc", "/v1/api/users/42", "/api/users/42/orders"}},
+	}
+	for _, tt := range tests {
+		m, err := NewResourceMatcher(tt.strategy, tt.pattern)
+		assert.NoError(t, err)
+		for _, res := range tt.matched {
+			assert.True(t, m.Match(res), "%s %s should match %s", tt.strategy, tt.pattern, res)
+		}
+		for _, res := range tt.unmatched {
+			assert.False(t, m.Match(res), "%s %s should not match %s", tt.strategy, tt.pattern, res)
+		}
+	}
+
+	_, err := NewResourceMatcher(ResourceMatchRegex, "/api/(")
+	assert.Error(t, err)
+	_, err = NewResourceMatcher(ResourceMatchPrefix, "")
+	assert.Error(t, err)
+	_, err = NewResourceMatcher(ResourceMatchStrategy(100), "/api")
+	assert.Error(t, err)
+}
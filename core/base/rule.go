@@ -14,10 +14,112 @@
 
 package base
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/alibaba/sentinel-golang/util"
+	"github.com/pkg/errors"
+)
 
 type SentinelRule interface {
 	fmt.Stringer
 
 	ResourceName() string
 }
+
+// RuleMode indicates whether a rule actually rejects the traffic.
+type RuleMode uint8
+
+const (
+	// RuleModeEnforce is the default mode, the rule rejects the traffic once triggered.
+	RuleModeEnforce RuleMode = iota
+	// RuleModeShadow is the dry-run mode, the rule runs its checks and records the would-be blocks
+	// (see EntryContext.ShadowBlocks), but never rejects the traffic.
+	RuleModeShadow
+)
+
+func (m RuleMode) String() string {
+	switch m {
+	case RuleModeEnforce:
+		return "Enforce"
+	case RuleModeShadow:
+		return "Shadow"
+	default:
+		return "Undefined"
+	}
+}
+
+var ruleModeNames = map[string]int64{
+	"enforce": int64(RuleModeEnforce),
+	"shadow":  int64(RuleModeShadow),
+}
+
+// UnmarshalJSON accepts either the integer code or the name (e.g. "Shadow") of RuleMode.
+func (m *RuleMode) UnmarshalJSON(data []byte) error {
+	v, err := util.ParseEnumJSON(data, ruleModeNames)
+	if err != nil {
+		return err
+	}
+	*m = RuleMode(v)
+	return nil
+}
+
+// IsShadow indicates whether the rule of the mode only records the would-be blocks.
+func (m RuleMode) IsShadow() bool {
+	return m == RuleModeShadow
+}
+
+// Reservation reserves a part of the threshold of a rule for the traffic with the given flag or from the given origin,
+// e.g. 20% of the QPS is always available to the health checks. The other traffic could never consume the reserve,
+// while the reserved traffic could also consume the unreserved capacity.
+type Reservation struct {
+	// Flag matches the entries with the flag (see api.WithFlag), 0 matches the entries with any flag.
+	Flag int32 `json:"flag,omitempty"`
+	// Origin matches the entries from the origin (see api.WithOrigin), empty matches the entries from any origin.
+	Origin string `json:"origin,omitempty"`
+	// Ratio is the reserved ratio of the threshold, which must be in (0, 1].
+	Ratio float64 `json:"ratio"`
+}
+
+func (r *Reservation) String() string {
+	return fmt.Sprintf("Reservation{Flag=%d, Origin=%s, Ratio=%.2f}", r.Flag, r.Origin, r.Ratio)
+}
+
+// Matches indicates whether the entry of the input is entitled to the reservation.
+func (r *Reservation) Matches(input *SentinelInput) bool {
+	return (r.Flag == 0 || r.Flag == input.Flag) && (r.Origin == "" || r.Origin == input.Origin)
+}
+
+// MatchReservation returns the index of the first reservation the entry of the input is entitled to, or -1 if none.
+func MatchReservation(reservations []*Reservation, input *SentinelInput) int {
+	if input == nil {
+		return -1
+	}
+	for i, r := range reservations {
+		if r.Matches(input) {
+			return i
+		}
+	}
+	return -1
+}
+
+// IsValidReservations checks the reservations of a rule, the reserved ratios must not sum to more than 1.
+func IsValidReservations(reservations []*Reservation) error {
+	total := 0.0
+	for _, r := range reservations {
+		if r == nil {
+			return errors.New("nil Reservation")
+		}
+		if r.Flag == 0 && r.Origin == "" {
+			return errors.New("Reservation must have either Flag or Origin")
+		}
+		if r.Ratio <= 0 || r.Ratio > 1 {
+			return errors.Errorf("invalid Ratio of %s, it must be in (0, 1]", r)
+		}
+		total += r.Ratio
+	}
+	if total > 1 && !util.Float64Equals(total, 1) {
+		return errors.New("the total Ratio of Reservations exceeds 1")
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package base
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/pkg/errors"
+)
+
+// RuleState indicates whether a loaded rule takes effect at present according to its effective period.
+type RuleState uint8
+
+const (
+	// RuleStateActive means the rule takes effect.
+	RuleStateActive RuleState = iota
+	// RuleStatePending means the rule doesn't take effect until its EffectiveFrom.
+	RuleStatePending
+	// RuleStateExpired means the rule doesn't take effect since its ExpiresAt.
+	RuleStateExpired
+)
+
+func (s RuleState) String() string {
+	switch s {
+	case RuleStateActive:
+		return "Active"
+	case RuleStatePending:
+		return "Pending"
+	case RuleStateExpired:
+		return "Expired"
+	default:
+		return "Undefined"
+	}
+}
+
+// MarshalJSON marshals the RuleState as its name, which is more readable for the introspection.
+func (s RuleState) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// RuleStatus is the introspection view of a loaded rule.
+type RuleStatus struct {
+	Rule  SentinelRule `json:"rule"`
+	State RuleState    `json:"state"`
+}
+
+// RuleStateAt returns the state at now of the rule with the given effective period in Unix milliseconds,
+// the rule takes effect in [effectiveFrom, expiresAt) and 0 means unbounded.
+func RuleStateAt(effectiveFrom, expiresAt, now uint64) RuleState {
+	if effectiveFrom != 0 && now < effectiveFrom {
+		return RuleStatePending
+	}
+	if expiresAt != 0 && now >= expiresAt {
+		return RuleStateExpired
+	}
+	return RuleStateActive
+}
+
+// IsValidEffectivePeriod checks the effective period of a rule.
+func IsValidEffectivePeriod(effectiveFrom, expiresAt uint64) error {
+	if effectiveFrom != 0 && expiresAt != 0 && expiresAt <= effectiveFrom {
+		return errors.New("ExpiresAt must be after EffectiveFrom")
+	}
+	return nil
+}
+
+// NextRuleTransition returns the earlier one of next and the next time after now at which the rule with the given
+// effective period becomes effective or expires, 0 means never.
+func NextRuleTransition(next, effectiveFrom, expiresAt, now uint64) uint64 {
+	for _, t := range []uint64{effectiveFrom, expiresAt} {
+		if t > now && (next == 0 || t < next) {
+			next = t
+		}
+	}
+	return next
+}
+
+// PeriodicRule is the rule which takes effect in its effective period only.
+type PeriodicRule interface {
+	SentinelRule
+	// EffectivePeriod returns the effective period [effectiveFrom, expiresAt) in Unix milliseconds, 0 means unbounded.
+	EffectivePeriod() (effectiveFrom, expiresAt uint64)
+}
+
+// IsRuleActiveAt checks whether the rule takes effect at now.
+func IsRuleActiveAt(rule PeriodicRule, now uint64) bool {
+	effectiveFrom, expiresAt := rule.EffectivePeriod()
+	return RuleStateAt(effectiveFrom, expiresAt, now) == RuleStateActive
+}
+
+// RuleStatusAt returns the status of the rule at now.
+func RuleStatusAt(rule PeriodicRule, now uint64) RuleStatus {
+	effectiveFrom, expiresAt := rule.EffectivePeriod()
+	return RuleStatus{Rule: rule, State: RuleStateAt(effectiveFrom, expiresAt, now)}
+}
+
+// RuleStatusesAt returns the statuses at now of the n rules, rule(i) returns the i-th rule.
+func RuleStatusesAt(n int, rule func(i int) PeriodicRule, now uint64) []RuleStatus {
+	ret := make([]RuleStatus, 0, n)
+	for i := 0; i < n; i++ {
+		ret = append(ret, RuleStatusAt(rule(i), now))
+	}
+	return ret
+}
+
+// RefreshableRules is the rules of a rule manager refreshed by RuleRefresher,
+// both methods are called with the update lock of the rule manager held.
+type RefreshableRules interface {
+	// PeriodicRules returns all the loaded rules.
+	PeriodicRules() []PeriodicRule
+	// ReloadRules rebuilds the rule manager from the loaded rules, which schedules the next refresh by Schedule.
+	ReloadRules() error
+}
+
+// RuleRefresher refreshes the rules of a rule manager in background once any rule becomes effective or expires,
+// so that the rule manager rebuilds itself without reloading the rules.
+type RuleRefresher struct {
+	// The 64-bit fields are kept at the head of struct to be 64-bit aligned for atomic operations on 32-bit platforms.
+	// next is the next time in Unix milliseconds to refresh the rules, 0 means never.
+	next       uint64
+	refreshing int32
+
+	module     string
+	updateLock sync.Locker
+	rules      RefreshableRules
+
+	timerMux sync.Mutex
+	timer    *time.Timer
+	// stopped indicates the refresher is stopped by Stop, it's guarded by timerMux.
+	stopped bool
+}
+
+// NewRuleRefresher creates a RuleRefresher of the rules of the module, which are reloaded with updateLock held.
+func NewRuleRefresher(module string, updateLock sync.Locker, rules RefreshableRules) *RuleRefresher {
+	return &RuleRefresher{
+		module:     module,
+		updateLock: updateLock,
+		rules:      rules,
+	}
+}
+
+// Schedule schedules the next refresh at the next time after now at which any of the loaded rules becomes effective
+// or expires, it must be called with the update lock held.
+func (r *RuleRefresher) Schedule(now uint64) {
+	r.schedule(r.rules.PeriodicRules(), now)
+}
+
+func (r *RuleRefresher) schedule(rules []PeriodicRule, now uint64) {
+	next := uint64(0)
+	for _, rule := range rules {
+		effectiveFrom, expiresAt := rule.EffectivePeriod()
+		next = NextRuleTransition(next, effectiveFrom, expiresAt, now)
+	}
+
+	r.timerMux.Lock()
+	defer r.timerMux.Unlock()
+	if r.stopped {
+		return
+	}
+	atomic.StoreUint64(&r.next, next)
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+	if next == 0 {
+		return
+	}
+	r.timer = time.AfterFunc(time.Duration(next-now)*time.Millisecond, func() {
+		// The rules are refreshed even if the clock lags behind the timer a little,
+		// since the refresh schedules itself again then.
+		if atomic.CompareAndSwapInt32(&r.refreshing, 0, 1) {
+			r.doRefresh()
+		}
+	})
+}
+
+// Stop stops the scheduled refresh, after which the refresh is never scheduled again.
+func (r *RuleRefresher) Stop() {
+	r.timerMux.Lock()
+	defer r.timerMux.Unlock()
+
+	r.stopped = true
+	atomic.StoreUint64(&r.next, 0)
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+}
+
+// Check starts refreshing the rules in background if the scheduled refresh is overdue, e.g. the clock is mocked,
+// while only one refresh runs at the same time. It only reads the next time to refresh unless it's overdue,
+// so it's cheap enough to be called on every entry.
+func (r *RuleRefresher) Check(now uint64) {
+	next := atomic.LoadUint64(&r.next)
+	if next == 0 || now < next {
+		return
+	}
+	if !atomic.CompareAndSwapInt32(&r.refreshing, 0, 1) {
+		return
+	}
+	if atomic.LoadUint64(&r.next) != next {
+		// Refreshed by another caller just now.
+		atomic.StoreInt32(&r.refreshing, 0)
+		return
+	}
+	go r.doRefresh()
+}
+
+func (r *RuleRefresher) doRefresh() {
+	defer atomic.StoreInt32(&r.refreshing, 0)
+
+	r.updateLock.Lock()
+	defer r.updateLock.Unlock()
+	if err := r.rules.ReloadRules(); err != nil {
+		logging.Error(err, "Fail to refresh the rules in RuleRefresher.doRefresh()", "module", r.module)
+	}
+}
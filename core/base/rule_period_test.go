@@ -0,0 +1,152 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package base
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuleStateAt(t *testing.T) {
+	assert.Equal(t, RuleStateActive, RuleStateAt(0, 0, 100))
+	assert.Equal(t, RuleStatePending, RuleStateAt(101, 0, 100))
+	assert.Equal(t, RuleStateActive, RuleStateAt(100, 101, 100))
+	assert.Equal(t, RuleStateExpired, RuleStateAt(0, 100, 100))
+
+	assert.NoError(t, IsValidEffectivePeriod(0, 100))
+	assert.NoError(t, IsValidEffectivePeriod(100, 0))
+	assert.Error(t, IsValidEffectivePeriod(100, 100))
+
+	assert.Equal(t, uint64(0), NextRuleTransition(0, 50, 100, 100))
+	assert.Equal(t, uint64(200), NextRuleTransition(0, 50, 200, 100))
+	assert.Equal(t, uint64(150), NextRuleTransition(200, 150, 300, 100))
+
+	b, err := json.Marshal(RuleStatus{State: RuleStateExpired})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"rule":null,"state":"Expired"}`, string(b))
+}
+
+type periodicRuleMock struct {
+	effectiveFrom, expiresAt uint64
+}
+
+func (r *periodicRuleMock) ResourceName() string {
+	return "abc"
+}
+
+func (r *periodicRuleMock) String() string {
+	return "periodicRuleMock"
+}
+
+func (r *periodicRuleMock) EffectivePeriod() (uint64, uint64) {
+	return r.effectiveFrom, r.expiresAt
+}
+
+type refreshableRulesMock struct {
+	rules  []PeriodicRule
+	reload func() error
+}
+
+func (r *refreshableRulesMock) PeriodicRules() []PeriodicRule {
+	return r.rules
+}
+
+func (r *refreshableRulesMock) ReloadRules() error {
+	return r.reload()
+}
+
+func TestRuleStatusesAt(t *testing.T) {
+	rules := []periodicRuleMock{{effectiveFrom: 200}, {expiresAt: 100}, {}}
+	statuses := RuleStatusesAt(len(rules), func(i int) PeriodicRule {
+		return &rules[i]
+	}, 150)
+	assert.Equal(t, []RuleStatus{
+		{Rule: &rules[0], State: RuleStatePending},
+		{Rule: &rules[1], State: RuleStateExpired},
+		{Rule: &rules[2], State: RuleStateActive},
+	}, statuses)
+}
+
+func TestRuleRefresher(t *testing.T) {
+	t.Run("Timer", func(t *testing.T) {
+		refreshed := make(chan struct{}, 1)
+		now := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+		rules := &refreshableRulesMock{
+			rules: []PeriodicRule{&periodicRuleMock{expiresAt: now + 50}, &periodicRuleMock{effectiveFrom: now - 10}},
+			reload: func() error {
+				refreshed <- struct{}{}
+				return nil
+			},
+		}
+		r := NewRuleRefresher("mock", &sync.Mutex{}, rules)
+		r.Schedule(now)
+		select {
+		case <-refreshed:
+		case <-time.After(time.Second):
+			t.Fatal("the rules are not refreshed by the timer")
+		}
+	})
+
+	t.Run("Check", func(t *testing.T) {
+		var refreshed int32
+		rules := &refreshableRulesMock{rules: []PeriodicRule{&periodicRuleMock{effectiveFrom: 200}}}
+		r := NewRuleRefresher("mock", &sync.Mutex{}, rules)
+		rules.reload = func() error {
+			atomic.AddInt32(&refreshed, 1)
+			rules.rules = nil
+			r.Schedule(300)
+			return errors.New("reload failed")
+		}
+		r.Check(100)
+		r.Schedule(100)
+		// The timer fires after about 100ms, check the overdue refresh before it.
+		r.Check(199)
+		time.Sleep(10 * time.Millisecond)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&refreshed))
+		r.Check(200)
+		r.Check(200)
+		time.Sleep(10 * time.Millisecond)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&refreshed))
+		// The timer is stopped once the rules are scheduled again.
+		time.Sleep(150 * time.Millisecond)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&refreshed))
+	})
+
+	t.Run("Stop", func(t *testing.T) {
+		var refreshed int32
+		now := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+		rules := &refreshableRulesMock{
+			rules: []PeriodicRule{&periodicRuleMock{expiresAt: now + 20}},
+			reload: func() error {
+				atomic.AddInt32(&refreshed, 1)
+				return nil
+			},
+		}
+		r := NewRuleRefresher("mock", &sync.Mutex{}, rules)
+		r.Schedule(now)
+		r.Stop()
+		// The refresh is never scheduled again once stopped.
+		r.Schedule(now)
+		r.Check(now + 20)
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&refreshed))
+	})
+}
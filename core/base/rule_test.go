@@ -0,0 +1,53 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package base
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuleMode_UnmarshalJSON(t *testing.T) {
+	var v struct {
+		Mode RuleMode `json:"mode"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(`{"mode": "Shadow"}`), &v))
+	assert.Equal(t, RuleModeShadow, v.Mode)
+	assert.True(t, v.Mode.IsShadow())
+	assert.NoError(t, json.Unmarshal([]byte(`{"mode": 0}`), &v))
+	assert.Equal(t, RuleModeEnforce, v.Mode)
+	assert.False(t, v.Mode.IsShadow())
+	assert.Error(t, json.Unmarshal([]byte(`{"mode": "dry"}`), &v))
+}
+
+func TestReservation(t *testing.T) {
+	reservations := []*Reservation{
+		{Flag: 1, Ratio: 0.2},
+		{Origin: "health", Ratio: 0.3},
+	}
+	assert.NoError(t, IsValidReservations(reservations))
+	assert.Equal(t, 0, MatchReservation(reservations, &SentinelInput{Flag: 1, Origin: "health"}))
+	assert.Equal(t, 1, MatchReservation(reservations, &SentinelInput{Origin: "health"}))
+	assert.Equal(t, -1, MatchReservation(reservations, &SentinelInput{Flag: 2}))
+	assert.Equal(t, -1, MatchReservation(reservations, nil))
+
+	assert.Error(t, IsValidReservations([]*Reservation{nil}))
+	assert.Error(t, IsValidReservations([]*Reservation{{Ratio: 0.2}}))
+	assert.Error(t, IsValidReservations([]*Reservation{{Flag: 1, Ratio: 0}}))
+	assert.Error(t, IsValidReservations([]*Reservation{{Flag: 1, Ratio: 0.6}, {Flag: 2, Ratio: 0.6}}))
+	assert.NoError(t, IsValidReservations([]*Reservation{{Flag: 1, Ratio: 0.7}, {Flag: 2, Ratio: 0.3}}))
+}
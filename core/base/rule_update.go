@@ -0,0 +1,165 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package base
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// InvalidRule reports a rule rejected by the validation of a rule transaction.
+type InvalidRule struct {
+	// Module is the module of the rule, e.g. "flow".
+	Module string `json:"module"`
+	// Index is the index of the rule in the rules of the module.
+	Index  int          `json:"index"`
+	Rule   SentinelRule `json:"rule"`
+	Reason string       `json:"reason"`
+}
+
+// RuleValidationError reports all the invalid rules of a rule transaction, none of the rules is applied then.
+type RuleValidationError struct {
+	InvalidRules []InvalidRule `json:"invalidRules"`
+}
+
+func (e *RuleValidationError) Error() string {
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("%d invalid rule(s):", len(e.InvalidRules)))
+	for _, r := range e.InvalidRules {
+		sb.WriteString(fmt.Sprintf(" [%s #%d] %s;", r.Module, r.Index, r.Reason))
+	}
+	return sb.String()
+}
+
+// ValidateRules checks the n rules of the module by check, which returns the i-th rule (nil if the rule is nil)
+// and its validation error, and reports each invalid rule with its index.
+func ValidateRules(module string, n int, check func(i int) (SentinelRule, error)) []InvalidRule {
+	var invalidRules []InvalidRule
+	for i := 0; i < n; i++ {
+		if rule, err := check(i); err != nil {
+			invalidRules = append(invalidRules, InvalidRule{Module: module, Index: i, Rule: rule, Reason: err.Error()})
+		}
+	}
+	return invalidRules
+}
+
+// PreparedRuleUpdate is the rules of a module which are validated and built by the rule manager but not applied yet.
+// It holds the update lock of the rule manager since prepared, so it must be released by ApplyRuleUpdates
+// or DiscardRuleUpdates.
+type PreparedRuleUpdate interface {
+	// Lock locks the state of the rule manager read by the traffic, e.g. the traffic controllers.
+	Lock()
+	// Unlock unlocks the state of the rule manager read by the traffic.
+	Unlock()
+	// Swap replaces the state read by the traffic with the prepared one, it must be called with Lock held.
+	Swap()
+	// Release completes the update and releases the update lock of the rule manager,
+	// swapped indicates whether Swap was called.
+	Release(swapped bool)
+}
+
+// ApplyRuleUpdates applies the prepared updates in one step: the states of all the rule managers are locked,
+// swapped and unlocked together, so the traffic never observes a part of the updates.
+func ApplyRuleUpdates(updates ...PreparedRuleUpdate) {
+	for _, u := range updates {
+		u.Lock()
+	}
+	for _, u := range updates {
+		u.Swap()
+	}
+	for i := len(updates) - 1; i >= 0; i-- {
+		updates[i].Unlock()
+	}
+	for _, u := range updates {
+		u.Release(true)
+	}
+}
+
+// DiscardRuleUpdates releases the prepared updates without applying them.
+func DiscardRuleUpdates(updates ...PreparedRuleUpdate) {
+	for _, u := range updates {
+		u.Release(false)
+	}
+}
+
+// RuleUpdate is the PreparedRuleUpdate of a rule manager, which swaps the state built from the rules
+// under the locks of the rule manager.
+type RuleUpdate struct {
+	updateLock sync.Locker
+	stateLock  sync.Locker
+	swap       func()
+	complete   func()
+	discard    func()
+}
+
+// NewRuleUpdate creates the RuleUpdate of the rule manager with its update lock and the lock of the state read by the traffic.
+// swap replaces the state with the built one, and complete records the rules as the current rules after swapped.
+func NewRuleUpdate(updateLock, stateLock sync.Locker, swap, complete func()) *RuleUpdate {
+	return &RuleUpdate{
+		updateLock: updateLock,
+		stateLock:  stateLock,
+		swap:       swap,
+		complete:   complete,
+	}
+}
+
+// PrepareRuleUpdate acquires the update lock of the rule manager and builds the update by build,
+// the lock is released if the update fails to build.
+func PrepareRuleUpdate(updateLock sync.Locker, build func() (*RuleUpdate, error)) (PreparedRuleUpdate, error) {
+	updateLock.Lock()
+	u, err := build()
+	if err != nil {
+		updateLock.Unlock()
+		return nil, err
+	}
+	return u, nil
+}
+
+func (u *RuleUpdate) Lock() {
+	u.stateLock.Lock()
+}
+
+func (u *RuleUpdate) Unlock() {
+	u.stateLock.Unlock()
+}
+
+func (u *RuleUpdate) Swap() {
+	u.swap()
+}
+
+func (u *RuleUpdate) Release(swapped bool) {
+	if swapped {
+		u.complete()
+	} else if u.discard != nil {
+		u.discard()
+	}
+	u.updateLock.Unlock()
+}
+
+// OnDiscard sets discard, which undoes the side effects of building the update (e.g. the resource nodes pinned
+// by the built traffic controllers) once the update is released without swapped.
+func (u *RuleUpdate) OnDiscard(discard func()) *RuleUpdate {
+	u.discard = discard
+	return u
+}
+
+// Apply applies the update alone without releasing the update lock, which is held by the caller.
+func (u *RuleUpdate) Apply() {
+	u.Lock()
+	u.Swap()
+	u.Unlock()
+	u.complete()
+}
@@ -0,0 +1,113 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package base
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ruleUpdateRecorder struct {
+	name  string
+	calls *[]string
+}
+
+func (u *ruleUpdateRecorder) Lock() {
+	*u.calls = append(*u.calls, u.name+".Lock")
+}
+
+func (u *ruleUpdateRecorder) Unlock() {
+	*u.calls = append(*u.calls, u.name+".Unlock")
+}
+
+func (u *ruleUpdateRecorder) Swap() {
+	*u.calls = append(*u.calls, u.name+".Swap")
+}
+
+func (u *ruleUpdateRecorder) Release(swapped bool) {
+	if swapped {
+		*u.calls = append(*u.calls, u.name+".Release")
+	} else {
+		*u.calls = append(*u.calls, u.name+".Discard")
+	}
+}
+
+func TestApplyRuleUpdates(t *testing.T) {
+	calls := make([]string, 0)
+	a, b := &ruleUpdateRecorder{name: "a", calls: &calls}, &ruleUpdateRecorder{name: "b", calls: &calls}
+	ApplyRuleUpdates(a, b)
+	assert.Equal(t, []string{"a.Lock", "b.Lock", "a.Swap", "b.Swap", "b.Unlock", "a.Unlock", "a.Release", "b.Release"}, calls)
+
+	calls = calls[:0]
+	DiscardRuleUpdates(a, b)
+	assert.Equal(t, []string{"a.Discard", "b.Discard"}, calls)
+}
+
+func TestRuleValidationError(t *testing.T) {
+	err := &RuleValidationError{InvalidRules: []InvalidRule{
+		{Module: "flow", Index: 1, Reason: "nil Rule"},
+		{Module: "hotspot", Index: 0, Reason: "empty resource name"},
+	}}
+	assert.Equal(t, "2 invalid rule(s): [flow #1] nil Rule; [hotspot #0] empty resource name;", err.Error())
+}
+
+func TestPrepareRuleUpdate(t *testing.T) {
+	var updateMux, stateMux sync.Mutex
+	calls := make([]string, 0)
+	build := func() (*RuleUpdate, error) {
+		return NewRuleUpdate(&updateMux, &stateMux, func() {
+			calls = append(calls, "swap")
+		}, func() {
+			calls = append(calls, "complete")
+		}).OnDiscard(func() {
+			calls = append(calls, "discard")
+		}), nil
+	}
+
+	u, err := PrepareRuleUpdate(&updateMux, build)
+	assert.Nil(t, err)
+	ApplyRuleUpdates(u)
+	assert.Equal(t, []string{"swap", "complete"}, calls)
+
+	calls = calls[:0]
+	u, err = PrepareRuleUpdate(&updateMux, build)
+	assert.Nil(t, err)
+	DiscardRuleUpdates(u)
+	assert.Equal(t, []string{"discard"}, calls)
+
+	u, err = PrepareRuleUpdate(&updateMux, func() (*RuleUpdate, error) {
+		return nil, errors.New("build failed")
+	})
+	assert.NotNil(t, err)
+	assert.Nil(t, u)
+	// The update lock is released after applied, discarded or failed.
+	updateMux.Lock()
+	updateMux.Unlock()
+}
+
+func TestValidateRules(t *testing.T) {
+	rules := []string{"a", "", "b"}
+	invalidRules := ValidateRules("flow", len(rules), func(i int) (SentinelRule, error) {
+		if rules[i] == "" {
+			return nil, errors.New("empty resource name")
+		}
+		return nil, nil
+	})
+	assert.Equal(t, []InvalidRule{{Module: "flow", Index: 1, Reason: "empty resource name"}}, invalidRules)
+}
@@ -0,0 +1,141 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package base
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alibaba/sentinel-golang/util"
+	"github.com/pkg/errors"
+)
+
+// ScheduledThreshold overrides the threshold of a rule during a time window of the day,
+// e.g. the batch window from 01:00 to 05:00 could take 5x the daytime QPS.
+type ScheduledThreshold struct {
+	// Weekdays are the days (0 is Sunday) the window applies to, empty means every day.
+	// The window spanning midnight applies to the day it starts.
+	Weekdays []time.Weekday `json:"weekdays,omitempty"`
+	// Start and End are the time of the day in the format of "15:04" or "15:04:05", the window is [Start, End),
+	// and it spans midnight if End is not after Start.
+	Start string `json:"start"`
+	End   string `json:"end"`
+	// TimeZone is the IANA time zone name (e.g. "Asia/Shanghai") of Start and End, empty means UTC.
+	TimeZone string `json:"timeZone,omitempty"`
+	// Threshold is the threshold of the rule during the window.
+	Threshold float64 `json:"threshold"`
+}
+
+func (s *ScheduledThreshold) String() string {
+	return fmt.Sprintf("ScheduledThreshold{Weekdays=%v, Start=%s, End=%s, TimeZone=%s, Threshold=%.2f}",
+		s.Weekdays, s.Start, s.End, s.TimeZone, s.Threshold)
+}
+
+type scheduledWindow struct {
+	weekdays  uint8
+	start     int
+	end       int
+	loc       *time.Location
+	threshold float64
+}
+
+func (w *scheduledWindow) onDay(d time.Weekday) bool {
+	return w.weekdays == 0 || w.weekdays&(1<<uint(d)) != 0
+}
+
+func (w *scheduledWindow) contains(t time.Time) bool {
+	t = t.In(w.loc)
+	sec := t.Hour()*3600 + t.Minute()*60 + t.Second()
+	if w.start < w.end {
+		return w.start <= sec && sec < w.end && w.onDay(t.Weekday())
+	}
+	// The window spans midnight.
+	return (sec >= w.start && w.onDay(t.Weekday())) || (sec < w.end && w.onDay((t.Weekday()+6)%7))
+}
+
+// ThresholdSchedule is the compiled form of the scheduled thresholds of a rule.
+type ThresholdSchedule struct {
+	windows []*scheduledWindow
+}
+
+// NewThresholdSchedule compiles the scheduled thresholds, it returns nil if there is no scheduled threshold.
+func NewThresholdSchedule(schedule []*ScheduledThreshold) (*ThresholdSchedule, error) {
+	if len(schedule) == 0 {
+		return nil, nil
+	}
+	windows := make([]*scheduledWindow, 0, len(schedule))
+	for _, s := range schedule {
+		if s == nil {
+			return nil, errors.New("nil ScheduledThreshold")
+		}
+		if s.Threshold < 0 {
+			return nil, errors.Errorf("negative Threshold of %s", s)
+		}
+		w := &scheduledWindow{threshold: s.Threshold}
+		var err error
+		if w.start, err = parseTimeOfDay(s.Start); err != nil {
+			return nil, errors.Wrapf(err, "invalid Start of %s", s)
+		}
+		if w.end, err = parseTimeOfDay(s.End); err != nil {
+			return nil, errors.Wrapf(err, "invalid End of %s", s)
+		}
+		if w.loc, err = time.LoadLocation(s.TimeZone); err != nil {
+			return nil, errors.Wrapf(err, "invalid TimeZone of %s", s)
+		}
+		for _, d := range s.Weekdays {
+			if d < time.Sunday || d > time.Saturday {
+				return nil, errors.Errorf("invalid Weekdays of %s", s)
+			}
+			w.weekdays |= 1 << uint(d)
+		}
+		windows = append(windows, w)
+	}
+	return &ThresholdSchedule{windows: windows}, nil
+}
+
+// ThresholdAt returns the threshold of the first window containing the time, the second returned value is false
+// if no window contains the time.
+func (s *ThresholdSchedule) ThresholdAt(t time.Time) (float64, bool) {
+	if s == nil {
+		return 0, false
+	}
+	for _, w := range s.windows {
+		if w.contains(t) {
+			return w.threshold, true
+		}
+	}
+	return 0, false
+}
+
+// CurrentThreshold returns the threshold of the window containing the current time of util.CurrentClock(),
+// or the given default threshold if no window contains it.
+func (s *ThresholdSchedule) CurrentThreshold(defaultThreshold float64) float64 {
+	if s == nil {
+		return defaultThreshold
+	}
+	if threshold, ok := s.ThresholdAt(util.CurrentClock().Now()); ok {
+		return threshold
+	}
+	return defaultThreshold
+}
+
+func parseTimeOfDay(s string) (int, error) {
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Hour()*3600 + t.Minute()*60 + t.Second(), nil
+		}
+	}
+	return 0, errors.Errorf("time of day %q is not in the format of 15:04 or 15:04:05", s)
+}
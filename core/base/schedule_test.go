@@ -0,0 +1,63 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package base
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThresholdSchedule(t *testing.T) {
+	schedule, err := NewThresholdSchedule([]*ScheduledThreshold{
+		{Start: "01:00", End: "05:00", Threshold: 500},
+		{Weekdays: []time.Weekday{time.Saturday}, Start: "22:00", End: "02:00", TimeZone: "Asia/Shanghai", Threshold: 50},
+	})
+	assert.NoError(t, err)
+
+	// Monday.
+	threshold, ok := schedule.ThresholdAt(time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC))
+	assert.True(t, ok)
+	assert.Equal(t, 500.0, threshold)
+	_, ok = schedule.ThresholdAt(time.Date(2024, 1, 1, 5, 0, 0, 0, time.UTC))
+	assert.False(t, ok)
+	assert.Equal(t, 100.0, schedule.CurrentThreshold(100))
+
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	assert.NoError(t, err)
+	// The window spanning midnight applies to the day it starts.
+	threshold, ok = schedule.ThresholdAt(time.Date(2024, 1, 6, 23, 0, 0, 0, shanghai))
+	assert.True(t, ok)
+	assert.Equal(t, 50.0, threshold)
+	threshold, ok = schedule.ThresholdAt(time.Date(2024, 1, 7, 1, 30, 0, 0, shanghai))
+	assert.True(t, ok)
+	assert.Equal(t, 50.0, threshold)
+	_, ok = schedule.ThresholdAt(time.Date(2024, 1, 7, 23, 0, 0, 0, shanghai))
+	assert.False(t, ok)
+
+	var nilSchedule *ThresholdSchedule
+	assert.Equal(t, 100.0, nilSchedule.CurrentThreshold(100))
+	schedule, err = NewThresholdSchedule(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, schedule)
+
+	_, err = NewThresholdSchedule([]*ScheduledThreshold{{Start: "1am", End: "05:00"}})
+	assert.Error(t, err)
+	_, err = NewThresholdSchedule([]*ScheduledThreshold{{Start: "01:00", End: "05:00", TimeZone: "Mars/Olympus"}})
+	assert.Error(t, err)
+	_, err = NewThresholdSchedule([]*ScheduledThreshold{{Weekdays: []time.Weekday{7}, Start: "01:00", End: "05:00"}})
+	assert.Error(t, err)
+}
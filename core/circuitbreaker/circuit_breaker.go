@@ -19,6 +19,7 @@ import (
 	"sync/atomic"
 
 	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/event"
 	sbase "github.com/alibaba/sentinel-golang/core/stat/base"
 	"github.com/alibaba/sentinel-golang/logging"
 	"github.com/alibaba/sentinel-golang/util"
@@ -152,6 +153,7 @@ func (b *circuitBreakerBase) fromClosedToOpen(snapshot interface{}) bool {
 		for _, listener := range stateChangeListeners {
 			listener.OnTransformToOpen(Closed, *b.rule, snapshot)
 		}
+		b.publishStateChange(Closed, Open, snapshot)
 		return true
 	}
 	return false
@@ -164,6 +166,7 @@ func (b *circuitBreakerBase) fromOpenToHalfOpen(ctx *base.EntryContext) bool {
 		for _, listener := range stateChangeListeners {
 			listener.OnTransformToHalfOpen(Open, *b.rule)
 		}
+		b.publishStateChange(Open, HalfOpen, nil)
 
 		entry := ctx.Entry()
 		if entry == nil {
@@ -177,6 +180,7 @@ func (b *circuitBreakerBase) fromOpenToHalfOpen(ctx *base.EntryContext) bool {
 					for _, listener := range stateChangeListeners {
 						listener.OnTransformToOpen(HalfOpen, *b.rule, 1.0)
 					}
+					b.publishStateChange(HalfOpen, Open, 1.0)
 				}
 				return nil
 			})
@@ -194,6 +198,7 @@ func (b *circuitBreakerBase) fromHalfOpenToOpen(snapshot interface{}) bool {
 		for _, listener := range stateChangeListeners {
 			listener.OnTransformToOpen(HalfOpen, *b.rule, snapshot)
 		}
+		b.publishStateChange(HalfOpen, Open, snapshot)
 		return true
 	}
 	return false
@@ -206,11 +211,27 @@ func (b *circuitBreakerBase) fromHalfOpenToClosed() bool {
 		for _, listener := range stateChangeListeners {
 			listener.OnTransformToClosed(HalfOpen, *b.rule)
 		}
+		b.publishStateChange(HalfOpen, Closed, nil)
 		return true
 	}
 	return false
 }
 
+// publishStateChange publishes the state transformation to the event bus if anyone subscribes it.
+func (b *circuitBreakerBase) publishStateChange(from, to State, snapshot interface{}) {
+	if !event.HasSubscriber(event.TypeCircuitBreakerStateChanged) {
+		return
+	}
+	rule := *b.rule
+	event.Publish(&event.CircuitBreakerStateChangedEvent{
+		Time:     util.CurrentTimeMillis(),
+		Rule:     &rule,
+		From:     from.String(),
+		To:       to.String(),
+		Snapshot: snapshot,
+	})
+}
+
 //================================= slowRtCircuitBreaker ====================================
 type slowRtCircuitBreaker struct {
 	circuitBreakerBase
@@ -282,11 +303,10 @@ func (b *slowRtCircuitBreaker) OnRequestComplete(rt uint64, _ error) {
 
 	slowCount := uint64(0)
 	totalCount := uint64(0)
-	counters := metricStat.allCounter()
-	for _, c := range counters {
+	metricStat.forEachCounter(func(c *slowRequestCounter) {
 		slowCount += atomic.LoadUint64(&c.slowCount)
 		totalCount += atomic.LoadUint64(&c.totalCount)
-	}
+	})
 	slowRatio := float64(slowCount) / float64(totalCount)
 
 	// handleStateChange
@@ -324,9 +344,9 @@ func (b *slowRtCircuitBreaker) OnRequestComplete(rt uint64, _ error) {
 }
 
 func (b *slowRtCircuitBreaker) resetMetric() {
-	for _, c := range b.stat.allCounter() {
+	b.stat.forEachCounter(func(c *slowRequestCounter) {
 		c.reset()
-	}
+	})
 }
 
 type slowRequestCounter struct {
@@ -378,23 +398,21 @@ func (s *slowRequestLeapArray) currentCounter() (*slowRequestCounter, error) {
 	return counter, nil
 }
 
-func (s *slowRequestLeapArray) allCounter() []*slowRequestCounter {
-	buckets := s.data.Values()
-	ret := make([]*slowRequestCounter, 0, len(buckets))
-	for _, b := range buckets {
+// forEachCounter calls fn with the counter of every valid bucket, without allocation.
+func (s *slowRequestLeapArray) forEachCounter(fn func(*slowRequestCounter)) {
+	s.data.ForEachValue(func(b *sbase.BucketWrap) {
 		mb := b.Value.Load()
 		if mb == nil {
-			logging.Error(errors.New("current bucket atomic Value is nil"), "Current bucket atomic Value is nil in slowRequestLeapArray.allCounter()")
-			continue
+			logging.Error(errors.New("current bucket atomic Value is nil"), "Current bucket atomic Value is nil in slowRequestLeapArray.forEachCounter()")
+			return
 		}
 		counter, ok := mb.(*slowRequestCounter)
 		if !ok {
-			logging.Error(errors.New("bucket data type error"), "Bucket data type error in slowRequestLeapArray.allCounter()", "expect type", "*slowRequestCounter", "actual type", reflect.TypeOf(mb).Name())
-			continue
+			logging.Error(errors.New("bucket data type error"), "Bucket data type error in slowRequestLeapArray.forEachCounter()", "expect type", "*slowRequestCounter", "actual type", reflect.TypeOf(mb).Name())
+			return
 		}
-		ret = append(ret, counter)
-	}
-	return ret
+		fn(counter)
+	})
 }
 
 //================================= errorRatioCircuitBreaker ====================================
@@ -464,11 +482,10 @@ func (b *errorRatioCircuitBreaker) OnRequestComplete(_ uint64, err error) {
 
 	errorCount := uint64(0)
 	totalCount := uint64(0)
-	counters := metricStat.allCounter()
-	for _, c := range counters {
+	metricStat.forEachCounter(func(c *errorCounter) {
 		errorCount += atomic.LoadUint64(&c.errorCount)
 		totalCount += atomic.LoadUint64(&c.totalCount)
-	}
+	})
 	errorRatio := float64(errorCount) / float64(totalCount)
 
 	// handleStateChangeWhenThresholdExceeded
@@ -503,9 +520,9 @@ func (b *errorRatioCircuitBreaker) OnRequestComplete(_ uint64, err error) {
 }
 
 func (b *errorRatioCircuitBreaker) resetMetric() {
-	for _, c := range b.stat.allCounter() {
+	b.stat.forEachCounter(func(c *errorCounter) {
 		c.reset()
-	}
+	})
 }
 
 type errorCounter struct {
@@ -557,23 +574,21 @@ func (s *errorCounterLeapArray) currentCounter() (*errorCounter, error) {
 	return counter, nil
 }
 
-func (s *errorCounterLeapArray) allCounter() []*errorCounter {
-	buckets := s.data.Values()
-	ret := make([]*errorCounter, 0, len(buckets))
-	for _, b := range buckets {
+// forEachCounter calls fn with the counter of every valid bucket, without allocation.
+func (s *errorCounterLeapArray) forEachCounter(fn func(*errorCounter)) {
+	s.data.ForEachValue(func(b *sbase.BucketWrap) {
 		mb := b.Value.Load()
 		if mb == nil {
-			logging.Error(errors.New("current bucket atomic Value is nil"), "Current bucket atomic Value is nil in errorCounterLeapArray.allCounter()")
-			continue
+			logging.Error(errors.New("current bucket atomic Value is nil"), "Current bucket atomic Value is nil in errorCounterLeapArray.forEachCounter()")
+			return
 		}
 		counter, ok := mb.(*errorCounter)
 		if !ok {
-			logging.Error(errors.New("bucket data type error"), "Bucket data type error in errorCounterLeapArray.allCounter()", "expect type", "*errorCounter", "actual type", reflect.TypeOf(mb).Name())
-			continue
+			logging.Error(errors.New("bucket data type error"), "Bucket data type error in errorCounterLeapArray.forEachCounter()", "expect type", "*errorCounter", "actual type", reflect.TypeOf(mb).Name())
+			return
 		}
-		ret = append(ret, counter)
-	}
-	return ret
+		fn(counter)
+	})
 }
 
 //================================= errorCountCircuitBreaker ====================================
@@ -643,11 +658,10 @@ func (b *errorCountCircuitBreaker) OnRequestComplete(_ uint64, err error) {
 
 	errorCount := uint64(0)
 	totalCount := uint64(0)
-	counters := metricStat.allCounter()
-	for _, c := range counters {
+	metricStat.forEachCounter(func(c *errorCounter) {
 		errorCount += atomic.LoadUint64(&c.errorCount)
 		totalCount += atomic.LoadUint64(&c.totalCount)
-	}
+	})
 	// handleStateChangeWhenThresholdExceeded
 	curStatus := b.CurrentState()
 	if curStatus == Open {
@@ -679,7 +693,7 @@ func (b *errorCountCircuitBreaker) OnRequestComplete(_ uint64, err error) {
 }
 
 func (b *errorCountCircuitBreaker) resetMetric() {
-	for _, c := range b.stat.allCounter() {
+	b.stat.forEachCounter(func(c *errorCounter) {
 		c.reset()
-	}
+	})
 }
@@ -18,8 +18,10 @@ import (
 	"errors"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/event"
 	sbase "github.com/alibaba/sentinel-golang/core/stat/base"
 	"github.com/alibaba/sentinel-golang/logging"
 	"github.com/alibaba/sentinel-golang/util"
@@ -363,6 +365,39 @@ func TestFromClosedToOpen(t *testing.T) {
 	})
 }
 
+func TestStateChangedEvent(t *testing.T) {
+	events := make(chan event.Event, 1)
+	s, err := event.Subscribe(func(e event.Event) {
+		events <- e
+	}, event.WithTypes(event.TypeCircuitBreakerStateChanged))
+	assert.Nil(t, err)
+	defer s.Unsubscribe()
+
+	r := &Rule{
+		Resource:         "abc",
+		Strategy:         ErrorCount,
+		RetryTimeoutMs:   3000,
+		MinRequestAmount: 10,
+		StatIntervalMs:   10000,
+		Threshold:        1.0,
+	}
+	b, err := newErrorCountCircuitBreaker(r)
+	assert.Nil(t, err)
+	assert.True(t, b.fromClosedToOpen(2.0))
+
+	select {
+	case e := <-events:
+		changed := e.(*event.CircuitBreakerStateChangedEvent)
+		assert.Equal(t, "Closed", changed.From)
+		assert.Equal(t, "Open", changed.To)
+		assert.Equal(t, 2.0, changed.Snapshot)
+		assert.Equal(t, "abc", changed.Rule.ResourceName())
+		assert.True(t, changed.Rule != r)
+	case <-time.After(time.Second):
+		t.Fatal("the state changed event is not delivered")
+	}
+}
+
 func TestFromHalfOpenToOpen(t *testing.T) {
 	ClearStateChangeListeners()
 	stateChangeListenerMock := &StateChangeListenerMock{}
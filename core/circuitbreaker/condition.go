@@ -0,0 +1,52 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package circuitbreaker
+
+import (
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/logging"
+)
+
+// appliesTo checks whether the rule applies to the entry of the input, according to the Conditions of the rule.
+func (m *RuleManager) appliesTo(rule *Rule, input *base.SentinelInput) bool {
+	if rule == nil || len(rule.Conditions) == 0 {
+		return true
+	}
+	v, ok := m.conditions.Load(rule)
+	if !ok {
+		conditions, err := base.NewConditionMatcher(rule.Conditions)
+		if err != nil {
+			logging.Error(err, "Invalid Conditions of the rule in circuitbreaker.RuleManager.appliesTo()", "rule", rule)
+		}
+		v, _ = m.conditions.LoadOrStore(rule, conditions)
+	}
+	return v.(*base.ConditionMatcher).Matches(input)
+}
+
+// pruneConditions drops the compiled conditions of the rules which are no longer loaded.
+func (m *RuleManager) pruneConditions() {
+	loaded := make(map[*Rule]struct{})
+	m.updateMux.RLock()
+	for _, rule := range rulesFrom(m.breakerRules) {
+		loaded[rule] = struct{}{}
+	}
+	m.updateMux.RUnlock()
+	m.conditions.Range(func(key, _ interface{}) bool {
+		if _, ok := loaded[key.(*Rule)]; !ok {
+			m.conditions.Delete(key)
+		}
+		return true
+	})
+}
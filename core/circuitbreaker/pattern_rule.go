@@ -0,0 +1,199 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package circuitbreaker
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/config"
+	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/pkg/errors"
+)
+
+// patternRule is a circuit breaking rule whose Resource is a pattern rather than an exact resource name.
+type patternRule struct {
+	rule    *Rule
+	matcher *base.ResourceMatcher
+	// sharedCb is the circuit breaker shared by all the matched resources if the rule asks for the shared statistic,
+	// otherwise each matched resource has its own circuit breaker, which is built on the first access.
+	sharedCb CircuitBreaker
+}
+
+// patternRuleSet holds all the pattern rules, and caches the circuit breakers resolved for the accessed resources.
+// A new set is created whenever the rules are updated, so that the cache never outlives the rules it's resolved from.
+type patternRuleSet struct {
+	rules []*patternRule
+
+	resolved    map[string][]CircuitBreaker
+	resolvedMux sync.RWMutex
+	// overflowWarned indicates whether the warning of reaching the max amount of resources has been logged.
+	overflowWarned bool
+}
+
+func newPatternRuleSet(patternRuleMap map[string][]*patternRule) *patternRuleSet {
+	if len(patternRuleMap) == 0 {
+		return nil
+	}
+	patterns := make([]string, 0, len(patternRuleMap))
+	for pattern := range patternRuleMap {
+		patterns = append(patterns, pattern)
+	}
+	// Keep the order of the rules deterministic.
+	sort.Strings(patterns)
+	rules := make([]*patternRule, 0, len(patterns))
+	for _, pattern := range patterns {
+		rules = append(rules, patternRuleMap[pattern]...)
+	}
+	return &patternRuleSet{
+		rules:    rules,
+		resolved: make(map[string][]CircuitBreaker),
+	}
+}
+
+// breakersOf returns the circuit breakers of the exact rules of res, followed by those of the matched pattern rules.
+func (s *patternRuleSet) breakersOf(res string, exactCbs []CircuitBreaker) []CircuitBreaker {
+	s.resolvedMux.RLock()
+	cbs, ok := s.resolved[res]
+	s.resolvedMux.RUnlock()
+	if ok {
+		return cbs
+	}
+
+	s.resolvedMux.Lock()
+	defer s.resolvedMux.Unlock()
+	if cbs, ok = s.resolved[res]; ok {
+		return cbs
+	}
+	// The cache is limited by the max amount of resources, the resources beyond the limit are only guarded
+	// by the shared circuit breakers, since their own circuit breakers could not be retained.
+	cacheable := len(s.resolved) < int(config.MaxResourceAmount())
+	cbs = make([]CircuitBreaker, 0, len(exactCbs)+1)
+	cbs = append(cbs, exactCbs...)
+	for _, p := range s.rules {
+		if !p.matcher.Match(res) {
+			continue
+		}
+		if p.sharedCb != nil {
+			cbs = append(cbs, p.sharedCb)
+			continue
+		}
+		if !cacheable {
+			if !s.overflowWarned {
+				s.overflowWarned = true
+				logging.Warn("[CircuitBreaker] The max amount of resources is reached, the pattern rules without shared statistic are skipped for the new resources",
+					"resource", res, "rule", p.rule)
+			}
+			continue
+		}
+		cb, err := newCircuitBreakerOf(p.rule)
+		if err != nil {
+			logging.Error(err, "Fail to build the circuit breaker of the matched resource in circuitbreaker.patternRuleSet.breakersOf()",
+				"resource", res, "rule", p.rule)
+			continue
+		}
+		cbs = append(cbs, cb)
+	}
+	if cacheable {
+		s.resolved[res] = cbs
+	}
+	return cbs
+}
+
+// resolvedBreakers returns the circuit breakers resolved for the accessed resources so far, the resources without any circuit breaker are omitted.
+func (s *patternRuleSet) resolvedBreakers() map[string][]CircuitBreaker {
+	s.resolvedMux.RLock()
+	defer s.resolvedMux.RUnlock()
+
+	ret := make(map[string][]CircuitBreaker, len(s.resolved))
+	for res, cbs := range s.resolved {
+		if len(cbs) > 0 {
+			ret[res] = cbs
+		}
+	}
+	return ret
+}
+
+// buildPatternRules builds the pattern rules of the same pattern, the shared circuit breakers of the equivalent old rules are reused.
+func buildPatternRules(pattern string, rulesOfPattern []*Rule, oldPatternRules []*patternRule) []*patternRule {
+	newPatternRules := make([]*patternRule, 0, len(rulesOfPattern))
+	for _, r := range rulesOfPattern {
+		if pattern != r.Resource {
+			logging.Error(errors.Errorf("unmatched resource pattern expect: %s, actual: %s", pattern, r.Resource), "Unmatched resource pattern in circuitBreaker.buildPatternRules()", "rule", r)
+			continue
+		}
+		reused := false
+		for idx, old := range oldPatternRules {
+			if old.rule.isEqualsTo(r) {
+				newPatternRules = append(newPatternRules, old)
+				oldPatternRules = append(oldPatternRules[:idx], oldPatternRules[idx+1:]...)
+				reused = true
+				break
+			}
+		}
+		if reused {
+			continue
+		}
+		matcher, err := base.NewResourceMatcher(r.ResourceMatchStrategy, r.Resource)
+		if err != nil {
+			logging.Warn("[CircuitBreaker buildPatternRules] Ignoring the rule due to invalid resource pattern", "rule", r, "err", err.Error())
+			continue
+		}
+		p := &patternRule{
+			rule:    r,
+			matcher: matcher,
+		}
+		if r.SharedStat {
+			if p.sharedCb, err = newCircuitBreakerOf(r); err != nil {
+				logging.Warn("[CircuitBreaker buildPatternRules] Ignoring the rule due to bad generated circuit breaker", "rule", r, "err", err.Error())
+				continue
+			}
+		}
+		newPatternRules = append(newPatternRules, p)
+	}
+	return newPatternRules
+}
+
+// newCircuitBreakerOf generates a new circuit breaker with its own statistic for the rule.
+func newCircuitBreakerOf(r *Rule) (CircuitBreaker, error) {
+	cbGenMux.RLock()
+	generator := cbGenFuncMap[r.Strategy]
+	cbGenMux.RUnlock()
+	if generator == nil {
+		return nil, errors.New("unsupported circuit breaking strategy")
+	}
+	cb, err := generator(r, nil)
+	if err != nil {
+		return nil, err
+	}
+	if cb == nil {
+		return nil, errors.New("bad generated circuit breaker")
+	}
+	return cb, nil
+}
+
+// splitPatternRules splits the rules into the exact rules and the pattern rules.
+func splitPatternRules(rules []*Rule) (exactRules, patternRules []*Rule) {
+	exactRules = make([]*Rule, 0, len(rules))
+	for _, r := range rules {
+		if r.ResourceMatchStrategy.IsPattern() {
+			patternRules = append(patternRules, r)
+		} else {
+			exactRules = append(exactRules, r)
+		}
+	}
+	return exactRules, patternRules
+}
@@ -0,0 +1,90 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package circuitbreaker
+
+import (
+	"testing"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/stat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatternRules(t *testing.T) {
+	newRule := func(pattern string, strategy base.ResourceMatchStrategy, sharedStat bool) *Rule {
+		return &Rule{
+			Resource:              pattern,
+			ResourceMatchStrategy: strategy,
+			SharedStat:            sharedStat,
+			Strategy:              ErrorCount,
+			RetryTimeoutMs:        1000,
+			MinRequestAmount:      5,
+			StatIntervalMs:        1000,
+			Threshold:             10,
+		}
+	}
+
+	t.Run("PerResourceBreaker", func(t *testing.T) {
+		m := NewRuleManager(stat.NewNodeStorage(nil))
+		r := newRule("rpc:.*", base.ResourceMatchRegex, false)
+		succ, err := m.LoadRules([]*Rule{r})
+		assert.True(t, succ && err == nil)
+		assert.Equal(t, 1, len(m.GetRules()))
+		assert.Equal(t, 0, len(m.GetBreakers()))
+
+		cbsA := m.getBreakersOfResource("rpc:a")
+		cbsB := m.getBreakersOfResource("rpc:b")
+		assert.Equal(t, 1, len(cbsA))
+		assert.Equal(t, 1, len(cbsB))
+		assert.Equal(t, 0, len(m.getBreakersOfResource("http:a")))
+		// Each matched resource has its own circuit breaker.
+		assert.True(t, cbsA[0] != cbsB[0])
+		assert.True(t, cbsA[0].BoundRule() == r)
+		// The resolved circuit breakers are cached.
+		assert.True(t, m.getBreakersOfResource("rpc:a")[0] == cbsA[0])
+		breakers := m.GetBreakers()
+		assert.Equal(t, 2, len(breakers))
+		assert.True(t, breakers["rpc:a"][0] == cbsA[0])
+
+		assert.Nil(t, m.ClearRules())
+		assert.Equal(t, 0, len(m.getBreakersOfResource("rpc:a")))
+	})
+
+	t.Run("SharedBreaker", func(t *testing.T) {
+		m := NewRuleManager(stat.NewNodeStorage(nil))
+		r := newRule("rpc:", base.ResourceMatchPrefix, true)
+		exact := newRule("rpc:a", base.ResourceMatchExact, false)
+		succ, err := m.LoadRules([]*Rule{r, exact})
+		assert.True(t, succ && err == nil)
+
+		cbsA := m.getBreakersOfResource("rpc:a")
+		cbsB := m.getBreakersOfResource("rpc:b")
+		assert.Equal(t, 2, len(cbsA))
+		assert.Equal(t, 1, len(cbsB))
+		assert.True(t, cbsA[0].BoundRule() == exact)
+		assert.True(t, cbsA[1] == cbsB[0])
+
+		succ, err = m.LoadRulesOfResource("rpc:", nil)
+		assert.True(t, succ && err == nil)
+		assert.Equal(t, 1, len(m.GetRules()))
+		assert.Equal(t, 1, len(m.getBreakersOfResource("rpc:a")))
+		assert.Equal(t, 0, len(m.getBreakersOfResource("rpc:b")))
+	})
+
+	t.Run("InvalidPattern", func(t *testing.T) {
+		assert.Error(t, IsValidRule(newRule("", base.ResourceMatchGlob, false)))
+		assert.Error(t, IsValidRule(newRule("rpc:[", base.ResourceMatchRegex, false)))
+	})
+}
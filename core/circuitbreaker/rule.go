@@ -16,7 +16,9 @@ package circuitbreaker
 
 import (
 	"fmt"
+	"reflect"
 
+	"github.com/alibaba/sentinel-golang/core/base"
 	"github.com/alibaba/sentinel-golang/util"
 )
 
@@ -46,13 +48,35 @@ func (s Strategy) String() string {
 	}
 }
 
+var strategyNames = map[string]int64{
+	"slowrequestratio": int64(SlowRequestRatio),
+	"errorratio":       int64(ErrorRatio),
+	"errorcount":       int64(ErrorCount),
+}
+
+// UnmarshalJSON accepts either the integer code or the name (e.g. "SlowRequestRatio") of Strategy.
+func (s *Strategy) UnmarshalJSON(data []byte) error {
+	v, err := util.ParseEnumJSON(data, strategyNames)
+	if err != nil {
+		return err
+	}
+	*s = Strategy(v)
+	return nil
+}
+
 // Rule encompasses the fields of circuit breaking rule.
 type Rule struct {
 	// unique id
 	Id string `json:"id,omitempty"`
-	// resource name
-	Resource string   `json:"resource"`
-	Strategy Strategy `json:"strategy"`
+	// resource name, or the resource pattern if ResourceMatchStrategy is not exact
+	Resource string `json:"resource"`
+	// ResourceMatchStrategy indicates how Resource matches the resource names. By default Resource is the exact
+	// resource name, otherwise it's a prefix, glob or regex pattern and the rule applies to all the matched resources.
+	ResourceMatchStrategy base.ResourceMatchStrategy `json:"resourceMatchStrategy,omitempty"`
+	// SharedStat only takes effect for the pattern rules. By default each matched resource has its own circuit breaker,
+	// while all the matched resources share a single circuit breaker (and its statistic) if SharedStat is true.
+	SharedStat bool     `json:"sharedStat,omitempty"`
+	Strategy   Strategy `json:"strategy"`
 	// RetryTimeoutMs represents recovery timeout (in milliseconds) before the circuit breaker opens.
 	// During the open period, no requests are permitted until the timeout has elapsed.
 	// After that, the circuit breaker will transform to half-open state for trying a few "trial" requests.
@@ -78,20 +102,31 @@ type Rule struct {
 	// for ErrorRatio, it represents the max error request ratio
 	// for ErrorCount, it represents the max error request count
 	Threshold float64 `json:"threshold"`
+	// Conditions restrict the rule to the entries matching all of them, e.g. the ones with the attachment region == "eu",
+	// the rule applies to all the entries if empty. Only the completed matching entries are recorded by the circuit breaker.
+	Conditions []*base.MatchCondition `json:"conditions,omitempty"`
+	// Mode indicates whether the rule rejects the traffic (RuleModeEnforce, by default),
+	// or only records the would-be blocks without rejecting anything (RuleModeShadow).
+	Mode base.RuleMode `json:"mode,omitempty"`
+	// EffectiveFrom and ExpiresAt are the optional effective period of the rule in Unix milliseconds, 0 means unbounded.
+	// The rule takes effect in [EffectiveFrom, ExpiresAt), out of which it's kept loaded but ignored.
+	EffectiveFrom uint64 `json:"effectiveFrom,omitempty"`
+	ExpiresAt     uint64 `json:"expiresAt,omitempty"`
 }
 
 func (r *Rule) String() string {
 	// fallback string
-	return fmt.Sprintf("{id=%s, resource=%s, strategy=%s, RetryTimeoutMs=%d, MinRequestAmount=%d, StatIntervalMs=%d, StatSlidingWindowBucketCount=%d, MaxAllowedRtMs=%d, Threshold=%f}",
-		r.Id, r.Resource, r.Strategy, r.RetryTimeoutMs, r.MinRequestAmount, r.StatIntervalMs, r.StatSlidingWindowBucketCount, r.MaxAllowedRtMs, r.Threshold)
+	return fmt.Sprintf("{id=%s, resource=%s, resourceMatchStrategy=%s, sharedStat=%t, strategy=%s, RetryTimeoutMs=%d, MinRequestAmount=%d, StatIntervalMs=%d, StatSlidingWindowBucketCount=%d, MaxAllowedRtMs=%d, Threshold=%f, Conditions=%v, Mode=%s, EffectiveFrom=%d, ExpiresAt=%d}",
+		r.Id, r.Resource, r.ResourceMatchStrategy, r.SharedStat, r.Strategy, r.RetryTimeoutMs, r.MinRequestAmount, r.StatIntervalMs, r.StatSlidingWindowBucketCount, r.MaxAllowedRtMs, r.Threshold, r.Conditions, r.Mode, r.EffectiveFrom, r.ExpiresAt)
 }
 
 func (r *Rule) isStatReusable(newRule *Rule) bool {
 	if newRule == nil {
 		return false
 	}
-	return r.Resource == newRule.Resource && r.Strategy == newRule.Strategy && r.StatIntervalMs == newRule.StatIntervalMs &&
-		r.StatSlidingWindowBucketCount == newRule.StatSlidingWindowBucketCount
+	return r.Resource == newRule.Resource && r.ResourceMatchStrategy == newRule.ResourceMatchStrategy && r.SharedStat == newRule.SharedStat &&
+		r.Strategy == newRule.Strategy && r.StatIntervalMs == newRule.StatIntervalMs &&
+		r.StatSlidingWindowBucketCount == newRule.StatSlidingWindowBucketCount && reflect.DeepEqual(r.Conditions, newRule.Conditions)
 }
 
 func (r *Rule) ResourceName() string {
@@ -102,8 +137,11 @@ func (r *Rule) isEqualsToBase(newRule *Rule) bool {
 	if newRule == nil {
 		return false
 	}
-	return r.Resource == newRule.Resource && r.Strategy == newRule.Strategy && r.RetryTimeoutMs == newRule.RetryTimeoutMs &&
-		r.MinRequestAmount == newRule.MinRequestAmount && r.StatIntervalMs == newRule.StatIntervalMs && r.StatSlidingWindowBucketCount == newRule.StatSlidingWindowBucketCount
+	return r.Resource == newRule.Resource && r.ResourceMatchStrategy == newRule.ResourceMatchStrategy && r.SharedStat == newRule.SharedStat &&
+		r.Strategy == newRule.Strategy && r.RetryTimeoutMs == newRule.RetryTimeoutMs &&
+		r.MinRequestAmount == newRule.MinRequestAmount && r.StatIntervalMs == newRule.StatIntervalMs && r.StatSlidingWindowBucketCount == newRule.StatSlidingWindowBucketCount &&
+		reflect.DeepEqual(r.Conditions, newRule.Conditions) && r.Mode == newRule.Mode &&
+		r.EffectiveFrom == newRule.EffectiveFrom && r.ExpiresAt == newRule.ExpiresAt
 }
 
 func (r *Rule) isEqualsTo(newRule *Rule) bool {
@@ -19,6 +19,9 @@ import (
 	"reflect"
 	"sync"
 
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/event"
+	"github.com/alibaba/sentinel-golang/core/stat"
 	"github.com/alibaba/sentinel-golang/logging"
 	"github.com/alibaba/sentinel-golang/util"
 	"github.com/pkg/errors"
@@ -26,18 +29,62 @@ import (
 
 type CircuitBreakerGenFunc func(r *Rule, reuseStat interface{}) (CircuitBreaker, error)
 
+// RuleManager manages the circuit breaking rules and the circuit breakers built from them.
+// The package-level functions (e.g. LoadRules) operate on the default RuleManager.
+type RuleManager struct {
+	breakerRules map[string][]*Rule
+	breakers     map[string][]CircuitBreaker
+	// patternRules holds the rules whose Resource is a pattern, keyed by the pattern.
+	patternRules map[string][]*patternRule
+	// patterns is the set of all the pattern rules built from patternRules, nil if there is no pattern rule.
+	patterns *patternRuleSet
+	// conditions holds the compiled *base.ConditionMatcher of the rules with Conditions, keyed by *Rule.
+	conditions    sync.Map
+	updateMux     sync.RWMutex
+	currentRules  map[string][]*Rule
+	updateRuleMux sync.Mutex
+	// refresher rebuilds the circuit breakers once any rule becomes effective or expires.
+	refresher *base.RuleRefresher
+	// pins holds the nodes of the resources with the rules, so that the statistics of them are never accounted to
+	// the shared overflow node once the max amount of resources is reached.
+	pins *stat.ResourceNodePins
+}
+
 var (
 	cbGenFuncMap = make(map[Strategy]CircuitBreakerGenFunc, 4)
+	cbGenMux     = new(sync.RWMutex)
 
-	breakerRules  = make(map[string][]*Rule)
-	breakers      = make(map[string][]CircuitBreaker)
-	updateMux     = new(sync.RWMutex)
-	currentRules  = make(map[string][]*Rule, 0)
-	updateRuleMux = new(sync.Mutex)
-
+	// stateChangeListeners are shared by the circuit breakers of all the rule managers.
 	stateChangeListeners = make([]StateChangeListener, 0)
+
+	defaultRuleManager = NewRuleManager(stat.DefaultNodeStorage())
 )
 
+// NewRuleManager creates an empty RuleManager, which pins the nodes of the resources with the rules in nodes.
+func NewRuleManager(nodes *stat.NodeStorage) *RuleManager {
+	m := &RuleManager{
+		breakerRules: make(map[string][]*Rule),
+		breakers:     make(map[string][]CircuitBreaker),
+		patternRules: make(map[string][]*patternRule),
+		currentRules: make(map[string][]*Rule, 0),
+		pins:         stat.NewResourceNodePins(nodes),
+	}
+	m.refresher = base.NewRuleRefresher(event.ModuleCircuitBreaker, &m.updateRuleMux, (*refreshableRules)(m))
+	return m
+}
+
+// DefaultRuleManager returns the RuleManager operated by the package-level functions.
+func DefaultRuleManager() *RuleManager {
+	return defaultRuleManager
+}
+
+func managerOrDefault(m *RuleManager) *RuleManager {
+	if m == nil {
+		return defaultRuleManager
+	}
+	return m
+}
+
 func init() {
 	cbGenFuncMap[SlowRequestRatio] = func(r *Rule, reuseStat interface{}) (CircuitBreaker, error) {
 		if r == nil {
@@ -90,9 +137,14 @@ func init() {
 // GetRulesOfResource need to compete circuit breaker module's global lock and the high performance losses of copy,
 // 		reduce or do not call GetRulesOfResource frequently if possible
 func GetRulesOfResource(resource string) []Rule {
-	updateMux.RLock()
-	resRules, ok := breakerRules[resource]
-	updateMux.RUnlock()
+	return defaultRuleManager.GetRulesOfResource(resource)
+}
+
+// GetRulesOfResource returns specific resource's rules based on copy.
+func (m *RuleManager) GetRulesOfResource(resource string) []Rule {
+	m.updateMux.RLock()
+	resRules, ok := m.breakerRules[resource]
+	m.updateMux.RUnlock()
 	if !ok {
 		return nil
 	}
@@ -108,9 +160,14 @@ func GetRulesOfResource(resource string) []Rule {
 // GetRules need to compete circuit breaker module's global lock and the high performance losses of copy,
 // 		reduce or do not call GetRules if possible
 func GetRules() []Rule {
-	updateMux.RLock()
-	rules := rulesFrom(breakerRules)
-	updateMux.RUnlock()
+	return defaultRuleManager.GetRules()
+}
+
+// GetRules returns all the rules based on copy.
+func (m *RuleManager) GetRules() []Rule {
+	m.updateMux.RLock()
+	rules := rulesFrom(m.breakerRules)
+	m.updateMux.RUnlock()
 	ret := make([]Rule, 0, len(rules))
 	for _, rule := range rules {
 		ret = append(ret, *rule)
@@ -120,10 +177,22 @@ func GetRules() []Rule {
 
 // ClearRules clear all the previous rules.
 func ClearRules() error {
-	_, err := LoadRules(nil)
+	return defaultRuleManager.ClearRules()
+}
+
+// ClearRules clear all the previous rules of the rule manager.
+func (m *RuleManager) ClearRules() error {
+	_, err := m.LoadRules(nil)
 	return err
 }
 
+// Close stops refreshing the rules in background and clears all the rules of the rule manager,
+// which must not be used any more then.
+func (m *RuleManager) Close() error {
+	m.refresher.Stop()
+	return m.ClearRules()
+}
+
 // LoadRules replaces old rules with the given circuit breaking rules.
 //
 // return value:
@@ -131,69 +200,104 @@ func ClearRules() error {
 // bool: was designed to indicate whether the internal map has been changed
 // error: was designed to indicate whether occurs the error.
 func LoadRules(rules []*Rule) (bool, error) {
-	resRulesMap := make(map[string][]*Rule, 16)
-	for _, rule := range rules {
-		resRules, exist := resRulesMap[rule.Resource]
-		if !exist {
-			resRules = make([]*Rule, 0, 1)
-		}
-		resRulesMap[rule.Resource] = append(resRules, rule)
-	}
+	return defaultRuleManager.LoadRules(rules)
+}
+
+// LoadRules replaces old rules of the rule manager with the given circuit breaking rules.
+func (m *RuleManager) LoadRules(rules []*Rule) (bool, error) {
+	resRulesMap := resRulesMapOf(rules)
 
-	updateRuleMux.Lock()
-	defer updateRuleMux.Unlock()
-	isEqual := reflect.DeepEqual(currentRules, resRulesMap)
+	m.updateRuleMux.Lock()
+	defer m.updateRuleMux.Unlock()
+	isEqual := reflect.DeepEqual(m.currentRules, resRulesMap)
 	if isEqual {
 		logging.Info("[CircuitBreaker] Load rules is the same with current rules, so ignore load operation.")
 		return false, nil
 	}
 
-	err := onRuleUpdate(resRulesMap)
+	err := m.onRuleUpdate(resRulesMap)
 	return true, err
 }
 
 // LoadRulesOfResource loads the given resource's circuitBreaker rules to the rule manager, while all previous resource's rules will be replaced.
 // the first returned value indicates whether do real load operation, if the rules is the same with previous resource's rules, return false
 func LoadRulesOfResource(res string, rules []*Rule) (bool, error) {
+	return defaultRuleManager.LoadRulesOfResource(res, rules)
+}
+
+// LoadRulesOfResource loads the given resource's circuitBreaker rules to the rule manager, while all previous resource's rules will be replaced.
+func (m *RuleManager) LoadRulesOfResource(res string, rules []*Rule) (bool, error) {
 	if len(res) == 0 {
 		return false, errors.New("empty resource")
 	}
-	updateRuleMux.Lock()
-	defer updateRuleMux.Unlock()
+	m.updateRuleMux.Lock()
+	defer m.updateRuleMux.Unlock()
 	// clear resource rules
 	if len(rules) == 0 {
 		// clear resource's currentRules
-		delete(currentRules, res)
+		delete(m.currentRules, res)
 		// clear breakers & breakerRules
-		updateMux.Lock()
-		delete(breakers, res)
-		delete(breakerRules, res)
-		updateMux.Unlock()
+		m.updateMux.Lock()
+		delete(m.breakers, res)
+		delete(m.breakerRules, res)
+		delete(m.patternRules, res)
+		// The pattern rule set caches the exact rules as well, so it's always rebuilt.
+		m.patterns = newPatternRuleSet(m.patternRules)
+		m.pinResourceNodes()
+		m.updateMux.Unlock()
+		m.pruneConditions()
+		m.refresher.Schedule(util.CurrentTimeMillis())
 		logging.Info("[CircuitBreaker] clear resource level rules", "resource", res)
+		publishRuleChange(res, nil)
 		return true, nil
 	}
 	// load resource level rules
-	isEqual := reflect.DeepEqual(currentRules[res], rules)
+	isEqual := reflect.DeepEqual(m.currentRules[res], rules)
 	if isEqual {
 		logging.Info("[CircuitBreaker] Load resource level rules is the same with current resource level rules, so ignore load operation.")
 		return false, nil
 	}
-	err := onResourceRuleUpdate(res, rules)
+	err := m.onResourceRuleUpdate(res, rules)
 	return true, err
 }
 
-func getBreakersOfResource(resource string) []CircuitBreaker {
-	updateMux.RLock()
-	resCBs := breakers[resource]
-	updateMux.RUnlock()
-	ret := make([]CircuitBreaker, 0, len(resCBs))
-	if len(resCBs) == 0 {
-		return ret
+// GetBreakers returns all the circuit breakers currently in effect, grouped by resource.
+// The circuit breakers of the pattern rules are grouped by the matched resources accessed so far.
+func GetBreakers() map[string][]CircuitBreaker {
+	return defaultRuleManager.GetBreakers()
+}
+
+// GetBreakers returns all the circuit breakers of the rule manager currently in effect, grouped by resource.
+func (m *RuleManager) GetBreakers() map[string][]CircuitBreaker {
+	m.updateMux.RLock()
+	defer m.updateMux.RUnlock()
+	ret := make(map[string][]CircuitBreaker, len(m.breakers))
+	for res, resCBs := range m.breakers {
+		ret[res] = append(make([]CircuitBreaker, 0, len(resCBs)), resCBs...)
+	}
+	if m.patterns != nil {
+		for res, resCBs := range m.patterns.resolvedBreakers() {
+			ret[res] = append(make([]CircuitBreaker, 0, len(resCBs)), resCBs...)
+		}
 	}
-	ret = append(ret, resCBs...)
 	return ret
 }
 
+// getBreakersOfResource returns the circuit breakers of the resource, including those of the matched pattern rules.
+// The returned slice is shared and must not be modified, it's never mutated by the rule manager
+// since the breakers of a resource are always replaced by a new slice.
+func (m *RuleManager) getBreakersOfResource(resource string) []CircuitBreaker {
+	m.refresher.Check(util.CurrentTimeMillis())
+	m.updateMux.RLock()
+	resCBs := m.breakers[resource]
+	patterns := m.patterns
+	m.updateMux.RUnlock()
+	if patterns == nil {
+		return resCBs
+	}
+	return patterns.breakersOf(resource, resCBs)
+}
+
 func calculateReuseIndexFor(r *Rule, oldResCbs []CircuitBreaker) (equalIdx, reuseStatIdx int) {
 	// the index of equivalent rule in old circuit breaker slice
 	equalIdx = -1
@@ -221,7 +325,29 @@ func calculateReuseIndexFor(r *Rule, oldResCbs []CircuitBreaker) (equalIdx, reus
 }
 
 // Concurrent safe to update rules
-func onRuleUpdate(rawResRulesMap map[string][]*Rule) (err error) {
+// ruleUpdate holds the circuit breakers built from the rules, which are swapped into the rule manager at once.
+type ruleUpdate struct {
+	m                *RuleManager
+	rawResRulesMap   map[string][]*Rule
+	validResRulesMap map[string][]*Rule
+	breakers         map[string][]CircuitBreaker
+	patternRules     map[string][]*patternRule
+	now              uint64
+	start            uint64
+}
+
+func (m *RuleManager) onRuleUpdate(rawResRulesMap map[string][]*Rule) error {
+	u, err := m.buildRuleUpdate(rawResRulesMap)
+	if err != nil {
+		return err
+	}
+	u.Apply()
+	return nil
+}
+
+// buildRuleUpdate builds the circuit breakers of the valid rules without applying them.
+// It must be called with updateRuleMux held.
+func (m *RuleManager) buildRuleUpdate(rawResRulesMap map[string][]*Rule) (u *base.RuleUpdate, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			var ok bool
@@ -249,35 +375,87 @@ func onRuleUpdate(rawResRulesMap map[string][]*Rule) (err error) {
 
 	start := util.CurrentTimeNano()
 
-	updateMux.RLock()
+	m.updateMux.RLock()
 	breakersClone := make(map[string][]CircuitBreaker, len(validResRulesMap))
-	for res, tcs := range breakers {
+	for res, tcs := range m.breakers {
 		resTcClone := make([]CircuitBreaker, 0, len(tcs))
 		resTcClone = append(resTcClone, tcs...)
 		breakersClone[res] = resTcClone
 	}
-	updateMux.RUnlock()
+	patternRulesClone := make(map[string][]*patternRule, len(m.patternRules))
+	for pattern, ps := range m.patternRules {
+		patternRulesClone[pattern] = append(make([]*patternRule, 0, len(ps)), ps...)
+	}
+	m.updateMux.RUnlock()
 
+	now := util.CurrentTimeMillis()
 	newBreakers := make(map[string][]CircuitBreaker, len(validResRulesMap))
+	newPatternRules := make(map[string][]*patternRule)
 	for res, resRules := range validResRulesMap {
-		newCbsOfRes := buildResourceCircuitBreaker(res, resRules, breakersClone[res])
-		if len(newCbsOfRes) > 0 {
+		activeRules, _ := splitInactiveRules(resRules, now)
+		exactRules, patternRules := splitPatternRules(activeRules)
+		if newCbsOfRes := buildResourceCircuitBreaker(res, exactRules, breakersClone[res]); len(newCbsOfRes) > 0 {
 			newBreakers[res] = newCbsOfRes
 		}
+		if newPatternRulesOfRes := buildPatternRules(res, patternRules, patternRulesClone[res]); len(newPatternRulesOfRes) > 0 {
+			newPatternRules[res] = newPatternRulesOfRes
+		}
 	}
 
-	updateMux.Lock()
-	breakerRules = validResRulesMap
-	breakers = newBreakers
-	updateMux.Unlock()
-	currentRules = rawResRulesMap
+	update := &ruleUpdate{
+		m:                m,
+		rawResRulesMap:   rawResRulesMap,
+		validResRulesMap: validResRulesMap,
+		breakers:         newBreakers,
+		patternRules:     newPatternRules,
+		now:              now,
+		start:            start,
+	}
+	return base.NewRuleUpdate(&m.updateRuleMux, &m.updateMux, update.swap, update.complete), nil
+}
 
-	logging.Debug("[CircuitBreaker onRuleUpdate] Time statistics(ns) for updating circuit breaker rule", "timeCost", util.CurrentTimeNano()-start)
-	logRuleUpdate(validResRulesMap)
-	return nil
+// swap replaces the state read by the traffic with the built one, it must be called with the state locked.
+func (u *ruleUpdate) swap() {
+	u.m.breakerRules = u.validResRulesMap
+	u.m.breakers = u.breakers
+	u.m.patternRules = u.patternRules
+	u.m.patterns = newPatternRuleSet(u.patternRules)
+	u.m.pinResourceNodes()
+}
+
+// complete records the swapped rules as the current rules, it must be called with updateRuleMux held.
+func (u *ruleUpdate) complete() {
+	u.m.pruneConditions()
+	u.m.currentRules = u.rawResRulesMap
+	u.m.refresher.Schedule(u.now)
+
+	logging.Debug("[CircuitBreaker onRuleUpdate] Time statistics(ns) for updating circuit breaker rule", "timeCost", util.CurrentTimeNano()-u.start)
+	logRuleUpdate(u.validResRulesMap)
+}
+
+// pinResourceNodes pins the nodes of the resources with the circuit breakers and unpins the others, it must be
+// called with updateMux locked.
+func (m *RuleManager) pinResourceNodes() {
+	resources := make(map[string]struct{}, len(m.breakers))
+	for res := range m.breakers {
+		resources[res] = struct{}{}
+	}
+	m.pins.PinOnly(resources)
+}
+
+func resRulesMapOf(rules []*Rule) map[string][]*Rule {
+	resRulesMap := make(map[string][]*Rule, 16)
+	for _, rule := range rules {
+		resRules, exist := resRulesMap[rule.Resource]
+		if !exist {
+			resRules = make([]*Rule, 0, 1)
+		}
+		resRulesMap[rule.Resource] = append(resRules, rule)
+	}
+	return resRulesMap
 }
 
-func onResourceRuleUpdate(res string, rawResRules []*Rule) (err error) {
+func (m *RuleManager) onResourceRuleUpdate(res string, rawResRules []*Rule) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			var ok bool
@@ -299,25 +477,44 @@ func onResourceRuleUpdate(res string, rawResRules []*Rule) (err error) {
 
 	start := util.CurrentTimeNano()
 	oldResCbs := make([]CircuitBreaker, 0)
-	updateMux.RLock()
-	oldResCbs = append(oldResCbs, breakers[res]...)
-	updateMux.RUnlock()
-
-	newCbsOfRes := buildResourceCircuitBreaker(res, rawResRules, oldResCbs)
-
-	updateMux.Lock()
+	oldPatternRules := make([]*patternRule, 0)
+	m.updateMux.RLock()
+	oldResCbs = append(oldResCbs, m.breakers[res]...)
+	oldPatternRules = append(oldPatternRules, m.patternRules[res]...)
+	m.updateMux.RUnlock()
+
+	now := util.CurrentTimeMillis()
+	activeRules, inactiveRules := splitInactiveRules(validResRules, now)
+	exactRules, patternRules := splitPatternRules(activeRules)
+	newCbsOfRes := buildResourceCircuitBreaker(res, exactRules, oldResCbs)
+	newPatternRulesOfRes := buildPatternRules(res, patternRules, oldPatternRules)
+
+	m.updateMux.Lock()
+	if len(newCbsOfRes) == 0 && len(newPatternRulesOfRes) == 0 && len(inactiveRules) == 0 {
+		delete(m.breakerRules, res)
+	} else {
+		m.breakerRules[res] = validResRules
+	}
 	if len(newCbsOfRes) == 0 {
-		delete(breakerRules, res)
-		delete(breakers, res)
+		delete(m.breakers, res)
+	} else {
+		m.breakers[res] = newCbsOfRes
+	}
+	if len(newPatternRulesOfRes) == 0 {
+		delete(m.patternRules, res)
 	} else {
-		breakerRules[res] = validResRules
-		breakers[res] = newCbsOfRes
+		m.patternRules[res] = newPatternRulesOfRes
 	}
-	updateMux.Unlock()
-	currentRules[res] = rawResRules
+	m.patterns = newPatternRuleSet(m.patternRules)
+	m.pinResourceNodes()
+	m.updateMux.Unlock()
+	m.pruneConditions()
+	m.currentRules[res] = rawResRules
+	m.refresher.Schedule(now)
 
 	logging.Debug("[CircuitBreaker onResourceRuleUpdate] Time statistics(ns) for updating circuit breaker rule", "timeCost", util.CurrentTimeNano()-start)
 	logging.Info("[CircuitBreaker] load resource level rules", "resource", res, "validResRules", validResRules)
+	publishRuleChange(res, validResRules)
 	return nil
 }
 
@@ -346,6 +543,16 @@ func logRuleUpdate(m map[string][]*Rule) {
 	} else {
 		logging.Info("[CircuitBreakerRuleManager] Circuit breaking rules were loaded", "rules", rs)
 	}
+	publishRuleChange("", rs)
+}
+
+// publishRuleChange publishes the valid rules loaded for the resource (all the resources if res is empty) to the event bus.
+func publishRuleChange(res string, rules []*Rule) {
+	sentinelRules := make([]base.SentinelRule, 0, len(rules))
+	for _, r := range rules {
+		sentinelRules = append(sentinelRules, r)
+	}
+	event.PublishRuleChange(event.ModuleCircuitBreaker, res, sentinelRules)
 }
 
 // RegisterStateChangeListeners registers the global state change listener for all circuit breakers
@@ -373,8 +580,8 @@ func SetCircuitBreakerGenerator(s Strategy, generator CircuitBreakerGenFunc) err
 	if s <= ErrorCount {
 		return errors.New("not allowed to replace the generator for default circuit breaking strategies")
 	}
-	updateMux.Lock()
-	defer updateMux.Unlock()
+	cbGenMux.Lock()
+	defer cbGenMux.Unlock()
 
 	cbGenFuncMap[s] = generator
 	return nil
@@ -384,8 +591,8 @@ func RemoveCircuitBreakerGenerator(s Strategy) error {
 	if s <= ErrorCount {
 		return errors.New("not allowed to remove the generator for default circuit breaking strategies")
 	}
-	updateMux.Lock()
-	defer updateMux.Unlock()
+	cbGenMux.Lock()
+	defer cbGenMux.Unlock()
 
 	delete(cbGenFuncMap, s)
 	return nil
@@ -393,7 +600,12 @@ func RemoveCircuitBreakerGenerator(s Strategy) error {
 
 // ClearRulesOfResource clears resource level rules in circuitBreaker module.
 func ClearRulesOfResource(res string) error {
-	_, err := LoadRulesOfResource(res, nil)
+	return defaultRuleManager.ClearRulesOfResource(res)
+}
+
+// ClearRulesOfResource clears resource level rules of the rule manager.
+func (m *RuleManager) ClearRulesOfResource(res string) error {
+	_, err := m.LoadRulesOfResource(res, nil)
 	return err
 }
 
@@ -417,7 +629,9 @@ func buildResourceCircuitBreaker(res string, rulesOfRes []*Rule, oldResCbs []Cir
 			continue
 		}
 
+		cbGenMux.RLock()
 		generator := cbGenFuncMap[r.Strategy]
+		cbGenMux.RUnlock()
 		if generator == nil {
 			logging.Warn("[CircuitBreaker buildResourceCircuitBreaker] Ignoring the rule due to unsupported circuit breaking strategy", "rule", r)
 			continue
@@ -453,12 +667,23 @@ func IsValidRule(r *Rule) error {
 	if r.StatIntervalMs <= 0 {
 		return errors.New("invalid StatIntervalMs")
 	}
+	if err := base.IsValidEffectivePeriod(r.EffectiveFrom, r.ExpiresAt); err != nil {
+		return err
+	}
+	if err := base.IsValidConditions(r.Conditions); err != nil {
+		return errors.Wrap(err, "invalid Conditions")
+	}
 	if r.RetryTimeoutMs <= 0 {
 		return errors.New("invalid RetryTimeoutMs")
 	}
 	if r.Threshold < 0.0 {
 		return errors.New("invalid Threshold")
 	}
+	if r.ResourceMatchStrategy.IsPattern() {
+		if _, err := base.NewResourceMatcher(r.ResourceMatchStrategy, r.Resource); err != nil {
+			return errors.Wrap(err, "invalid resource pattern")
+		}
+	}
 	if r.Strategy == SlowRequestRatio && r.Threshold > 1.0 {
 		return errors.New("invalid slow request ratio threshold (valid range: [0.0, 1.0])")
 	}
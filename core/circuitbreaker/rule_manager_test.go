@@ -18,13 +18,16 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/alibaba/sentinel-golang/core/stat"
 	"github.com/stretchr/testify/assert"
 )
 
 func clearData() {
-	breakerRules = make(map[string][]*Rule)
-	breakers = make(map[string][]CircuitBreaker)
-	currentRules = make(map[string][]*Rule, 0)
+	defaultRuleManager.breakerRules = make(map[string][]*Rule)
+	defaultRuleManager.breakers = make(map[string][]CircuitBreaker)
+	defaultRuleManager.patternRules = make(map[string][]*patternRule)
+	defaultRuleManager.patterns = nil
+	defaultRuleManager.currentRules = make(map[string][]*Rule, 0)
 }
 
 func Test_isApplicableRule_valid(t *testing.T) {
@@ -163,12 +166,12 @@ func Test_onUpdateRules(t *testing.T) {
 		rules = append(rules, r1, r2, r3)
 		resRulesMap := make(map[string][]*Rule)
 		resRulesMap["abc01"] = rules
-		err := onRuleUpdate(resRulesMap)
+		err := defaultRuleManager.onRuleUpdate(resRulesMap)
 		if err != nil {
 			t.Fatal(err)
 		}
-		assert.True(t, len(breakers["abc01"]) == 3)
-		assert.True(t, len(breakerRules["abc01"]) == 3)
+		assert.True(t, len(defaultRuleManager.breakers["abc01"]) == 3)
+		assert.True(t, len(defaultRuleManager.breakerRules["abc01"]) == 3)
 		clearData()
 	})
 
@@ -178,7 +181,7 @@ func Test_onUpdateRules(t *testing.T) {
 		}
 		resRulesMap := make(map[string][]*Rule)
 		resRulesMap["abc01"] = []*Rule{r1}
-		err := onRuleUpdate(resRulesMap)
+		err := defaultRuleManager.onRuleUpdate(resRulesMap)
 		assert.Nil(t, err)
 		assert.True(t, len(GetRules()) == 0)
 		clearData()
@@ -214,13 +217,13 @@ func Test_onRuleUpdate(t *testing.T) {
 		}
 
 		_, _ = LoadRules([]*Rule{r1, r2, r3})
-		b2 := breakers["abc"][1]
+		b2 := defaultRuleManager.breakers["abc"][1]
 
-		assert.True(t, len(breakers) == 1)
-		assert.True(t, len(breakers["abc"]) == 3)
-		assert.True(t, reflect.DeepEqual(breakers["abc"][0].BoundRule(), r1))
-		assert.True(t, reflect.DeepEqual(breakers["abc"][1].BoundRule(), r2))
-		assert.True(t, reflect.DeepEqual(breakers["abc"][2].BoundRule(), r3))
+		assert.True(t, len(defaultRuleManager.breakers) == 1)
+		assert.True(t, len(defaultRuleManager.breakers["abc"]) == 3)
+		assert.True(t, reflect.DeepEqual(defaultRuleManager.breakers["abc"][0].BoundRule(), r1))
+		assert.True(t, reflect.DeepEqual(defaultRuleManager.breakers["abc"][1].BoundRule(), r2))
+		assert.True(t, reflect.DeepEqual(defaultRuleManager.breakers["abc"][2].BoundRule(), r3))
 
 		r4 := &Rule{
 			Resource:         "abc",
@@ -256,8 +259,8 @@ func Test_onRuleUpdate(t *testing.T) {
 			Threshold:        10.0,
 		}
 		_, _ = LoadRules([]*Rule{r4, r5, r6, r7})
-		assert.True(t, len(breakers) == 1)
-		newCbs := breakers["abc"]
+		assert.True(t, len(defaultRuleManager.breakers) == 1)
+		newCbs := defaultRuleManager.breakers["abc"]
 		assert.True(t, len(newCbs) == 4, "Expect:4, in fact:", len(newCbs))
 		assert.True(t, reflect.DeepEqual(newCbs[0].BoundRule(), r1))
 		assert.True(t, reflect.DeepEqual(newCbs[1].BoundStat(), b2.BoundStat()))
@@ -333,7 +336,7 @@ func TestGetBreakersOfResource(t *testing.T) {
 
 	_, _ = LoadRules([]*Rule{r1})
 
-	cbs := getBreakersOfResource("abc")
+	cbs := defaultRuleManager.getBreakersOfResource("abc")
 	assert.True(t, len(cbs) == 1 && cbs[0].BoundRule() == r1)
 	clearData()
 }
@@ -441,8 +444,8 @@ func TestLoadRulesOfResource(t *testing.T) {
 	t.Run("LoadRulesOfResource_clear", func(t *testing.T) {
 		succ, err = LoadRulesOfResource("abc1", []*Rule{})
 		assert.True(t, succ && err == nil)
-		assert.True(t, len(breakerRules["abc1"]) == 0 && len(currentRules["abc1"]) == 0)
-		assert.True(t, len(breakerRules["abc2"]) == 1 && len(currentRules["abc2"]) == 1)
+		assert.True(t, len(defaultRuleManager.breakerRules["abc1"]) == 0 && len(defaultRuleManager.currentRules["abc1"]) == 0)
+		assert.True(t, len(defaultRuleManager.breakerRules["abc2"]) == 1 && len(defaultRuleManager.currentRules["abc2"]) == 1)
 	})
 	clearData()
 }
@@ -479,16 +482,16 @@ func Test_onResourceRuleUpdate(t *testing.T) {
 	t.Run("Test_onResourceRuleUpdate_normal", func(t *testing.T) {
 		r11 := r1
 		r11.Threshold = 0.5
-		err = onResourceRuleUpdate("abc1", []*Rule{&r11})
+		err = defaultRuleManager.onResourceRuleUpdate("abc1", []*Rule{&r11})
 
-		assert.True(t, len(breakerRules["abc1"]) == 1)
-		assert.True(t, len(breakers["abc1"]) == 1)
-		assert.True(t, len(currentRules["abc1"]) == 1)
-		assert.True(t, breakers["abc1"][0].BoundRule() == &r11)
+		assert.True(t, len(defaultRuleManager.breakerRules["abc1"]) == 1)
+		assert.True(t, len(defaultRuleManager.breakers["abc1"]) == 1)
+		assert.True(t, len(defaultRuleManager.currentRules["abc1"]) == 1)
+		assert.True(t, defaultRuleManager.breakers["abc1"][0].BoundRule() == &r11)
 
-		assert.True(t, len(breakerRules["abc2"]) == 1)
-		assert.True(t, len(breakers["abc2"]) == 1)
-		assert.True(t, len(currentRules["abc2"]) == 1)
+		assert.True(t, len(defaultRuleManager.breakerRules["abc2"]) == 1)
+		assert.True(t, len(defaultRuleManager.breakers["abc2"]) == 1)
+		assert.True(t, len(defaultRuleManager.currentRules["abc2"]) == 1)
 
 		clearData()
 	})
@@ -526,12 +529,29 @@ func TestClearRulesOfResource(t *testing.T) {
 	t.Run("TestClearRulesOfResource_normal", func(t *testing.T) {
 		assert.True(t, ClearRulesOfResource("abc1") == nil)
 
-		assert.True(t, len(breakerRules["abc1"]) == 0)
-		assert.True(t, len(breakers["abc1"]) == 0)
-		assert.True(t, len(currentRules["abc1"]) == 0)
-		assert.True(t, len(breakerRules["abc2"]) == 1)
-		assert.True(t, len(breakers["abc2"]) == 1)
-		assert.True(t, len(currentRules["abc2"]) == 1)
+		assert.True(t, len(defaultRuleManager.breakerRules["abc1"]) == 0)
+		assert.True(t, len(defaultRuleManager.breakers["abc1"]) == 0)
+		assert.True(t, len(defaultRuleManager.currentRules["abc1"]) == 0)
+		assert.True(t, len(defaultRuleManager.breakerRules["abc2"]) == 1)
+		assert.True(t, len(defaultRuleManager.breakers["abc2"]) == 1)
+		assert.True(t, len(defaultRuleManager.currentRules["abc2"]) == 1)
 		clearData()
 	})
 }
+
+func TestRuleManager_PinResourceNodes(t *testing.T) {
+	m := NewRuleManager(stat.NewNodeStorage(nil))
+	rule := func(res string) *Rule {
+		return &Rule{Resource: res, Strategy: ErrorCount, RetryTimeoutMs: 1000, MinRequestAmount: 5, StatIntervalMs: 1000, Threshold: 10.0}
+	}
+	_, err := m.LoadRules([]*Rule{rule("abc1"), rule("abc2")})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]struct{}{"abc1": {}, "abc2": {}}, m.pins.Resources())
+
+	_, err = m.LoadRulesOfResource("abc1", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]struct{}{"abc2": {}}, m.pins.Resources())
+
+	assert.Nil(t, m.ClearRules())
+	assert.Equal(t, 0, len(m.pins.Resources()))
+}
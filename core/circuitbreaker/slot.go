@@ -20,13 +20,22 @@ import (
 
 const (
 	RuleCheckSlotOrder = 5000
+
+	blockMsg = "circuit breaker check blocked"
 )
 
 var (
-	DefaultSlot = &Slot{}
+	DefaultSlot = NewSlot(defaultRuleManager)
 )
 
 type Slot struct {
+	// manager is the RuleManager to check the breakers of, the default RuleManager is used if nil.
+	manager *RuleManager
+}
+
+// NewSlot creates a Slot checking the circuit breakers of the given RuleManager.
+func NewSlot(manager *RuleManager) *Slot {
+	return &Slot{manager: manager}
 }
 
 func (s *Slot) Order() uint32 {
@@ -39,22 +48,28 @@ func (b *Slot) Check(ctx *base.EntryContext) *base.TokenResult {
 	if len(resource) == 0 {
 		return result
 	}
-	if passed, rule := checkPass(ctx); !passed {
-		msg := "circuit breaker check blocked"
+	if passed, rule := checkPass(managerOrDefault(b.manager), ctx); !passed {
 		if result == nil {
-			result = base.NewTokenResultBlockedWithCause(base.BlockTypeCircuitBreaking, msg, rule, nil)
+			result = base.NewTokenResultBlockedWithCause(base.BlockTypeCircuitBreaking, blockMsg, rule, nil)
 		} else {
-			result.ResetToBlockedWithCause(base.BlockTypeCircuitBreaking, msg, rule, nil)
+			result.ResetToBlockedWithCause(base.BlockTypeCircuitBreaking, blockMsg, rule, nil)
 		}
 	}
 	return result
 }
 
-func checkPass(ctx *base.EntryContext) (bool, *Rule) {
-	breakers := getBreakersOfResource(ctx.Resource.Name())
+func checkPass(m *RuleManager, ctx *base.EntryContext) (bool, *Rule) {
+	breakers := m.getBreakersOfResource(ctx.Resource.Name())
 	for _, breaker := range breakers {
+		if !m.appliesTo(breaker.BoundRule(), ctx.Input) {
+			continue
+		}
 		passed := breaker.TryPass(ctx)
 		if !passed {
+			if rule := breaker.BoundRule(); rule.Mode.IsShadow() {
+				ctx.AddShadowBlock(base.NewBlockErrorWithCause(base.BlockTypeCircuitBreaking, blockMsg, rule, nil))
+				continue
+			}
 			return false, breaker.BoundRule()
 		}
 	}
@@ -47,7 +47,7 @@ func Test_Check(t *testing.T) {
 
 		_, err := LoadRules(rules)
 		assert.Nil(t, err)
-		assert.True(t, len(getBreakersOfResource("abc")) == 1)
+		assert.True(t, len(defaultRuleManager.getBreakersOfResource("abc")) == 1)
 		s := &Slot{}
 		ctx := &base.EntryContext{
 			Resource:        base.NewResourceWrapper("abc", base.ResTypeCommon, base.Inbound),
@@ -80,7 +80,7 @@ func Test_Check(t *testing.T) {
 
 		_, err := LoadRules(rules)
 		assert.Nil(t, err)
-		assert.True(t, len(getBreakersOfResource("abc")) == 1)
+		assert.True(t, len(defaultRuleManager.getBreakersOfResource("abc")) == 1)
 
 		s := &Slot{}
 		ctx := &base.EntryContext{
@@ -95,6 +95,7 @@ func Test_Check(t *testing.T) {
 		e := SetCircuitBreakerGenerator(100, func(r *Rule, reuseStat interface{}) (CircuitBreaker, error) {
 			circuitBreakerMock := &CircuitBreakerMock{}
 			circuitBreakerMock.On("TryPass", mock.Anything).Return(true)
+			circuitBreakerMock.On("BoundRule", mock.Anything).Return(r)
 			return circuitBreakerMock, nil
 		})
 		assert.True(t, e == nil)
@@ -111,7 +112,7 @@ func Test_Check(t *testing.T) {
 			},
 		})
 		assert.Nil(t, err)
-		assert.True(t, len(getBreakersOfResource("abc")) == 1)
+		assert.True(t, len(defaultRuleManager.getBreakersOfResource("abc")) == 1)
 
 		s := &Slot{}
 		ctx := &base.EntryContext{
@@ -23,12 +23,19 @@ const (
 )
 
 var (
-	DefaultMetricStatSlot = &MetricStatSlot{}
+	DefaultMetricStatSlot = NewMetricStatSlot(defaultRuleManager)
 )
 
 // MetricStatSlot records metrics for circuit breaker on invocation completed.
 // MetricStatSlot must be filled into slot chain if circuit breaker is alive.
 type MetricStatSlot struct {
+	// manager is the RuleManager holding the breakers, the default RuleManager is used if nil.
+	manager *RuleManager
+}
+
+// NewMetricStatSlot creates a MetricStatSlot recording the metrics for the circuit breakers of the given RuleManager.
+func NewMetricStatSlot(manager *RuleManager) *MetricStatSlot {
+	return &MetricStatSlot{manager: manager}
 }
 
 func (s *MetricStatSlot) Order() uint32 {
@@ -49,7 +56,11 @@ func (c *MetricStatSlot) OnCompleted(ctx *base.EntryContext) {
 	res := ctx.Resource.Name()
 	err := ctx.Err()
 	rt := ctx.Rt()
-	for _, cb := range getBreakersOfResource(res) {
+	m := managerOrDefault(c.manager)
+	for _, cb := range m.getBreakersOfResource(res) {
+		if !m.appliesTo(cb.BoundRule(), ctx.Input) {
+			continue
+		}
 		cb.OnRequestComplete(rt, err)
 	}
 }
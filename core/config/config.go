@@ -254,3 +254,15 @@ func MetricStatisticIntervalMs() uint32 {
 func MetricStatisticSampleCount() uint32 {
 	return globalCfg.MetricStatisticSampleCount()
 }
+
+func MaxResourceAmount() uint32 {
+	return globalCfg.MaxResourceAmount()
+}
+
+func ResourceOverflowPolicy() OverflowPolicy {
+	return globalCfg.ResourceOverflowPolicy()
+}
+
+func ResourceIdleTimeoutMs() uint32 {
+	return globalCfg.ResourceIdleTimeoutMs()
+}
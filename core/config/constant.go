@@ -14,6 +14,8 @@
 
 package config
 
+import "math"
+
 const (
 	// UnknownProjectName represents the "default" value
 	// that indicates the project name is absent.
@@ -36,4 +38,9 @@ const (
 	DefaultCpuStatCollectIntervalMs    uint32 = 1000
 	DefaultMemoryStatCollectIntervalMs uint32 = 150
 	DefaultWarmUpColdFactor            uint32 = 3
+	DefaultResourceIdleTimeoutMs       uint32 = 60000
+
+	// UnboundedResourceAmount is the max amount of resources if it's not configured, which is math.MaxInt32
+	// rather than math.MaxUint32 so that it still fits in int on the 32-bit platforms.
+	UnboundedResourceAmount uint32 = math.MaxInt32
 )
@@ -13,4 +13,18 @@
 // limitations under the License.
 
 // Package config provides general configuration mechanism.
+//
+// The amount of the resource statistic nodes is unbounded by default, only a warning is logged once it exceeds
+// base.DefaultMaxResourceAmount. It could be limited by sentinel.stat.resource in the config file:
+//
+//	sentinel:
+//	  stat:
+//	    resource:
+//	      maxCount: 10000
+//	      overflowPolicy: evictIdle
+//	      idleTimeoutMs: 60000
+//
+// Once maxCount is reached, the statistics of the new resources are accounted to the shared overflow node ("reject",
+// the default policy), or the nodes of the idle resources are evicted to make room for them first ("evictIdle").
+// The nodes of the resources with the rules are always retained regardless of maxCount.
 package config
@@ -76,9 +76,37 @@ type StatConfig struct {
 	MetricStatisticSampleCount uint32 `yaml:"metricStatisticSampleCount"`
 	MetricStatisticIntervalMs  uint32 `yaml:"metricStatisticIntervalMs"`
 
+	// Resource represents the configuration items of the resource statistic nodes.
+	Resource ResourceStatConfig `yaml:"resource"`
+
 	System SystemStatConfig `yaml:"system"`
 }
 
+// OverflowPolicy indicates how to handle the new resources once the amount of resources reaches the limit.
+type OverflowPolicy string
+
+const (
+	// OverflowReject rejects creating the statistic node of the new resource,
+	// the statistics of the new resources are accounted to the shared overflow node.
+	OverflowReject OverflowPolicy = "reject"
+	// OverflowEvictIdle evicts the nodes of the idle resources to make room for the new resource.
+	// If no resource is idle, it falls back to OverflowReject.
+	OverflowEvictIdle OverflowPolicy = "evictIdle"
+)
+
+// ResourceStatConfig represents the configuration items of the resource statistic nodes.
+type ResourceStatConfig struct {
+	// MaxCount is the max amount of the resource statistic nodes, 0 (the default) means unbounded,
+	// in which case only a warning is logged once the amount exceeds base.DefaultMaxResourceAmount.
+	MaxCount uint32 `yaml:"maxCount"`
+	// OverflowPolicy is the policy applied once MaxCount is reached, OverflowReject by default.
+	// It takes no effect if MaxCount is 0.
+	OverflowPolicy OverflowPolicy `yaml:"overflowPolicy"`
+	// IdleTimeoutMs is the duration without any access, after which the resource is considered idle
+	// and could be evicted by the OverflowEvictIdle policy.
+	IdleTimeoutMs uint32 `yaml:"idleTimeoutMs"`
+}
+
 // SystemStatConfig represents the configuration items of system statistics.
 type SystemStatConfig struct {
 	// CollectIntervalMs represents the collecting interval of the system metrics collector.
@@ -118,6 +146,10 @@ func NewDefaultConfig() *Entity {
 				GlobalStatisticIntervalMsTotal:  base.DefaultIntervalMsTotal,
 				MetricStatisticSampleCount:      base.DefaultSampleCount,
 				MetricStatisticIntervalMs:       base.DefaultIntervalMs,
+				Resource: ResourceStatConfig{
+					OverflowPolicy: OverflowReject,
+					IdleTimeoutMs:  DefaultResourceIdleTimeoutMs,
+				},
 				System: SystemStatConfig{
 					CollectIntervalMs:       DefaultSystemStatCollectIntervalMs,
 					CollectLoadIntervalMs:   DefaultLoadStatCollectIntervalMs,
@@ -158,6 +190,11 @@ func checkConfValid(conf *SentinelConfig) error {
 		conf.Stat.GlobalStatisticSampleCountTotal, conf.Stat.GlobalStatisticIntervalMsTotal); err != nil {
 		return err
 	}
+	switch conf.Stat.Resource.OverflowPolicy {
+	case "", OverflowReject, OverflowEvictIdle:
+	default:
+		return errors.Errorf("Illegal resource stat globalCfg: unknown overflowPolicy %s", conf.Stat.Resource.OverflowPolicy)
+	}
 	return nil
 }
 
@@ -236,3 +273,29 @@ func (entity *Entity) MetricStatisticIntervalMs() uint32 {
 func (entity *Entity) MetricStatisticSampleCount() uint32 {
 	return entity.Sentinel.Stat.MetricStatisticSampleCount
 }
+
+// MaxResourceAmount returns the max amount of the resource statistic nodes,
+// UnboundedResourceAmount if absent (i.e. the amount of resources isn't limited).
+func (entity *Entity) MaxResourceAmount() uint32 {
+	if entity.Sentinel.Stat.Resource.MaxCount == 0 {
+		return UnboundedResourceAmount
+	}
+	return entity.Sentinel.Stat.Resource.MaxCount
+}
+
+// ResourceOverflowPolicy returns the policy applied once the max amount of resources is reached,
+// OverflowReject if absent.
+func (entity *Entity) ResourceOverflowPolicy() OverflowPolicy {
+	if entity.Sentinel.Stat.Resource.OverflowPolicy == "" {
+		return OverflowReject
+	}
+	return entity.Sentinel.Stat.Resource.OverflowPolicy
+}
+
+// ResourceIdleTimeoutMs returns the idle timeout of the resources, DefaultResourceIdleTimeoutMs if absent.
+func (entity *Entity) ResourceIdleTimeoutMs() uint32 {
+	if entity.Sentinel.Stat.Resource.IdleTimeoutMs == 0 {
+		return DefaultResourceIdleTimeoutMs
+	}
+	return entity.Sentinel.Stat.Resource.IdleTimeoutMs
+}
@@ -0,0 +1,57 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"runtime/debug"
+	"strings"
+)
+
+const (
+	// sentinelModulePath is the path of the Sentinel Go module, whose version is read from the build info.
+	sentinelModulePath = "github.com/alibaba/sentinel-golang"
+	// unknownSentinelVersion is reported if the module version is not available,
+	// it's still in the form of a version so that the dashboard could parse it.
+	unknownSentinelVersion = "0.0.0-unknown"
+)
+
+// SentinelVersion represents the version of Sentinel Go, which is reported to the dashboard.
+// It's the version of the Sentinel Go module that the application is built with, i.e. the release tag,
+// so that it never drifts from the releases. It's unknownSentinelVersion if the module version is not available,
+// e.g. in the tests of Sentinel Go itself, or the module is replaced by a local directory.
+var SentinelVersion = sentinelVersionOf(debug.ReadBuildInfo())
+
+func sentinelVersionOf(info *debug.BuildInfo, ok bool) string {
+	if !ok || info == nil {
+		return unknownSentinelVersion
+	}
+	module := &info.Main
+	for _, dep := range info.Deps {
+		if dep.Path == sentinelModulePath {
+			module = dep
+			break
+		}
+	}
+	if module.Path != sentinelModulePath {
+		return unknownSentinelVersion
+	}
+	if module.Replace != nil {
+		module = module.Replace
+	}
+	if module.Version == "" || module.Version == "(devel)" {
+		return unknownSentinelVersion
+	}
+	return strings.TrimPrefix(module.Version, "v")
+}
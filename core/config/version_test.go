@@ -0,0 +1,46 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSentinelVersionOf(t *testing.T) {
+	assert.Equal(t, unknownSentinelVersion, sentinelVersionOf(nil, false))
+	// Sentinel Go itself in development.
+	assert.Equal(t, unknownSentinelVersion, sentinelVersionOf(&debug.BuildInfo{
+		Main: debug.Module{Path: sentinelModulePath, Version: "(devel)"},
+	}, true))
+	// The application depending on a release of Sentinel Go.
+	assert.Equal(t, "1.0.4", sentinelVersionOf(&debug.BuildInfo{
+		Main: debug.Module{Path: "example.com/app", Version: "(devel)"},
+		Deps: []*debug.Module{{Path: "github.com/pkg/errors", Version: "v0.9.1"}, {Path: sentinelModulePath, Version: "v1.0.4"}},
+	}, true))
+	assert.Equal(t, "1.0.5-rc1", sentinelVersionOf(&debug.BuildInfo{
+		Main: debug.Module{Path: "example.com/app"},
+		Deps: []*debug.Module{{Path: sentinelModulePath, Version: "v1.0.4", Replace: &debug.Module{Path: sentinelModulePath, Version: "v1.0.5-rc1"}}},
+	}, true))
+	// Replaced by a local directory.
+	assert.Equal(t, unknownSentinelVersion, sentinelVersionOf(&debug.BuildInfo{
+		Main: debug.Module{Path: "example.com/app"},
+		Deps: []*debug.Module{{Path: sentinelModulePath, Version: "v1.0.4", Replace: &debug.Module{Path: "../sentinel-golang"}}},
+	}, true))
+	// Sentinel Go is absent in the build info.
+	assert.Equal(t, unknownSentinelVersion, sentinelVersionOf(&debug.BuildInfo{Main: debug.Module{Path: "example.com/app"}}, true))
+}
@@ -0,0 +1,284 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package event
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/alibaba/sentinel-golang/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultBufferSize is the default number of the events buffered for each subscriber.
+	DefaultBufferSize = 1024
+	// DefaultBlockSampleInterval is the default interval of sampling the blocked entries,
+	// i.e. one BlockedEvent is published for every 100 blocked entries.
+	DefaultBlockSampleInterval = 100
+)
+
+// Handler handles the events delivered to a subscriber.
+type Handler func(e Event)
+
+// Bus delivers the published events to the subscribers of their types. The events are delivered to each subscriber
+// in order by its own goroutine, through its own buffer: once the buffer is full, the subsequent events are dropped
+// for the subscriber rather than blocking the publisher or the other subscribers.
+// The package-level functions (e.g. Subscribe) operate on the default Bus.
+type Bus struct {
+	mux         sync.Mutex
+	subscribers atomic.Value // []*Subscription
+	// subscribed counts the subscribers of each type, so that the publishers could skip the events nobody subscribes to.
+	subscribed [typeSize]int32
+
+	blockSampleInterval uint32
+	blockCount          uint32
+}
+
+var defaultBus = NewBus()
+
+// NewBus creates a Bus without any subscriber.
+func NewBus() *Bus {
+	b := &Bus{blockSampleInterval: DefaultBlockSampleInterval}
+	b.subscribers.Store(make([]*Subscription, 0))
+	return b
+}
+
+// DefaultBus returns the Bus operated by the package-level functions, to which Sentinel publishes the events.
+func DefaultBus() *Bus {
+	return defaultBus
+}
+
+type subscribeOptions struct {
+	types      []Type
+	bufferSize int
+}
+
+// SubscribeOption configures a subscription.
+type SubscribeOption func(*subscribeOptions)
+
+// WithTypes subscribes the events of the given types only, all the events are subscribed by default.
+func WithTypes(types ...Type) SubscribeOption {
+	return func(opts *subscribeOptions) {
+		opts.types = types
+	}
+}
+
+// WithBufferSize sets the number of the events buffered for the subscriber, DefaultBufferSize by default.
+func WithBufferSize(size int) SubscribeOption {
+	return func(opts *subscribeOptions) {
+		opts.bufferSize = size
+	}
+}
+
+// Subscription is a subscriber of the Bus.
+type Subscription struct {
+	bus     *Bus
+	handler Handler
+	types   [typeSize]bool
+	events  chan Event
+	dropped uint64
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Subscribe subscribes the events of the default Bus with the handler.
+func Subscribe(handler Handler, opts ...SubscribeOption) (*Subscription, error) {
+	return defaultBus.Subscribe(handler, opts...)
+}
+
+// Subscribe subscribes the events with the handler, which is called by the dedicated goroutine of the subscription.
+func (b *Bus) Subscribe(handler Handler, opts ...SubscribeOption) (*Subscription, error) {
+	if handler == nil {
+		return nil, errors.New("nil handler")
+	}
+	options := &subscribeOptions{bufferSize: DefaultBufferSize}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.bufferSize <= 0 {
+		return nil, errors.Errorf("invalid buffer size: %d", options.bufferSize)
+	}
+	s := &Subscription{
+		bus:     b,
+		handler: handler,
+		events:  make(chan Event, options.bufferSize),
+		done:    make(chan struct{}),
+	}
+	if len(options.types) == 0 {
+		for t := range s.types {
+			s.types[t] = true
+		}
+	}
+	for _, t := range options.types {
+		if t >= typeSize {
+			return nil, errors.Errorf("unknown event type: %d", t)
+		}
+		s.types[t] = true
+	}
+
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	subscribers := b.subscribers.Load().([]*Subscription)
+	b.subscribers.Store(append(append(make([]*Subscription, 0, len(subscribers)+1), subscribers...), s))
+	b.updateSubscribed(s, 1)
+	go util.RunWithRecover(s.run)
+	return s, nil
+}
+
+func (b *Bus) updateSubscribed(s *Subscription, delta int32) {
+	for t, subscribed := range s.types {
+		if subscribed {
+			atomic.AddInt32(&b.subscribed[t], delta)
+		}
+	}
+}
+
+// Unsubscribe stops delivering the events to the subscriber, the buffered events are dropped.
+func (s *Subscription) Unsubscribe() {
+	s.closeOnce.Do(func() {
+		b := s.bus
+		b.mux.Lock()
+		subscribers := b.subscribers.Load().([]*Subscription)
+		remaining := make([]*Subscription, 0, len(subscribers))
+		for _, sub := range subscribers {
+			if sub != s {
+				remaining = append(remaining, sub)
+			}
+		}
+		b.subscribers.Store(remaining)
+		b.updateSubscribed(s, -1)
+		b.mux.Unlock()
+		close(s.done)
+	})
+}
+
+// Dropped returns the number of the events dropped for the subscriber since its buffer was full.
+func (s *Subscription) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+func (s *Subscription) run() {
+	for {
+		select {
+		case e := <-s.events:
+			s.handle(e)
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *Subscription) handle(e Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			logging.Error(errors.Errorf("%+v", r), "Unexpected panic in the event handler", "eventType", e.Type())
+		}
+	}()
+	s.handler(e)
+}
+
+// HasSubscriber checks whether the default Bus has any subscriber of the type.
+func HasSubscriber(t Type) bool {
+	return defaultBus.HasSubscriber(t)
+}
+
+// HasSubscriber checks whether there is any subscriber of the type, the publishers are expected to check it
+// before building the event.
+func (b *Bus) HasSubscriber(t Type) bool {
+	return t < typeSize && atomic.LoadInt32(&b.subscribed[t]) > 0
+}
+
+// Publish publishes the event to the default Bus.
+func Publish(e Event) {
+	defaultBus.Publish(e)
+}
+
+// Publish delivers the event to the subscribers of its type without blocking.
+func (b *Bus) Publish(e Event) {
+	if e == nil || !b.HasSubscriber(e.Type()) {
+		return
+	}
+	for _, s := range b.subscribers.Load().([]*Subscription) {
+		if !s.types[e.Type()] {
+			continue
+		}
+		select {
+		case s.events <- e:
+		default:
+			atomic.AddUint64(&s.dropped, 1)
+		}
+	}
+}
+
+// SetBlockSampleInterval sets the interval of sampling the blocked entries of the default Bus.
+func SetBlockSampleInterval(interval uint32) {
+	defaultBus.SetBlockSampleInterval(interval)
+}
+
+// SetBlockSampleInterval sets the interval of sampling the blocked entries, i.e. one BlockedEvent is published
+// for every interval blocked entries, 1 means all the blocked entries are published.
+func (b *Bus) SetBlockSampleInterval(interval uint32) {
+	if interval == 0 {
+		interval = 1
+	}
+	atomic.StoreUint32(&b.blockSampleInterval, interval)
+}
+
+// PublishBlocked publishes the BlockedEvent of the blocked entry to the default Bus if it's sampled.
+func PublishBlocked(ctx *base.EntryContext, blockError *base.BlockError) {
+	defaultBus.PublishBlocked(ctx, blockError)
+}
+
+// PublishBlocked publishes the BlockedEvent of the blocked entry if it's sampled.
+func (b *Bus) PublishBlocked(ctx *base.EntryContext, blockError *base.BlockError) {
+	if ctx == nil || blockError == nil || !b.HasSubscriber(TypeBlocked) {
+		return
+	}
+	if atomic.AddUint32(&b.blockCount, 1)%atomic.LoadUint32(&b.blockSampleInterval) != 0 {
+		return
+	}
+	e := &BlockedEvent{
+		Time:       util.CurrentTimeMillis(),
+		BlockError: base.NewBlockErrorFromDeepCopy(blockError),
+	}
+	if ctx.Resource != nil {
+		e.Resource = ctx.Resource.Name()
+	}
+	if ctx.Input != nil {
+		e.Origin = ctx.Input.Origin
+	}
+	b.Publish(e)
+}
+
+// PublishRuleChange publishes the RulesLoadedEvent of the rules of the module to the default Bus,
+// or the RulesRemovedEvent if rules is empty.
+func PublishRuleChange(module, resource string, rules []base.SentinelRule) {
+	defaultBus.PublishRuleChange(module, resource, rules)
+}
+
+// PublishRuleChange publishes the RulesLoadedEvent of the rules of the module, or the RulesRemovedEvent if rules is empty.
+// The resource is empty if the rules of all the resources are replaced.
+func (b *Bus) PublishRuleChange(module, resource string, rules []base.SentinelRule) {
+	change := RuleChange{Time: util.CurrentTimeMillis(), Module: module, Resource: resource, Rules: rules}
+	if len(rules) == 0 {
+		b.Publish(&RulesRemovedEvent{RuleChange: change})
+	} else {
+		b.Publish(&RulesLoadedEvent{RuleChange: change})
+	}
+}
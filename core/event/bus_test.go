@@ -0,0 +1,175 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package event
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/stretchr/testify/assert"
+)
+
+type eventRecorder struct {
+	mux    sync.Mutex
+	events []Event
+}
+
+func (r *eventRecorder) handle(e Event) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.events = append(r.events, e)
+}
+
+func (r *eventRecorder) received() []Event {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return append([]Event(nil), r.events...)
+}
+
+func (r *eventRecorder) await(t *testing.T, n int) []Event {
+	assert.Eventually(t, func() bool {
+		return len(r.received()) >= n
+	}, time.Second, time.Millisecond)
+	return r.received()
+}
+
+func TestBus_Subscribe(t *testing.T) {
+	b := NewBus()
+	_, err := b.Subscribe(nil)
+	assert.Error(t, err)
+	_, err = b.Subscribe(func(e Event) {}, WithBufferSize(0))
+	assert.Error(t, err)
+	_, err = b.Subscribe(func(e Event) {}, WithTypes(typeSize))
+	assert.Error(t, err)
+	assert.False(t, b.HasSubscriber(TypeBlocked))
+
+	all, filtered := &eventRecorder{}, &eventRecorder{}
+	s1, err := b.Subscribe(all.handle)
+	assert.NoError(t, err)
+	s2, err := b.Subscribe(filtered.handle, WithTypes(TypeDatasourceError))
+	assert.NoError(t, err)
+	assert.True(t, b.HasSubscriber(TypeBlocked))
+	assert.True(t, b.HasSubscriber(TypeDatasourceError))
+
+	b.Publish(&SystemCollectorErrorEvent{Collector: "cpu"})
+	b.Publish(&DatasourceErrorEvent{Name: "ds"})
+	events := all.await(t, 2)
+	assert.Equal(t, TypeSystemCollectorError, events[0].Type())
+	assert.Equal(t, TypeDatasourceError, events[1].Type())
+	events = filtered.await(t, 1)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, "ds", events[0].(*DatasourceErrorEvent).Name)
+
+	s1.Unsubscribe()
+	s1.Unsubscribe()
+	assert.False(t, b.HasSubscriber(TypeBlocked))
+	assert.True(t, b.HasSubscriber(TypeDatasourceError))
+	b.Publish(&DatasourceErrorEvent{Name: "ds"})
+	filtered.await(t, 2)
+	assert.Equal(t, 2, len(all.received()))
+
+	s2.Unsubscribe()
+	assert.False(t, b.HasSubscriber(TypeDatasourceError))
+}
+
+func TestBus_PublishDropsOnFullBuffer(t *testing.T) {
+	b := NewBus()
+	release := make(chan struct{})
+	slow, err := b.Subscribe(func(e Event) {
+		<-release
+	}, WithBufferSize(1))
+	assert.NoError(t, err)
+	fast := &eventRecorder{}
+	_, err = b.Subscribe(fast.handle)
+	assert.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		b.Publish(&DatasourceErrorEvent{Name: "ds"})
+	}
+	fast.await(t, 10)
+	// One event is being handled and another one is buffered at most.
+	assert.True(t, slow.Dropped() >= 8)
+	close(release)
+	slow.Unsubscribe()
+}
+
+func TestBus_HandlerPanic(t *testing.T) {
+	b := NewBus()
+	r := &eventRecorder{}
+	_, err := b.Subscribe(func(e Event) {
+		r.handle(e)
+		panic("unexpected")
+	})
+	assert.NoError(t, err)
+	b.Publish(&DatasourceErrorEvent{Name: "ds1"})
+	b.Publish(&DatasourceErrorEvent{Name: "ds2"})
+	assert.Equal(t, 2, len(r.await(t, 2)))
+}
+
+func TestBus_PublishBlocked(t *testing.T) {
+	b := NewBus()
+	b.SetBlockSampleInterval(3)
+	r := &eventRecorder{}
+	_, err := b.Subscribe(r.handle, WithTypes(TypeBlocked))
+	assert.NoError(t, err)
+
+	ctx := base.NewEmptyEntryContext()
+	ctx.Resource = base.NewResourceWrapper("abc", base.ResTypeCommon, base.Inbound)
+	ctx.Input = &base.SentinelInput{Origin: "caller"}
+	blockError := base.NewBlockErrorWithMessage(base.BlockTypeFlow, "blocked")
+	for i := 0; i < 7; i++ {
+		b.PublishBlocked(ctx, blockError)
+	}
+	events := r.await(t, 2)
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, 2, len(r.received()))
+	e := events[0].(*BlockedEvent)
+	assert.Equal(t, "abc", e.Resource)
+	assert.Equal(t, "caller", e.Origin)
+	assert.Equal(t, base.BlockTypeFlow, e.BlockError.BlockType())
+	assert.True(t, e.BlockError != blockError)
+}
+
+type fakeRule struct {
+	resource string
+}
+
+func (r *fakeRule) String() string {
+	return r.resource
+}
+
+func (r *fakeRule) ResourceName() string {
+	return r.resource
+}
+
+func TestBus_PublishRuleChange(t *testing.T) {
+	b := NewBus()
+	r := &eventRecorder{}
+	_, err := b.Subscribe(r.handle, WithTypes(TypeRulesLoaded, TypeRulesRemoved))
+	assert.NoError(t, err)
+
+	b.PublishRuleChange(ModuleFlow, "abc", []base.SentinelRule{&fakeRule{resource: "abc"}})
+	b.PublishRuleChange(ModuleFlow, "abc", nil)
+	events := r.await(t, 2)
+	loaded := events[0].(*RulesLoadedEvent)
+	assert.Equal(t, ModuleFlow, loaded.Module)
+	assert.Equal(t, "abc", loaded.Resource)
+	assert.Equal(t, 1, len(loaded.Rules))
+	removed := events[1].(*RulesRemovedEvent)
+	assert.Equal(t, "abc", removed.Resource)
+	assert.Equal(t, 0, len(removed.Rules))
+}
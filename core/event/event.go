@@ -0,0 +1,187 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package event provides the event bus to which Sentinel publishes its runtime events,
+// i.e. the rules loaded or removed by each module, the state transformations of the circuit breakers,
+// the sampled blocked entries, and the failures of the data sources and the system metric collectors.
+//
+// The events are delivered to each subscriber asynchronously through its own buffer, so a slow subscriber
+// never blocks the traffic or the other subscribers, and the events overflowing its buffer are dropped:
+//
+//	s, err := event.Subscribe(func(e event.Event) {
+//	    if changed, ok := e.(*event.CircuitBreakerStateChangedEvent); ok {
+//	        alert(changed.Rule.ResourceName(), changed.To)
+//	    }
+//	}, event.WithTypes(event.TypeCircuitBreakerStateChanged))
+package event
+
+import (
+	"github.com/alibaba/sentinel-golang/core/base"
+)
+
+// Type is the type of Event.
+type Type uint8
+
+const (
+	// TypeRulesLoaded is the type of RulesLoadedEvent.
+	TypeRulesLoaded Type = iota
+	// TypeRulesRemoved is the type of RulesRemovedEvent.
+	TypeRulesRemoved
+	// TypeCircuitBreakerStateChanged is the type of CircuitBreakerStateChangedEvent.
+	TypeCircuitBreakerStateChanged
+	// TypeBlocked is the type of BlockedEvent.
+	TypeBlocked
+	// TypeDatasourceError is the type of DatasourceErrorEvent.
+	TypeDatasourceError
+	// TypeSystemCollectorError is the type of SystemCollectorErrorEvent.
+	TypeSystemCollectorError
+
+	typeSize
+)
+
+func (t Type) String() string {
+	switch t {
+	case TypeRulesLoaded:
+		return "RulesLoaded"
+	case TypeRulesRemoved:
+		return "RulesRemoved"
+	case TypeCircuitBreakerStateChanged:
+		return "CircuitBreakerStateChanged"
+	case TypeBlocked:
+		return "Blocked"
+	case TypeDatasourceError:
+		return "DatasourceError"
+	case TypeSystemCollectorError:
+		return "SystemCollectorError"
+	default:
+		return "Undefined"
+	}
+}
+
+// The modules publishing the rule events.
+const (
+	ModuleFlow           = "flow"
+	ModuleCircuitBreaker = "circuitbreaker"
+	ModuleHotspot        = "hotspot"
+	ModuleIsolation      = "isolation"
+	ModuleSystem         = "system"
+)
+
+// Event is published to the subscribers of its Type through the Bus.
+type Event interface {
+	Type() Type
+	// Timestamp returns the time in Unix milliseconds at which the event occurred.
+	Timestamp() uint64
+}
+
+// RuleChange describes the rules of a module changed at once.
+type RuleChange struct {
+	Time   uint64
+	Module string
+	// Resource is the resource whose rules are changed, empty if the rules of all the resources are replaced.
+	Resource string
+	// Rules are the valid rules loaded, which must not be modified.
+	Rules []base.SentinelRule
+}
+
+func (c *RuleChange) Timestamp() uint64 {
+	return c.Time
+}
+
+// RulesLoadedEvent is published once the rules of a module (or a resource of the module) are loaded.
+type RulesLoadedEvent struct {
+	RuleChange
+}
+
+func (e *RulesLoadedEvent) Type() Type {
+	return TypeRulesLoaded
+}
+
+// RulesRemovedEvent is published once the rules of a module (or a resource of the module) are cleared.
+type RulesRemovedEvent struct {
+	RuleChange
+}
+
+func (e *RulesRemovedEvent) Type() Type {
+	return TypeRulesRemoved
+}
+
+// CircuitBreakerStateChangedEvent is published once a circuit breaker transforms its state.
+type CircuitBreakerStateChangedEvent struct {
+	Time uint64
+	// Rule is the copy of the rule of the circuit breaker.
+	Rule base.SentinelRule
+	// From and To are the names of the states, i.e. "Closed", "Open" or "HalfOpen".
+	From string
+	To   string
+	// Snapshot is the triggered value on transforming to Open, nil otherwise.
+	Snapshot interface{}
+}
+
+func (e *CircuitBreakerStateChangedEvent) Type() Type {
+	return TypeCircuitBreakerStateChanged
+}
+
+func (e *CircuitBreakerStateChangedEvent) Timestamp() uint64 {
+	return e.Time
+}
+
+// BlockedEvent is published for the sampled blocked entries, see SetBlockSampleInterval.
+type BlockedEvent struct {
+	Time     uint64
+	Resource string
+	Origin   string
+	// BlockError is the copy of the block error of the entry.
+	BlockError *base.BlockError
+}
+
+func (e *BlockedEvent) Type() Type {
+	return TypeBlocked
+}
+
+func (e *BlockedEvent) Timestamp() uint64 {
+	return e.Time
+}
+
+// DatasourceErrorEvent is published once a data source fails to read or handle its source.
+type DatasourceErrorEvent struct {
+	Time uint64
+	// Name is the name the data source is tracked with, empty if not tracked.
+	Name string
+	Err  error
+}
+
+func (e *DatasourceErrorEvent) Type() Type {
+	return TypeDatasourceError
+}
+
+func (e *DatasourceErrorEvent) Timestamp() uint64 {
+	return e.Time
+}
+
+// SystemCollectorErrorEvent is published once the system metric collector fails to retrieve a metric.
+type SystemCollectorErrorEvent struct {
+	Time uint64
+	// Collector is the name of the failed collector, e.g. "cpu", "memory" or "load".
+	Collector string
+	Err       error
+}
+
+func (e *SystemCollectorErrorEvent) Type() Type {
+	return TypeSystemCollectorError
+}
+
+func (e *SystemCollectorErrorEvent) Timestamp() uint64 {
+	return e.Time
+}
@@ -27,4 +27,50 @@
 //  1. The function both SetTrafficShapingGenerator and RemoveTrafficShapingGenerator is not thread safe.
 //  2. Users can not override the Sentinel supported TrafficShapingController.
 //
+// The Resource of a rule could also be a pattern, by setting ResourceMatchStrategy to ResourceMatchPrefix, ResourceMatchGlob
+// or ResourceMatchRegex. Such a rule applies to all the matched resources, each of which is limited separately with its own statistic,
+// unless SharedStat is set, in which case all the matched resources share a single statistic and threshold.
+// The traffic controllers resolved for a resource are cached, so checking the pattern rules costs a map lookup on the hot path.
+//
+// A group rule limits several resources as a whole, e.g. all the export endpoints together may do 50 QPS. The Resource of the group rule
+// is the name of the group and MemberResources lists the member resources, which share a single statistic written by every member,
+// while the own rules of each member still take effect.
+//
+// Besides the rules, the hierarchical quotas could be loaded by LoadQuotas, e.g. a tenant gets 1000 QPS and each of its API keys
+// gets at most 200 QPS. The quotas form trees of resources, an entry of a resource must acquire the tokens from the quota node of
+// the resource and all its ancestors, and the tokens acquired from the lower levels are released if any level rejects
+// or the entry is blocked by the subsequent slots. The statistics of the quota nodes could be retrieved by GetQuotaStats.
+//
+// The FairShare control behavior divides the threshold among the active callers of the resource according to FairShareWeights.
+// The caller is identified by the origin of the entry (see api.WithOrigin), or by the attachment keyed by FairShareKey.
+// A caller within its fair share always passes, and only the callers exceeding their fair share are blocked, while the shares
+// of the idle callers are divided among the active callers. The pass is counted at checking and rolled back if the entry
+// is blocked by the subsequent rules or slots.
+//
+// The Reservations of a rule with the Reject control behavior reserve a part of the threshold, e.g. 20% of the QPS for the entries
+// with flag 1 (see api.WithFlag). The traffic entitled to no reservation could never consume the reserves left unused,
+// while the reserved traffic could also consume the unreserved capacity once its reserve is used up. The pass of the reserved
+// traffic is counted at checking and rolled back if the entry is blocked by the subsequent rules or slots.
+//
+// The Schedule of a rule with the Direct token calculate strategy overrides the Threshold during the time windows of the day,
+// e.g. the batch window from 01:00 to 05:00 could take 5x the daytime QPS. The windows are evaluated against util.CurrentClock(),
+// and the hotspot and isolation rules support the Schedule as well.
+//
+// A rule takes effect only in its optional effective period [EffectiveFrom, ExpiresAt) in Unix milliseconds,
+// out of which it's kept loaded but ignored, e.g. a promotion rule could be loaded days ahead and expires by itself.
+// The traffic controllers are rebuilt on the first check after any rule becomes effective or expires, without reloading the rules.
+// GetRuleStatuses (or the getRuleStatuses command) reports the loaded rules of each module with their states.
+//
+// The Conditions of a rule restrict it to the entries whose attachments or arguments match all of them, e.g.
+// {"source": "attachment", "key": "region", "operator": "equals", "values": ["eu"]}. The conditions are compiled once
+// on loading, and the traffic not matching is neither limited nor counted by the rule. The hotspot, isolation
+// and circuit breaker rules support the Conditions as well.
+//
+// Every change of the loaded rules of each module is published to the event bus (see package core/event)
+// as the RulesLoadedEvent, or the RulesRemovedEvent once the rules of the resource are cleared.
+//
+// The flow, isolation, hotspot, circuit breaker and system rules of a release could be loaded together by api.RuleTransaction,
+// which validates all of them and swaps the traffic controllers of all the modules in one step. If any rule is invalid,
+// nothing is applied and the returned *base.RuleValidationError reports each invalid rule with its module and index.
+//
 package flow
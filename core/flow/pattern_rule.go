@@ -0,0 +1,267 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flow
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/pkg/errors"
+)
+
+// resourceMatcher matches the resource names.
+type resourceMatcher interface {
+	Match(res string) bool
+}
+
+// memberMatcher matches the member resources of a group rule.
+type memberMatcher map[string]struct{}
+
+func newMemberMatcher(members []string) memberMatcher {
+	m := make(memberMatcher, len(members))
+	for _, member := range members {
+		m[member] = struct{}{}
+	}
+	return m
+}
+
+func (m memberMatcher) Match(res string) bool {
+	_, ok := m[res]
+	return ok
+}
+
+// patternRule is a flow rule whose Resource is a pattern rather than an exact resource name,
+// or a group rule whose Resource is the name of a group of member resources.
+type patternRule struct {
+	rule    *Rule
+	matcher resourceMatcher
+	// sharedTc is the traffic controller shared by all the matched resources if the rule asks for the shared statistic
+	// (which is always the case for the group rules), otherwise each matched resource has its own traffic controller, which is built on the first access.
+	sharedTc *TrafficShapingController
+}
+
+// patternRuleSet holds all the pattern rules, and caches the traffic controllers resolved for the accessed resources.
+// A new set is created whenever the rules are updated, so that the cache never outlives the rules it's resolved from.
+type patternRuleSet struct {
+	rules []*patternRule
+	// capacity is the max amount of the resources cached, which is the same as the NodeStorage of the rule manager.
+	capacity int
+
+	resolved    TrafficControllerMap
+	resolvedMux sync.RWMutex
+	// overflowWarned indicates whether the warning of reaching the max amount of resources has been logged.
+	overflowWarned int32
+}
+
+func newPatternRuleSet(patternRuleMap map[string][]*patternRule, capacity uint32) *patternRuleSet {
+	if len(patternRuleMap) == 0 {
+		return nil
+	}
+	patterns := make([]string, 0, len(patternRuleMap))
+	for pattern := range patternRuleMap {
+		patterns = append(patterns, pattern)
+	}
+	// Keep the order of the rules deterministic.
+	sort.Strings(patterns)
+	rules := make([]*patternRule, 0, len(patterns))
+	for _, pattern := range patterns {
+		rules = append(rules, patternRuleMap[pattern]...)
+	}
+	return &patternRuleSet{
+		rules:    rules,
+		capacity: int(capacity),
+		resolved: make(TrafficControllerMap),
+	}
+}
+
+// trafficControllersOf returns the traffic controllers of the exact rules of res, followed by those of the matched pattern rules.
+func (s *patternRuleSet) trafficControllersOf(m *RuleManager, res string, exactTcs []*TrafficShapingController) []*TrafficShapingController {
+	s.resolvedMux.RLock()
+	tcs, ok := s.resolved[res]
+	full := len(s.resolved) >= s.capacity
+	s.resolvedMux.RUnlock()
+	if ok {
+		return tcs
+	}
+	if full {
+		return s.sharedTrafficControllersOf(res, exactTcs)
+	}
+
+	s.resolvedMux.Lock()
+	defer s.resolvedMux.Unlock()
+	if tcs, ok = s.resolved[res]; ok {
+		return tcs
+	}
+	if len(s.resolved) >= s.capacity {
+		return s.sharedTrafficControllersOf(res, exactTcs)
+	}
+	tcs = make([]*TrafficShapingController, 0, len(exactTcs)+1)
+	tcs = append(tcs, exactTcs...)
+	for _, p := range s.rules {
+		if !p.matcher.Match(res) {
+			continue
+		}
+		if p.sharedTc != nil {
+			tcs = append(tcs, p.sharedTc)
+			continue
+		}
+		tc, err := m.buildMatchedTrafficShapingController(res, p.rule)
+		if err != nil {
+			logging.Error(err, "Fail to build the traffic controller of the matched resource in flow.patternRuleSet.trafficControllersOf()",
+				"resource", res, "rule", p.rule)
+			continue
+		}
+		tcs = append(tcs, tc)
+	}
+	// The resources matching no pattern rule are cached as well, so that they're not matched again.
+	s.resolved[res] = tcs
+	return tcs
+}
+
+// forEachTrafficControllers calls f with the traffic controllers of the pattern rules, including the resolved ones,
+// it must be called with resolvedMux locked.
+func (s *patternRuleSet) forEachTrafficControllers(f func(tcs []*TrafficShapingController)) {
+	for _, p := range s.rules {
+		if p.sharedTc != nil {
+			f([]*TrafficShapingController{p.sharedTc})
+		}
+	}
+	for _, tcs := range s.resolved {
+		f(tcs)
+	}
+}
+
+// sharedTrafficControllersOf resolves the traffic controllers of res without caching them once the cache is full,
+// so the resources beyond the limit are only controlled by the shared traffic controllers, since their own traffic
+// controllers could not be retained. The rules are immutable, so it doesn't need the lock of the cache.
+func (s *patternRuleSet) sharedTrafficControllersOf(res string, exactTcs []*TrafficShapingController) []*TrafficShapingController {
+	tcs := exactTcs
+	copied := false
+	for _, p := range s.rules {
+		if !p.matcher.Match(res) {
+			continue
+		}
+		if p.sharedTc == nil {
+			s.warnOverflowOnce(res, p.rule)
+			continue
+		}
+		if !copied {
+			tcs = append(make([]*TrafficShapingController, 0, len(exactTcs)+1), exactTcs...)
+			copied = true
+		}
+		tcs = append(tcs, p.sharedTc)
+	}
+	return tcs
+}
+
+func (s *patternRuleSet) warnOverflowOnce(res string, rule *Rule) {
+	if atomic.CompareAndSwapInt32(&s.overflowWarned, 0, 1) {
+		logging.Warn("[Flow] The max amount of resources is reached, the pattern rules without shared statistic are skipped for the new resources",
+			"resource", res, "rule", rule)
+	}
+}
+
+// buildPatternRules builds the pattern rules of the same pattern, the shared traffic controllers of the equivalent old rules are reused.
+func (m *RuleManager) buildPatternRules(pattern string, rulesOfPattern []*Rule, oldPatternRules []*patternRule) []*patternRule {
+	newPatternRules := make([]*patternRule, 0, len(rulesOfPattern))
+	for _, rule := range rulesOfPattern {
+		if pattern != rule.Resource {
+			logging.Error(errors.Errorf("unmatched resource pattern expect: %s, actual: %s", pattern, rule.Resource), "Unmatched resource pattern in flow.buildPatternRules()", "rule", rule)
+			continue
+		}
+		reused := false
+		for idx, old := range oldPatternRules {
+			if old.rule.isEqualsTo(rule) {
+				newPatternRules = append(newPatternRules, old)
+				oldPatternRules = append(oldPatternRules[:idx], oldPatternRules[idx+1:]...)
+				reused = true
+				break
+			}
+		}
+		if reused {
+			continue
+		}
+		p := &patternRule{
+			rule: rule,
+		}
+		if rule.isGroupRule() {
+			p.matcher = newMemberMatcher(rule.MemberResources)
+		} else {
+			matcher, err := base.NewResourceMatcher(rule.ResourceMatchStrategy, rule.Resource)
+			if err != nil {
+				logging.Error(err, "Ignoring the rule due to invalid resource pattern in flow.buildPatternRules()", "rule", rule)
+				continue
+			}
+			p.matcher = matcher
+		}
+		generator, supported := generatorOf(rule)
+		if !supported {
+			logging.Error(errors.New("unsupported flow control strategy"), "Ignoring the rule due to unsupported control behavior in flow.buildPatternRules()", "rule", rule)
+			continue
+		}
+		if rule.SharedStat || rule.isGroupRule() {
+			boundStat, err := m.generateSharedStatFor(rule)
+			if err == nil {
+				p.sharedTc, err = generator(rule, boundStat)
+			}
+			if p.sharedTc == nil || err != nil {
+				logging.Error(errors.New("bad generated traffic controller"), "Ignoring the rule due to bad generated traffic controller in flow.buildPatternRules()", "rule", rule)
+				continue
+			}
+		}
+		newPatternRules = append(newPatternRules, p)
+	}
+	return newPatternRules
+}
+
+// buildMatchedTrafficShapingController builds the traffic controller of the pattern rule for the matched resource res,
+// which is bound to the statistic of res.
+func (m *RuleManager) buildMatchedTrafficShapingController(res string, rule *Rule) (*TrafficShapingController, error) {
+	generator, supported := generatorOf(rule)
+	if !supported {
+		return nil, errors.New("unsupported flow control strategy")
+	}
+	var boundStat *standaloneStatistic
+	if rule.needStatistic() {
+		resolvedRule := *rule
+		resolvedRule.Resource = res
+		resolvedRule.ResourceMatchStrategy = base.ResourceMatchExact
+		var err error
+		if boundStat, err = m.generateStatFor(&resolvedRule); err != nil {
+			return nil, err
+		}
+	}
+	tc, err := generator(rule, boundStat)
+	if err != nil {
+		return nil, err
+	}
+	if tc == nil {
+		return nil, errors.New("bad generated traffic controller")
+	}
+	return tc, nil
+}
+
+func patternRulesFrom(m map[string][]*patternRule) []*Rule {
+	rules := make([]*Rule, 0, len(m))
+	for _, ps := range m {
+		for _, p := range ps {
+			rules = append(rules, p.rule)
+		}
+	}
+	return rules
+}
@@ -0,0 +1,220 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flow
+
+import (
+	"testing"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/config"
+	"github.com/alibaba/sentinel-golang/core/stat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatternRules(t *testing.T) {
+	t.Run("PerResourceStat", func(t *testing.T) {
+		nodes := stat.NewNodeStorage(nil)
+		m := NewRuleManager(nodes)
+		r := &Rule{
+			Resource:               "/api/",
+			ResourceMatchStrategy:  base.ResourceMatchPrefix,
+			Threshold:              10,
+			TokenCalculateStrategy: Direct,
+			ControlBehavior:        Reject,
+		}
+		succ, err := m.LoadRules([]*Rule{r})
+		assert.True(t, succ && err == nil)
+		assert.Equal(t, 0, len(m.tcMap))
+		assert.Equal(t, 1, len(m.GetRules()))
+		assert.Equal(t, 1, len(m.GetRulesOfResource("/api/")))
+
+		tcsA := m.getTrafficControllerListFor("/api/a")
+		tcsB := m.getTrafficControllerListFor("/api/b")
+		assert.Equal(t, 1, len(tcsA))
+		assert.Equal(t, 1, len(tcsB))
+		assert.Equal(t, 0, len(m.getTrafficControllerListFor("/other")))
+		// Each matched resource has its own traffic controller bound to its own statistic.
+		assert.True(t, tcsA[0] != tcsB[0])
+		assert.True(t, tcsA[0].boundStat.reuseResourceStat)
+		assert.True(t, tcsA[0].boundStat.readOnlyMetric != tcsB[0].boundStat.readOnlyMetric)
+		assert.NotNil(t, nodes.GetResourceNode("/api/a"))
+		assert.Nil(t, nodes.GetResourceNode("/api/"))
+		assert.True(t, tcsA[0].BoundRule() == r)
+		// The resolved traffic controllers are cached.
+		assert.True(t, m.getTrafficControllerListFor("/api/a")[0] == tcsA[0])
+
+		// The cache is dropped after the rules are updated.
+		r2 := *r
+		r2.Threshold = 20
+		succ, err = m.LoadRules([]*Rule{&r2})
+		assert.True(t, succ && err == nil)
+		tcsA2 := m.getTrafficControllerListFor("/api/a")
+		assert.Equal(t, 1, len(tcsA2))
+		assert.Equal(t, float64(20), tcsA2[0].BoundRule().Threshold)
+
+		assert.Nil(t, m.ClearRules())
+		assert.Nil(t, m.patterns)
+		assert.Equal(t, 0, len(m.getTrafficControllerListFor("/api/a")))
+	})
+
+	t.Run("SharedStat", func(t *testing.T) {
+		m := NewRuleManager(stat.NewNodeStorage(nil))
+		r := &Rule{
+			Resource:               "/api/*/users",
+			ResourceMatchStrategy:  base.ResourceMatchGlob,
+			SharedStat:             true,
+			Threshold:              10,
+			TokenCalculateStrategy: Direct,
+			ControlBehavior:        Reject,
+		}
+		exact := &Rule{
+			Resource:               "/api/v1/users",
+			Threshold:              5,
+			TokenCalculateStrategy: Direct,
+			ControlBehavior:        Reject,
+		}
+		succ, err := m.LoadRules([]*Rule{r, exact})
+		assert.True(t, succ && err == nil)
+		assert.Equal(t, 2, len(m.GetRules()))
+
+		tcs1 := m.getTrafficControllerListFor("/api/v1/users")
+		tcs2 := m.getTrafficControllerListFor("/api/v2/users")
+		assert.Equal(t, 2, len(tcs1))
+		assert.Equal(t, 1, len(tcs2))
+		// The exact rules come first.
+		assert.True(t, tcs1[0].BoundRule() == exact)
+		// All the matched resources share the same traffic controller and the independent statistic.
+		assert.True(t, tcs1[1] == tcs2[0])
+		assert.False(t, tcs2[0].boundStat.reuseResourceStat)
+		assert.NotNil(t, tcs2[0].boundStat.writeOnlyMetric)
+		assert.Equal(t, 0, len(m.getTrafficControllerListFor("/api/v1/v2/users")))
+
+		// The shared traffic controller is reused when the rule is unchanged.
+		r2 := *r
+		r2.Threshold = 20
+		succ, err = m.LoadRulesOfResource("/api/*/users", []*Rule{r, &r2})
+		assert.True(t, succ && err == nil)
+		tcs2 = m.getTrafficControllerListFor("/api/v2/users")
+		assert.Equal(t, 2, len(tcs2))
+		assert.True(t, tcs2[0] == tcs1[1])
+
+		succ, err = m.LoadRulesOfResource("/api/*/users", nil)
+		assert.True(t, succ && err == nil)
+		assert.Equal(t, 1, len(m.getTrafficControllerListFor("/api/v1/users")))
+		assert.Equal(t, 0, len(m.getTrafficControllerListFor("/api/v2/users")))
+	})
+
+	t.Run("GroupRule", func(t *testing.T) {
+		m := NewRuleManager(stat.NewNodeStorage(nil))
+		group := &Rule{
+			Resource:               "export-group",
+			MemberResources:        []string{"/export/a", "/export/b"},
+			Threshold:              50,
+			TokenCalculateStrategy: Direct,
+			ControlBehavior:        Reject,
+		}
+		member := &Rule{
+			Resource:               "/export/a",
+			Threshold:              10,
+			TokenCalculateStrategy: Direct,
+			ControlBehavior:        Reject,
+		}
+		succ, err := m.LoadRules([]*Rule{group, member})
+		assert.True(t, succ && err == nil)
+		assert.Equal(t, 2, len(m.GetRules()))
+		assert.Equal(t, 1, len(m.GetRulesOfResource("export-group")))
+
+		tcsA := m.getTrafficControllerListFor("/export/a")
+		tcsB := m.getTrafficControllerListFor("/export/b")
+		assert.Equal(t, 2, len(tcsA))
+		assert.Equal(t, 1, len(tcsB))
+		assert.True(t, tcsA[0].BoundRule() == member)
+		// All the members share the traffic controller of the group rule and its independent statistic.
+		assert.True(t, tcsA[1] == tcsB[0])
+		assert.True(t, tcsB[0].BoundRule() == group)
+		assert.False(t, tcsB[0].boundStat.reuseResourceStat)
+		assert.Equal(t, 0, len(m.getTrafficControllerListFor("/export/c")))
+		assert.Equal(t, 0, len(m.getTrafficControllerListFor("export-group")))
+	})
+
+	t.Run("ClearExactRules", func(t *testing.T) {
+		nodes := stat.NewNodeStorage(nil)
+		m := NewRuleManager(nodes)
+		slot := NewSlot(m)
+		_, err := m.LoadRules([]*Rule{
+			{Resource: "/api/", ResourceMatchStrategy: base.ResourceMatchPrefix, Threshold: 1000},
+			{Resource: "exact", Threshold: 0},
+		})
+		assert.Nil(t, err)
+		entryCtx := func() *base.EntryContext {
+			ctx := newQuotaEntryContext("exact", 1)
+			ctx.StatNode = nodes.GetOrCreateResourceNode("exact", base.ResTypeCommon)
+			return ctx
+		}
+		assert.True(t, slot.Check(entryCtx()).IsBlocked())
+
+		// The traffic controllers of the exact rules resolved with the pattern rules are dropped as well.
+		assert.Nil(t, m.ClearRulesOfResource("exact"))
+		assert.False(t, slot.Check(entryCtx()).IsBlocked())
+	})
+
+	t.Run("Overflow", func(t *testing.T) {
+		conf := config.NewDefaultConfig()
+		conf.Sentinel.Stat.Resource.MaxCount = 2
+		m := NewRuleManager(stat.NewNodeStorage(conf))
+		shared := &Rule{Resource: "/api/", ResourceMatchStrategy: base.ResourceMatchPrefix, SharedStat: true, Threshold: 10}
+		_, err := m.LoadRules([]*Rule{
+			shared,
+			{Resource: "/api/", ResourceMatchStrategy: base.ResourceMatchPrefix, Threshold: 20},
+		})
+		assert.Nil(t, err)
+
+		assert.Equal(t, 2, len(m.getTrafficControllerListFor("/api/a")))
+		assert.Equal(t, 0, len(m.getTrafficControllerListFor("/other")))
+		// The cache is limited by the NodeStorage of the rule manager, the resources beyond the limit are only
+		// controlled by the shared traffic controllers.
+		tcs := m.getTrafficControllerListFor("/api/b")
+		assert.Equal(t, 1, len(tcs))
+		assert.True(t, tcs[0].BoundRule() == shared)
+		assert.Equal(t, 2, len(m.patterns.resolved))
+	})
+
+	t.Run("InvalidGroupRule", func(t *testing.T) {
+		r := &Rule{
+			Resource:               "export-group",
+			MemberResources:        []string{"/export/a", ""},
+			Threshold:              50,
+			TokenCalculateStrategy: Direct,
+			ControlBehavior:        Reject,
+		}
+		assert.Error(t, IsValidRule(r))
+		r.MemberResources = []string{"/export/a"}
+		assert.NoError(t, IsValidRule(r))
+		r.RelationStrategy = AssociatedResource
+		r.RefResource = "/export/b"
+		assert.Error(t, IsValidRule(r))
+	})
+
+	t.Run("InvalidPattern", func(t *testing.T) {
+		r := &Rule{
+			Resource:               "/api/(",
+			ResourceMatchStrategy:  base.ResourceMatchRegex,
+			Threshold:              10,
+			TokenCalculateStrategy: Direct,
+			ControlBehavior:        Reject,
+		}
+		assert.Error(t, IsValidRule(r))
+	})
+}
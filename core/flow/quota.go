@@ -0,0 +1,337 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flow
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/config"
+	sbase "github.com/alibaba/sentinel-golang/core/stat/base"
+	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/alibaba/sentinel-golang/util"
+	"github.com/pkg/errors"
+)
+
+const quotaBlockMsg = "quota exceeded"
+
+// Quota is a node of the hierarchical quota tree. An entry of the resource of the node must acquire tokens
+// from the node and all its ancestors, so the Threshold of a node is shared by the node itself and all its descendants,
+// and the unused capacity of a parent is shared among its children.
+// e.g. a tenant with 1000 QPS whose children are the API keys of the tenant with 200 QPS each.
+type Quota struct {
+	// Resource is the resource name of the quota node, which is unique in the quota trees.
+	Resource string `json:"resource"`
+	// Threshold is the max QPS of the quota node.
+	Threshold float64 `json:"threshold"`
+	// Children are the child quota nodes.
+	Children []*Quota `json:"children,omitempty"`
+}
+
+func (q *Quota) String() string {
+	return fmt.Sprintf("Quota{Resource=%s, Threshold=%.2f, Children=%d}", q.Resource, q.Threshold, len(q.Children))
+}
+
+func (q *Quota) ResourceName() string {
+	return q.Resource
+}
+
+// QuotaStat is the statistic snapshot of a node in the quota trees.
+type QuotaStat struct {
+	Resource string
+	// Parent is the resource of the parent quota node, empty for the root.
+	Parent    string
+	Threshold float64
+	// AvailableTokens is the amount of the tokens which could be acquired right now from the node itself,
+	// regardless of its ancestors.
+	AvailableTokens float64
+	// PassQPS is the QPS of the entries which acquired the tokens from the node.
+	PassQPS float64
+	// BlockQPS is the QPS of the entries rejected by the node.
+	BlockQPS float64
+}
+
+// quotaBucket is the token bucket of a quota node, which is refilled at the rate of the threshold per second,
+// and holds the tokens of one second at most.
+type quotaBucket struct {
+	mux          sync.Mutex
+	threshold    float64
+	tokens       float64
+	lastRefillMs uint64
+}
+
+func newQuotaBucket(threshold float64) *quotaBucket {
+	return &quotaBucket{
+		threshold:    threshold,
+		tokens:       threshold,
+		lastRefillMs: util.CurrentTimeMillis(),
+	}
+}
+
+// refill must be called with mux held.
+func (b *quotaBucket) refill(now uint64) {
+	if now <= b.lastRefillMs {
+		return
+	}
+	b.tokens += float64(now-b.lastRefillMs) * b.threshold / 1000
+	if b.tokens > b.threshold {
+		b.tokens = b.threshold
+	}
+	b.lastRefillMs = now
+}
+
+func (b *quotaBucket) tryAcquire(count float64, now uint64) bool {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	b.refill(now)
+	if b.tokens < count {
+		return false
+	}
+	b.tokens -= count
+	return true
+}
+
+func (b *quotaBucket) release(count float64) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	b.tokens += count
+	if b.tokens > b.threshold {
+		b.tokens = b.threshold
+	}
+}
+
+func (b *quotaBucket) available(now uint64) float64 {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	b.refill(now)
+	return b.tokens
+}
+
+// quotaNode is the runtime node built from Quota.
+type quotaNode struct {
+	quota  *Quota
+	parent *quotaNode
+	bucket *quotaBucket
+	// writeMetric and readMetric record the pass and block count of the node.
+	writeMetric *sbase.BucketLeapArray
+	readMetric  *sbase.SlidingWindowMetric
+}
+
+// acquireQuota acquires count tokens from the node and all its ancestors. If any level rejects, the tokens acquired
+// from the lower levels are released and the rejecting node is returned, otherwise nil is returned.
+func acquireQuota(leaf *quotaNode, count uint32) *quotaNode {
+	now := util.CurrentTimeMillis()
+	for n := leaf; n != nil; n = n.parent {
+		if n.bucket.tryAcquire(float64(count), now) {
+			continue
+		}
+		for acquired := leaf; acquired != n; acquired = acquired.parent {
+			acquired.bucket.release(float64(count))
+		}
+		n.writeMetric.AddCount(base.MetricEventBlock, int64(count))
+		return n
+	}
+	return nil
+}
+
+// releaseQuota releases count tokens acquired from the node and all its ancestors.
+func releaseQuota(leaf *quotaNode, count uint32) {
+	for n := leaf; n != nil; n = n.parent {
+		n.bucket.release(float64(count))
+	}
+}
+
+func recordQuotaPass(leaf *quotaNode, count uint32) {
+	for n := leaf; n != nil; n = n.parent {
+		n.writeMetric.AddCount(base.MetricEventPass, int64(count))
+	}
+}
+
+// quotaDataKey is the key of the acquired quotaNode in EntryContext.Data.
+type quotaDataKey struct{}
+
+// checkQuota acquires the quota of the entry, the acquired node is recorded in the context
+// so that the tokens could be released if the entry is blocked by the subsequent slots.
+func (m *RuleManager) checkQuota(ctx *base.EntryContext) *base.TokenResult {
+	m.quotaMux.RLock()
+	leaf := m.quotaNodes[ctx.Resource.Name()]
+	m.quotaMux.RUnlock()
+	if leaf == nil {
+		return nil
+	}
+
+	batchCount := ctx.Input.BatchCount
+	if rejected := acquireQuota(leaf, batchCount); rejected != nil {
+		return base.NewTokenResultBlockedWithCause(base.BlockTypeFlow, quotaBlockMsg, rejected.quota, rejected.bucket.available(util.CurrentTimeMillis()))
+	}
+	if ctx.Data == nil {
+		ctx.Data = make(map[interface{}]interface{})
+	}
+	ctx.Data[quotaDataKey{}] = leaf
+	return nil
+}
+
+func acquiredQuotaOf(ctx *base.EntryContext) *quotaNode {
+	if ctx.Data == nil {
+		return nil
+	}
+	leaf, _ := ctx.Data[quotaDataKey{}].(*quotaNode)
+	return leaf
+}
+
+// LoadQuotas replaces all the previous quota trees with the given quota trees.
+// The tokens and the statistic of the quota nodes whose Resource and Threshold are unchanged are retained.
+// The first returned value indicates whether do real load operation, if the quotas are the same with previous quotas, return false.
+func LoadQuotas(quotas []*Quota) (bool, error) {
+	return defaultRuleManager.LoadQuotas(quotas)
+}
+
+// LoadQuotas replaces all the previous quota trees of the rule manager with the given quota trees.
+func (m *RuleManager) LoadQuotas(quotas []*Quota) (bool, error) {
+	m.updateRuleMux.Lock()
+	defer m.updateRuleMux.Unlock()
+
+	if reflect.DeepEqual(m.currentQuotas, quotas) {
+		logging.Info("[Flow] Load quotas is the same with current quotas, so ignore load operation.")
+		return false, nil
+	}
+	if err := IsValidQuotas(quotas); err != nil {
+		return false, err
+	}
+
+	m.quotaMux.RLock()
+	oldNodes := m.quotaNodes
+	m.quotaMux.RUnlock()
+
+	newNodes := make(map[string]*quotaNode)
+	var build func(q *Quota, parent *quotaNode)
+	build = func(q *Quota, parent *quotaNode) {
+		n := &quotaNode{
+			quota:  q,
+			parent: parent,
+		}
+		if old, ok := oldNodes[q.Resource]; ok && util.Float64Equals(old.quota.Threshold, q.Threshold) {
+			n.bucket, n.writeMetric, n.readMetric = old.bucket, old.writeMetric, old.readMetric
+		} else {
+			n.bucket = newQuotaBucket(q.Threshold)
+			n.writeMetric = sbase.NewBucketLeapArray(config.MetricStatisticSampleCount(), config.MetricStatisticIntervalMs())
+			n.readMetric, _ = sbase.NewSlidingWindowMetric(config.MetricStatisticSampleCount(), config.MetricStatisticIntervalMs(), n.writeMetric)
+		}
+		newNodes[q.Resource] = n
+		for _, child := range q.Children {
+			build(child, n)
+		}
+	}
+	for _, q := range quotas {
+		build(q, nil)
+	}
+
+	m.quotaMux.Lock()
+	m.quotaNodes = newNodes
+	m.quotaMux.Unlock()
+	m.currentQuotas = quotas
+
+	if len(quotas) == 0 {
+		logging.Info("[FlowRuleManager] Quotas were cleared")
+	} else {
+		logging.Info("[FlowRuleManager] Quotas were loaded", "quotas", len(newNodes))
+	}
+	return true, nil
+}
+
+// ClearQuotas clears all the quota trees.
+func ClearQuotas() error {
+	return defaultRuleManager.ClearQuotas()
+}
+
+// ClearQuotas clears all the quota trees of the rule manager.
+func (m *RuleManager) ClearQuotas() error {
+	_, err := m.LoadQuotas(nil)
+	return err
+}
+
+// GetQuotaStats returns the statistic snapshots of all the quota nodes, sorted by Resource.
+func GetQuotaStats() []QuotaStat {
+	return defaultRuleManager.GetQuotaStats()
+}
+
+// GetQuotaStats returns the statistic snapshots of all the quota nodes of the rule manager, sorted by Resource.
+func (m *RuleManager) GetQuotaStats() []QuotaStat {
+	m.quotaMux.RLock()
+	nodes := make([]*quotaNode, 0, len(m.quotaNodes))
+	for _, n := range m.quotaNodes {
+		nodes = append(nodes, n)
+	}
+	m.quotaMux.RUnlock()
+
+	now := util.CurrentTimeMillis()
+	ret := make([]QuotaStat, 0, len(nodes))
+	for _, n := range nodes {
+		s := QuotaStat{
+			Resource:        n.quota.Resource,
+			Threshold:       n.quota.Threshold,
+			AvailableTokens: n.bucket.available(now),
+			PassQPS:         n.readMetric.GetQPS(base.MetricEventPass),
+			BlockQPS:        n.readMetric.GetQPS(base.MetricEventBlock),
+		}
+		if n.parent != nil {
+			s.Parent = n.parent.quota.Resource
+		}
+		ret = append(ret, s)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Resource < ret[j].Resource
+	})
+	return ret
+}
+
+// IsValidQuotas checks whether the given quota trees are valid.
+func IsValidQuotas(quotas []*Quota) error {
+	resources := make(map[string]struct{})
+	var check func(q *Quota) error
+	check = func(q *Quota) error {
+		if q == nil {
+			return errors.New("nil Quota")
+		}
+		if q.Resource == "" {
+			return errors.New("empty Resource of Quota")
+		}
+		if _, ok := resources[q.Resource]; ok {
+			return errors.Errorf("duplicate Resource of Quota: %s", q.Resource)
+		}
+		resources[q.Resource] = struct{}{}
+		if q.Threshold < 0 {
+			return errors.Errorf("negative Threshold of Quota: %s", q.Resource)
+		}
+		for _, child := range q.Children {
+			if err := check(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, q := range quotas {
+		if err := check(q); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/stat"
+	"github.com/alibaba/sentinel-golang/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func newQuotaEntryContext(res string, batchCount uint32) *base.EntryContext {
+	ctx := base.NewEmptyEntryContext()
+	ctx.Resource = base.NewResourceWrapper(res, base.ResTypeCommon, base.Inbound)
+	ctx.Input = &base.SentinelInput{BatchCount: batchCount}
+	ctx.RuleCheckResult = base.NewTokenResultPass()
+	return ctx
+}
+
+func TestLoadQuotas(t *testing.T) {
+	m := NewRuleManager(stat.NewNodeStorage(nil))
+
+	_, err := m.LoadQuotas([]*Quota{{Resource: "tenant", Threshold: 10, Children: []*Quota{{Resource: "tenant", Threshold: 1}}}})
+	assert.Error(t, err)
+	_, err = m.LoadQuotas([]*Quota{{Resource: "tenant", Threshold: -1}})
+	assert.Error(t, err)
+	_, err = m.LoadQuotas([]*Quota{{Resource: "tenant", Threshold: 10, Children: []*Quota{nil}}})
+	assert.Error(t, err)
+
+	quotas := []*Quota{{Resource: "tenant", Threshold: 10, Children: []*Quota{{Resource: "key1", Threshold: 4}}}}
+	succ, err := m.LoadQuotas(quotas)
+	assert.True(t, succ && err == nil)
+	succ, err = m.LoadQuotas(quotas)
+	assert.False(t, succ)
+	assert.NoError(t, err)
+	assert.Nil(t, m.checkQuota(newQuotaEntryContext("key1", 1)))
+
+	// The tokens of the unchanged quota nodes are retained.
+	succ, err = m.LoadQuotas([]*Quota{{Resource: "tenant", Threshold: 10, Children: []*Quota{{Resource: "key1", Threshold: 5}}}})
+	assert.True(t, succ && err == nil)
+	stats := m.GetQuotaStats()
+	assert.Equal(t, 2, len(stats))
+	assert.Equal(t, "key1", stats[0].Resource)
+	assert.Equal(t, "tenant", stats[0].Parent)
+	assert.InDelta(t, 5, stats[0].AvailableTokens, 0.1)
+	assert.Equal(t, "tenant", stats[1].Resource)
+	assert.Equal(t, "", stats[1].Parent)
+	assert.InDelta(t, 9, stats[1].AvailableTokens, 0.1)
+
+	assert.NoError(t, m.ClearQuotas())
+	assert.Equal(t, 0, len(m.GetQuotaStats()))
+}
+
+func TestCheckQuota(t *testing.T) {
+	util.SetClock(util.NewMockClock())
+	defer util.SetClock(util.NewRealClock())
+
+	m := NewRuleManager(stat.NewNodeStorage(nil))
+	_, err := m.LoadQuotas([]*Quota{
+		{
+			Resource:  "tenant",
+			Threshold: 10,
+			Children: []*Quota{
+				{Resource: "key1", Threshold: 4},
+				{Resource: "key2", Threshold: 4},
+				{Resource: "key3", Threshold: 10},
+			},
+		},
+	})
+	assert.NoError(t, err)
+	slot := NewSlot(m)
+	statSlot := NewStandaloneStatSlot(m)
+
+	// The child rejects.
+	for i := 0; i < 4; i++ {
+		ctx := newQuotaEntryContext("key1", 1)
+		assert.False(t, slot.Check(ctx).IsBlocked())
+		statSlot.OnEntryPassed(ctx)
+	}
+	r := slot.Check(newQuotaEntryContext("key1", 1))
+	assert.True(t, r.IsBlocked())
+	assert.Equal(t, "key1", r.BlockError().TriggeredRule().ResourceName())
+
+	ctx := newQuotaEntryContext("key2", 4)
+	assert.False(t, slot.Check(ctx).IsBlocked())
+	statSlot.OnEntryPassed(ctx)
+
+	// The parent rejects, and the tokens acquired from the child are released.
+	r = slot.Check(newQuotaEntryContext("key3", 3))
+	assert.True(t, r.IsBlocked())
+	assert.Equal(t, "tenant", r.BlockError().TriggeredRule().ResourceName())
+	stats := m.GetQuotaStats()
+	assert.Equal(t, "key3", stats[2].Resource)
+	assert.InDelta(t, 10, stats[2].AvailableTokens, 0.001)
+	assert.Equal(t, "tenant", stats[3].Resource)
+	assert.InDelta(t, 2, stats[3].AvailableTokens, 0.001)
+	assert.True(t, stats[3].BlockQPS > 0)
+	assert.True(t, stats[3].PassQPS > 0)
+
+	// The tokens are released if the entry is blocked by the subsequent slots.
+	ctx = newQuotaEntryContext("key3", 2)
+	assert.False(t, slot.Check(ctx).IsBlocked())
+	assert.InDelta(t, 0, m.GetQuotaStats()[3].AvailableTokens, 0.001)
+	statSlot.OnEntryBlocked(ctx, base.NewBlockError(base.BlockTypeIsolation))
+	assert.InDelta(t, 2, m.GetQuotaStats()[3].AvailableTokens, 0.001)
+
+	// The tokens are refilled over time.
+	util.Sleep(500 * time.Millisecond)
+	stats = m.GetQuotaStats()
+	assert.InDelta(t, 2, stats[0].AvailableTokens, 0.001)
+	assert.InDelta(t, 7, stats[3].AvailableTokens, 0.001)
+}
@@ -17,7 +17,9 @@ package flow
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
 
+	"github.com/alibaba/sentinel-golang/core/base"
 	"github.com/alibaba/sentinel-golang/util"
 )
 
@@ -42,6 +44,21 @@ func (s RelationStrategy) String() string {
 	}
 }
 
+var relationStrategyNames = map[string]int64{
+	"currentresource":    int64(CurrentResource),
+	"associatedresource": int64(AssociatedResource),
+}
+
+// UnmarshalJSON accepts either the integer code or the name (e.g. "AssociatedResource") of RelationStrategy.
+func (s *RelationStrategy) UnmarshalJSON(data []byte) error {
+	v, err := util.ParseEnumJSON(data, relationStrategyNames)
+	if err != nil {
+		return err
+	}
+	*s = RelationStrategy(v)
+	return nil
+}
+
 type TokenCalculateStrategy int32
 
 const (
@@ -63,11 +80,33 @@ func (s TokenCalculateStrategy) String() string {
 	}
 }
 
+var tokenCalculateStrategyNames = map[string]int64{
+	"direct":         int64(Direct),
+	"warmup":         int64(WarmUp),
+	"memoryadaptive": int64(MemoryAdaptive),
+}
+
+// UnmarshalJSON accepts either the integer code or the name (e.g. "WarmUp") of TokenCalculateStrategy.
+func (s *TokenCalculateStrategy) UnmarshalJSON(data []byte) error {
+	v, err := util.ParseEnumJSON(data, tokenCalculateStrategyNames)
+	if err != nil {
+		return err
+	}
+	*s = TokenCalculateStrategy(v)
+	return nil
+}
+
 type ControlBehavior int32
 
 const (
 	Reject ControlBehavior = iota
 	Throttling
+	// FairShare divides the threshold among the active callers of the resource according to their weights,
+	// and only blocks the callers exceeding their fair share, while the shares of the idle callers are divided
+	// among the active callers. In JSON, it's either the integer code 2 or the case-insensitive name "FairShare"
+	// (see util.ParseEnumJSON). The custom control behaviors registered by SetTrafficShapingGenerator must take
+	// the values beyond it.
+	FairShare
 )
 
 func (s ControlBehavior) String() string {
@@ -76,17 +115,45 @@ func (s ControlBehavior) String() string {
 		return "Reject"
 	case Throttling:
 		return "Throttling"
+	case FairShare:
+		return "FairShare"
 	default:
 		return "Undefined"
 	}
 }
 
+var controlBehaviorNames = map[string]int64{
+	"reject":     int64(Reject),
+	"throttling": int64(Throttling),
+	"fairshare":  int64(FairShare),
+}
+
+// UnmarshalJSON accepts either the integer code or the name (e.g. "Reject") of ControlBehavior.
+func (s *ControlBehavior) UnmarshalJSON(data []byte) error {
+	v, err := util.ParseEnumJSON(data, controlBehaviorNames)
+	if err != nil {
+		return err
+	}
+	*s = ControlBehavior(v)
+	return nil
+}
+
 // Rule describes the strategy of flow control, the flow control strategy is based on QPS statistic metric
 type Rule struct {
 	// ID represents the unique ID of the rule (optional).
 	ID string `json:"id,omitempty"`
-	// Resource represents the resource name.
-	Resource               string                 `json:"resource"`
+	// Resource represents the resource name, or the resource pattern if ResourceMatchStrategy is not exact.
+	Resource string `json:"resource"`
+	// ResourceMatchStrategy indicates how Resource matches the resource names. By default Resource is the exact
+	// resource name, otherwise it's a prefix, glob or regex pattern and the rule applies to all the matched resources.
+	ResourceMatchStrategy base.ResourceMatchStrategy `json:"resourceMatchStrategy,omitempty"`
+	// SharedStat only takes effect for the pattern rules. By default each matched resource is controlled separately
+	// with its own statistic, while all the matched resources share a single statistic (and threshold) if SharedStat is true.
+	SharedStat bool `json:"sharedStat,omitempty"`
+	// MemberResources makes the rule a group rule if it's not empty, then Resource is the name of the group rather than
+	// a real resource, and all the member resources share a single statistic and threshold, alongside their own rules.
+	// e.g. the group rule with MemberResources ["/export/a", "/export/b"] and Threshold 50 limits the total QPS of the two resources to 50.
+	MemberResources        []string               `json:"memberResources,omitempty"`
 	TokenCalculateStrategy TokenCalculateStrategy `json:"tokenCalculateStrategy"`
 	ControlBehavior        ControlBehavior        `json:"controlBehavior"`
 	// Threshold means the threshold during StatIntervalInMs
@@ -105,6 +172,21 @@ type Rule struct {
 	// If the StatIntervalInMs user specifies can not reuse the global statistic of resource,
 	// 		sentinel will generate independent statistic structure for this rule.
 	StatIntervalInMs uint32 `json:"statIntervalInMs"`
+	// Schedule only takes effect when TokenCalculateStrategy is Direct. It overrides Threshold during its time windows,
+	// the first window containing the current time wins, and Threshold takes effect out of all the windows.
+	Schedule []*base.ScheduledThreshold `json:"schedule,omitempty"`
+	// FairShareKey only takes effect when ControlBehavior is FairShare. It's the attachment key of the entry whose value
+	// identifies the caller, and the origin of the entry identifies the caller if FairShareKey is empty.
+	FairShareKey string `json:"fairShareKey,omitempty"`
+	// FairShareWeights only takes effect when ControlBehavior is FairShare. It's the weights of the callers,
+	// and the weight of the caller absent from it is 1.
+	FairShareWeights map[string]float64 `json:"fairShareWeights,omitempty"`
+	// Reservations only take effect when ControlBehavior is Reject. Each reservation reserves a part of the threshold
+	// for the traffic with its flag or from its origin, which the other traffic could never consume.
+	Reservations []*base.Reservation `json:"reservations,omitempty"`
+	// Conditions restrict the rule to the entries matching all of them, e.g. the ones with the attachment region == "eu",
+	// the rule applies to all the entries if empty. The rule with Conditions only counts the matching traffic.
+	Conditions []*base.MatchCondition `json:"conditions,omitempty"`
 
 	// adaptive flow control algorithm related parameters
 	// limitation: LowMemUsageThreshold > HighMemUsageThreshold && MemHighWaterMarkBytes > MemLowWaterMarkBytes
@@ -115,20 +197,33 @@ type Rule struct {
 	HighMemUsageThreshold int64 `json:"highMemUsageThreshold"`
 	MemLowWaterMarkBytes  int64 `json:"memLowWaterMarkBytes"`
 	MemHighWaterMarkBytes int64 `json:"memHighWaterMarkBytes"`
+
+	// Mode indicates whether the rule rejects the traffic (RuleModeEnforce, by default),
+	// or only records the would-be blocks without rejecting anything (RuleModeShadow).
+	Mode base.RuleMode `json:"mode,omitempty"`
+	// EffectiveFrom and ExpiresAt are the optional effective period of the rule in Unix milliseconds, 0 means unbounded.
+	// The rule takes effect in [EffectiveFrom, ExpiresAt), out of which it's kept loaded but ignored.
+	EffectiveFrom uint64 `json:"effectiveFrom,omitempty"`
+	ExpiresAt     uint64 `json:"expiresAt,omitempty"`
 }
 
 func (r *Rule) isEqualsTo(newRule *Rule) bool {
 	if newRule == nil {
 		return false
 	}
-	if !(r.Resource == newRule.Resource && r.RelationStrategy == newRule.RelationStrategy &&
+	if !(r.Resource == newRule.Resource && r.ResourceMatchStrategy == newRule.ResourceMatchStrategy && r.SharedStat == newRule.SharedStat &&
+		reflect.DeepEqual(r.MemberResources, newRule.MemberResources) && r.RelationStrategy == newRule.RelationStrategy &&
 		r.RefResource == newRule.RefResource && r.StatIntervalInMs == newRule.StatIntervalInMs &&
 		r.TokenCalculateStrategy == newRule.TokenCalculateStrategy && r.ControlBehavior == newRule.ControlBehavior &&
 		util.Float64Equals(r.Threshold, newRule.Threshold) &&
 		r.MaxQueueingTimeMs == newRule.MaxQueueingTimeMs && r.WarmUpPeriodSec == newRule.WarmUpPeriodSec &&
 		r.WarmUpColdFactor == newRule.WarmUpColdFactor &&
+		r.FairShareKey == newRule.FairShareKey && reflect.DeepEqual(r.FairShareWeights, newRule.FairShareWeights) &&
+		reflect.DeepEqual(r.Reservations, newRule.Reservations) && reflect.DeepEqual(r.Schedule, newRule.Schedule) &&
+		reflect.DeepEqual(r.Conditions, newRule.Conditions) &&
 		r.LowMemUsageThreshold == newRule.LowMemUsageThreshold && r.HighMemUsageThreshold == newRule.HighMemUsageThreshold &&
-		r.MemLowWaterMarkBytes == newRule.MemLowWaterMarkBytes && r.MemHighWaterMarkBytes == newRule.MemHighWaterMarkBytes) {
+		r.MemLowWaterMarkBytes == newRule.MemLowWaterMarkBytes && r.MemHighWaterMarkBytes == newRule.MemHighWaterMarkBytes &&
+		r.Mode == newRule.Mode && r.EffectiveFrom == newRule.EffectiveFrom && r.ExpiresAt == newRule.ExpiresAt) {
 
 		return false
 	}
@@ -139,9 +234,15 @@ func (r *Rule) isStatReusable(newRule *Rule) bool {
 	if newRule == nil {
 		return false
 	}
-	return r.Resource == newRule.Resource && r.RelationStrategy == newRule.RelationStrategy &&
+	return r.Resource == newRule.Resource && r.ResourceMatchStrategy == newRule.ResourceMatchStrategy && r.SharedStat == newRule.SharedStat &&
+		reflect.DeepEqual(r.MemberResources, newRule.MemberResources) && r.RelationStrategy == newRule.RelationStrategy &&
 		r.RefResource == newRule.RefResource && r.StatIntervalInMs == newRule.StatIntervalInMs &&
-		r.needStatistic() && newRule.needStatistic()
+		reflect.DeepEqual(r.Conditions, newRule.Conditions) && r.needStatistic() && newRule.needStatistic()
+}
+
+// isGroupRule indicates whether the rule limits a group of member resources as a whole.
+func (r *Rule) isGroupRule() bool {
+	return len(r.MemberResources) > 0
 }
 
 func (r *Rule) needStatistic() bool {
@@ -152,12 +253,12 @@ func (r *Rule) String() string {
 	b, err := json.Marshal(r)
 	if err != nil {
 		// Return the fallback string
-		return fmt.Sprintf("Rule{Resource=%s, TokenCalculateStrategy=%s, ControlBehavior=%s, "+
-			"Threshold=%.2f, RelationStrategy=%s, RefResource=%s, MaxQueueingTimeMs=%d, WarmUpPeriodSec=%d, WarmUpColdFactor=%d, StatIntervalInMs=%d, "+
-			"LowMemUsageThreshold=%v, HighMemUsageThreshold=%v, MemLowWaterMarkBytes=%v, MemHighWaterMarkBytes=%v}",
-			r.Resource, r.TokenCalculateStrategy, r.ControlBehavior, r.Threshold, r.RelationStrategy, r.RefResource,
-			r.MaxQueueingTimeMs, r.WarmUpPeriodSec, r.WarmUpColdFactor, r.StatIntervalInMs,
-			r.LowMemUsageThreshold, r.HighMemUsageThreshold, r.MemLowWaterMarkBytes, r.MemHighWaterMarkBytes)
+		return fmt.Sprintf("Rule{Resource=%s, ResourceMatchStrategy=%s, SharedStat=%t, MemberResources=%v, TokenCalculateStrategy=%s, ControlBehavior=%s, "+
+			"Threshold=%.2f, RelationStrategy=%s, RefResource=%s, MaxQueueingTimeMs=%d, WarmUpPeriodSec=%d, WarmUpColdFactor=%d, StatIntervalInMs=%d, Schedule=%v, "+
+			"FairShareKey=%s, FairShareWeights=%v, Reservations=%v, Conditions=%v, LowMemUsageThreshold=%v, HighMemUsageThreshold=%v, MemLowWaterMarkBytes=%v, MemHighWaterMarkBytes=%v, Mode=%s, EffectiveFrom=%d, ExpiresAt=%d}",
+			r.Resource, r.ResourceMatchStrategy, r.SharedStat, r.MemberResources, r.TokenCalculateStrategy, r.ControlBehavior, r.Threshold, r.RelationStrategy, r.RefResource,
+			r.MaxQueueingTimeMs, r.WarmUpPeriodSec, r.WarmUpColdFactor, r.StatIntervalInMs, r.Schedule, r.FairShareKey, r.FairShareWeights, r.Reservations, r.Conditions,
+			r.LowMemUsageThreshold, r.HighMemUsageThreshold, r.MemLowWaterMarkBytes, r.MemHighWaterMarkBytes, r.Mode, r.EffectiveFrom, r.ExpiresAt)
 	}
 	return string(b)
 }
@@ -21,6 +21,7 @@ import (
 
 	"github.com/alibaba/sentinel-golang/core/base"
 	"github.com/alibaba/sentinel-golang/core/config"
+	"github.com/alibaba/sentinel-golang/core/event"
 	"github.com/alibaba/sentinel-golang/core/stat"
 	sbase "github.com/alibaba/sentinel-golang/core/stat/base"
 	"github.com/alibaba/sentinel-golang/core/system_metric"
@@ -40,19 +41,71 @@ type trafficControllerGenKey struct {
 // TrafficControllerMap represents the map storage for TrafficShapingController.
 type TrafficControllerMap map[string][]*TrafficShapingController
 
+// RuleManager manages the flow rules and the traffic controllers built from them.
+// The package-level functions (e.g. LoadRules) operate on the default RuleManager.
+type RuleManager struct {
+	// nodes provides the resource nodes whose statistics are reused by the rules.
+	nodes *stat.NodeStorage
+
+	tcMap TrafficControllerMap
+	// patternRuleMap holds the rules whose Resource is a pattern or the name of a group, keyed by the Resource.
+	patternRuleMap map[string][]*patternRule
+	// patterns is the set of all the pattern rules built from patternRuleMap, nil if there is no pattern rule.
+	patterns *patternRuleSet
+	// inactiveRules holds the valid rules out of their effective period, keyed by the Resource.
+	inactiveRules map[string][]*Rule
+	tcMux         sync.RWMutex
+	currentRules  map[string][]*Rule
+	updateRuleMux sync.Mutex
+	// refresher rebuilds the traffic controllers once any rule becomes effective or expires.
+	refresher *base.RuleRefresher
+	// pins holds the nodes of the resources whose statistics are reused by the rules, see pinResourceNode.
+	pins *stat.ResourceNodePins
+
+	// quotaNodes holds the nodes of the quota trees, keyed by the resource.
+	quotaNodes    map[string]*quotaNode
+	quotaMux      sync.RWMutex
+	currentQuotas []*Quota
+}
+
 var (
 	tcGenFuncMap = make(map[trafficControllerGenKey]TrafficControllerGenFunc, 4)
-	tcMap        = make(TrafficControllerMap)
-	tcMux        = new(sync.RWMutex)
+	tcGenMux     = new(sync.RWMutex)
 	nopStat      = &standaloneStatistic{
 		reuseResourceStat: false,
 		readOnlyMetric:    base.NopReadStat(),
 		writeOnlyMetric:   base.NopWriteStat(),
 	}
-	currentRules  = make(map[string][]*Rule, 0)
-	updateRuleMux = new(sync.Mutex)
+
+	defaultRuleManager = NewRuleManager(stat.DefaultNodeStorage())
 )
 
+// NewRuleManager creates an empty RuleManager, the rules of which reuse the statistics of the resource nodes in nodes.
+func NewRuleManager(nodes *stat.NodeStorage) *RuleManager {
+	m := &RuleManager{
+		nodes:          nodes,
+		tcMap:          make(TrafficControllerMap),
+		patternRuleMap: make(map[string][]*patternRule),
+		inactiveRules:  make(map[string][]*Rule),
+		currentRules:   make(map[string][]*Rule, 0),
+		pins:           stat.NewResourceNodePins(nodes),
+	}
+	m.refresher = base.NewRuleRefresher(event.ModuleFlow, &m.updateRuleMux, (*refreshableRules)(m))
+	return m
+}
+
+// DefaultRuleManager returns the RuleManager operated by the package-level functions.
+func DefaultRuleManager() *RuleManager {
+	return defaultRuleManager
+}
+
+func managerOrDefault(m *RuleManager) *RuleManager {
+	if m == nil {
+		return defaultRuleManager
+	}
+	return m
+}
+
 func init() {
 	// Initialize the traffic shaping controller generator map for existing control behaviors.
 	tcGenFuncMap[trafficControllerGenKey{
@@ -157,6 +210,51 @@ func init() {
 		tsc.flowChecker = NewThrottlingChecker(tsc, rule.MaxQueueingTimeMs, rule.StatIntervalInMs)
 		return tsc, nil
 	}
+	tcGenFuncMap[trafficControllerGenKey{
+		tokenCalculateStrategy: Direct,
+		controlBehavior:        FairShare,
+	}] = func(rule *Rule, _ *standaloneStatistic) (*TrafficShapingController, error) {
+		// The fair share checker counts the passed requests of each caller by itself, so we just give a nop stat.
+		tsc, err := NewTrafficShapingController(rule, nopStat)
+		if err != nil || tsc == nil {
+			return nil, err
+		}
+		tsc.flowCalculator = NewDirectTrafficShapingCalculator(tsc, rule.Threshold)
+		tsc.flowChecker = NewFairShareTrafficShapingChecker(tsc, rule)
+		return tsc, nil
+	}
+	tcGenFuncMap[trafficControllerGenKey{
+		tokenCalculateStrategy: WarmUp,
+		controlBehavior:        FairShare,
+	}] = func(rule *Rule, boundStat *standaloneStatistic) (*TrafficShapingController, error) {
+		if boundStat == nil {
+			var err error
+			boundStat, err = generateStatFor(rule)
+			if err != nil {
+				return nil, err
+			}
+		}
+		tsc, err := NewTrafficShapingController(rule, boundStat)
+		if err != nil || tsc == nil {
+			return nil, err
+		}
+		tsc.flowCalculator = NewWarmUpTrafficShapingCalculator(tsc, rule)
+		tsc.flowChecker = NewFairShareTrafficShapingChecker(tsc, rule)
+		return tsc, nil
+	}
+	tcGenFuncMap[trafficControllerGenKey{
+		tokenCalculateStrategy: MemoryAdaptive,
+		controlBehavior:        FairShare,
+	}] = func(rule *Rule, _ *standaloneStatistic) (*TrafficShapingController, error) {
+		// MemoryAdaptive token calculate strategy and fair share control behavior don't use stat, so we just give a nop stat.
+		tsc, err := NewTrafficShapingController(rule, nopStat)
+		if err != nil || tsc == nil {
+			return nil, err
+		}
+		tsc.flowCalculator = NewMemoryAdaptiveTrafficShapingCalculator(tsc, rule)
+		tsc.flowChecker = NewFairShareTrafficShapingChecker(tsc, rule)
+		return tsc, nil
+	}
 }
 
 func logRuleUpdate(m map[string][]*Rule) {
@@ -172,9 +270,42 @@ func logRuleUpdate(m map[string][]*Rule) {
 	} else {
 		logging.Info("[FlowRuleManager] Flow rules were loaded", "rules", rules)
 	}
+	publishRuleChange("", rules)
+}
+
+// publishRuleChange publishes the valid rules loaded for the resource (all the resources if res is empty) to the event bus.
+func publishRuleChange(res string, rules []*Rule) {
+	sentinelRules := make([]base.SentinelRule, 0, len(rules))
+	for _, r := range rules {
+		sentinelRules = append(sentinelRules, r)
+	}
+	event.PublishRuleChange(event.ModuleFlow, res, sentinelRules)
+}
+
+// ruleUpdate holds the traffic controllers built from the rules, which are swapped into the rule manager at once.
+type ruleUpdate struct {
+	m                *RuleManager
+	rawResRulesMap   map[string][]*Rule
+	validResRulesMap map[string][]*Rule
+	tcMap            TrafficControllerMap
+	patternRuleMap   map[string][]*patternRule
+	inactiveRules    map[string][]*Rule
+	now              uint64
+	start            uint64
 }
 
-func onRuleUpdate(rawResRulesMap map[string][]*Rule) (err error) {
+func (m *RuleManager) onRuleUpdate(rawResRulesMap map[string][]*Rule) error {
+	u, err := m.buildRuleUpdate(rawResRulesMap)
+	if err != nil {
+		return err
+	}
+	u.Apply()
+	return nil
+}
+
+// buildRuleUpdate builds the traffic controllers of the valid rules without applying them.
+// It must be called with updateRuleMux held.
+func (m *RuleManager) buildRuleUpdate(rawResRulesMap map[string][]*Rule) (u *base.RuleUpdate, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			var ok bool
@@ -182,6 +313,7 @@ func onRuleUpdate(rawResRulesMap map[string][]*Rule) (err error) {
 			if !ok {
 				err = fmt.Errorf("%v", r)
 			}
+			m.releaseUnusedResourceNodes()
 		}
 	}()
 
@@ -203,36 +335,91 @@ func onRuleUpdate(rawResRulesMap map[string][]*Rule) (err error) {
 
 	start := util.CurrentTimeNano()
 
-	tcMux.RLock()
+	m.tcMux.RLock()
 	tcMapClone := make(TrafficControllerMap, len(validResRulesMap))
-	for res, tcs := range tcMap {
+	for res, tcs := range m.tcMap {
 		resTcClone := make([]*TrafficShapingController, 0, len(tcs))
 		resTcClone = append(resTcClone, tcs...)
 		tcMapClone[res] = resTcClone
 	}
-	tcMux.RUnlock()
+	patternRuleMapClone := make(map[string][]*patternRule, len(m.patternRuleMap))
+	for pattern, ps := range m.patternRuleMap {
+		patternRuleMapClone[pattern] = append(make([]*patternRule, 0, len(ps)), ps...)
+	}
+	m.tcMux.RUnlock()
 
-	m := make(TrafficControllerMap, len(validResRulesMap))
+	now := util.CurrentTimeMillis()
+	newTcMap := make(TrafficControllerMap, len(validResRulesMap))
+	newPatternRuleMap := make(map[string][]*patternRule)
+	newInactiveRules := make(map[string][]*Rule)
 	for res, rulesOfRes := range validResRulesMap {
-		newTcsOfRes := buildResourceTrafficShapingController(res, rulesOfRes, tcMapClone[res])
-		if len(newTcsOfRes) > 0 {
-			m[res] = newTcsOfRes
+		activeRules, inactiveRules := splitInactiveRules(rulesOfRes, now)
+		if len(inactiveRules) > 0 {
+			newInactiveRules[res] = inactiveRules
+		}
+		exactRules, patternRules := splitPatternRules(activeRules)
+		if newTcsOfRes := m.buildResourceTrafficShapingController(res, exactRules, tcMapClone[res]); len(newTcsOfRes) > 0 {
+			newTcMap[res] = newTcsOfRes
+		}
+		if newPatternRules := m.buildPatternRules(res, patternRules, patternRuleMapClone[res]); len(newPatternRules) > 0 {
+			newPatternRuleMap[res] = newPatternRules
 		}
 	}
 
-	tcMux.Lock()
-	tcMap = m
-	tcMux.Unlock()
-	currentRules = rawResRulesMap
+	update := &ruleUpdate{
+		m:                m,
+		rawResRulesMap:   rawResRulesMap,
+		validResRulesMap: validResRulesMap,
+		tcMap:            newTcMap,
+		patternRuleMap:   newPatternRuleMap,
+		inactiveRules:    newInactiveRules,
+		now:              now,
+		start:            start,
+	}
+	// The traffic controllers built pin the nodes of their resources, which are unpinned if the update is discarded.
+	return base.NewRuleUpdate(&m.updateRuleMux, &m.tcMux, update.swap, update.complete).OnDiscard(m.releaseUnusedResourceNodes), nil
+}
 
-	logging.Debug("[Flow onRuleUpdate] Time statistic(ns) for updating flow rule", "timeCost", util.CurrentTimeNano()-start)
-	logRuleUpdate(validResRulesMap)
-	return nil
+// swap replaces the state read by the traffic with the built one, it must be called with the state locked.
+func (u *ruleUpdate) swap() {
+	u.m.tcMap = u.tcMap
+	u.m.patternRuleMap = u.patternRuleMap
+	u.m.patterns = newPatternRuleSet(u.patternRuleMap, u.m.nodes.MaxResourceAmount())
+	u.m.inactiveRules = u.inactiveRules
+	u.m.unpinUnusedResourceNodes()
+}
+
+// complete records the swapped rules as the current rules, it must be called with updateRuleMux held.
+func (u *ruleUpdate) complete() {
+	u.m.currentRules = u.rawResRulesMap
+	u.m.refresher.Schedule(u.now)
+
+	logging.Debug("[Flow onRuleUpdate] Time statistic(ns) for updating flow rule", "timeCost", util.CurrentTimeNano()-u.start)
+	logRuleUpdate(u.validResRulesMap)
 }
 
 // LoadRules loads the given flow rules to the rule manager, while all previous rules will be replaced.
 // the first returned value indicates whether do real load operation, if the rules is the same with previous rules, return false
 func LoadRules(rules []*Rule) (bool, error) {
+	return defaultRuleManager.LoadRules(rules)
+}
+
+// LoadRules loads the given flow rules to the rule manager, while all previous rules will be replaced.
+func (m *RuleManager) LoadRules(rules []*Rule) (bool, error) {
+	resRulesMap := resRulesMapOf(rules)
+
+	m.updateRuleMux.Lock()
+	defer m.updateRuleMux.Unlock()
+	isEqual := reflect.DeepEqual(m.currentRules, resRulesMap)
+	if isEqual {
+		logging.Info("[Flow] Load rules is the same with current rules, so ignore load operation.")
+		return false, nil
+	}
+	err := m.onRuleUpdate(resRulesMap)
+	return true, err
+}
+
+func resRulesMapOf(rules []*Rule) map[string][]*Rule {
 	resRulesMap := make(map[string][]*Rule, 16)
 	for _, rule := range rules {
 		resRules, exist := resRulesMap[rule.Resource]
@@ -241,19 +428,10 @@ func LoadRules(rules []*Rule) (bool, error) {
 		}
 		resRulesMap[rule.Resource] = append(resRules, rule)
 	}
-
-	updateRuleMux.Lock()
-	defer updateRuleMux.Unlock()
-	isEqual := reflect.DeepEqual(currentRules, resRulesMap)
-	if isEqual {
-		logging.Info("[Flow] Load rules is the same with current rules, so ignore load operation.")
-		return false, nil
-	}
-	err := onRuleUpdate(resRulesMap)
-	return true, err
+	return resRulesMap
 }
 
-func onResourceRuleUpdate(res string, rawResRules []*Rule) (err error) {
+func (m *RuleManager) onResourceRuleUpdate(res string, rawResRules []*Rule) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			var ok bool
@@ -275,84 +453,130 @@ func onResourceRuleUpdate(res string, rawResRules []*Rule) (err error) {
 
 	start := util.CurrentTimeNano()
 	oldResTcs := make([]*TrafficShapingController, 0)
-	tcMux.RLock()
-	oldResTcs = append(oldResTcs, tcMap[res]...)
-	tcMux.RUnlock()
-	newResTcs := buildResourceTrafficShapingController(res, validResRules, oldResTcs)
+	oldPatternRules := make([]*patternRule, 0)
+	m.tcMux.RLock()
+	oldResTcs = append(oldResTcs, m.tcMap[res]...)
+	oldPatternRules = append(oldPatternRules, m.patternRuleMap[res]...)
+	m.tcMux.RUnlock()
+	now := util.CurrentTimeMillis()
+	activeRules, inactiveRules := splitInactiveRules(validResRules, now)
+	exactRules, patternRules := splitPatternRules(activeRules)
+	newResTcs := m.buildResourceTrafficShapingController(res, exactRules, oldResTcs)
+	newPatternRules := m.buildPatternRules(res, patternRules, oldPatternRules)
 
-	tcMux.Lock()
+	m.tcMux.Lock()
 	if len(newResTcs) == 0 {
-		delete(tcMap, res)
+		delete(m.tcMap, res)
 	} else {
-		tcMap[res] = newResTcs
+		m.tcMap[res] = newResTcs
 	}
-	tcMux.Unlock()
-	currentRules[res] = rawResRules
+	if len(newPatternRules) == 0 {
+		delete(m.patternRuleMap, res)
+	} else {
+		m.patternRuleMap[res] = newPatternRules
+	}
+	m.patterns = newPatternRuleSet(m.patternRuleMap, m.nodes.MaxResourceAmount())
+	if len(inactiveRules) == 0 {
+		delete(m.inactiveRules, res)
+	} else {
+		m.inactiveRules[res] = inactiveRules
+	}
+	m.unpinUnusedResourceNodes()
+	m.tcMux.Unlock()
+	m.currentRules[res] = rawResRules
+	m.refresher.Schedule(now)
 	logging.Debug("[Flow onResourceRuleUpdate] Time statistic(ns) for updating flow rule", "timeCost", util.CurrentTimeNano()-start)
 	logging.Info("[Flow] load resource level rules", "resource", res, "validResRules", validResRules)
+	publishRuleChange(res, validResRules)
 	return nil
 }
 
 // LoadRulesOfResource loads the given resource's flow rules to the rule manager, while all previous resource's rules will be replaced.
 // the first returned value indicates whether do real load operation, if the rules is the same with previous resource's rules, return false
 func LoadRulesOfResource(res string, rules []*Rule) (bool, error) {
+	return defaultRuleManager.LoadRulesOfResource(res, rules)
+}
+
+// LoadRulesOfResource loads the given resource's flow rules to the rule manager, while all previous resource's rules will be replaced.
+func (m *RuleManager) LoadRulesOfResource(res string, rules []*Rule) (bool, error) {
 	if len(res) == 0 {
 		return false, errors.New("empty resource")
 	}
-	updateRuleMux.Lock()
-	defer updateRuleMux.Unlock()
+	m.updateRuleMux.Lock()
+	defer m.updateRuleMux.Unlock()
 	// clear resource rules
 	if len(rules) == 0 {
 		// clear resource's currentRules
-		delete(currentRules, res)
+		delete(m.currentRules, res)
 		// clear tcMap
-		tcMux.Lock()
-		delete(tcMap, res)
-		tcMux.Unlock()
+		m.tcMux.Lock()
+		delete(m.tcMap, res)
+		delete(m.patternRuleMap, res)
+		// The pattern rule set caches the traffic controllers of the exact rules as well, so it's always rebuilt.
+		m.patterns = newPatternRuleSet(m.patternRuleMap, m.nodes.MaxResourceAmount())
+		delete(m.inactiveRules, res)
+		m.unpinUnusedResourceNodes()
+		m.tcMux.Unlock()
+		m.refresher.Schedule(util.CurrentTimeMillis())
 		logging.Info("[Flow] clear resource level rules", "resource", res)
+		publishRuleChange(res, nil)
 		return true, nil
 	}
 	// load resource level rules
-	isEqual := reflect.DeepEqual(currentRules[res], rules)
+	isEqual := reflect.DeepEqual(m.currentRules[res], rules)
 	if isEqual {
 		logging.Info("[Flow] Load resource level rules is the same with current resource level rules, so ignore load operation.")
 		return false, nil
 	}
 
-	err := onResourceRuleUpdate(res, rules)
+	err := m.onResourceRuleUpdate(res, rules)
 	return true, err
 }
 
 // getRules returns all the rules。Any changes of rules take effect for flow module
 // getRules is an internal interface.
-func getRules() []*Rule {
-	tcMux.RLock()
-	defer tcMux.RUnlock()
+func (m *RuleManager) getRules() []*Rule {
+	m.tcMux.RLock()
+	defer m.tcMux.RUnlock()
 
-	return rulesFrom(tcMap)
+	rules := append(rulesFrom(m.tcMap), patternRulesFrom(m.patternRuleMap)...)
+	for _, rs := range m.inactiveRules {
+		rules = append(rules, rs...)
+	}
+	return rules
 }
 
 // getRulesOfResource returns specific resource's rules。Any changes of rules take effect for flow module
 // getRulesOfResource is an internal interface.
-func getRulesOfResource(res string) []*Rule {
-	tcMux.RLock()
-	defer tcMux.RUnlock()
+func (m *RuleManager) getRulesOfResource(res string) []*Rule {
+	m.tcMux.RLock()
+	defer m.tcMux.RUnlock()
 
-	resTcs, exist := tcMap[res]
-	if !exist {
+	resTcs, exist := m.tcMap[res]
+	resPatternRules, patternExist := m.patternRuleMap[res]
+	resInactiveRules, inactiveExist := m.inactiveRules[res]
+	if !exist && !patternExist && !inactiveExist {
 		return nil
 	}
-	ret := make([]*Rule, 0, len(resTcs))
+	ret := make([]*Rule, 0, len(resTcs)+len(resPatternRules)+len(resInactiveRules))
 	for _, tc := range resTcs {
 		ret = append(ret, tc.BoundRule())
 	}
-	return ret
+	for _, p := range resPatternRules {
+		ret = append(ret, p.rule)
+	}
+	return append(ret, resInactiveRules...)
 }
 
 // GetRules returns all the rules based on copy.
 // It doesn't take effect for flow module if user changes the rule.
 func GetRules() []Rule {
-	rules := getRules()
+	return defaultRuleManager.GetRules()
+}
+
+// GetRules returns all the rules based on copy.
+func (m *RuleManager) GetRules() []Rule {
+	rules := m.getRules()
 	ret := make([]Rule, 0, len(rules))
 	for _, rule := range rules {
 		ret = append(ret, *rule)
@@ -363,7 +587,12 @@ func GetRules() []Rule {
 // GetRulesOfResource returns specific resource's rules based on copy.
 // It doesn't take effect for flow module if user changes the rule.
 func GetRulesOfResource(res string) []Rule {
-	rules := getRulesOfResource(res)
+	return defaultRuleManager.GetRulesOfResource(res)
+}
+
+// GetRulesOfResource returns specific resource's rules based on copy.
+func (m *RuleManager) GetRulesOfResource(res string) []Rule {
+	rules := m.getRulesOfResource(res)
 	ret := make([]Rule, 0, len(rules))
 	for _, rule := range rules {
 		ret = append(ret, *rule)
@@ -373,13 +602,33 @@ func GetRulesOfResource(res string) []Rule {
 
 // ClearRules clears all the rules in flow module.
 func ClearRules() error {
-	_, err := LoadRules(nil)
+	return defaultRuleManager.ClearRules()
+}
+
+// ClearRules clears all the rules of the rule manager.
+func (m *RuleManager) ClearRules() error {
+	_, err := m.LoadRules(nil)
 	return err
 }
 
+// Close stops refreshing the rules in background and clears all the rules and quotas of the rule manager,
+// which must not be used any more then.
+func (m *RuleManager) Close() error {
+	m.refresher.Stop()
+	if err := m.ClearRules(); err != nil {
+		return err
+	}
+	return m.ClearQuotas()
+}
+
 // ClearRulesOfResource clears resource level rules in flow module.
 func ClearRulesOfResource(res string) error {
-	_, err := LoadRulesOfResource(res, nil)
+	return defaultRuleManager.ClearRulesOfResource(res)
+}
+
+// ClearRulesOfResource clears resource level rules of the rule manager.
+func (m *RuleManager) ClearRulesOfResource(res string) error {
+	_, err := m.LoadRulesOfResource(res, nil)
 	return err
 }
 
@@ -401,7 +650,13 @@ func rulesFrom(m TrafficControllerMap) []*Rule {
 	return rules
 }
 
+// generateStatFor generates the statistic of the rule from the nodes of the default RuleManager,
+// which is used by the built-in generators if no statistic is given.
 func generateStatFor(rule *Rule) (*standaloneStatistic, error) {
+	return defaultRuleManager.generateStatFor(rule)
+}
+
+func (m *RuleManager) generateStatFor(rule *Rule) (*standaloneStatistic, error) {
 	if !rule.needStatistic() {
 		return nopStat, nil
 	}
@@ -413,9 +668,13 @@ func generateStatFor(rule *Rule) (*standaloneStatistic, error) {
 	var resNode *stat.ResourceNode
 	if rule.RelationStrategy == AssociatedResource {
 		// use associated statistic
-		resNode = stat.GetOrCreateResourceNode(rule.RefResource, base.ResTypeCommon)
+		resNode = m.pinResourceNode(rule.RefResource)
 	} else {
-		resNode = stat.GetOrCreateResourceNode(rule.Resource, base.ResTypeCommon)
+		resNode = m.pinResourceNode(rule.Resource)
+		if len(rule.Conditions) > 0 {
+			// The rule with Conditions only counts the matching traffic, which the resource's statistic couldn't tell.
+			return newIndependentStatOf(rule)
+		}
 	}
 	if intervalInMs == 0 || intervalInMs == config.MetricStatisticIntervalMs() {
 		// default case, use the resource's default statistic
@@ -426,19 +685,7 @@ func generateStatFor(rule *Rule) (*standaloneStatistic, error) {
 		return &retStat, nil
 	}
 
-	sampleCount := uint32(0)
-	//calculate the sample count
-	if intervalInMs > config.GlobalStatisticIntervalMsTotal() {
-		sampleCount = 1
-	} else if intervalInMs < config.GlobalStatisticBucketLengthInMs() {
-		sampleCount = 1
-	} else {
-		if intervalInMs%config.GlobalStatisticBucketLengthInMs() == 0 {
-			sampleCount = intervalInMs / config.GlobalStatisticBucketLengthInMs()
-		} else {
-			sampleCount = 1
-		}
-	}
+	sampleCount := sampleCountOf(intervalInMs)
 	err := base.CheckValidityForReuseStatistic(sampleCount, intervalInMs, config.GlobalStatisticSampleCountTotal(), config.GlobalStatisticIntervalMsTotal())
 	if err == nil {
 		// global statistic reusable
@@ -452,19 +699,55 @@ func generateStatFor(rule *Rule) (*standaloneStatistic, error) {
 		return &retStat, nil
 	} else if err == base.GlobalStatisticNonReusableError {
 		logging.Info("[FlowRuleManager] Flow rule couldn't reuse global statistic and will generate independent statistic", "rule", rule)
-		retStat.reuseResourceStat = false
-		realLeapArray := sbase.NewBucketLeapArray(sampleCount, intervalInMs)
-		metricStat, e := sbase.NewSlidingWindowMetric(sampleCount, intervalInMs, realLeapArray)
-		if e != nil {
-			return nil, errors.Errorf("fail to generate statistic for warm up rule: %+v, err: %+v", rule, e)
-		}
-		retStat.readOnlyMetric = metricStat
-		retStat.writeOnlyMetric = realLeapArray
-		return &retStat, nil
+		return newIndependentStat(rule, sampleCount, intervalInMs)
 	}
 	return nil, errors.Wrapf(err, "fail to new standalone statistic because of invalid StatIntervalInMs in flow.Rule, StatIntervalInMs: %d", intervalInMs)
 }
 
+// generateSharedStatFor generates the statistic shared by all the resources matching the pattern rule or the group rule.
+// The pattern or the group itself is not a real resource, so an independent statistic is always generated
+// unless the rule reads the statistic of the associated resource.
+func (m *RuleManager) generateSharedStatFor(rule *Rule) (*standaloneStatistic, error) {
+	if !rule.needStatistic() || rule.RelationStrategy == AssociatedResource {
+		return m.generateStatFor(rule)
+	}
+
+	return newIndependentStatOf(rule)
+}
+
+// newIndependentStatOf generates the independent statistic with the StatIntervalInMs of the rule.
+func newIndependentStatOf(rule *Rule) (*standaloneStatistic, error) {
+	intervalInMs := rule.StatIntervalInMs
+	if intervalInMs == 0 || intervalInMs == config.MetricStatisticIntervalMs() {
+		return newIndependentStat(rule, config.MetricStatisticSampleCount(), config.MetricStatisticIntervalMs())
+	}
+	return newIndependentStat(rule, sampleCountOf(intervalInMs), intervalInMs)
+}
+
+// sampleCountOf calculates the sample count of the statistic with the given interval.
+func sampleCountOf(intervalInMs uint32) uint32 {
+	if intervalInMs > config.GlobalStatisticIntervalMsTotal() || intervalInMs < config.GlobalStatisticBucketLengthInMs() {
+		return 1
+	}
+	if intervalInMs%config.GlobalStatisticBucketLengthInMs() == 0 {
+		return intervalInMs / config.GlobalStatisticBucketLengthInMs()
+	}
+	return 1
+}
+
+func newIndependentStat(rule *Rule, sampleCount, intervalInMs uint32) (*standaloneStatistic, error) {
+	realLeapArray := sbase.NewBucketLeapArray(sampleCount, intervalInMs)
+	metricStat, e := sbase.NewSlidingWindowMetric(sampleCount, intervalInMs, realLeapArray)
+	if e != nil {
+		return nil, errors.Errorf("fail to generate statistic for rule: %+v, err: %+v", rule, e)
+	}
+	return &standaloneStatistic{
+		reuseResourceStat: false,
+		readOnlyMetric:    metricStat,
+		writeOnlyMetric:   realLeapArray,
+	}, nil
+}
+
 // SetTrafficShapingGenerator sets the traffic controller generator for the given TokenCalculateStrategy and ControlBehavior.
 // Note that modifying the generator of default control strategy is not allowed.
 func SetTrafficShapingGenerator(tokenCalculateStrategy TokenCalculateStrategy, controlBehavior ControlBehavior, generator TrafficControllerGenFunc) error {
@@ -475,11 +758,11 @@ func SetTrafficShapingGenerator(tokenCalculateStrategy TokenCalculateStrategy, c
 	if tokenCalculateStrategy >= Direct && tokenCalculateStrategy <= WarmUp {
 		return errors.New("not allowed to replace the generator for default control strategy")
 	}
-	if controlBehavior >= Reject && controlBehavior <= Throttling {
+	if controlBehavior >= Reject && controlBehavior <= FairShare {
 		return errors.New("not allowed to replace the generator for default control strategy")
 	}
-	tcMux.Lock()
-	defer tcMux.Unlock()
+	tcGenMux.Lock()
+	defer tcGenMux.Unlock()
 
 	tcGenFuncMap[trafficControllerGenKey{
 		tokenCalculateStrategy: tokenCalculateStrategy,
@@ -492,11 +775,11 @@ func RemoveTrafficShapingGenerator(tokenCalculateStrategy TokenCalculateStrategy
 	if tokenCalculateStrategy >= Direct && tokenCalculateStrategy <= WarmUp {
 		return errors.New("not allowed to replace the generator for default control strategy")
 	}
-	if controlBehavior >= Reject && controlBehavior <= Throttling {
+	if controlBehavior >= Reject && controlBehavior <= FairShare {
 		return errors.New("not allowed to replace the generator for default control strategy")
 	}
-	tcMux.Lock()
-	defer tcMux.Unlock()
+	tcGenMux.Lock()
+	defer tcGenMux.Unlock()
 
 	delete(tcGenFuncMap, trafficControllerGenKey{
 		tokenCalculateStrategy: tokenCalculateStrategy,
@@ -505,11 +788,91 @@ func RemoveTrafficShapingGenerator(tokenCalculateStrategy TokenCalculateStrategy
 	return nil
 }
 
-func getTrafficControllerListFor(name string) []*TrafficShapingController {
-	tcMux.RLock()
-	defer tcMux.RUnlock()
+// pinResourceNode returns the pinned node of the resource, the resource node is pinned once by the rule manager
+// however many rules hold it.
+func (m *RuleManager) pinResourceNode(res string) *stat.ResourceNode {
+	return m.pins.Pin(res)
+}
+
+// unpinUnusedResourceNodes unpins the nodes of the resources no longer held by the traffic controllers,
+// including the ones resolved from the pattern rules, it must be called with tcMux locked.
+func (m *RuleManager) unpinUnusedResourceNodes() {
+	used := make(map[string]struct{})
+	addUsed := func(tcs []*TrafficShapingController) {
+		for _, tc := range tcs {
+			if rule := tc.BoundRule(); rule != nil && rule.needStatistic() {
+				used[statResourceOf(rule)] = struct{}{}
+			}
+		}
+	}
+	for _, tcs := range m.tcMap {
+		addUsed(tcs)
+	}
+	if m.patterns == nil {
+		m.pins.Retain(used)
+		return
+	}
+	// The traffic controllers resolved from the pattern rules pin the nodes as well, so the resolving is held off
+	// until the pins are settled.
+	m.patterns.resolvedMux.RLock()
+	defer m.patterns.resolvedMux.RUnlock()
+	m.patterns.forEachTrafficControllers(addUsed)
+	m.pins.Retain(used)
+}
+
+// releaseUnusedResourceNodes unpins the nodes pinned by the traffic controllers which are built but not swapped in.
+func (m *RuleManager) releaseUnusedResourceNodes() {
+	m.tcMux.RLock()
+	defer m.tcMux.RUnlock()
 
-	return tcMap[name]
+	m.unpinUnusedResourceNodes()
+}
+
+// statResourceOf returns the resource whose statistic is read by the rule.
+func statResourceOf(rule *Rule) string {
+	if rule.RelationStrategy == AssociatedResource {
+		return rule.RefResource
+	}
+	return rule.Resource
+}
+
+// getTrafficControllerListFor returns the traffic controllers of the resource, including those of the matched pattern rules.
+func (m *RuleManager) getTrafficControllerListFor(name string) []*TrafficShapingController {
+	m.refresher.Check(util.CurrentTimeMillis())
+	m.tcMux.RLock()
+	tcs := m.tcMap[name]
+	patterns := m.patterns
+	m.tcMux.RUnlock()
+
+	if patterns == nil {
+		return tcs
+	}
+	return patterns.trafficControllersOf(m, name, tcs)
+}
+
+// splitPatternRules splits the rules into the exact rules and the pattern rules, the group rules are regarded as
+// pattern rules that match the member resources.
+func splitPatternRules(rules []*Rule) (exactRules, patternRules []*Rule) {
+	exactRules = make([]*Rule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.ResourceMatchStrategy.IsPattern() || rule.isGroupRule() {
+			patternRules = append(patternRules, rule)
+		} else {
+			exactRules = append(exactRules, rule)
+		}
+	}
+	return exactRules, patternRules
+}
+
+func generatorOf(rule *Rule) (TrafficControllerGenFunc, bool) {
+	tcGenMux.RLock()
+	defer tcGenMux.RUnlock()
+
+	generator, supported := tcGenFuncMap[trafficControllerGenKey{
+		tokenCalculateStrategy: rule.TokenCalculateStrategy,
+		controlBehavior:        rule.ControlBehavior,
+	}]
+	return generator, supported && generator != nil
 }
 
 func calculateReuseIndexFor(r *Rule, oldResTcs []*TrafficShapingController) (equalIdx, reuseStatIdx int) {
@@ -539,7 +902,7 @@ func calculateReuseIndexFor(r *Rule, oldResTcs []*TrafficShapingController) (equ
 }
 
 // buildResourceTrafficShapingController builds TrafficShapingController slice from rules. the resource of rules must be equals to res
-func buildResourceTrafficShapingController(res string, rulesOfRes []*Rule, oldResTcs []*TrafficShapingController) []*TrafficShapingController {
+func (m *RuleManager) buildResourceTrafficShapingController(res string, rulesOfRes []*Rule, oldResTcs []*TrafficShapingController) []*TrafficShapingController {
 	newTcsOfRes := make([]*TrafficShapingController, 0, len(rulesOfRes))
 	for _, rule := range rulesOfRes {
 		if res != rule.Resource {
@@ -558,11 +921,8 @@ func buildResourceTrafficShapingController(res string, rulesOfRes []*Rule, oldRe
 			continue
 		}
 
-		generator, supported := tcGenFuncMap[trafficControllerGenKey{
-			tokenCalculateStrategy: rule.TokenCalculateStrategy,
-			controlBehavior:        rule.ControlBehavior,
-		}]
-		if !supported || generator == nil {
+		generator, supported := generatorOf(rule)
+		if !supported {
 			logging.Error(errors.New("unsupported flow control strategy"), "Ignoring the rule due to unsupported control behavior in flow.buildResourceTrafficShapingController()", "rule", rule)
 			continue
 		}
@@ -570,6 +930,12 @@ func buildResourceTrafficShapingController(res string, rulesOfRes []*Rule, oldRe
 		var e error
 		if reuseStatIdx >= 0 {
 			tc, e = generator(rule, &(oldResTcs[reuseStatIdx].boundStat))
+		} else if rule.needStatistic() {
+			// The statistic is generated here rather than in the generator, so that it's bound to the nodes of the rule manager.
+			var boundStat *standaloneStatistic
+			if boundStat, e = m.generateStatFor(rule); e == nil {
+				tc, e = generator(rule, boundStat)
+			}
 		} else {
 			tc, e = generator(rule, nil)
 		}
@@ -595,9 +961,30 @@ func IsValidRule(rule *Rule) error {
 	if rule.Resource == "" {
 		return errors.New("empty Resource")
 	}
+	if rule.ResourceMatchStrategy.IsPattern() {
+		if _, err := base.NewResourceMatcher(rule.ResourceMatchStrategy, rule.Resource); err != nil {
+			return err
+		}
+	}
+	if rule.isGroupRule() {
+		if rule.ResourceMatchStrategy.IsPattern() {
+			return errors.New("group rule must not have resource pattern")
+		}
+		if rule.RelationStrategy != CurrentResource {
+			return errors.New("RelationStrategy of group rule must be CurrentResource")
+		}
+		for _, member := range rule.MemberResources {
+			if member == "" {
+				return errors.New("empty member resource of group rule")
+			}
+		}
+	}
 	if rule.Threshold < 0 {
 		return errors.New("negative Threshold")
 	}
+	if err := base.IsValidEffectivePeriod(rule.EffectiveFrom, rule.ExpiresAt); err != nil {
+		return err
+	}
 	if int32(rule.TokenCalculateStrategy) < 0 {
 		return errors.New("negative TokenCalculateStrategy")
 	}
@@ -618,6 +1005,32 @@ func IsValidRule(rule *Rule) error {
 			return errors.New("WarmUpColdFactor must be great than 1")
 		}
 	}
+	if len(rule.Schedule) > 0 {
+		if rule.TokenCalculateStrategy != Direct {
+			return errors.New("Schedule only takes effect when TokenCalculateStrategy is Direct")
+		}
+		if _, err := base.NewThresholdSchedule(rule.Schedule); err != nil {
+			return err
+		}
+	}
+	if len(rule.Reservations) > 0 {
+		if rule.ControlBehavior != Reject {
+			return errors.New("Reservations only take effect when ControlBehavior is Reject")
+		}
+		if err := base.IsValidReservations(rule.Reservations); err != nil {
+			return err
+		}
+	}
+	if err := base.IsValidConditions(rule.Conditions); err != nil {
+		return err
+	}
+	if rule.ControlBehavior == FairShare {
+		for caller, weight := range rule.FairShareWeights {
+			if weight < 0 {
+				return errors.Errorf("negative weight of caller %s in FairShareWeights", caller)
+			}
+		}
+	}
 	if rule.StatIntervalInMs > 10*60*1000 {
 		logging.Info("StatIntervalInMs is great than 10 minutes, less than 10 minutes is recommended.")
 	}
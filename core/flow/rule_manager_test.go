@@ -17,15 +17,21 @@ package flow
 import (
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/alibaba/sentinel-golang/core/base"
 	"github.com/alibaba/sentinel-golang/core/stat"
 	sbase "github.com/alibaba/sentinel-golang/core/stat/base"
+	"github.com/alibaba/sentinel-golang/util"
 	"github.com/stretchr/testify/assert"
 )
 
 func clearData() {
-	tcMap = make(TrafficControllerMap)
-	currentRules = make(map[string][]*Rule, 0)
+	defaultRuleManager.tcMap = make(TrafficControllerMap)
+	defaultRuleManager.patternRuleMap = make(map[string][]*patternRule)
+	defaultRuleManager.patterns = nil
+	defaultRuleManager.inactiveRules = make(map[string][]*Rule)
+	defaultRuleManager.currentRules = make(map[string][]*Rule, 0)
 }
 func TestSetAndRemoveTrafficShapingGenerator(t *testing.T) {
 	tsc := &TrafficShapingController{}
@@ -36,6 +42,10 @@ func TestSetAndRemoveTrafficShapingGenerator(t *testing.T) {
 	assert.Error(t, err, "default control behaviors are not allowed to be modified")
 	err = RemoveTrafficShapingGenerator(Direct, Reject)
 	assert.Error(t, err, "default control behaviors are not allowed to be removed")
+	err = SetTrafficShapingGenerator(TokenCalculateStrategy(111), FairShare, func(_ *Rule, _ *standaloneStatistic) (*TrafficShapingController, error) {
+		return tsc, nil
+	})
+	assert.Error(t, err, "default control behaviors are not allowed to be modified")
 
 	err = SetTrafficShapingGenerator(TokenCalculateStrategy(111), ControlBehavior(112), func(_ *Rule, _ *standaloneStatistic) (*TrafficShapingController, error) {
 		return tsc, nil
@@ -58,8 +68,8 @@ func TestSetAndRemoveTrafficShapingGenerator(t *testing.T) {
 	}
 	assert.NoError(t, err)
 	assert.Contains(t, tcGenFuncMap, cs)
-	assert.NotZero(t, len(tcMap[resource]))
-	assert.Equal(t, tsc, tcMap[resource][0])
+	assert.NotZero(t, len(defaultRuleManager.tcMap[resource]))
+	assert.Equal(t, tsc, defaultRuleManager.tcMap[resource][0])
 
 	err = RemoveTrafficShapingGenerator(TokenCalculateStrategy(111), ControlBehavior(112))
 	assert.NoError(t, err)
@@ -84,6 +94,12 @@ func TestIsValidFlowRule(t *testing.T) {
 
 	assert.NoError(t, IsValidRule(goodRule1))
 	assert.NoError(t, IsValidRule(goodRule2))
+
+	reservations := []*base.Reservation{{Flag: 1, Ratio: 0.2}}
+	assert.NoError(t, IsValidRule(&Rule{Threshold: 10, Resource: "test", ControlBehavior: Reject, Reservations: reservations}))
+	assert.Error(t, IsValidRule(&Rule{Threshold: 10, Resource: "test", ControlBehavior: Throttling, Reservations: reservations}))
+	assert.Error(t, IsValidRule(&Rule{Threshold: 10, Resource: "test", ControlBehavior: Reject, Reservations: []*base.Reservation{{Ratio: 0.2}}}))
+	assert.Error(t, IsValidRule(&Rule{Threshold: 10, Resource: "test", ControlBehavior: FairShare, FairShareWeights: map[string]float64{"a": -1}}))
 }
 
 func TestGetRules(t *testing.T) {
@@ -156,7 +172,7 @@ func TestGetRules(t *testing.T) {
 		if _, err := LoadRules([]*Rule{r1, r2}); err != nil {
 			t.Fatal(err)
 		}
-		rs2 := getRules()
+		rs2 := defaultRuleManager.getRules()
 		if rs2[0].Resource == "abc1" {
 			assert.True(t, rs2[0] == r1)
 			assert.True(t, rs2[1] == r2)
@@ -168,9 +184,9 @@ func TestGetRules(t *testing.T) {
 			assert.True(t, reflect.DeepEqual(rs2[0], r2))
 			assert.True(t, reflect.DeepEqual(rs2[1], r1))
 		}
-		assert.True(t, len(tcMap["abc2"]) == 1 && !tcMap["abc2"][0].boundStat.reuseResourceStat)
-		assert.True(t, reflect.DeepEqual(tcMap["abc2"][0].boundStat.readOnlyMetric, nopStat.readOnlyMetric))
-		assert.True(t, reflect.DeepEqual(tcMap["abc2"][0].boundStat.writeOnlyMetric, nopStat.writeOnlyMetric))
+		assert.True(t, len(defaultRuleManager.tcMap["abc2"]) == 1 && !defaultRuleManager.tcMap["abc2"][0].boundStat.reuseResourceStat)
+		assert.True(t, reflect.DeepEqual(defaultRuleManager.tcMap["abc2"][0].boundStat.readOnlyMetric, nopStat.readOnlyMetric))
+		assert.True(t, reflect.DeepEqual(defaultRuleManager.tcMap["abc2"][0].boundStat.writeOnlyMetric, nopStat.writeOnlyMetric))
 		clearData()
 	})
 }
@@ -262,8 +278,8 @@ func Test_buildResourceTrafficShapingController(t *testing.T) {
 			ControlBehavior:        Throttling,
 			MaxQueueingTimeMs:      10,
 		}
-		assert.True(t, len(tcMap["abc1"]) == 0)
-		tcs := buildResourceTrafficShapingController("abc1", []*Rule{r1, r2}, tcMap["abc1"])
+		assert.True(t, len(defaultRuleManager.tcMap["abc1"]) == 0)
+		tcs := defaultRuleManager.buildResourceTrafficShapingController("abc1", []*Rule{r1, r2}, defaultRuleManager.tcMap["abc1"])
 		assert.True(t, len(tcs) == 2)
 		assert.True(t, tcs[0].BoundRule() == r1)
 		assert.True(t, tcs[1].BoundRule() == r2)
@@ -373,8 +389,8 @@ func Test_buildResourceTrafficShapingController(t *testing.T) {
 		assert.True(t, stat4.readOnlyMetric != nil)
 		assert.True(t, stat4.writeOnlyMetric != nil)
 
-		tcMap["abc1"] = []*TrafficShapingController{fakeTc0, fakeTc1, fakeTc2, fakeTc3, fakeTc4}
-		assert.True(t, len(tcMap["abc1"]) == 5)
+		defaultRuleManager.tcMap["abc1"] = []*TrafficShapingController{fakeTc0, fakeTc1, fakeTc2, fakeTc3, fakeTc4}
+		assert.True(t, len(defaultRuleManager.tcMap["abc1"]) == 5)
 		// reuse stat with rule 1
 		r12 := &Rule{
 			Resource:               "abc1",
@@ -415,7 +431,7 @@ func Test_buildResourceTrafficShapingController(t *testing.T) {
 			StatIntervalInMs:       50000,
 		}
 
-		tcs := buildResourceTrafficShapingController("abc1", []*Rule{r12, r22, r32, r42}, tcMap["abc1"])
+		tcs := defaultRuleManager.buildResourceTrafficShapingController("abc1", []*Rule{r12, r22, r32, r42}, defaultRuleManager.tcMap["abc1"])
 		assert.True(t, len(tcs) == 4)
 
 		assert.True(t, tcs[0].BoundRule() == r12)
@@ -544,8 +560,8 @@ func TestLoadRulesOfResource(t *testing.T) {
 	t.Run("LoadRulesOfResource_clear", func(t *testing.T) {
 		succ, err = LoadRulesOfResource("abc1", []*Rule{})
 		assert.True(t, succ && err == nil)
-		assert.True(t, len(tcMap["abc1"]) == 0 && len(currentRules["abc1"]) == 0)
-		assert.True(t, len(tcMap["abc2"]) == 2 && len(currentRules["abc2"]) == 2)
+		assert.True(t, len(defaultRuleManager.tcMap["abc1"]) == 0 && len(defaultRuleManager.currentRules["abc1"]) == 0)
+		assert.True(t, len(defaultRuleManager.tcMap["abc2"]) == 2 && len(defaultRuleManager.currentRules["abc2"]) == 2)
 	})
 	clearData()
 }
@@ -582,14 +598,14 @@ func Test_onResourceRuleUpdate(t *testing.T) {
 	t.Run("Test_onResourceRuleUpdate_normal", func(t *testing.T) {
 		r111 := r11
 		r111.Threshold = 100
-		err = onResourceRuleUpdate("abc1", []*Rule{&r111})
+		err = defaultRuleManager.onResourceRuleUpdate("abc1", []*Rule{&r111})
 
-		assert.True(t, len(tcMap["abc1"]) == 1)
-		assert.True(t, len(currentRules["abc1"]) == 1)
-		assert.True(t, tcMap["abc1"][0].rule == &r111)
+		assert.True(t, len(defaultRuleManager.tcMap["abc1"]) == 1)
+		assert.True(t, len(defaultRuleManager.currentRules["abc1"]) == 1)
+		assert.True(t, defaultRuleManager.tcMap["abc1"][0].rule == &r111)
 
-		assert.True(t, len(tcMap["abc2"]) == 2)
-		assert.True(t, len(currentRules["abc2"]) == 2)
+		assert.True(t, len(defaultRuleManager.tcMap["abc2"]) == 2)
+		assert.True(t, len(defaultRuleManager.currentRules["abc2"]) == 2)
 
 		clearData()
 	})
@@ -627,10 +643,99 @@ func TestClearRulesOfResource(t *testing.T) {
 	t.Run("TestClearRulesOfResource_normal", func(t *testing.T) {
 		assert.True(t, ClearRulesOfResource("abc1") == nil)
 
-		assert.True(t, len(tcMap["abc1"]) == 0)
-		assert.True(t, len(currentRules["abc1"]) == 0)
-		assert.True(t, len(tcMap["abc2"]) == 2)
-		assert.True(t, len(currentRules["abc2"]) == 2)
+		assert.True(t, len(defaultRuleManager.tcMap["abc1"]) == 0)
+		assert.True(t, len(defaultRuleManager.currentRules["abc1"]) == 0)
+		assert.True(t, len(defaultRuleManager.tcMap["abc2"]) == 2)
+		assert.True(t, len(defaultRuleManager.currentRules["abc2"]) == 2)
 		clearData()
 	})
 }
+
+func TestNewRuleManager(t *testing.T) {
+	nodes := stat.NewNodeStorage(nil)
+	m := NewRuleManager(nodes)
+	r := &Rule{
+		Resource:               "abc-manager",
+		Threshold:              10,
+		TokenCalculateStrategy: Direct,
+		ControlBehavior:        Reject,
+		StatIntervalInMs:       5000,
+	}
+	succ, err := m.LoadRules([]*Rule{r})
+	assert.True(t, succ && err == nil)
+	defer m.ClearRules()
+
+	assert.Equal(t, 1, len(m.GetRulesOfResource("abc-manager")))
+	assert.Equal(t, 0, len(GetRulesOfResource("abc-manager")))
+	// The standalone statistic of the rule is bound to the node of the manager's own NodeStorage.
+	assert.NotNil(t, nodes.GetResourceNode("abc-manager"))
+	assert.Nil(t, stat.GetResourceNode("abc-manager"))
+}
+
+func TestRuleManager_UnpinResourceNodes(t *testing.T) {
+	m := NewRuleManager(stat.NewNodeStorage(nil))
+	_, err := m.LoadRules([]*Rule{
+		{Resource: "abc", Threshold: 10},
+		{Resource: "abc", Threshold: 20, StatIntervalInMs: 5000},
+		{Resource: "def", Threshold: 10, RelationStrategy: AssociatedResource, RefResource: "ref"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]struct{}{"abc": {}, "ref": {}}, m.pins.Resources())
+
+	_, err = m.LoadRules([]*Rule{{Resource: "abc", Threshold: 10}})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]struct{}{"abc": {}}, m.pins.Resources())
+
+	_, err = m.LoadRulesOfResource("abc", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(m.pins.Resources()))
+
+	// The nodes pinned by the discarded update are unpinned.
+	_, err = m.LoadRules([]*Rule{{Resource: "abc", Threshold: 10}})
+	assert.Nil(t, err)
+	u, err := m.PrepareRules([]*Rule{{Resource: "def", Threshold: 10}})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]struct{}{"abc": {}, "def": {}}, m.pins.Resources())
+	base.DiscardRuleUpdates(u)
+	assert.Equal(t, map[string]struct{}{"abc": {}}, m.pins.Resources())
+}
+
+func TestRuleEffectivePeriod(t *testing.T) {
+	util.SetClock(util.NewMockClock())
+	defer util.SetClock(util.NewRealClock())
+
+	m := NewRuleManager(stat.NewNodeStorage(nil))
+	now := util.CurrentTimeMillis()
+	_, err := m.LoadRules([]*Rule{
+		{Resource: "abc", Threshold: 10, ExpiresAt: now + 1000},
+		{Resource: "abc", Threshold: 20, EffectiveFrom: now + 1000},
+		{Resource: "abc", Threshold: 30, EffectiveFrom: now + 1000, ExpiresAt: now + 500},
+	})
+	assert.Nil(t, err)
+
+	thresholdsOf := func() []float64 {
+		ret := make([]float64, 0)
+		for _, tc := range m.getTrafficControllerListFor("abc") {
+			ret = append(ret, tc.BoundRule().Threshold)
+		}
+		return ret
+	}
+	statesOf := func() map[float64]base.RuleState {
+		ret := make(map[float64]base.RuleState)
+		for _, s := range m.GetRuleStatuses() {
+			ret[s.Rule.(*Rule).Threshold] = s.State
+		}
+		return ret
+	}
+	assert.Equal(t, []float64{10}, thresholdsOf())
+	// The rule with invalid effective period is ignored.
+	assert.Equal(t, map[float64]base.RuleState{10: base.RuleStateActive, 20: base.RuleStatePending}, statesOf())
+
+	// The traffic controllers are rebuilt in background once the rules become effective or expire, without reloading.
+	util.Sleep(time.Second)
+	assert.Eventually(t, func() bool {
+		return reflect.DeepEqual([]float64{20}, thresholdsOf())
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, map[float64]base.RuleState{10: base.RuleStateExpired, 20: base.RuleStateActive}, statesOf())
+	assert.Equal(t, 2, len(m.GetRulesOfResource("abc")))
+}
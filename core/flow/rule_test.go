@@ -15,6 +15,7 @@
 package flow
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -185,3 +186,21 @@ func TestRuleIsStatReusable(t *testing.T) {
 	}
 	assert.True(t, r61.isStatReusable(r62))
 }
+
+func TestRuleUnmarshalJSONWithEnumNames(t *testing.T) {
+	src := `{"resource": "abc", "tokenCalculateStrategy": "WarmUp", "controlBehavior": "throttling", "relationStrategy": 1}`
+	r := &Rule{}
+	assert.Nil(t, json.Unmarshal([]byte(src), r))
+	assert.Equal(t, WarmUp, r.TokenCalculateStrategy)
+	assert.Equal(t, Throttling, r.ControlBehavior)
+	assert.Equal(t, AssociatedResource, r.RelationStrategy)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"controlBehavior": "Unknown"}`), r))
+
+	// FairShare is accepted by either its name or its integer code.
+	for _, cb := range []string{`"FairShare"`, `"fairshare"`, `2`, `"2"`} {
+		r = &Rule{}
+		assert.Nil(t, json.Unmarshal([]byte(`{"controlBehavior": `+cb+`}`), r))
+		assert.Equal(t, FairShare, r.ControlBehavior, cb)
+	}
+}
@@ -27,10 +27,17 @@ const (
 )
 
 var (
-	DefaultSlot = &Slot{}
+	DefaultSlot = NewSlot(defaultRuleManager)
 )
 
 type Slot struct {
+	// manager is the RuleManager to check the rules of, the default RuleManager is used if nil.
+	manager *RuleManager
+}
+
+// NewSlot creates a Slot checking the rules of the given RuleManager.
+func NewSlot(manager *RuleManager) *Slot {
+	return &Slot{manager: manager}
 }
 
 func (s *Slot) Order() uint32 {
@@ -39,7 +46,8 @@ func (s *Slot) Order() uint32 {
 
 func (s *Slot) Check(ctx *base.EntryContext) *base.TokenResult {
 	res := ctx.Resource.Name()
-	tcs := getTrafficControllerListFor(res)
+	m := managerOrDefault(s.manager)
+	tcs := m.getTrafficControllerListFor(res)
 	result := ctx.RuleCheckResult
 
 	// Check rules in order
@@ -48,15 +56,27 @@ func (s *Slot) Check(ctx *base.EntryContext) *base.TokenResult {
 			logging.Warn("[FlowSlot Check]Nil traffic controller found", "resourceName", res)
 			continue
 		}
-		r := canPassCheck(tc, ctx.StatNode, ctx.Input.BatchCount)
+		if !tc.appliesTo(ctx.Input) {
+			continue
+		}
+		r := canPassCheck(m.nodes, tc, ctx.StatNode, ctx.Input.BatchCount, ctx.Input)
 		if r == nil {
 			// nil means pass
+			recordCountedPass(ctx, tc)
 			continue
 		}
 		if r.Status() == base.ResultStatusBlocked {
+			if tc.rule.Mode.IsShadow() {
+				ctx.AddShadowBlock(base.NewBlockErrorFromDeepCopy(r.BlockError()))
+				continue
+			}
 			return r
 		}
 		if r.Status() == base.ResultStatusShouldWait {
+			if tc.rule.Mode.IsShadow() {
+				// The rule in shadow mode never delays the traffic either.
+				continue
+			}
 			if nanosToWait := r.NanosToWait(); nanosToWait > 0 {
 				// Handle waiting action.
 				util.Sleep(nanosToWait)
@@ -64,31 +84,62 @@ func (s *Slot) Check(ctx *base.EntryContext) *base.TokenResult {
 			continue
 		}
 	}
+	if r := m.checkQuota(ctx); r != nil {
+		return r
+	}
 	return result
 }
 
-func canPassCheck(tc *TrafficShapingController, node base.StatNode, batchCount uint32) *base.TokenResult {
-	return canPassCheckWithFlag(tc, node, batchCount, 0)
+// countedPassesDataKey is the key of the traffic controllers which have counted the pass of the entry
+// in EntryContext.Data, see RollbackTrafficShapingChecker.
+type countedPassesDataKey struct{}
+
+// recordCountedPass records the traffic controller in the context if it has counted the pass of the entry,
+// so that the pass could be rolled back if the entry is blocked afterwards.
+func recordCountedPass(ctx *base.EntryContext, tc *TrafficShapingController) {
+	if checker, ok := tc.flowChecker.(RollbackTrafficShapingChecker); !ok || !checker.CountsPass(ctx.Input) {
+		return
+	}
+	if ctx.Data == nil {
+		ctx.Data = make(map[interface{}]interface{})
+	}
+	tcs, _ := ctx.Data[countedPassesDataKey{}].([]*TrafficShapingController)
+	ctx.Data[countedPassesDataKey{}] = append(tcs, tc)
+}
+
+// rollbackCountedPasses rolls back the passes counted by the traffic controllers recorded in the context.
+func rollbackCountedPasses(ctx *base.EntryContext) {
+	if ctx.Data == nil {
+		return
+	}
+	tcs, _ := ctx.Data[countedPassesDataKey{}].([]*TrafficShapingController)
+	for _, tc := range tcs {
+		tc.flowChecker.(RollbackTrafficShapingChecker).Rollback(ctx.Input.BatchCount, ctx.Input)
+	}
+}
+
+func canPassCheck(nodes *stat.NodeStorage, tc *TrafficShapingController, node base.StatNode, batchCount uint32, input *base.SentinelInput) *base.TokenResult {
+	return canPassCheckWithFlag(nodes, tc, node, batchCount, 0, input)
 }
 
-func canPassCheckWithFlag(tc *TrafficShapingController, node base.StatNode, batchCount uint32, flag int32) *base.TokenResult {
-	return checkInLocal(tc, node, batchCount, flag)
+func canPassCheckWithFlag(nodes *stat.NodeStorage, tc *TrafficShapingController, node base.StatNode, batchCount uint32, flag int32, input *base.SentinelInput) *base.TokenResult {
+	return checkInLocal(nodes, tc, node, batchCount, flag, input)
 }
 
-func selectNodeByRelStrategy(rule *Rule, node base.StatNode) base.StatNode {
+func selectNodeByRelStrategy(nodes *stat.NodeStorage, rule *Rule, node base.StatNode) base.StatNode {
 	if rule.RelationStrategy == AssociatedResource {
-		return stat.GetResourceNode(rule.RefResource)
+		return nodes.GetResourceNode(rule.RefResource)
 	}
 	return node
 }
 
-func checkInLocal(tc *TrafficShapingController, resStat base.StatNode, batchCount uint32, flag int32) *base.TokenResult {
-	actual := selectNodeByRelStrategy(tc.rule, resStat)
+func checkInLocal(nodes *stat.NodeStorage, tc *TrafficShapingController, resStat base.StatNode, batchCount uint32, flag int32, input *base.SentinelInput) *base.TokenResult {
+	actual := selectNodeByRelStrategy(nodes, tc.rule, resStat)
 	if actual == nil {
 		logging.FrequentErrorOnce.Do(func() {
 			logging.Error(errors.Errorf("nil resource node"), "No resource node for flow rule in FlowSlot.checkInLocal()", "rule", tc.rule)
 		})
 		return base.NewTokenResultPass()
 	}
-	return tc.PerformChecking(actual, batchCount, flag)
+	return tc.performCheckingFor(actual, batchCount, flag, input)
 }
@@ -64,5 +64,47 @@ func Test_FlowSlot_StandaloneStat(t *testing.T) {
 		}
 		statSLot.OnEntryPassed(ctx)
 	}
-	assert.True(t, getTrafficControllerListFor("abc")[0].boundStat.readOnlyMetric.GetSum(base.MetricEventPass) == 50)
+	assert.True(t, defaultRuleManager.getTrafficControllerListFor("abc")[0].boundStat.readOnlyMetric.GetSum(base.MetricEventPass) == 50)
+}
+
+func Test_FlowSlot_ShadowMode(t *testing.T) {
+	nodes := stat.NewNodeStorage(nil)
+	m := NewRuleManager(nodes)
+	slot := NewSlot(m)
+	_, err := m.LoadRules([]*Rule{
+		{
+			Resource:               "abc-shadow",
+			TokenCalculateStrategy: Direct,
+			ControlBehavior:        Reject,
+			Threshold:              0,
+			Mode:                   base.RuleModeShadow,
+		},
+	})
+	assert.NoError(t, err)
+
+	ctx := &base.EntryContext{
+		Resource: base.NewResourceWrapper("abc-shadow", base.ResTypeCommon, base.Inbound),
+		StatNode: nodes.GetOrCreateResourceNode("abc-shadow", base.ResTypeCommon),
+		Input: &base.SentinelInput{
+			BatchCount: 1,
+		},
+		RuleCheckResult: base.NewTokenResultPass(),
+	}
+	r := slot.Check(ctx)
+	assert.True(t, r == nil || r.IsPass())
+	assert.Equal(t, 1, len(ctx.ShadowBlocks()))
+	assert.Equal(t, base.BlockTypeFlow, ctx.ShadowBlocks()[0].BlockType())
+
+	// The same rule in enforce mode rejects the traffic.
+	_, err = m.LoadRules([]*Rule{
+		{
+			Resource:               "abc-shadow",
+			TokenCalculateStrategy: Direct,
+			ControlBehavior:        Reject,
+			Threshold:              0,
+		},
+	})
+	assert.NoError(t, err)
+	r = slot.Check(ctx)
+	assert.True(t, r != nil && r.IsBlocked())
 }
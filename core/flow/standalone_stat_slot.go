@@ -25,10 +25,17 @@ const (
 )
 
 var (
-	DefaultStandaloneStatSlot = &StandaloneStatSlot{}
+	DefaultStandaloneStatSlot = NewStandaloneStatSlot(defaultRuleManager)
 )
 
 type StandaloneStatSlot struct {
+	// manager is the RuleManager holding the standalone statistics, the default RuleManager is used if nil.
+	manager *RuleManager
+}
+
+// NewStandaloneStatSlot creates a StandaloneStatSlot recording the standalone statistics of the rules of the given RuleManager.
+func NewStandaloneStatSlot(manager *RuleManager) *StandaloneStatSlot {
+	return &StandaloneStatSlot{manager: manager}
 }
 
 func (s *StandaloneStatSlot) Order() uint32 {
@@ -36,9 +43,12 @@ func (s *StandaloneStatSlot) Order() uint32 {
 }
 
 func (s StandaloneStatSlot) OnEntryPassed(ctx *base.EntryContext) {
+	if leaf := acquiredQuotaOf(ctx); leaf != nil {
+		recordQuotaPass(leaf, ctx.Input.BatchCount)
+	}
 	res := ctx.Resource.Name()
-	for _, tc := range getTrafficControllerListFor(res) {
-		if !tc.boundStat.reuseResourceStat {
+	for _, tc := range managerOrDefault(s.manager).getTrafficControllerListFor(res) {
+		if !tc.boundStat.reuseResourceStat && tc.appliesTo(ctx.Input) {
 			if tc.boundStat.writeOnlyMetric != nil {
 				tc.boundStat.writeOnlyMetric.AddCount(base.MetricEventPass, int64(ctx.Input.BatchCount))
 			} else {
@@ -49,7 +59,11 @@ func (s StandaloneStatSlot) OnEntryPassed(ctx *base.EntryContext) {
 }
 
 func (s StandaloneStatSlot) OnEntryBlocked(ctx *base.EntryContext, blockError *base.BlockError) {
-	// Do nothing
+	// Release the acquired quota and roll back the counted passes if the entry is blocked by the subsequent slots.
+	if leaf := acquiredQuotaOf(ctx); leaf != nil {
+		releaseQuota(leaf, ctx.Input.BatchCount)
+	}
+	rollbackCountedPasses(ctx)
 }
 
 func (s StandaloneStatSlot) OnCompleted(ctx *base.EntryContext) {
@@ -16,22 +16,35 @@ package flow
 
 import (
 	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/config"
+	sbase "github.com/alibaba/sentinel-golang/core/stat/base"
+	"github.com/alibaba/sentinel-golang/logging"
 )
 
 type DirectTrafficShapingCalculator struct {
 	owner     *TrafficShapingController
 	threshold float64
+	// schedule overrides the threshold during its time windows, nil if the rule has no Schedule.
+	schedule *base.ThresholdSchedule
 }
 
 func NewDirectTrafficShapingCalculator(owner *TrafficShapingController, threshold float64) *DirectTrafficShapingCalculator {
-	return &DirectTrafficShapingCalculator{
+	c := &DirectTrafficShapingCalculator{
 		owner:     owner,
 		threshold: threshold,
 	}
+	if owner != nil && owner.rule != nil && len(owner.rule.Schedule) > 0 {
+		schedule, err := base.NewThresholdSchedule(owner.rule.Schedule)
+		if err != nil {
+			logging.Error(err, "Invalid Schedule of the rule in flow.NewDirectTrafficShapingCalculator()", "rule", owner.rule)
+		}
+		c.schedule = schedule
+	}
+	return c
 }
 
 func (d *DirectTrafficShapingCalculator) CalculateAllowedTokens(uint32, int32) float64 {
-	return d.threshold
+	return d.schedule.CurrentThreshold(d.threshold)
 }
 
 func (d *DirectTrafficShapingCalculator) BoundOwner() *TrafficShapingController {
@@ -41,13 +54,37 @@ func (d *DirectTrafficShapingCalculator) BoundOwner() *TrafficShapingController
 type RejectTrafficShapingChecker struct {
 	owner *TrafficShapingController
 	rule  *Rule
+	// reservedStats records the pass count of the traffic entitled to each reservation of the rule.
+	reservedStats []*sbase.SlidingWindowMetric
+	reservedWrite []*sbase.BucketLeapArray
 }
 
 func NewRejectTrafficShapingChecker(owner *TrafficShapingController, rule *Rule) *RejectTrafficShapingChecker {
-	return &RejectTrafficShapingChecker{
+	c := &RejectTrafficShapingChecker{
 		owner: owner,
 		rule:  rule,
 	}
+	if len(rule.Reservations) > 0 {
+		intervalInMs := rule.StatIntervalInMs
+		if intervalInMs == 0 {
+			intervalInMs = config.MetricStatisticIntervalMs()
+		}
+		sampleCount := sampleCountOf(intervalInMs)
+		c.reservedStats = make([]*sbase.SlidingWindowMetric, 0, len(rule.Reservations))
+		c.reservedWrite = make([]*sbase.BucketLeapArray, 0, len(rule.Reservations))
+		for range rule.Reservations {
+			writeMetric := sbase.NewBucketLeapArray(sampleCount, intervalInMs)
+			readMetric, err := sbase.NewSlidingWindowMetric(sampleCount, intervalInMs, writeMetric)
+			if err != nil {
+				logging.Error(err, "Fail to generate the reserved statistic in flow.NewRejectTrafficShapingChecker()", "rule", rule)
+				c.reservedStats, c.reservedWrite = nil, nil
+				break
+			}
+			c.reservedStats = append(c.reservedStats, readMetric)
+			c.reservedWrite = append(c.reservedWrite, writeMetric)
+		}
+	}
+	return c
 }
 
 func (d *RejectTrafficShapingChecker) BoundOwner() *TrafficShapingController {
@@ -55,14 +92,68 @@ func (d *RejectTrafficShapingChecker) BoundOwner() *TrafficShapingController {
 }
 
 func (d *RejectTrafficShapingChecker) DoCheck(resStat base.StatNode, batchCount uint32, threshold float64) *base.TokenResult {
+	return d.DoCheckWithReservation(resStat, batchCount, threshold, -1)
+}
+
+// DoCheckWithReservation checks the traffic entitled to the reservation of the given index, or the traffic entitled to
+// no reservation if the index is negative. The traffic could never consume the reserves of the other reservations
+// which are left unused by their reserved traffic.
+func (d *RejectTrafficShapingChecker) DoCheckWithReservation(_ base.StatNode, batchCount uint32, threshold float64, reservation int) *base.TokenResult {
 	metricReadonlyStat := d.BoundOwner().boundStat.readOnlyMetric
 	if metricReadonlyStat == nil {
 		return nil
 	}
 	curCount := float64(metricReadonlyStat.GetSum(base.MetricEventPass))
-	if curCount+float64(batchCount) > threshold {
+	if reservation >= len(d.reservedStats) {
+		reservation = -1
+	}
+	if curCount+float64(batchCount)+d.unusedReserves(threshold, reservation) > threshold {
 		msg := "flow reject check blocked"
 		return base.NewTokenResultBlockedWithCause(base.BlockTypeFlow, msg, d.rule, curCount)
 	}
+	if reservation >= 0 {
+		d.reservedWrite[reservation].AddCount(base.MetricEventPass, int64(batchCount))
+	}
 	return nil
 }
+
+// reservationOf returns the index of the reservation which the traffic of the input is entitled to,
+// or -1 if the traffic is entitled to no reservation.
+func (d *RejectTrafficShapingChecker) reservationOf(input *base.SentinelInput) int {
+	if len(d.reservedWrite) == 0 {
+		return -1
+	}
+	if reservation := base.MatchReservation(d.rule.Reservations, input); reservation < len(d.reservedWrite) {
+		return reservation
+	}
+	return -1
+}
+
+// CountsPass checks whether the traffic of the input is entitled to any reservation, the pass of which is counted
+// in the reserved statistic at checking.
+func (d *RejectTrafficShapingChecker) CountsPass(input *base.SentinelInput) bool {
+	return d.reservationOf(input) >= 0
+}
+
+// Rollback rolls back the pass of the reserved traffic of the input, which is blocked after passing the check.
+func (d *RejectTrafficShapingChecker) Rollback(batchCount uint32, input *base.SentinelInput) {
+	if reservation := d.reservationOf(input); reservation >= 0 {
+		d.reservedWrite[reservation].AddCount(base.MetricEventPass, -int64(batchCount))
+	}
+}
+
+// unusedReserves returns the sum of the reserves which are not consumed by the reserved traffic yet,
+// except the reserve of the given index.
+func (d *RejectTrafficShapingChecker) unusedReserves(threshold float64, except int) float64 {
+	unused := 0.0
+	for i, stat := range d.reservedStats {
+		if i == except {
+			continue
+		}
+		reserve := threshold * d.rule.Reservations[i].Ratio
+		if used := float64(stat.GetSum(base.MetricEventPass)); used < reserve {
+			unused += reserve - used
+		}
+	}
+	return unused
+}
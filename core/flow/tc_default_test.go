@@ -0,0 +1,56 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/util"
+	"github.com/stretchr/testify/assert"
+)
+
+// sleepUntilHour advances the mock clock to the next given hour of the day in UTC.
+func sleepUntilHour(hour int) {
+	now := util.CurrentClock().Now().UTC()
+	target := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, time.UTC)
+	if !target.After(now) {
+		target = target.Add(24 * time.Hour)
+	}
+	util.Sleep(target.Sub(now))
+}
+
+func TestDirectTrafficShapingCalculator_Schedule(t *testing.T) {
+	util.SetClock(util.NewMockClock())
+	defer util.SetClock(util.NewRealClock())
+
+	rule := &Rule{
+		Resource:  "abc",
+		Threshold: 100,
+		Schedule:  []*base.ScheduledThreshold{{Start: "01:00", End: "05:00", Threshold: 500}},
+	}
+	assert.NoError(t, IsValidRule(rule))
+	c := NewDirectTrafficShapingCalculator(&TrafficShapingController{rule: rule}, rule.Threshold)
+
+	sleepUntilHour(2)
+	assert.Equal(t, 500.0, c.CalculateAllowedTokens(1, 0))
+	sleepUntilHour(6)
+	assert.Equal(t, 100.0, c.CalculateAllowedTokens(1, 0))
+
+	rule.TokenCalculateStrategy = WarmUp
+	rule.WarmUpPeriodSec = 10
+	assert.Error(t, IsValidRule(rule))
+}
@@ -0,0 +1,284 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flow
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/util"
+)
+
+const (
+	BlockMsgFairShare = "flow fair share check blocked, the caller exceeds its fair share"
+
+	// maxFairShareCallers is the max amount of the callers tracked by a FairShareTrafficShapingChecker in a statistic interval,
+	// the callers beyond the limit are regarded as the same anonymous caller.
+	maxFairShareCallers = 1024
+)
+
+// FairShareTrafficShapingChecker divides the threshold among the active callers of the resource according to their weights.
+// A caller within its fair share always passes, while a caller exceeding its fair share is blocked, so that a noisy caller
+// never starves the others. The shares of the idle callers are divided among the active callers, and the callers share
+// the whole threshold if all the active callers are weighted zero.
+//
+// The pass count of each caller is estimated by the sliding window of two consecutive statistic intervals,
+// and the callers that didn't pass in the last two intervals are regarded as idle. The pass is counted at checking,
+// and it's rolled back if the entry is blocked afterwards, see Rollback.
+type FairShareTrafficShapingChecker struct {
+	owner      *TrafficShapingController
+	rule       *Rule
+	intervalMs uint64
+
+	// window is the *fairShareWindow of the current statistic interval.
+	window    atomic.Value
+	rotateMux sync.Mutex
+}
+
+// fairShareWindow holds the pass counts of the callers in a statistic interval.
+type fairShareWindow struct {
+	// The 64-bit fields are kept at the head of struct to be 64-bit aligned for atomic operations on 32-bit platforms.
+	// total is the pass count of all the callers in the interval.
+	total int64
+	// activeWeight is the bits of the float64 sum of the weights of the active callers.
+	activeWeight uint64
+
+	start uint64
+	// prev holds the pass counts of the callers in the previous interval, which is never modified.
+	prev      map[string]int64
+	prevTotal int64
+
+	// callers holds the *fairShareCaller of the callers in the interval.
+	callers     sync.Map
+	callerCount int32
+}
+
+// fairShareCaller holds the pass count of a caller in the interval.
+type fairShareCaller struct {
+	count int64
+	// active indicates whether the weight of the caller is counted in the activeWeight of the interval.
+	active int32
+}
+
+func NewFairShareTrafficShapingChecker(owner *TrafficShapingController, rule *Rule) *FairShareTrafficShapingChecker {
+	intervalMs := uint64(rule.StatIntervalInMs)
+	if intervalMs == 0 {
+		intervalMs = 1000
+	}
+	c := &FairShareTrafficShapingChecker{
+		owner:      owner,
+		rule:       rule,
+		intervalMs: intervalMs,
+	}
+	c.window.Store(&fairShareWindow{start: util.CurrentTimeMillis(), prev: make(map[string]int64)})
+	return c
+}
+
+func (c *FairShareTrafficShapingChecker) BoundOwner() *TrafficShapingController {
+	return c.owner
+}
+
+// DoCheck checks on behalf of the anonymous caller.
+func (c *FairShareTrafficShapingChecker) DoCheck(resStat base.StatNode, batchCount uint32, threshold float64) *base.TokenResult {
+	return c.DoCheckForCaller(resStat, batchCount, threshold, "")
+}
+
+func (c *FairShareTrafficShapingChecker) DoCheckForCaller(_ base.StatNode, batchCount uint32, threshold float64, caller string) *base.TokenResult {
+	now := util.CurrentTimeMillis()
+	w := c.windowAt(now)
+	caller, p := w.callerOf(caller)
+	// The weight of the previous interval in the sliding window.
+	prevRatio := 1 - float64(now-w.start)/float64(c.intervalMs)
+
+	weight := c.weightOf(caller)
+	totalWeight := loadFloat64(&w.activeWeight)
+	if !w.isActive(caller, p) {
+		totalWeight += weight
+	}
+	batch := int64(batchCount)
+	if totalWeight <= 0 {
+		// All the active callers are weighted zero, so they share the whole threshold.
+		for {
+			total := atomic.LoadInt64(&w.total)
+			if float64(w.prevTotal)*prevRatio+float64(total+batch) > threshold {
+				return base.NewTokenResultBlockedWithCause(base.BlockTypeFlow, BlockMsgFairShare, c.rule, float64(w.prev[caller])*prevRatio+float64(atomic.LoadInt64(&p.count)))
+			}
+			if atomic.CompareAndSwapInt64(&w.total, total, total+batch) {
+				atomic.AddInt64(&p.count, batch)
+				c.activate(w, caller, p)
+				return nil
+			}
+		}
+	}
+
+	share := threshold * weight / totalWeight
+	for {
+		count := atomic.LoadInt64(&p.count)
+		callerCount := float64(w.prev[caller])*prevRatio + float64(count)
+		if callerCount+float64(batch) > share {
+			return base.NewTokenResultBlockedWithCause(base.BlockTypeFlow, BlockMsgFairShare, c.rule, callerCount)
+		}
+		if atomic.CompareAndSwapInt64(&p.count, count, count+batch) {
+			atomic.AddInt64(&w.total, batch)
+			c.activate(w, caller, p)
+			return nil
+		}
+	}
+}
+
+// CountsPass always returns true, since the pass of each caller is counted at checking.
+func (c *FairShareTrafficShapingChecker) CountsPass(*base.SentinelInput) bool {
+	return true
+}
+
+// Rollback rolls back the pass of the entry of the input, which is blocked after passing the check.
+// The pass is not rolled back once the statistic interval of it has passed.
+func (c *FairShareTrafficShapingChecker) Rollback(batchCount uint32, input *base.SentinelInput) {
+	caller := ""
+	if input != nil {
+		caller = callerOf(c.rule, input)
+	}
+	w := c.window.Load().(*fairShareWindow)
+	v, ok := w.callers.Load(caller)
+	if !ok {
+		v, ok = w.callers.Load("")
+		if !ok {
+			return
+		}
+	}
+	p := v.(*fairShareCaller)
+	batch := int64(batchCount)
+	for {
+		count := atomic.LoadInt64(&p.count)
+		if count < batch {
+			return
+		}
+		if atomic.CompareAndSwapInt64(&p.count, count, count-batch) {
+			atomic.AddInt64(&w.total, -batch)
+			return
+		}
+	}
+}
+
+// windowAt returns the window of the interval which now is in, the window is slid if now is beyond it.
+func (c *FairShareTrafficShapingChecker) windowAt(now uint64) *fairShareWindow {
+	w := c.window.Load().(*fairShareWindow)
+	if now < w.start+c.intervalMs {
+		return w
+	}
+	c.rotateMux.Lock()
+	defer c.rotateMux.Unlock()
+
+	w = c.window.Load().(*fairShareWindow)
+	if now < w.start+c.intervalMs {
+		return w
+	}
+	next := &fairShareWindow{
+		start: now - (now-w.start)%c.intervalMs,
+		prev:  make(map[string]int64),
+	}
+	if now < w.start+2*c.intervalMs {
+		// The callers passed in the previous interval are still active.
+		activeWeight := 0.0
+		w.callers.Range(func(key, value interface{}) bool {
+			if count := atomic.LoadInt64(&value.(*fairShareCaller).count); count > 0 {
+				next.prev[key.(string)] = count
+				next.prevTotal += count
+				activeWeight += c.weightOf(key.(string))
+			}
+			return true
+		})
+		storeFloat64(&next.activeWeight, activeWeight)
+	}
+	c.window.Store(next)
+	return next
+}
+
+// callerOf returns the caller and its counter in the window, the callers beyond maxFairShareCallers are regarded as
+// the same anonymous caller.
+func (w *fairShareWindow) callerOf(caller string) (string, *fairShareCaller) {
+	if v, ok := w.callers.Load(caller); ok {
+		return caller, v.(*fairShareCaller)
+	}
+	if _, ok := w.prev[caller]; !ok && atomic.LoadInt32(&w.callerCount) >= maxFairShareCallers {
+		caller = ""
+	}
+	v, loaded := w.callers.LoadOrStore(caller, &fairShareCaller{})
+	if !loaded {
+		atomic.AddInt32(&w.callerCount, 1)
+	}
+	return caller, v.(*fairShareCaller)
+}
+
+// isActive checks whether the weight of the caller is counted in the activeWeight of the window.
+func (w *fairShareWindow) isActive(caller string, p *fairShareCaller) bool {
+	if _, ok := w.prev[caller]; ok {
+		return true
+	}
+	return atomic.LoadInt32(&p.active) == 1
+}
+
+// activate counts the weight of the passed caller in the activeWeight of the window.
+func (c *FairShareTrafficShapingChecker) activate(w *fairShareWindow, caller string, p *fairShareCaller) {
+	if _, ok := w.prev[caller]; ok {
+		return
+	}
+	if atomic.CompareAndSwapInt32(&p.active, 0, 1) {
+		addFloat64(&w.activeWeight, c.weightOf(caller))
+	}
+}
+
+func (c *FairShareTrafficShapingChecker) weightOf(caller string) float64 {
+	if w, ok := c.rule.FairShareWeights[caller]; ok {
+		return w
+	}
+	return 1
+}
+
+func loadFloat64(addr *uint64) float64 {
+	return math.Float64frombits(atomic.LoadUint64(addr))
+}
+
+func storeFloat64(addr *uint64, v float64) {
+	atomic.StoreUint64(addr, math.Float64bits(v))
+}
+
+func addFloat64(addr *uint64, delta float64) {
+	for {
+		old := atomic.LoadUint64(addr)
+		if atomic.CompareAndSwapUint64(addr, old, math.Float64bits(math.Float64frombits(old)+delta)) {
+			return
+		}
+	}
+}
+
+// callerOf returns the identity of the caller of the entry for the fair share rule, which is the value of the attachment
+// keyed by FairShareKey, or the origin of the entry if FairShareKey is empty.
+func callerOf(rule *Rule, input *base.SentinelInput) string {
+	if rule.FairShareKey == "" {
+		return input.Origin
+	}
+	v, ok := input.Attachments[rule.FairShareKey]
+	if !ok || v == nil {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,138 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flow
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func passCountOf(c *FairShareTrafficShapingChecker, caller string, threshold float64, n int) int {
+	passed := 0
+	for i := 0; i < n; i++ {
+		if c.DoCheckForCaller(nil, 1, threshold, caller) == nil {
+			passed++
+		}
+	}
+	return passed
+}
+
+func TestFairShareTrafficShapingChecker_DoCheckForCaller(t *testing.T) {
+	util.SetClock(util.NewMockClock())
+	defer util.SetClock(util.NewRealClock())
+
+	t.Run("SingleCaller", func(t *testing.T) {
+		c := NewFairShareTrafficShapingChecker(nil, &Rule{Resource: "abc", ControlBehavior: FairShare})
+		assert.Equal(t, 10, passCountOf(c, "a", 10, 20))
+		r := c.DoCheckForCaller(nil, 1, 10, "a")
+		assert.True(t, r.IsBlocked())
+		assert.Equal(t, BlockMsgFairShare, r.BlockError().BlockMsg())
+	})
+
+	t.Run("WeightedCallers", func(t *testing.T) {
+		c := NewFairShareTrafficShapingChecker(nil, &Rule{
+			Resource:         "abc",
+			ControlBehavior:  FairShare,
+			FairShareWeights: map[string]float64{"a": 3},
+		})
+		assert.Equal(t, 1, passCountOf(c, "b", 8, 1))
+		// a could pass its share 6, but not the remaining share of b.
+		assert.Equal(t, 6, passCountOf(c, "a", 8, 10))
+		assert.Equal(t, 1, passCountOf(c, "b", 8, 10))
+	})
+
+	t.Run("WithinShareAlwaysPasses", func(t *testing.T) {
+		c := NewFairShareTrafficShapingChecker(nil, &Rule{Resource: "abc", ControlBehavior: FairShare})
+		assert.Equal(t, 10, passCountOf(c, "a", 10, 20))
+		// b is within its fair share, though a has used up the threshold.
+		assert.Equal(t, 5, passCountOf(c, "b", 10, 20))
+		assert.Equal(t, 0, passCountOf(c, "a", 10, 1))
+	})
+
+	t.Run("BorrowIdleShares", func(t *testing.T) {
+		c := NewFairShareTrafficShapingChecker(nil, &Rule{
+			Resource:         "abc",
+			ControlBehavior:  FairShare,
+			FairShareWeights: map[string]float64{"b": 1, "c": 2},
+		})
+		// b and c are idle, so a borrows all their shares.
+		assert.Equal(t, 10, passCountOf(c, "a", 10, 20))
+	})
+
+	t.Run("SlidingWindow", func(t *testing.T) {
+		c := NewFairShareTrafficShapingChecker(nil, &Rule{Resource: "abc", ControlBehavior: FairShare, StatIntervalInMs: 1000})
+		assert.Equal(t, 10, passCountOf(c, "a", 10, 20))
+		util.Sleep(1500 * time.Millisecond)
+		// Half of the previous interval still counts.
+		assert.Equal(t, 5, passCountOf(c, "a", 10, 20))
+		util.Sleep(2000 * time.Millisecond)
+		assert.Equal(t, 10, passCountOf(c, "a", 10, 20))
+	})
+
+	t.Run("ZeroWeights", func(t *testing.T) {
+		c := NewFairShareTrafficShapingChecker(nil, &Rule{
+			Resource:         "abc",
+			ControlBehavior:  FairShare,
+			FairShareWeights: map[string]float64{"a": 0, "b": 0},
+		})
+		// The callers weighted zero share the whole threshold.
+		assert.Equal(t, 6, passCountOf(c, "a", 10, 6))
+		assert.Equal(t, 4, passCountOf(c, "b", 10, 20))
+		assert.Equal(t, 0, passCountOf(c, "a", 10, 1))
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		rule := &Rule{Resource: "abc", ControlBehavior: FairShare, FairShareKey: "tenant"}
+		c := NewFairShareTrafficShapingChecker(nil, rule)
+		assert.Equal(t, 10, passCountOf(c, "a", 10, 20))
+		c.Rollback(2, &base.SentinelInput{Attachments: map[interface{}]interface{}{"tenant": "a"}})
+		assert.Equal(t, 2, passCountOf(c, "a", 10, 20))
+		// Rolling back the absent caller is a no-op.
+		c.Rollback(2, &base.SentinelInput{Attachments: map[interface{}]interface{}{"tenant": "b"}})
+		assert.Equal(t, 0, passCountOf(c, "a", 10, 1))
+	})
+}
+
+func TestFairShareTrafficShapingChecker_Concurrency(t *testing.T) {
+	c := NewFairShareTrafficShapingChecker(nil, &Rule{Resource: "abc", ControlBehavior: FairShare, StatIntervalInMs: 60000})
+	var passed int64
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			atomic.AddInt64(&passed, int64(passCountOf(c, "a", 1000, 500)))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(1000), passed)
+}
+
+func TestCallerOf(t *testing.T) {
+	input := &base.SentinelInput{
+		Origin:      "app1",
+		Attachments: map[interface{}]interface{}{"tenant": "t1", "uid": 42},
+	}
+	assert.Equal(t, "app1", callerOf(&Rule{}, input))
+	assert.Equal(t, "t1", callerOf(&Rule{FairShareKey: "tenant"}, input))
+	assert.Equal(t, "42", callerOf(&Rule{FairShareKey: "uid"}, input))
+	assert.Equal(t, "", callerOf(&Rule{FairShareKey: "absent"}, input))
+}
@@ -17,6 +17,7 @@ package flow
 import (
 	"github.com/alibaba/sentinel-golang/core/base"
 	"github.com/alibaba/sentinel-golang/metrics"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // TrafficShapingCalculator calculates the actual traffic shaping threshold
@@ -33,6 +34,33 @@ type TrafficShapingChecker interface {
 	DoCheck(resStat base.StatNode, batchCount uint32, threshold float64) *base.TokenResult
 }
 
+// CallerTrafficShapingChecker is the TrafficShapingChecker which performs checking on behalf of the caller of the resource,
+// e.g. the checker of the FairShare control behavior.
+type CallerTrafficShapingChecker interface {
+	TrafficShapingChecker
+	DoCheckForCaller(resStat base.StatNode, batchCount uint32, threshold float64, caller string) *base.TokenResult
+}
+
+// RollbackTrafficShapingChecker is the TrafficShapingChecker which counts the pass of the traffic at checking,
+// e.g. the checker of the FairShare control behavior and the checker of the Reservations. The pass is rolled back if the entry is blocked afterwards
+// by the subsequent rules or slots.
+type RollbackTrafficShapingChecker interface {
+	TrafficShapingChecker
+	// CountsPass checks whether the pass of the entry of the given input is counted at checking.
+	CountsPass(input *base.SentinelInput) bool
+	// Rollback rolls back the pass of the entry of the given input, which is blocked after passing the check.
+	Rollback(batchCount uint32, input *base.SentinelInput)
+}
+
+// ReservedTrafficShapingChecker is the TrafficShapingChecker which guarantees the Reservations of the rule,
+// e.g. the checker of the Reject control behavior.
+type ReservedTrafficShapingChecker interface {
+	TrafficShapingChecker
+	// DoCheckWithReservation performs checking for the traffic entitled to the reservation of the given index,
+	// the index is negative if the traffic is entitled to no reservation.
+	DoCheckWithReservation(resStat base.StatNode, batchCount uint32, threshold float64, reservation int) *base.TokenResult
+}
+
 // standaloneStatistic indicates the independent statistic for each TrafficShapingController
 type standaloneStatistic struct {
 	// reuseResourceStat indicates whether current standaloneStatistic reuse the current resource's global statistic
@@ -54,10 +82,28 @@ type TrafficShapingController struct {
 	rule *Rule
 	// boundStat is the statistic of current TrafficShapingController
 	boundStat standaloneStatistic
+	// thresholdGauge is the resolved gauge of the threshold of the resource, which avoids resolving it on every check.
+	thresholdGauge prometheus.Gauge
+	// conditions is compiled from the Conditions of the rule, nil if the rule applies to all the entries.
+	conditions *base.ConditionMatcher
 }
 
 func NewTrafficShapingController(rule *Rule, boundStat *standaloneStatistic) (*TrafficShapingController, error) {
-	return &TrafficShapingController{rule: rule, boundStat: *boundStat}, nil
+	conditions, err := base.NewConditionMatcher(rule.Conditions)
+	if err != nil {
+		return nil, err
+	}
+	return &TrafficShapingController{
+		rule:           rule,
+		boundStat:      *boundStat,
+		thresholdGauge: metrics.ResourceFlowThresholdGauge(rule.Resource),
+		conditions:     conditions,
+	}, nil
+}
+
+// appliesTo checks whether the rule applies to the entry of the input, according to the Conditions of the rule.
+func (t *TrafficShapingController) appliesTo(input *base.SentinelInput) bool {
+	return t.conditions.Matches(input)
 }
 
 func (t *TrafficShapingController) BoundRule() *Rule {
@@ -73,7 +119,27 @@ func (t *TrafficShapingController) FlowCalculator() TrafficShapingCalculator {
 }
 
 func (t *TrafficShapingController) PerformChecking(resStat base.StatNode, batchCount uint32, flag int32) *base.TokenResult {
+	return t.performCheckingFor(resStat, batchCount, flag, nil)
+}
+
+// performCheckingFor performs checking for the entry of the input, which identifies the caller
+// and the reservation of the entry. The input could be nil.
+func (t *TrafficShapingController) performCheckingFor(resStat base.StatNode, batchCount uint32, flag int32, input *base.SentinelInput) *base.TokenResult {
 	allowedTokens := t.flowCalculator.CalculateAllowedTokens(batchCount, flag)
-	metrics.SetResourceFlowThreshold(t.rule.Resource, allowedTokens)
+	if t.thresholdGauge != nil {
+		t.thresholdGauge.Set(allowedTokens)
+	} else {
+		metrics.SetResourceFlowThreshold(t.rule.Resource, allowedTokens)
+	}
+	switch checker := t.flowChecker.(type) {
+	case CallerTrafficShapingChecker:
+		caller := ""
+		if input != nil {
+			caller = callerOf(t.rule, input)
+		}
+		return checker.DoCheckForCaller(resStat, batchCount, allowedTokens, caller)
+	case ReservedTrafficShapingChecker:
+		return checker.DoCheckWithReservation(resStat, batchCount, allowedTokens, base.MatchReservation(t.rule.Reservations, input))
+	}
 	return t.flowChecker.DoCheck(resStat, batchCount, allowedTokens)
 }
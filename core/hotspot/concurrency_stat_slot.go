@@ -26,11 +26,18 @@ const (
 )
 
 var (
-	DefaultConcurrencyStatSlot = &ConcurrencyStatSlot{}
+	DefaultConcurrencyStatSlot = NewConcurrencyStatSlot(defaultRuleManager)
 )
 
 // ConcurrencyStatSlot is to record the Concurrency statistic for all arguments
 type ConcurrencyStatSlot struct {
+	// manager is the RuleManager holding the concurrency rules, the default RuleManager is used if nil.
+	manager *RuleManager
+}
+
+// NewConcurrencyStatSlot creates a ConcurrencyStatSlot recording the concurrency statistic for the rules of the given RuleManager.
+func NewConcurrencyStatSlot(manager *RuleManager) *ConcurrencyStatSlot {
+	return &ConcurrencyStatSlot{manager: manager}
 }
 
 func (s *ConcurrencyStatSlot) Order() uint32 {
@@ -39,7 +46,7 @@ func (s *ConcurrencyStatSlot) Order() uint32 {
 
 func (c *ConcurrencyStatSlot) OnEntryPassed(ctx *base.EntryContext) {
 	res := ctx.Resource.Name()
-	tcs := getTrafficControllersFor(res)
+	tcs := managerOrDefault(c.manager).getTrafficControllersFor(res)
 	for _, tc := range tcs {
 		if tc.BoundRule().MetricType != Concurrency {
 			continue
@@ -66,7 +73,7 @@ func (c *ConcurrencyStatSlot) OnEntryBlocked(ctx *base.EntryContext, blockError
 
 func (c *ConcurrencyStatSlot) OnCompleted(ctx *base.EntryContext) {
 	res := ctx.Resource.Name()
-	tcs := getTrafficControllersFor(res)
+	tcs := managerOrDefault(c.manager).getTrafficControllersFor(res)
 	for _, tc := range tcs {
 		if tc.BoundRule().MetricType != Concurrency {
 			continue
@@ -0,0 +1,192 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hotspot
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/config"
+	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/pkg/errors"
+)
+
+// patternRule is a hotspot param flow rule whose Resource is a pattern rather than an exact resource name.
+type patternRule struct {
+	rule    *Rule
+	matcher *base.ResourceMatcher
+	// sharedTc is the traffic controller shared by all the matched resources if the rule asks for the shared statistic,
+	// otherwise each matched resource has its own traffic controller, which is built on the first access.
+	sharedTc TrafficShapingController
+}
+
+// patternRuleSet holds all the pattern rules, and caches the traffic controllers resolved for the accessed resources.
+// A new set is created whenever the rules are updated, so that the cache never outlives the rules it's resolved from.
+type patternRuleSet struct {
+	rules []*patternRule
+
+	resolved    trafficControllerMap
+	resolvedMux sync.RWMutex
+	// overflowWarned indicates whether the warning of reaching the max amount of resources has been logged.
+	overflowWarned bool
+}
+
+func newPatternRuleSet(patternRuleMap map[string][]*patternRule) *patternRuleSet {
+	if len(patternRuleMap) == 0 {
+		return nil
+	}
+	patterns := make([]string, 0, len(patternRuleMap))
+	for pattern := range patternRuleMap {
+		patterns = append(patterns, pattern)
+	}
+	// Keep the order of the rules deterministic.
+	sort.Strings(patterns)
+	rules := make([]*patternRule, 0, len(patterns))
+	for _, pattern := range patterns {
+		rules = append(rules, patternRuleMap[pattern]...)
+	}
+	return &patternRuleSet{
+		rules:    rules,
+		resolved: make(trafficControllerMap),
+	}
+}
+
+// trafficControllersOf returns the traffic controllers of the exact rules of res, followed by those of the matched pattern rules.
+func (s *patternRuleSet) trafficControllersOf(res string, exactTcs []TrafficShapingController) []TrafficShapingController {
+	s.resolvedMux.RLock()
+	tcs, ok := s.resolved[res]
+	s.resolvedMux.RUnlock()
+	if ok {
+		return tcs
+	}
+
+	s.resolvedMux.Lock()
+	defer s.resolvedMux.Unlock()
+	if tcs, ok = s.resolved[res]; ok {
+		return tcs
+	}
+	// The cache is limited by the max amount of resources, the resources beyond the limit are only controlled
+	// by the shared traffic controllers, since their own traffic controllers could not be retained.
+	cacheable := len(s.resolved) < int(config.MaxResourceAmount())
+	tcs = make([]TrafficShapingController, 0, len(exactTcs)+1)
+	tcs = append(tcs, exactTcs...)
+	for _, p := range s.rules {
+		if !p.matcher.Match(res) {
+			continue
+		}
+		if p.sharedTc != nil {
+			tcs = append(tcs, p.sharedTc)
+			continue
+		}
+		if !cacheable {
+			if !s.overflowWarned {
+				s.overflowWarned = true
+				logging.Warn("[HotSpot] The max amount of resources is reached, the pattern rules without shared statistic are skipped for the new resources",
+					"resource", res, "rule", p.rule)
+			}
+			continue
+		}
+		tc, err := newTrafficShapingControllerOf(p.rule)
+		if err != nil {
+			logging.Error(err, "Fail to build the traffic controller of the matched resource in hotspot.patternRuleSet.trafficControllersOf()",
+				"resource", res, "rule", p.rule)
+			continue
+		}
+		tcs = append(tcs, tc)
+	}
+	if cacheable {
+		s.resolved[res] = tcs
+	}
+	return tcs
+}
+
+// buildPatternRules builds the pattern rules of the same pattern, the shared traffic controllers of the equivalent old rules are reused.
+func buildPatternRules(pattern string, rulesOfPattern []*Rule, oldPatternRules []*patternRule) []*patternRule {
+	newPatternRules := make([]*patternRule, 0, len(rulesOfPattern))
+	for _, rule := range rulesOfPattern {
+		if pattern != rule.Resource {
+			logging.Error(errors.Errorf("unmatched resource pattern, expect: %s, actual: %s", pattern, rule.Resource), "Unmatched resource pattern in hotspot.buildPatternRules()", "rule", rule)
+			continue
+		}
+		reused := false
+		for idx, old := range oldPatternRules {
+			if old.rule.Equals(rule) {
+				newPatternRules = append(newPatternRules, old)
+				oldPatternRules = append(oldPatternRules[:idx], oldPatternRules[idx+1:]...)
+				reused = true
+				break
+			}
+		}
+		if reused {
+			continue
+		}
+		matcher, err := base.NewResourceMatcher(rule.ResourceMatchStrategy, rule.Resource)
+		if err != nil {
+			logging.Warn("[HotSpot buildPatternRules] Ignoring the hotspot param flow rule due to invalid resource pattern", "rule", rule, "err", err.Error())
+			continue
+		}
+		p := &patternRule{
+			rule:    rule,
+			matcher: matcher,
+		}
+		if rule.SharedStat {
+			if p.sharedTc, err = newTrafficShapingControllerOf(rule); err != nil {
+				logging.Warn("[HotSpot buildPatternRules] Ignoring the hotspot param flow rule due to bad generated traffic controller", "rule", rule, "err", err.Error())
+				continue
+			}
+		}
+		newPatternRules = append(newPatternRules, p)
+	}
+	return newPatternRules
+}
+
+// newTrafficShapingControllerOf generates a new traffic controller with its own statistic metric for the rule.
+func newTrafficShapingControllerOf(rule *Rule) (TrafficShapingController, error) {
+	tcGenMux.RLock()
+	generator, supported := tcGenFuncMap[rule.ControlBehavior]
+	tcGenMux.RUnlock()
+	if !supported || generator == nil {
+		return nil, errors.New("unsupported control behavior")
+	}
+	tc := generator(rule, nil)
+	if tc == nil {
+		return nil, errors.New("bad generated traffic controller")
+	}
+	return tc, nil
+}
+
+// splitPatternRules splits the rules into the exact rules and the pattern rules.
+func splitPatternRules(rules []*Rule) (exactRules, patternRules []*Rule) {
+	exactRules = make([]*Rule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.ResourceMatchStrategy.IsPattern() {
+			patternRules = append(patternRules, rule)
+		} else {
+			exactRules = append(exactRules, rule)
+		}
+	}
+	return exactRules, patternRules
+}
+
+func patternRulesFrom(m map[string][]*patternRule) []*Rule {
+	rules := make([]*Rule, 0, len(m))
+	for _, ps := range m {
+		for _, p := range ps {
+			rules = append(rules, p.rule)
+		}
+	}
+	return rules
+}
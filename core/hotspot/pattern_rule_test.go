@@ -0,0 +1,72 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hotspot
+
+import (
+	"testing"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/stat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatternRules(t *testing.T) {
+	newRule := func(pattern string, sharedStat bool) *Rule {
+		return &Rule{
+			Resource:              pattern,
+			ResourceMatchStrategy: base.ResourceMatchGlob,
+			SharedStat:            sharedStat,
+			MetricType:            QPS,
+			ControlBehavior:       Reject,
+			ParamIndex:            0,
+			Threshold:             10,
+			DurationInSec:         1,
+		}
+	}
+
+	m := NewRuleManager(stat.NewNodeStorage(nil))
+	r1 := newRule("/api/**", false)
+	r2 := newRule("/api/*/users", true)
+	succ, err := m.LoadRules([]*Rule{r1, r2})
+	assert.True(t, succ && err == nil)
+	assert.Equal(t, 2, len(m.GetRules()))
+	assert.Equal(t, 1, len(m.GetRulesOfResource("/api/**")))
+
+	tcs1 := m.getTrafficControllersFor("/api/v1/users")
+	tcs2 := m.getTrafficControllersFor("/api/v2/users")
+	tcs3 := m.getTrafficControllersFor("/api/v1/orders/1")
+	assert.Equal(t, 2, len(tcs1))
+	assert.Equal(t, 2, len(tcs2))
+	assert.Equal(t, 1, len(tcs3))
+	assert.Equal(t, 0, len(m.getTrafficControllersFor("/web/v1/users")))
+	// The rule with SharedStat shares the traffic controller and its metric among the matched resources.
+	assert.True(t, tcs1[1] == tcs2[1])
+	assert.True(t, tcs1[1].BoundRule() == r2)
+	// Otherwise each matched resource has its own parameter metric.
+	assert.True(t, tcs1[0] != tcs2[0])
+	assert.True(t, tcs1[0].BoundMetric() != tcs2[0].BoundMetric())
+	// The resolved traffic controllers are cached.
+	assert.True(t, m.getTrafficControllersFor("/api/v1/users")[0] == tcs1[0])
+
+	succ, err = m.LoadRulesOfResource("/api/*/users", nil)
+	assert.True(t, succ && err == nil)
+	assert.Equal(t, 1, len(m.getTrafficControllersFor("/api/v1/users")))
+	assert.Nil(t, m.ClearRules())
+	assert.Equal(t, 0, len(m.getTrafficControllersFor("/api/v1/users")))
+
+	invalid := newRule("/api/[", false)
+	invalid.ResourceMatchStrategy = base.ResourceMatchRegex
+	assert.Error(t, IsValidRule(invalid))
+}
@@ -19,6 +19,9 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/util"
 )
 
 // ControlBehavior indicates the traffic shaping behaviour.
@@ -40,6 +43,21 @@ func (t ControlBehavior) String() string {
 	}
 }
 
+var controlBehaviorNames = map[string]int64{
+	"reject":     int64(Reject),
+	"throttling": int64(Throttling),
+}
+
+// UnmarshalJSON accepts either the integer code or the name (e.g. "Reject") of ControlBehavior.
+func (t *ControlBehavior) UnmarshalJSON(data []byte) error {
+	v, err := util.ParseEnumJSON(data, controlBehaviorNames)
+	if err != nil {
+		return err
+	}
+	*t = ControlBehavior(v)
+	return nil
+}
+
 // MetricType represents the target metric type.
 type MetricType int32
 
@@ -61,12 +79,33 @@ func (t MetricType) String() string {
 	}
 }
 
+var metricTypeNames = map[string]int64{
+	"concurrency": int64(Concurrency),
+	"qps":         int64(QPS),
+}
+
+// UnmarshalJSON accepts either the integer code or the name (e.g. "QPS") of MetricType.
+func (t *MetricType) UnmarshalJSON(data []byte) error {
+	v, err := util.ParseEnumJSON(data, metricTypeNames)
+	if err != nil {
+		return err
+	}
+	*t = MetricType(v)
+	return nil
+}
+
 // Rule represents the hotspot(frequent) parameter flow control rule
 type Rule struct {
 	// ID is the unique id
 	ID string `json:"id,omitempty"`
-	// Resource is the resource name
+	// Resource is the resource name, or the resource pattern if ResourceMatchStrategy is not exact
 	Resource string `json:"resource"`
+	// ResourceMatchStrategy indicates how Resource matches the resource names. By default Resource is the exact
+	// resource name, otherwise it's a prefix, glob or regex pattern and the rule applies to all the matched resources.
+	ResourceMatchStrategy base.ResourceMatchStrategy `json:"resourceMatchStrategy,omitempty"`
+	// SharedStat only takes effect for the pattern rules. By default each matched resource has its own parameter statistic,
+	// while all the matched resources share a single parameter statistic (and threshold) if SharedStat is true.
+	SharedStat bool `json:"sharedStat,omitempty"`
 	// MetricType indicates the metric type for checking logic.
 	// For Concurrency metric, hotspot module will check the each hot parameter's concurrency,
 	//		if concurrency exceeds the Threshold, reject the traffic directly.
@@ -86,6 +125,9 @@ type Rule struct {
 	ParamKey string `json:"paramKey"`
 	// Threshold is the threshold to trigger rejection
 	Threshold int64 `json:"threshold"`
+	// Schedule overrides Threshold during its time windows (the threshold of each window is truncated to integer),
+	// the first window containing the current time wins. The SpecificItems are not affected.
+	Schedule []*base.ScheduledThreshold `json:"schedule,omitempty"`
 	// MaxQueueingTimeMs only takes effect when ControlBehavior is Throttling and MetricType is QPS
 	MaxQueueingTimeMs int64 `json:"maxQueueingTimeMs"`
 	// BurstCount is the silent count
@@ -98,14 +140,24 @@ type Rule struct {
 	ParamsMaxCapacity int64 `json:"paramsMaxCapacity"`
 	// SpecificItems indicates the special threshold for specific value
 	SpecificItems map[interface{}]int64 `json:"specificItems"`
+	// Conditions restrict the rule to the entries matching all of them, e.g. the ones with the attachment plan in (free, trial),
+	// the rule applies to all the entries if empty.
+	Conditions []*base.MatchCondition `json:"conditions,omitempty"`
+	// Mode indicates whether the rule rejects the traffic (RuleModeEnforce, by default),
+	// or only records the would-be blocks without rejecting anything (RuleModeShadow).
+	Mode base.RuleMode `json:"mode,omitempty"`
+	// EffectiveFrom and ExpiresAt are the optional effective period of the rule in Unix milliseconds, 0 means unbounded.
+	// The rule takes effect in [EffectiveFrom, ExpiresAt), out of which it's kept loaded but ignored.
+	EffectiveFrom uint64 `json:"effectiveFrom,omitempty"`
+	ExpiresAt     uint64 `json:"expiresAt,omitempty"`
 }
 
 func (r *Rule) String() string {
 	b, err := json.Marshal(r)
 	if err != nil {
 		// Return the fallback string
-		return fmt.Sprintf("{Id:%s, Resource:%s, MetricType:%+v, ControlBehavior:%+v, ParamIndex:%d, ParamKey:%s, Threshold:%d, MaxQueueingTimeMs:%d, BurstCount:%d, DurationInSec:%d, ParamsMaxCapacity:%d, SpecificItems:%+v}",
-			r.ID, r.Resource, r.MetricType, r.ControlBehavior, r.ParamIndex, r.ParamKey, r.Threshold, r.MaxQueueingTimeMs, r.BurstCount, r.DurationInSec, r.ParamsMaxCapacity, r.SpecificItems)
+		return fmt.Sprintf("{Id:%s, Resource:%s, ResourceMatchStrategy:%s, SharedStat:%t, MetricType:%+v, ControlBehavior:%+v, ParamIndex:%d, ParamKey:%s, Threshold:%d, Schedule:%v, MaxQueueingTimeMs:%d, BurstCount:%d, DurationInSec:%d, ParamsMaxCapacity:%d, SpecificItems:%+v, Conditions:%v, Mode:%s, EffectiveFrom:%d, ExpiresAt:%d}",
+			r.ID, r.Resource, r.ResourceMatchStrategy, r.SharedStat, r.MetricType, r.ControlBehavior, r.ParamIndex, r.ParamKey, r.Threshold, r.Schedule, r.MaxQueueingTimeMs, r.BurstCount, r.DurationInSec, r.ParamsMaxCapacity, r.SpecificItems, r.Conditions, r.Mode, r.EffectiveFrom, r.ExpiresAt)
 	}
 	return string(b)
 }
@@ -115,12 +167,15 @@ func (r *Rule) ResourceName() string {
 
 // IsStatReusable checks whether current rule is "statistically" equal to the given rule.
 func (r *Rule) IsStatReusable(newRule *Rule) bool {
-	return r.Resource == newRule.Resource && r.ControlBehavior == newRule.ControlBehavior && r.ParamsMaxCapacity == newRule.ParamsMaxCapacity && r.DurationInSec == newRule.DurationInSec && r.MetricType == newRule.MetricType
+	return r.Resource == newRule.Resource && r.ResourceMatchStrategy == newRule.ResourceMatchStrategy && r.SharedStat == newRule.SharedStat &&
+		r.ControlBehavior == newRule.ControlBehavior && r.ParamsMaxCapacity == newRule.ParamsMaxCapacity && r.DurationInSec == newRule.DurationInSec && r.MetricType == newRule.MetricType
 }
 
 // Equals checks whether current rule is consistent with the given rule.
 func (r *Rule) Equals(newRule *Rule) bool {
-	baseCheck := r.Resource == newRule.Resource && r.MetricType == newRule.MetricType && r.ControlBehavior == newRule.ControlBehavior && r.ParamsMaxCapacity == newRule.ParamsMaxCapacity && r.ParamIndex == newRule.ParamIndex && r.ParamKey == newRule.ParamKey && r.Threshold == newRule.Threshold && r.DurationInSec == newRule.DurationInSec && reflect.DeepEqual(r.SpecificItems, newRule.SpecificItems)
+	baseCheck := r.Resource == newRule.Resource && r.ResourceMatchStrategy == newRule.ResourceMatchStrategy && r.SharedStat == newRule.SharedStat &&
+		r.MetricType == newRule.MetricType && r.ControlBehavior == newRule.ControlBehavior && r.ParamsMaxCapacity == newRule.ParamsMaxCapacity && r.ParamIndex == newRule.ParamIndex && r.ParamKey == newRule.ParamKey && r.Threshold == newRule.Threshold && reflect.DeepEqual(r.Schedule, newRule.Schedule) && r.DurationInSec == newRule.DurationInSec && reflect.DeepEqual(r.SpecificItems, newRule.SpecificItems) && r.Mode == newRule.Mode &&
+		reflect.DeepEqual(r.Conditions, newRule.Conditions) && r.EffectiveFrom == newRule.EffectiveFrom && r.ExpiresAt == newRule.ExpiresAt
 	if !baseCheck {
 		return false
 	}
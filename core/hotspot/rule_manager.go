@@ -19,6 +19,9 @@ import (
 	"reflect"
 	"sync"
 
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/event"
+	"github.com/alibaba/sentinel-golang/core/stat"
 	"github.com/alibaba/sentinel-golang/logging"
 	"github.com/alibaba/sentinel-golang/util"
 	"github.com/pkg/errors"
@@ -30,14 +33,58 @@ type TrafficControllerGenFunc func(r *Rule, reuseMetric *ParamsMetric) TrafficSh
 // trafficControllerMap represents the map storage for TrafficShapingController.
 type trafficControllerMap map[string][]TrafficShapingController
 
+// RuleManager manages the hotspot param flow rules and the traffic controllers built from them.
+// The package-level functions (e.g. LoadRules) operate on the default RuleManager.
+type RuleManager struct {
+	tcMap trafficControllerMap
+	// patternRuleMap holds the rules whose Resource is a pattern, keyed by the pattern.
+	patternRuleMap map[string][]*patternRule
+	// patterns is the set of all the pattern rules built from patternRuleMap, nil if there is no pattern rule.
+	patterns *patternRuleSet
+	// inactiveRules holds the valid rules out of their effective period, keyed by the Resource.
+	inactiveRules map[string][]*Rule
+	tcMux         sync.RWMutex
+	currentRules  map[string][]*Rule
+	updateRuleMux sync.Mutex
+	// refresher rebuilds the traffic controllers once any rule becomes effective or expires.
+	refresher *base.RuleRefresher
+	// pins holds the nodes of the resources with the rules, so that the statistics of them are never accounted to
+	// the shared overflow node once the max amount of resources is reached.
+	pins *stat.ResourceNodePins
+}
+
 var (
-	tcGenFuncMap  = make(map[ControlBehavior]TrafficControllerGenFunc, 4)
-	tcMap         = make(trafficControllerMap)
-	tcMux         = new(sync.RWMutex)
-	currentRules  = make(map[string][]*Rule, 0)
-	updateRuleMux = new(sync.Mutex)
+	tcGenFuncMap = make(map[ControlBehavior]TrafficControllerGenFunc, 4)
+	tcGenMux     = new(sync.RWMutex)
+
+	defaultRuleManager = NewRuleManager(stat.DefaultNodeStorage())
 )
 
+// NewRuleManager creates an empty RuleManager, which pins the nodes of the resources with the rules in nodes.
+func NewRuleManager(nodes *stat.NodeStorage) *RuleManager {
+	m := &RuleManager{
+		tcMap:          make(trafficControllerMap),
+		patternRuleMap: make(map[string][]*patternRule),
+		inactiveRules:  make(map[string][]*Rule),
+		currentRules:   make(map[string][]*Rule, 0),
+		pins:           stat.NewResourceNodePins(nodes),
+	}
+	m.refresher = base.NewRuleRefresher(event.ModuleHotspot, &m.updateRuleMux, (*refreshableRules)(m))
+	return m
+}
+
+// DefaultRuleManager returns the RuleManager operated by the package-level functions.
+func DefaultRuleManager() *RuleManager {
+	return defaultRuleManager
+}
+
+func managerOrDefault(m *RuleManager) *RuleManager {
+	if m == nil {
+		return defaultRuleManager
+	}
+	return m
+}
+
 func init() {
 	// Initialize the traffic shaping controller generator map for existing control behaviors.
 	tcGenFuncMap[Reject] = func(r *Rule, reuseMetric *ParamsMetric) TrafficShapingController {
@@ -74,11 +121,18 @@ func init() {
 	}
 }
 
-func getTrafficControllersFor(res string) []TrafficShapingController {
-	tcMux.RLock()
-	defer tcMux.RUnlock()
+// getTrafficControllersFor returns the traffic controllers of the resource, including those of the matched pattern rules.
+func (m *RuleManager) getTrafficControllersFor(res string) []TrafficShapingController {
+	m.refresher.Check(util.CurrentTimeMillis())
+	m.tcMux.RLock()
+	tcs := m.tcMap[res]
+	patterns := m.patterns
+	m.tcMux.RUnlock()
 
-	return tcMap[res]
+	if patterns == nil {
+		return tcs
+	}
+	return patterns.trafficControllersOf(res, tcs)
 }
 
 // LoadRules replaces all old hotspot param flow rules with the given rules.
@@ -86,24 +140,22 @@ func getTrafficControllersFor(res string) []TrafficShapingController {
 //   bool: indicates whether the internal map has been changed;
 //   error: indicates whether occurs the error.
 func LoadRules(rules []*Rule) (bool, error) {
-	resRulesMap := make(map[string][]*Rule, 16)
-	for _, rule := range rules {
-		resRules, exists := resRulesMap[rule.Resource]
-		if !exists {
-			resRules = make([]*Rule, 0, 1)
-		}
-		resRulesMap[rule.Resource] = append(resRules, rule)
-	}
+	return defaultRuleManager.LoadRules(rules)
+}
 
-	updateRuleMux.Lock()
-	defer updateRuleMux.Unlock()
-	isEqual := reflect.DeepEqual(currentRules, resRulesMap)
+// LoadRules replaces all old hotspot param flow rules of the rule manager with the given rules.
+func (m *RuleManager) LoadRules(rules []*Rule) (bool, error) {
+	resRulesMap := resRulesMapOf(rules)
+
+	m.updateRuleMux.Lock()
+	defer m.updateRuleMux.Unlock()
+	isEqual := reflect.DeepEqual(m.currentRules, resRulesMap)
 	if isEqual {
 		logging.Info("[HotSpot] Load rules is the same with current rules, so ignore load operation.")
 		return false, nil
 	}
 
-	err := onRuleUpdate(resRulesMap)
+	err := m.onRuleUpdate(resRulesMap)
 	return true, err
 }
 
@@ -112,9 +164,17 @@ func LoadRules(rules []*Rule) (bool, error) {
 // GetRules need to compete hotspot module's global lock and the high performance losses of copy,
 // 		reduce or do not call GetRules if possible.
 func GetRules() []Rule {
-	tcMux.RLock()
-	rules := rulesFrom(tcMap)
-	tcMux.RUnlock()
+	return defaultRuleManager.GetRules()
+}
+
+// GetRules returns all the hotspot param flow rules of the rule manager based on copy.
+func (m *RuleManager) GetRules() []Rule {
+	m.tcMux.RLock()
+	rules := append(rulesFrom(m.tcMap), patternRulesFrom(m.patternRuleMap)...)
+	for _, rs := range m.inactiveRules {
+		rules = append(rules, rs...)
+	}
+	m.tcMux.RUnlock()
 
 	ret := make([]Rule, 0, len(rules))
 	for _, rule := range rules {
@@ -128,30 +188,83 @@ func GetRules() []Rule {
 // GetRulesOfResource need to compete hotspot module's global lock and the high performance losses of copy,
 // 		reduce or do not call GetRulesOfResource frequently if possible.
 func GetRulesOfResource(res string) []Rule {
-	tcMux.RLock()
-	resTcs := tcMap[res]
-	tcMux.RUnlock()
+	return defaultRuleManager.GetRulesOfResource(res)
+}
+
+// GetRulesOfResource returns specific resource's hotspot parameter flow control rules of the rule manager based on copy.
+func (m *RuleManager) GetRulesOfResource(res string) []Rule {
+	m.tcMux.RLock()
+	resTcs := m.tcMap[res]
+	resPatternRules := m.patternRuleMap[res]
+	resInactiveRules := m.inactiveRules[res]
+	m.tcMux.RUnlock()
 
-	ret := make([]Rule, 0, len(resTcs))
+	ret := make([]Rule, 0, len(resTcs)+len(resPatternRules)+len(resInactiveRules))
 	for _, tc := range resTcs {
 		ret = append(ret, *tc.BoundRule())
 	}
+	for _, p := range resPatternRules {
+		ret = append(ret, *p.rule)
+	}
+	for _, rule := range resInactiveRules {
+		ret = append(ret, *rule)
+	}
 	return ret
 }
 
 // ClearRules clears all hotspot param flow rules.
 func ClearRules() error {
-	_, err := LoadRules(nil)
+	return defaultRuleManager.ClearRules()
+}
+
+// ClearRules clears all hotspot param flow rules of the rule manager.
+func (m *RuleManager) ClearRules() error {
+	_, err := m.LoadRules(nil)
 	return err
 }
 
+// Close stops refreshing the rules in background and clears all the rules of the rule manager,
+// which must not be used any more then.
+func (m *RuleManager) Close() error {
+	m.refresher.Stop()
+	return m.ClearRules()
+}
+
 // ClearRulesOfResource clears resource level hotspot param flow rules.
 func ClearRulesOfResource(res string) error {
-	_, err := LoadRulesOfResource(res, nil)
+	return defaultRuleManager.ClearRulesOfResource(res)
+}
+
+// ClearRulesOfResource clears resource level hotspot param flow rules of the rule manager.
+func (m *RuleManager) ClearRulesOfResource(res string) error {
+	_, err := m.LoadRulesOfResource(res, nil)
 	return err
 }
 
-func onRuleUpdate(rawResRulesMap map[string][]*Rule) (err error) {
+// ruleUpdate holds the traffic controllers built from the rules, which are swapped into the rule manager at once.
+type ruleUpdate struct {
+	m                *RuleManager
+	rawResRulesMap   map[string][]*Rule
+	validResRulesMap map[string][]*Rule
+	tcMap            trafficControllerMap
+	patternRuleMap   map[string][]*patternRule
+	inactiveRules    map[string][]*Rule
+	now              uint64
+	start            uint64
+}
+
+func (m *RuleManager) onRuleUpdate(rawResRulesMap map[string][]*Rule) error {
+	u, err := m.buildRuleUpdate(rawResRulesMap)
+	if err != nil {
+		return err
+	}
+	u.Apply()
+	return nil
+}
+
+// buildRuleUpdate builds the traffic controllers of the valid rules without applying them.
+// It must be called with updateRuleMux held.
+func (m *RuleManager) buildRuleUpdate(rawResRulesMap map[string][]*Rule) (u *base.RuleUpdate, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			var ok bool
@@ -180,32 +293,91 @@ func onRuleUpdate(rawResRulesMap map[string][]*Rule) (err error) {
 
 	start := util.CurrentTimeNano()
 
-	tcMux.RLock()
-	tcMapClone := make(trafficControllerMap, len(tcMap))
-	for res, tcs := range tcMap {
+	m.tcMux.RLock()
+	tcMapClone := make(trafficControllerMap, len(m.tcMap))
+	for res, tcs := range m.tcMap {
 		resTcClone := make([]TrafficShapingController, 0, len(tcs))
 		resTcClone = append(resTcClone, tcs...)
 		tcMapClone[res] = resTcClone
 	}
-	tcMux.RUnlock()
+	patternRuleMapClone := make(map[string][]*patternRule, len(m.patternRuleMap))
+	for pattern, ps := range m.patternRuleMap {
+		patternRuleMapClone[pattern] = append(make([]*patternRule, 0, len(ps)), ps...)
+	}
+	m.tcMux.RUnlock()
 
-	m := make(trafficControllerMap, len(validResRulesMap))
+	now := util.CurrentTimeMillis()
+	newTcMap := make(trafficControllerMap, len(validResRulesMap))
+	newPatternRuleMap := make(map[string][]*patternRule)
+	newInactiveRules := make(map[string][]*Rule)
 	for res, rules := range validResRulesMap {
-		m[res] = buildResourceTrafficShapingController(res, rules, tcMapClone[res])
+		activeRules, inactiveRules := splitInactiveRules(rules, now)
+		if len(inactiveRules) > 0 {
+			newInactiveRules[res] = inactiveRules
+		}
+		exactRules, patternRules := splitPatternRules(activeRules)
+		if len(exactRules) > 0 {
+			newTcMap[res] = buildResourceTrafficShapingController(res, exactRules, tcMapClone[res])
+		}
+		if newPatternRules := buildPatternRules(res, patternRules, patternRuleMapClone[res]); len(newPatternRules) > 0 {
+			newPatternRuleMap[res] = newPatternRules
+		}
 	}
 
-	tcMux.Lock()
-	tcMap = m
-	tcMux.Unlock()
+	update := &ruleUpdate{
+		m:                m,
+		rawResRulesMap:   rawResRulesMap,
+		validResRulesMap: validResRulesMap,
+		tcMap:            newTcMap,
+		patternRuleMap:   newPatternRuleMap,
+		inactiveRules:    newInactiveRules,
+		now:              now,
+		start:            start,
+	}
+	return base.NewRuleUpdate(&m.updateRuleMux, &m.tcMux, update.swap, update.complete), nil
+}
 
-	currentRules = rawResRulesMap
+// swap replaces the state read by the traffic with the built one, it must be called with the state locked.
+func (u *ruleUpdate) swap() {
+	u.m.tcMap = u.tcMap
+	u.m.patternRuleMap = u.patternRuleMap
+	u.m.patterns = newPatternRuleSet(u.patternRuleMap)
+	u.m.inactiveRules = u.inactiveRules
+	u.m.pinResourceNodes()
+}
 
-	logging.Debug("[HotSpot onRuleUpdate] Time statistic(ns) for updating hotspot param flow rules", "timeCost", util.CurrentTimeNano()-start)
-	logRuleUpdate(validResRulesMap)
-	return nil
+// complete records the swapped rules as the current rules, it must be called with updateRuleMux held.
+func (u *ruleUpdate) complete() {
+	u.m.currentRules = u.rawResRulesMap
+	u.m.refresher.Schedule(u.now)
+
+	logging.Debug("[HotSpot onRuleUpdate] Time statistic(ns) for updating hotspot param flow rules", "timeCost", util.CurrentTimeNano()-u.start)
+	logRuleUpdate(u.validResRulesMap)
+}
+
+// pinResourceNodes pins the nodes of the resources with the rules and unpins the others, it must be called with
+// tcMux locked.
+func (m *RuleManager) pinResourceNodes() {
+	resources := make(map[string]struct{}, len(m.tcMap))
+	for res := range m.tcMap {
+		resources[res] = struct{}{}
+	}
+	m.pins.PinOnly(resources)
+}
+
+func resRulesMapOf(rules []*Rule) map[string][]*Rule {
+	resRulesMap := make(map[string][]*Rule, 16)
+	for _, rule := range rules {
+		resRules, exists := resRulesMap[rule.Resource]
+		if !exists {
+			resRules = make([]*Rule, 0, 1)
+		}
+		resRulesMap[rule.Resource] = append(resRules, rule)
+	}
+	return resRulesMap
 }
 
-func onResourceRuleUpdate(res string, rawResRules []*Rule) (err error) {
+func (m *RuleManager) onResourceRuleUpdate(res string, rawResRules []*Rule) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			var ok bool
@@ -227,24 +399,44 @@ func onResourceRuleUpdate(res string, rawResRules []*Rule) (err error) {
 
 	start := util.CurrentTimeNano()
 	oldResTcs := make([]TrafficShapingController, 0, 8)
-	tcMux.RLock()
-	oldResTcs = append(oldResTcs, tcMap[res]...)
-	tcMux.RUnlock()
-
-	newResTcs := buildResourceTrafficShapingController(res, validResRules, oldResTcs)
-
-	tcMux.Lock()
+	oldPatternRules := make([]*patternRule, 0)
+	m.tcMux.RLock()
+	oldResTcs = append(oldResTcs, m.tcMap[res]...)
+	oldPatternRules = append(oldPatternRules, m.patternRuleMap[res]...)
+	m.tcMux.RUnlock()
+
+	now := util.CurrentTimeMillis()
+	activeRules, inactiveRules := splitInactiveRules(validResRules, now)
+	exactRules, patternRules := splitPatternRules(activeRules)
+	newResTcs := buildResourceTrafficShapingController(res, exactRules, oldResTcs)
+	newPatternRules := buildPatternRules(res, patternRules, oldPatternRules)
+
+	m.tcMux.Lock()
 	if len(newResTcs) == 0 {
-		delete(tcMap, res)
+		delete(m.tcMap, res)
+	} else {
+		m.tcMap[res] = newResTcs
+	}
+	if len(newPatternRules) == 0 {
+		delete(m.patternRuleMap, res)
+	} else {
+		m.patternRuleMap[res] = newPatternRules
+	}
+	m.patterns = newPatternRuleSet(m.patternRuleMap)
+	if len(inactiveRules) == 0 {
+		delete(m.inactiveRules, res)
 	} else {
-		tcMap[res] = newResTcs
+		m.inactiveRules[res] = inactiveRules
 	}
-	tcMux.Unlock()
+	m.pinResourceNodes()
+	m.tcMux.Unlock()
 
-	currentRules[res] = rawResRules
+	m.currentRules[res] = rawResRules
+	m.refresher.Schedule(now)
 
 	logging.Debug("[HotSpot onResourceRuleUpdate] Time statistic(ns) for updating hotspot param flow rules", "timeCost", util.CurrentTimeNano()-start)
 	logging.Info("[HotSpot] load resource level hotspot param flow rules", "resource", res, "validResRules", validResRules)
+	publishRuleChange(res, validResRules)
 	return nil
 }
 
@@ -252,33 +444,45 @@ func onResourceRuleUpdate(res string, rawResRules []*Rule) (err error) {
 // while all previous resource's rules will be replaced. The first returned value indicates whether
 // do real load operation, if the rules is the same with previous resource's rules, return false.
 func LoadRulesOfResource(res string, rules []*Rule) (bool, error) {
+	return defaultRuleManager.LoadRulesOfResource(res, rules)
+}
+
+// LoadRulesOfResource loads the given resource's hotspot param flow rules to the rule manager.
+func (m *RuleManager) LoadRulesOfResource(res string, rules []*Rule) (bool, error) {
 	if len(res) == 0 {
 		return false, errors.New("empty resource")
 	}
 
-	updateRuleMux.Lock()
-	defer updateRuleMux.Unlock()
+	m.updateRuleMux.Lock()
+	defer m.updateRuleMux.Unlock()
 
 	// clear resource rules
 	if len(rules) == 0 {
-		// clear resource's currentRules
-		delete(currentRules, res)
-		// clear tcMap
-		tcMux.Lock()
-		delete(tcMap, res)
-		tcMux.Unlock()
+		// clear resource's m.currentRules
+		delete(m.currentRules, res)
+		// clear m.tcMap
+		m.tcMux.Lock()
+		delete(m.tcMap, res)
+		delete(m.patternRuleMap, res)
+		// The pattern rule set caches the exact rules as well, so it's always rebuilt.
+		m.patterns = newPatternRuleSet(m.patternRuleMap)
+		delete(m.inactiveRules, res)
+		m.pinResourceNodes()
+		m.tcMux.Unlock()
+		m.refresher.Schedule(util.CurrentTimeMillis())
 		logging.Info("[HotSpot] clear resource level hotspot param flow rules", "resource", res)
+		publishRuleChange(res, nil)
 		return true, nil
 	}
 
 	// load resource level rules
-	isEqual := reflect.DeepEqual(currentRules[res], rules)
+	isEqual := reflect.DeepEqual(m.currentRules[res], rules)
 	if isEqual {
 		logging.Info("[HotSpot] Load resource level hotspot param flow rules is the same with current resource level rules, so ignore load operation.")
 		return false, nil
 	}
 
-	err := onResourceRuleUpdate(res, rules)
+	err := m.onResourceRuleUpdate(res, rules)
 	return true, err
 }
 
@@ -295,6 +499,16 @@ func logRuleUpdate(m map[string][]*Rule) {
 	} else {
 		logging.Info("[HotspotRuleManager] Hotspot param flow rules were loaded", "rules", rules)
 	}
+	publishRuleChange("", rules)
+}
+
+// publishRuleChange publishes the valid rules loaded for the resource (all the resources if res is empty) to the event bus.
+func publishRuleChange(res string, rules []*Rule) {
+	sentinelRules := make([]base.SentinelRule, 0, len(rules))
+	for _, r := range rules {
+		sentinelRules = append(sentinelRules, r)
+	}
+	event.PublishRuleChange(event.ModuleHotspot, res, sentinelRules)
 }
 
 func rulesFrom(m trafficControllerMap) []*Rule {
@@ -361,7 +575,9 @@ func buildResourceTrafficShapingController(res string, resRules []*Rule, oldResT
 		}
 
 		// generate new traffic shaping controller
+		tcGenMux.RLock()
 		generator, supported := tcGenFuncMap[rule.ControlBehavior]
+		tcGenMux.RUnlock()
 		if !supported {
 			logging.Warn("[HotSpot buildResourceTrafficShapingController] Ignoring the hotspot param flow rule due to unsupported control behavior", "rule", rule)
 			continue
@@ -395,6 +611,9 @@ func IsValidRule(rule *Rule) error {
 	if rule.Threshold < 0 {
 		return errors.New("negative threshold")
 	}
+	if err := base.IsValidEffectivePeriod(rule.EffectiveFrom, rule.ExpiresAt); err != nil {
+		return err
+	}
 	if rule.MetricType < 0 {
 		return errors.New("invalid metric type")
 	}
@@ -404,9 +623,20 @@ func IsValidRule(rule *Rule) error {
 	if rule.MetricType == QPS && rule.DurationInSec <= 0 {
 		return errors.New("invalid duration")
 	}
+	if rule.ResourceMatchStrategy.IsPattern() {
+		if _, err := base.NewResourceMatcher(rule.ResourceMatchStrategy, rule.Resource); err != nil {
+			return errors.Wrap(err, "invalid resource pattern")
+		}
+	}
 	if rule.ParamIndex > 0 && rule.ParamKey != "" {
 		return errors.New("invalid param index and param key are mutually exclusive")
 	}
+	if _, err := base.NewThresholdSchedule(rule.Schedule); err != nil {
+		return errors.Wrap(err, "invalid schedule")
+	}
+	if err := base.IsValidConditions(rule.Conditions); err != nil {
+		return errors.Wrap(err, "invalid conditions")
+	}
 	return checkControlBehaviorField(rule)
 }
 
@@ -436,8 +666,8 @@ func SetTrafficShapingGenerator(cb ControlBehavior, generator TrafficControllerG
 	if cb >= Reject && cb <= Throttling {
 		return errors.New("not allowed to replace the generator for default control behaviors")
 	}
-	tcMux.Lock()
-	defer tcMux.Unlock()
+	tcGenMux.Lock()
+	defer tcGenMux.Unlock()
 
 	tcGenFuncMap[cb] = generator
 	return nil
@@ -447,8 +677,8 @@ func RemoveTrafficShapingGenerator(cb ControlBehavior) error {
 	if cb >= Reject && cb <= Throttling {
 		return errors.New("not allowed to replace the generator for default control behaviors")
 	}
-	tcMux.Lock()
-	defer tcMux.Unlock()
+	tcGenMux.Lock()
+	defer tcGenMux.Unlock()
 
 	delete(tcGenFuncMap, cb)
 	return nil
@@ -20,12 +20,16 @@ import (
 	"testing"
 
 	"github.com/alibaba/sentinel-golang/core/hotspot/cache"
+	"github.com/alibaba/sentinel-golang/core/stat"
 	"github.com/stretchr/testify/assert"
 )
 
 func clearData() {
-	tcMap = make(trafficControllerMap)
-	currentRules = make(map[string][]*Rule, 0)
+	defaultRuleManager.tcMap = make(trafficControllerMap)
+	defaultRuleManager.patternRuleMap = make(map[string][]*patternRule)
+	defaultRuleManager.patterns = nil
+	defaultRuleManager.inactiveRules = make(map[string][]*Rule)
+	defaultRuleManager.currentRules = make(map[string][]*Rule, 0)
 }
 
 func Test_tcGenFuncMap(t *testing.T) {
@@ -223,7 +227,7 @@ func Test_onRuleUpdate(t *testing.T) {
 	if !updated || err != nil {
 		t.Fatalf("Fail to prepare data, err: %+v", err)
 	}
-	assert.True(t, len(tcMap["abc"]) == 4)
+	assert.True(t, len(defaultRuleManager.tcMap["abc"]) == 4)
 
 	r21 := &Rule{
 		ID:                "21",
@@ -262,10 +266,10 @@ func Test_onRuleUpdate(t *testing.T) {
 		SpecificItems:     specific3,
 	}
 
-	oldTc1Ptr := tcMap["abc"][0]
-	oldTc2Ptr := tcMap["abc"][1]
-	oldTc3Ptr := tcMap["abc"][2]
-	oldTc4Ptr := tcMap["abc"][3]
+	oldTc1Ptr := defaultRuleManager.tcMap["abc"][0]
+	oldTc2Ptr := defaultRuleManager.tcMap["abc"][1]
+	oldTc3Ptr := defaultRuleManager.tcMap["abc"][2]
+	oldTc4Ptr := defaultRuleManager.tcMap["abc"][3]
 	oldTc1PtrAddr := fmt.Sprintf("%p", oldTc1Ptr)
 	oldTc2PtrAddr := fmt.Sprintf("%p", oldTc2Ptr)
 	oldTc3PtrAddr := fmt.Sprintf("%p", oldTc3Ptr)
@@ -274,16 +278,16 @@ func Test_onRuleUpdate(t *testing.T) {
 	fmt.Println(oldTc2PtrAddr)
 	fmt.Println(oldTc3PtrAddr)
 	fmt.Println(oldTc4PtrAddr)
-	oldTc2MetricPtrAddr := fmt.Sprintf("%p", tcMap["abc"][1].BoundMetric())
+	oldTc2MetricPtrAddr := fmt.Sprintf("%p", defaultRuleManager.tcMap["abc"][1].BoundMetric())
 	fmt.Println("oldTc2MetricPtr:", oldTc2MetricPtrAddr)
 
 	rulesMap := map[string][]*Rule{
 		"abc": {r21, r22, r23},
 	}
-	err = onRuleUpdate(rulesMap)
+	err = defaultRuleManager.onRuleUpdate(rulesMap)
 	assert.True(t, err == nil)
-	assert.True(t, len(tcMap) == 1)
-	abcTcs := tcMap["abc"]
+	assert.True(t, len(defaultRuleManager.tcMap) == 1)
+	abcTcs := defaultRuleManager.tcMap["abc"]
 	assert.True(t, len(abcTcs) == 3)
 	newTc1Ptr := abcTcs[0]
 	newTc2Ptr := abcTcs[1]
@@ -418,8 +422,8 @@ func TestLoadRulesOfResource(t *testing.T) {
 	t.Run("LoadRulesOfResource_clear", func(t *testing.T) {
 		succ, err = LoadRulesOfResource("abc1", []*Rule{})
 		assert.True(t, succ && err == nil)
-		assert.True(t, len(tcMap["abc1"]) == 0 && len(currentRules["abc1"]) == 0)
-		assert.True(t, len(tcMap["abc2"]) == 2 && len(currentRules["abc2"]) == 2)
+		assert.True(t, len(defaultRuleManager.tcMap["abc1"]) == 0 && len(defaultRuleManager.currentRules["abc1"]) == 0)
+		assert.True(t, len(defaultRuleManager.tcMap["abc2"]) == 2 && len(defaultRuleManager.currentRules["abc2"]) == 2)
 	})
 }
 
@@ -485,14 +489,14 @@ func Test_onResourceRuleUpdate(t *testing.T) {
 	t.Run("Test_onResourceRuleUpdate_normal", func(t *testing.T) {
 		r11Copy := r11
 		r11Copy.Threshold = 500
-		err = onResourceRuleUpdate("abc1", []*Rule{&r11Copy})
+		err = defaultRuleManager.onResourceRuleUpdate("abc1", []*Rule{&r11Copy})
 
-		assert.True(t, len(tcMap["abc1"]) == 1)
-		assert.True(t, len(currentRules["abc1"]) == 1)
-		assert.True(t, tcMap["abc1"][0].BoundRule() == &r11Copy)
+		assert.True(t, len(defaultRuleManager.tcMap["abc1"]) == 1)
+		assert.True(t, len(defaultRuleManager.currentRules["abc1"]) == 1)
+		assert.True(t, defaultRuleManager.tcMap["abc1"][0].BoundRule() == &r11Copy)
 
-		assert.True(t, len(tcMap["abc2"]) == 2)
-		assert.True(t, len(currentRules["abc2"]) == 2)
+		assert.True(t, len(defaultRuleManager.tcMap["abc2"]) == 2)
+		assert.True(t, len(defaultRuleManager.currentRules["abc2"]) == 2)
 
 		clearData()
 	})
@@ -560,10 +564,27 @@ func TestClearRulesOfResource(t *testing.T) {
 	t.Run("TestClearRulesOfResource_normal", func(t *testing.T) {
 		assert.True(t, ClearRulesOfResource("abc1") == nil)
 
-		assert.True(t, len(tcMap["abc1"]) == 0)
-		assert.True(t, len(currentRules["abc1"]) == 0)
-		assert.True(t, len(tcMap["abc2"]) == 2)
-		assert.True(t, len(currentRules["abc2"]) == 2)
+		assert.True(t, len(defaultRuleManager.tcMap["abc1"]) == 0)
+		assert.True(t, len(defaultRuleManager.currentRules["abc1"]) == 0)
+		assert.True(t, len(defaultRuleManager.tcMap["abc2"]) == 2)
+		assert.True(t, len(defaultRuleManager.currentRules["abc2"]) == 2)
 		clearData()
 	})
 }
+
+func TestRuleManager_PinResourceNodes(t *testing.T) {
+	m := NewRuleManager(stat.NewNodeStorage(nil))
+	rule := func(res string) *Rule {
+		return &Rule{Resource: res, MetricType: QPS, ControlBehavior: Reject, Threshold: 10, DurationInSec: 1}
+	}
+	_, err := m.LoadRules([]*Rule{rule("abc1"), rule("abc2")})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]struct{}{"abc1": {}, "abc2": {}}, m.pins.Resources())
+
+	_, err = m.LoadRulesOfResource("abc1", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]struct{}{"abc2": {}}, m.pins.Resources())
+
+	assert.Nil(t, m.ClearRules())
+	assert.Equal(t, 0, len(m.pins.Resources()))
+}
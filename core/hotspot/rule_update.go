@@ -0,0 +1,43 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hotspot
+
+import (
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/event"
+)
+
+// ValidateRules checks the hotspot param flow rules and reports each invalid rule with its index in rules.
+func ValidateRules(rules []*Rule) []base.InvalidRule {
+	return base.ValidateRules(event.ModuleHotspot, len(rules), func(i int) (base.SentinelRule, error) {
+		if rules[i] == nil {
+			return nil, IsValidRule(nil)
+		}
+		return rules[i], IsValidRule(rules[i])
+	})
+}
+
+// PrepareRules prepares the given hotspot param flow rules of the default RuleManager, see RuleManager.PrepareRules.
+func PrepareRules(rules []*Rule) (base.PreparedRuleUpdate, error) {
+	return defaultRuleManager.PrepareRules(rules)
+}
+
+// PrepareRules builds the given hotspot param flow rules to replace all the previous rules like LoadRules,
+// but they're applied by base.ApplyRuleUpdates.
+func (m *RuleManager) PrepareRules(rules []*Rule) (base.PreparedRuleUpdate, error) {
+	return base.PrepareRuleUpdate(&m.updateRuleMux, func() (*base.RuleUpdate, error) {
+		return m.buildRuleUpdate(resRulesMapOf(rules))
+	})
+}
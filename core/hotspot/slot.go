@@ -24,10 +24,17 @@ const (
 )
 
 var (
-	DefaultSlot = &Slot{}
+	DefaultSlot = NewSlot(defaultRuleManager)
 )
 
 type Slot struct {
+	// manager is the RuleManager to check the rules of, the default RuleManager is used if nil.
+	manager *RuleManager
+}
+
+// NewSlot creates a Slot checking the rules of the given RuleManager.
+func NewSlot(manager *RuleManager) *Slot {
+	return &Slot{manager: manager}
 }
 
 func (s *Slot) Order() uint32 {
@@ -39,7 +46,7 @@ func (s *Slot) Check(ctx *base.EntryContext) *base.TokenResult {
 	batch := int64(ctx.Input.BatchCount)
 
 	result := ctx.RuleCheckResult
-	tcs := getTrafficControllersFor(res)
+	tcs := managerOrDefault(s.manager).getTrafficControllersFor(res)
 	for _, tc := range tcs {
 		arg := tc.ExtractArgs(ctx)
 		if arg == nil {
@@ -50,9 +57,17 @@ func (s *Slot) Check(ctx *base.EntryContext) *base.TokenResult {
 			continue
 		}
 		if r.Status() == base.ResultStatusBlocked {
+			if tc.BoundRule().Mode.IsShadow() {
+				ctx.AddShadowBlock(base.NewBlockErrorFromDeepCopy(r.BlockError()))
+				continue
+			}
 			return r
 		}
 		if r.Status() == base.ResultStatusShouldWait {
+			if tc.BoundRule().Mode.IsShadow() {
+				// The rule in shadow mode never delays the traffic either.
+				continue
+			}
 			if nanosToWait := r.NanosToWait(); nanosToWait > 0 {
 				// Handle waiting action.
 				util.Sleep(nanosToWait)
@@ -52,12 +52,24 @@ type baseTrafficShapingController struct {
 	durationInSec int64
 
 	metric *ParamsMetric
+	// schedule overrides the threshold during its time windows, nil if the rule has no Schedule.
+	schedule *base.ThresholdSchedule
+	// conditions is compiled from the Conditions of the rule, nil if the rule applies to all the entries.
+	conditions *base.ConditionMatcher
 }
 
 func newBaseTrafficShapingControllerWithMetric(r *Rule, metric *ParamsMetric) *baseTrafficShapingController {
 	if r.SpecificItems == nil {
 		r.SpecificItems = make(map[interface{}]int64)
 	}
+	schedule, err := base.NewThresholdSchedule(r.Schedule)
+	if err != nil {
+		logging.Error(err, "Invalid Schedule of the rule in hotspot.newBaseTrafficShapingControllerWithMetric()", "rule", r)
+	}
+	conditions, err := base.NewConditionMatcher(r.Conditions)
+	if err != nil {
+		logging.Error(err, "Invalid Conditions of the rule in hotspot.newBaseTrafficShapingControllerWithMetric()", "rule", r)
+	}
 	return &baseTrafficShapingController{
 		r:             r,
 		res:           r.Resource,
@@ -65,6 +77,8 @@ func newBaseTrafficShapingControllerWithMetric(r *Rule, metric *ParamsMetric) *b
 		paramIndex:    r.ParamIndex,
 		paramKey:      r.ParamKey,
 		threshold:     r.Threshold,
+		schedule:      schedule,
+		conditions:    conditions,
 		specificItems: r.SpecificItems,
 		durationInSec: r.DurationInSec,
 		metric:        metric,
@@ -113,6 +127,14 @@ func (c *baseTrafficShapingController) BoundMetric() *ParamsMetric {
 	return c.metric
 }
 
+// currentThreshold returns the threshold of the scheduled window containing the current time, or the threshold of the rule.
+func (c *baseTrafficShapingController) currentThreshold() int64 {
+	if c.schedule == nil {
+		return c.threshold
+	}
+	return int64(c.schedule.CurrentThreshold(float64(c.threshold)))
+}
+
 func (c *baseTrafficShapingController) performCheckingForConcurrencyMetric(arg interface{}) *base.TokenResult {
 	specificItem := c.specificItems
 	initConcurrency := new(int64)
@@ -131,7 +153,7 @@ func (c *baseTrafficShapingController) performCheckingForConcurrencyMetric(arg i
 		msg := fmt.Sprintf("hotspot specific concurrency check blocked, arg: %v", arg)
 		return base.NewTokenResultBlockedWithCause(base.BlockTypeHotSpotParamFlow, msg, c.BoundRule(), concurrency)
 	}
-	threshold := c.threshold
+	threshold := c.currentThreshold()
 	if concurrency <= threshold {
 		return nil
 	}
@@ -160,9 +182,9 @@ func (c *baseTrafficShapingController) BoundParamIndex() int {
 }
 
 // ExtractArgs matches the arg from ctx based on TrafficShapingController
-// return nil if match failed.
+// return nil if match failed, or the entry doesn't satisfy the Conditions of the rule.
 func (c *baseTrafficShapingController) ExtractArgs(ctx *base.EntryContext) (value interface{}) {
-	if c == nil {
+	if c == nil || !c.conditions.Matches(ctx.Input) {
 		return nil
 	}
 	value = c.extractAttachmentArgs(ctx)
@@ -244,7 +266,7 @@ func (c *rejectTrafficShapingController) PerformChecking(arg interface{}, batchC
 	}
 
 	// calculate available token
-	tokenCount := c.threshold
+	tokenCount := c.currentThreshold()
 	val, existed := c.specificItems[arg]
 	if existed {
 		tokenCount = val
@@ -339,7 +361,7 @@ func (c *throttlingTrafficShapingController) PerformChecking(arg interface{}, ba
 	}
 
 	// calculate available token
-	tokenCount := c.threshold
+	tokenCount := c.currentThreshold()
 	val, existed := c.specificItems[arg]
 	if existed {
 		tokenCount = val
@@ -372,5 +372,41 @@ func Test_baseTrafficShapingController_ExtractArgs(t *testing.T) {
 		c.paramKey = "test2"
 		ret = c.ExtractArgs(ctx)
 		assert.Nil(t, ret)
+
+		// not matching the conditions
+		c.paramIndex = 1
+		c.conditions, _ = base.NewConditionMatcher([]*base.MatchCondition{
+			{Source: base.ConditionSourceAttachment, Key: "test1", Operator: base.ConditionOpEquals, Values: []string{"v2"}},
+		})
+		ret = c.ExtractArgs(ctx)
+		assert.Nil(t, ret)
+		attachments["test1"] = "v2"
+		ret = c.ExtractArgs(ctx)
+		assert.True(t, reflect.DeepEqual(ret, 2), ret)
 	})
 }
+
+func Test_baseTrafficShapingController_currentThreshold(t *testing.T) {
+	util.SetClock(util.NewMockClock())
+	defer util.SetClock(util.NewRealClock())
+
+	r := &Rule{
+		Resource:      "abc",
+		MetricType:    Concurrency,
+		Threshold:     10,
+		Schedule:      []*base.ScheduledThreshold{{Start: "01:00", End: "05:00", Threshold: 50}},
+		DurationInSec: 1,
+	}
+	assert.NoError(t, IsValidRule(r))
+	c := newBaseTrafficShapingController(r)
+
+	now := util.CurrentClock().Now().UTC()
+	batchStart := time.Date(now.Year(), now.Month(), now.Day(), 2, 0, 0, 0, time.UTC)
+	if !batchStart.After(now) {
+		batchStart = batchStart.Add(24 * time.Hour)
+	}
+	util.Sleep(batchStart.Sub(now))
+	assert.Equal(t, int64(50), c.currentThreshold())
+	util.Sleep(4 * time.Hour)
+	assert.Equal(t, int64(10), c.currentThreshold())
+}
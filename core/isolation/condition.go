@@ -0,0 +1,68 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package isolation
+
+import (
+	"sync/atomic"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/logging"
+)
+
+// appliesTo checks whether the rule applies to the entry of the input, according to the Conditions of the rule.
+func (m *RuleManager) appliesTo(rule *Rule, input *base.SentinelInput) bool {
+	if len(rule.Conditions) == 0 {
+		return true
+	}
+	v, ok := m.conditions.Load(rule)
+	if !ok {
+		conditions, err := base.NewConditionMatcher(rule.Conditions)
+		if err != nil {
+			logging.Error(err, "Invalid Conditions of the rule in isolation.RuleManager.appliesTo()", "rule", rule)
+		}
+		v, _ = m.conditions.LoadOrStore(rule, conditions)
+	}
+	return v.(*base.ConditionMatcher).Matches(input)
+}
+
+// matchedConcurrency tracks the concurrency of the entries matching the Conditions of a rule on a resource.
+type matchedConcurrency struct {
+	count int64
+}
+
+func (m *RuleManager) matchedConcurrencyOf(res string, rule *Rule) *matchedConcurrency {
+	key := ruleStateKey{res: res, rule: rule}
+	if v, ok := m.matched.Load(key); ok {
+		return v.(*matchedConcurrency)
+	}
+	v, _ := m.matched.LoadOrStore(key, &matchedConcurrency{})
+	return v.(*matchedConcurrency)
+}
+
+func (c *matchedConcurrency) current() uint32 {
+	if cur := atomic.LoadInt64(&c.count); cur > 0 {
+		return uint32(cur)
+	}
+	return 0
+}
+
+// take takes the matched concurrency until the entry exits.
+func (c *matchedConcurrency) take(ctx *base.EntryContext, batchCount uint32) {
+	e := ctx.Entry()
+	if e == nil {
+		return
+	}
+	takeUntilExit(e, &c.count, batchCount)
+}
@@ -0,0 +1,115 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package isolation
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/config"
+	"github.com/alibaba/sentinel-golang/logging"
+)
+
+// patternRule is an isolation rule whose Resource is a pattern rather than an exact resource name.
+// The isolation rules have no statistic of their own, so the pattern rule always limits the concurrency
+// of each matched resource separately.
+type patternRule struct {
+	rule    *Rule
+	matcher *base.ResourceMatcher
+}
+
+// patternRuleSet holds all the pattern rules, and caches the rules resolved for the accessed resources.
+// A new set is created whenever the rules are updated, so that the cache never outlives the rules it's resolved from.
+type patternRuleSet struct {
+	rules []*patternRule
+
+	resolved    map[string][]*Rule
+	resolvedMux sync.RWMutex
+	// overflowWarned indicates whether the warning of reaching the max amount of resources has been logged.
+	overflowWarned bool
+}
+
+func newPatternRuleSet(patternRuleMap map[string][]*Rule) *patternRuleSet {
+	if len(patternRuleMap) == 0 {
+		return nil
+	}
+	patterns := make([]string, 0, len(patternRuleMap))
+	for pattern := range patternRuleMap {
+		patterns = append(patterns, pattern)
+	}
+	// Keep the order of the rules deterministic.
+	sort.Strings(patterns)
+	rules := make([]*patternRule, 0, len(patterns))
+	for _, pattern := range patterns {
+		for _, rule := range patternRuleMap[pattern] {
+			matcher, err := base.NewResourceMatcher(rule.ResourceMatchStrategy, rule.Resource)
+			if err != nil {
+				logging.Warn("[Isolation newPatternRuleSet] Ignoring the isolation rule due to invalid resource pattern", "rule", rule, "reason", err.Error())
+				continue
+			}
+			rules = append(rules, &patternRule{
+				rule:    rule,
+				matcher: matcher,
+			})
+		}
+	}
+	return &patternRuleSet{
+		rules:    rules,
+		resolved: make(map[string][]*Rule),
+	}
+}
+
+// rulesOf returns the exact rules of res, followed by the matched pattern rules.
+func (s *patternRuleSet) rulesOf(res string, exactRules []*Rule) []*Rule {
+	s.resolvedMux.RLock()
+	rules, ok := s.resolved[res]
+	s.resolvedMux.RUnlock()
+	if ok {
+		return rules
+	}
+
+	rules = make([]*Rule, 0, len(exactRules)+1)
+	rules = append(rules, exactRules...)
+	for _, p := range s.rules {
+		if p.matcher.Match(res) {
+			rules = append(rules, p.rule)
+		}
+	}
+
+	s.resolvedMux.Lock()
+	defer s.resolvedMux.Unlock()
+	// The cache is limited by the max amount of resources, the rules of the resources beyond the limit
+	// are resolved on every access.
+	if len(s.resolved) < int(config.MaxResourceAmount()) {
+		s.resolved[res] = rules
+	} else if !s.overflowWarned {
+		s.overflowWarned = true
+		logging.Warn("[Isolation] The max amount of resources is reached, the isolation rules of the new resources won't be cached", "resource", res)
+	}
+	return rules
+}
+
+// splitPatternRules splits the rules into the exact rules and the pattern rules.
+func splitPatternRules(rules []*Rule) (exactRules, patternRules []*Rule) {
+	for _, rule := range rules {
+		if rule.ResourceMatchStrategy.IsPattern() {
+			patternRules = append(patternRules, rule)
+		} else {
+			exactRules = append(exactRules, rule)
+		}
+	}
+	return exactRules, patternRules
+}
@@ -0,0 +1,76 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package isolation
+
+import (
+	"sync/atomic"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+)
+
+// ruleStateKey identifies the state (e.g. the reserved concurrency) of a rule on a resource,
+// since a pattern rule limits the concurrency of each matched resource separately.
+type ruleStateKey struct {
+	res  string
+	rule *Rule
+}
+
+// reservedConcurrency tracks the concurrency of the traffic entitled to each reservation of a rule on a resource.
+type reservedConcurrency struct {
+	counts []int64
+}
+
+func (m *RuleManager) reservedConcurrencyOf(res string, rule *Rule) *reservedConcurrency {
+	key := ruleStateKey{res: res, rule: rule}
+	if v, ok := m.reserved.Load(key); ok {
+		return v.(*reservedConcurrency)
+	}
+	v, _ := m.reserved.LoadOrStore(key, &reservedConcurrency{counts: make([]int64, len(rule.Reservations))})
+	return v.(*reservedConcurrency)
+}
+
+// unusedReserves returns the sum of the reserved concurrency which is not taken by the reserved traffic yet,
+// except the reserve of the given index.
+func (c *reservedConcurrency) unusedReserves(rule *Rule, threshold uint32, except int) float64 {
+	unused := 0.0
+	for i, r := range rule.Reservations {
+		if i == except {
+			continue
+		}
+		reserve := float64(threshold) * r.Ratio
+		if used := float64(atomic.LoadInt64(&c.counts[i])); used < reserve {
+			unused += reserve - used
+		}
+	}
+	return unused
+}
+
+// take takes the reserved concurrency of the given index until the entry exits.
+func (c *reservedConcurrency) take(ctx *base.EntryContext, index int, batchCount uint32) {
+	e := ctx.Entry()
+	if e == nil {
+		return
+	}
+	takeUntilExit(e, &c.counts[index], batchCount)
+}
+
+// takeUntilExit adds batchCount to the counter, which is subtracted once the entry exits.
+func takeUntilExit(e *base.SentinelEntry, counter *int64, batchCount uint32) {
+	atomic.AddInt64(counter, int64(batchCount))
+	e.WhenExit(func(*base.SentinelEntry, *base.EntryContext) error {
+		atomic.AddInt64(counter, -int64(batchCount))
+		return nil
+	})
+}
@@ -17,6 +17,9 @@ package isolation
 import (
 	"encoding/json"
 	"fmt"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/util"
 )
 
 // MetricType represents the target metric type.
@@ -36,20 +39,55 @@ func (s MetricType) String() string {
 	}
 }
 
+var metricTypeNames = map[string]int64{
+	"concurrency": int64(Concurrency),
+}
+
+// UnmarshalJSON accepts either the integer code or the name (e.g. "Concurrency") of MetricType.
+func (s *MetricType) UnmarshalJSON(data []byte) error {
+	v, err := util.ParseEnumJSON(data, metricTypeNames)
+	if err != nil {
+		return err
+	}
+	*s = MetricType(v)
+	return nil
+}
+
 // Rule describes the concurrency num control, that is similar to semaphore
 type Rule struct {
 	// ID represents the unique ID of the rule (optional).
-	ID         string     `json:"id,omitempty"`
-	Resource   string     `json:"resource"`
-	MetricType MetricType `json:"metricType"`
-	Threshold  uint32     `json:"threshold"`
+	ID string `json:"id,omitempty"`
+	// Resource represents the resource name, or the resource pattern if ResourceMatchStrategy is not exact.
+	Resource string `json:"resource"`
+	// ResourceMatchStrategy indicates how Resource matches the resource names. By default Resource is the exact
+	// resource name, otherwise it's a prefix, glob or regex pattern and the rule limits the concurrency
+	// of each matched resource separately.
+	ResourceMatchStrategy base.ResourceMatchStrategy `json:"resourceMatchStrategy,omitempty"`
+	MetricType            MetricType                 `json:"metricType"`
+	Threshold             uint32                     `json:"threshold"`
+	// Schedule overrides Threshold during its time windows (the threshold of each window is truncated to integer),
+	// the first window containing the current time wins.
+	Schedule []*base.ScheduledThreshold `json:"schedule,omitempty"`
+	// Reservations reserve a part of the Threshold for the traffic with the given flags or from the given origins,
+	// which the other traffic could never take.
+	Reservations []*base.Reservation `json:"reservations,omitempty"`
+	// Conditions restrict the rule to the entries matching all of them, e.g. the ones with the attachment region == "eu",
+	// the rule applies to all the entries if empty. The rule with Conditions only limits the concurrency of the matching entries.
+	Conditions []*base.MatchCondition `json:"conditions,omitempty"`
+	// Mode indicates whether the rule rejects the traffic (RuleModeEnforce, by default),
+	// or only records the would-be blocks without rejecting anything (RuleModeShadow).
+	Mode base.RuleMode `json:"mode,omitempty"`
+	// EffectiveFrom and ExpiresAt are the optional effective period of the rule in Unix milliseconds, 0 means unbounded.
+	// The rule takes effect in [EffectiveFrom, ExpiresAt), out of which it's kept loaded but ignored.
+	EffectiveFrom uint64 `json:"effectiveFrom,omitempty"`
+	ExpiresAt     uint64 `json:"expiresAt,omitempty"`
 }
 
 func (r *Rule) String() string {
 	b, err := json.Marshal(r)
 	if err != nil {
 		// Return the fallback string
-		return fmt.Sprintf("{Id=%s, Resource=%s, MetricType=%s, Threshold=%d}", r.ID, r.Resource, r.MetricType.String(), r.Threshold)
+		return fmt.Sprintf("{Id=%s, Resource=%s, ResourceMatchStrategy=%s, MetricType=%s, Threshold=%d, Schedule=%v, Reservations=%v, Conditions=%v, Mode=%s, EffectiveFrom=%d, ExpiresAt=%d}", r.ID, r.Resource, r.ResourceMatchStrategy, r.MetricType.String(), r.Threshold, r.Schedule, r.Reservations, r.Conditions, r.Mode, r.EffectiveFrom, r.ExpiresAt)
 	}
 	return string(b)
 }
@@ -18,44 +18,122 @@ import (
 	"reflect"
 	"sync"
 
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/event"
+	"github.com/alibaba/sentinel-golang/core/stat"
 	"github.com/alibaba/sentinel-golang/logging"
 	"github.com/alibaba/sentinel-golang/util"
 	"github.com/pkg/errors"
 )
 
-var (
-	ruleMap       = make(map[string][]*Rule)
-	rwMux         = &sync.RWMutex{}
-	currentRules  = make(map[string][]*Rule, 0)
-	updateRuleMux = new(sync.Mutex)
-)
+// RuleManager manages the isolation rules.
+// The package-level functions (e.g. LoadRules) operate on the default RuleManager.
+type RuleManager struct {
+	ruleMap map[string][]*Rule
+	// patternRuleMap holds the rules whose Resource is a pattern, keyed by the pattern.
+	patternRuleMap map[string][]*Rule
+	// patterns is the set of all the pattern rules in patternRuleMap, nil if there is no pattern rule.
+	patterns *patternRuleSet
+	// reserved holds the *reservedConcurrency of the rules with Reservations, keyed by ruleStateKey.
+	reserved sync.Map
+	// matched holds the *matchedConcurrency of the rules with Conditions, keyed by ruleStateKey.
+	matched sync.Map
+	// conditions holds the compiled *base.ConditionMatcher of the rules with Conditions, keyed by *Rule.
+	conditions sync.Map
+	// schedules holds the compiled *base.ThresholdSchedule of the rules with Schedule, keyed by *Rule.
+	schedules sync.Map
+	// inactiveRules holds the valid rules out of their effective period, keyed by the Resource.
+	inactiveRules map[string][]*Rule
+	rwMux         sync.RWMutex
+	currentRules  map[string][]*Rule
+	updateRuleMux sync.Mutex
+	// refresher rebuilds the rule maps once any rule becomes effective or expires.
+	refresher *base.RuleRefresher
+	// nodes holds the resource nodes read by the rules, and pins holds the nodes of the resources with the rules,
+	// so that the rules never read the shared overflow node of nodes.
+	nodes *stat.NodeStorage
+	pins  *stat.ResourceNodePins
+}
+
+var defaultRuleManager = NewRuleManager(stat.DefaultNodeStorage())
+
+// NewRuleManager creates an empty RuleManager, the rules of which read the concurrency of the resource nodes in nodes.
+func NewRuleManager(nodes *stat.NodeStorage) *RuleManager {
+	if nodes == nil {
+		nodes = stat.DefaultNodeStorage()
+	}
+	m := &RuleManager{
+		ruleMap:        make(map[string][]*Rule),
+		patternRuleMap: make(map[string][]*Rule),
+		inactiveRules:  make(map[string][]*Rule),
+		currentRules:   make(map[string][]*Rule, 0),
+		nodes:          nodes,
+		pins:           stat.NewResourceNodePins(nodes),
+	}
+	m.refresher = base.NewRuleRefresher(event.ModuleIsolation, &m.updateRuleMux, (*refreshableRules)(m))
+	return m
+}
+
+// DefaultRuleManager returns the RuleManager operated by the package-level functions.
+func DefaultRuleManager() *RuleManager {
+	return defaultRuleManager
+}
+
+func managerOrDefault(m *RuleManager) *RuleManager {
+	if m == nil {
+		return defaultRuleManager
+	}
+	return m
+}
 
 // LoadRules loads the given isolation rules to the rule manager, while all previous rules will be replaced.
 // the first returned value indicates whether do real load operation, if the rules is the same with previous rules, return false
 func LoadRules(rules []*Rule) (bool, error) {
-	resRulesMap := make(map[string][]*Rule, 16)
-	for _, rule := range rules {
-		resRules, exist := resRulesMap[rule.Resource]
-		if !exist {
-			resRules = make([]*Rule, 0, 1)
-		}
-		resRulesMap[rule.Resource] = append(resRules, rule)
-	}
+	return defaultRuleManager.LoadRules(rules)
+}
 
-	updateRuleMux.Lock()
-	defer updateRuleMux.Unlock()
-	isEqual := reflect.DeepEqual(currentRules, resRulesMap)
+// LoadRules loads the given isolation rules to the rule manager, while all previous rules will be replaced.
+func (m *RuleManager) LoadRules(rules []*Rule) (bool, error) {
+	resRulesMap := resRulesMapOf(rules)
+
+	m.updateRuleMux.Lock()
+	defer m.updateRuleMux.Unlock()
+	isEqual := reflect.DeepEqual(m.currentRules, resRulesMap)
 	if isEqual {
 		logging.Info("[Isolation] Load rules is the same with current rules, so ignore load operation.")
 		return false, nil
 	}
 
-	err := onRuleUpdate(resRulesMap)
+	err := m.onRuleUpdate(resRulesMap)
 	return true, err
 }
 
-func onRuleUpdate(rawResRulesMap map[string][]*Rule) (err error) {
+// ruleUpdate holds the rule maps built from the rules, which are swapped into the rule manager at once.
+type ruleUpdate struct {
+	m                *RuleManager
+	rawResRulesMap   map[string][]*Rule
+	validResRulesMap map[string][]*Rule
+	ruleMap          map[string][]*Rule
+	patternRuleMap   map[string][]*Rule
+	inactiveRules    map[string][]*Rule
+	now              uint64
+	start            uint64
+}
+
+func (m *RuleManager) onRuleUpdate(rawResRulesMap map[string][]*Rule) error {
+	u := m.buildRuleUpdate(rawResRulesMap)
+	u.Apply()
+	return nil
+}
+
+// buildRuleUpdate builds the rule maps of the valid rules without applying them.
+// It must be called with updateRuleMux held.
+func (m *RuleManager) buildRuleUpdate(rawResRulesMap map[string][]*Rule) *base.RuleUpdate {
 	validResRulesMap := make(map[string][]*Rule, len(rawResRulesMap))
+	newRuleMap := make(map[string][]*Rule, len(rawResRulesMap))
+	newPatternRuleMap := make(map[string][]*Rule)
+	newInactiveRules := make(map[string][]*Rule)
+	now := util.CurrentTimeMillis()
 	for res, rules := range rawResRulesMap {
 		validResRules := make([]*Rule, 0, len(rules))
 		for _, rule := range rules {
@@ -68,50 +146,106 @@ func onRuleUpdate(rawResRulesMap map[string][]*Rule) (err error) {
 		if len(validResRules) > 0 {
 			validResRulesMap[res] = validResRules
 		}
+		activeRules, inactiveRules := splitInactiveRules(validResRules, now)
+		if len(inactiveRules) > 0 {
+			newInactiveRules[res] = inactiveRules
+		}
+		exactRules, patternRules := splitPatternRules(activeRules)
+		if len(exactRules) > 0 {
+			newRuleMap[res] = exactRules
+		}
+		if len(patternRules) > 0 {
+			newPatternRuleMap[res] = patternRules
+		}
 	}
 
-	start := util.CurrentTimeNano()
-	rwMux.Lock()
-	ruleMap = validResRulesMap
-	rwMux.Unlock()
-	currentRules = rawResRulesMap
+	update := &ruleUpdate{
+		m:                m,
+		rawResRulesMap:   rawResRulesMap,
+		validResRulesMap: validResRulesMap,
+		ruleMap:          newRuleMap,
+		patternRuleMap:   newPatternRuleMap,
+		inactiveRules:    newInactiveRules,
+		now:              now,
+		start:            util.CurrentTimeNano(),
+	}
+	return base.NewRuleUpdate(&m.updateRuleMux, &m.rwMux, update.swap, update.complete)
+}
+
+// swap replaces the state read by the traffic with the built one, it must be called with the state locked.
+func (u *ruleUpdate) swap() {
+	u.m.ruleMap = u.ruleMap
+	u.m.patternRuleMap = u.patternRuleMap
+	u.m.patterns = newPatternRuleSet(u.patternRuleMap)
+	u.m.inactiveRules = u.inactiveRules
+	u.m.pinResourceNodes()
+}
 
-	logging.Debug("[Isolation onRuleUpdate] Time statistic(ns) for updating isolation rule", "timeCost", util.CurrentTimeNano()-start)
-	logRuleUpdate(validResRulesMap)
-	return
+// complete records the swapped rules as the current rules, it must be called with updateRuleMux held.
+func (u *ruleUpdate) complete() {
+	u.m.pruneRuleStates()
+	u.m.currentRules = u.rawResRulesMap
+	u.m.refresher.Schedule(u.now)
+
+	logging.Debug("[Isolation onRuleUpdate] Time statistic(ns) for updating isolation rule", "timeCost", util.CurrentTimeNano()-u.start)
+	logRuleUpdate(u.validResRulesMap)
+}
+
+func resRulesMapOf(rules []*Rule) map[string][]*Rule {
+	resRulesMap := make(map[string][]*Rule, 16)
+	for _, rule := range rules {
+		resRules, exist := resRulesMap[rule.Resource]
+		if !exist {
+			resRules = make([]*Rule, 0, 1)
+		}
+		resRulesMap[rule.Resource] = append(resRules, rule)
+	}
+	return resRulesMap
 }
 
 // LoadRulesOfResource loads the given resource's isolation rules to the rule manager, while all previous resource's rules will be replaced.
 // the first returned value indicates whether do real load operation, if the rules is the same with previous resource's rules, return false
 func LoadRulesOfResource(res string, rules []*Rule) (bool, error) {
+	return defaultRuleManager.LoadRulesOfResource(res, rules)
+}
+
+// LoadRulesOfResource loads the given resource's isolation rules to the rule manager, while all previous resource's rules will be replaced.
+func (m *RuleManager) LoadRulesOfResource(res string, rules []*Rule) (bool, error) {
 	if len(res) == 0 {
 		return false, errors.New("empty resource")
 	}
-	updateRuleMux.Lock()
-	defer updateRuleMux.Unlock()
+	m.updateRuleMux.Lock()
+	defer m.updateRuleMux.Unlock()
 	// clear resource rules
 	if len(rules) == 0 {
 		// clear resource's currentRules
-		delete(currentRules, res)
+		delete(m.currentRules, res)
 		// clear ruleMap
-		rwMux.Lock()
-		delete(ruleMap, res)
-		rwMux.Unlock()
+		m.rwMux.Lock()
+		delete(m.ruleMap, res)
+		delete(m.patternRuleMap, res)
+		// The pattern rule set caches the exact rules as well, so it's always rebuilt.
+		m.patterns = newPatternRuleSet(m.patternRuleMap)
+		delete(m.inactiveRules, res)
+		m.pinResourceNodes()
+		m.rwMux.Unlock()
+		m.refresher.Schedule(util.CurrentTimeMillis())
 		logging.Info("[Isolation] clear resource level rules", "resource", res)
+		publishRuleChange(res, nil)
 		return true, nil
 	}
 	// load resource level rules
-	isEqual := reflect.DeepEqual(currentRules[res], rules)
+	isEqual := reflect.DeepEqual(m.currentRules[res], rules)
 	if isEqual {
 		logging.Info("[Isolation] Load resource level rules is the same with current resource level rules, so ignore load operation.")
 		return false, nil
 	}
 
-	err := onResourceRuleUpdate(res, rules)
+	err := m.onResourceRuleUpdate(res, rules)
 	return true, err
 }
 
-func onResourceRuleUpdate(res string, rawResRules []*Rule) (err error) {
+func (m *RuleManager) onResourceRuleUpdate(res string, rawResRules []*Rule) (err error) {
 	validResRules := make([]*Rule, 0, len(rawResRules))
 	for _, rule := range rawResRules {
 		if err := IsValidRule(rule); err != nil {
@@ -122,35 +256,75 @@ func onResourceRuleUpdate(res string, rawResRules []*Rule) (err error) {
 	}
 
 	start := util.CurrentTimeNano()
-	rwMux.Lock()
-	if len(validResRules) == 0 {
-		delete(ruleMap, res)
+	now := util.CurrentTimeMillis()
+	activeRules, inactiveRules := splitInactiveRules(validResRules, now)
+	exactRules, patternRules := splitPatternRules(activeRules)
+	m.rwMux.Lock()
+	if len(exactRules) == 0 {
+		delete(m.ruleMap, res)
+	} else {
+		m.ruleMap[res] = exactRules
+	}
+	if len(patternRules) == 0 {
+		delete(m.patternRuleMap, res)
+	} else {
+		m.patternRuleMap[res] = patternRules
+	}
+	m.patterns = newPatternRuleSet(m.patternRuleMap)
+	if len(inactiveRules) == 0 {
+		delete(m.inactiveRules, res)
 	} else {
-		ruleMap[res] = validResRules
+		m.inactiveRules[res] = inactiveRules
 	}
-	rwMux.Unlock()
-	currentRules[res] = rawResRules
+	m.pinResourceNodes()
+	m.rwMux.Unlock()
+	m.pruneRuleStates()
+	m.currentRules[res] = rawResRules
+	m.refresher.Schedule(now)
 	logging.Debug("[Isolation onResourceRuleUpdate] Time statistic(ns) for updating isolation rule", "timeCost", util.CurrentTimeNano()-start)
 	logging.Info("[Isolation] load resource level rules", "resource", res, "validResRules", validResRules)
+	publishRuleChange(res, validResRules)
 	return nil
 }
 
 // ClearRules clears all the rules in isolation module.
 func ClearRules() error {
-	_, err := LoadRules(nil)
+	return defaultRuleManager.ClearRules()
+}
+
+// ClearRules clears all the rules of the rule manager.
+func (m *RuleManager) ClearRules() error {
+	_, err := m.LoadRules(nil)
 	return err
 }
 
+// Close stops refreshing the rules in background and clears all the rules of the rule manager,
+// which must not be used any more then.
+func (m *RuleManager) Close() error {
+	m.refresher.Stop()
+	return m.ClearRules()
+}
+
 // ClearRulesOfResource clears resource level rules in isolation module.
 func ClearRulesOfResource(res string) error {
-	_, err := LoadRulesOfResource(res, nil)
+	return defaultRuleManager.ClearRulesOfResource(res)
+}
+
+// ClearRulesOfResource clears resource level rules of the rule manager.
+func (m *RuleManager) ClearRulesOfResource(res string) error {
+	_, err := m.LoadRulesOfResource(res, nil)
 	return err
 }
 
 // GetRules returns all the rules based on copy.
 // It doesn't take effect for isolation module if user changes the rule.
 func GetRules() []Rule {
-	rules := getRules()
+	return defaultRuleManager.GetRules()
+}
+
+// GetRules returns all the rules of the rule manager based on copy.
+func (m *RuleManager) GetRules() []Rule {
+	rules := m.getRules()
 	ret := make([]Rule, 0, len(rules))
 	for _, rule := range rules {
 		ret = append(ret, *rule)
@@ -161,7 +335,12 @@ func GetRules() []Rule {
 // GetRulesOfResource returns specific resource's rules based on copy.
 // It doesn't take effect for isolation module if user changes the rule.
 func GetRulesOfResource(res string) []Rule {
-	rules := getRulesOfResource(res)
+	return defaultRuleManager.GetRulesOfResource(res)
+}
+
+// GetRulesOfResource returns specific resource's rules of the rule manager based on copy.
+func (m *RuleManager) GetRulesOfResource(res string) []Rule {
+	rules := m.getRulesOfResource(res)
 	ret := make([]Rule, 0, len(rules))
 	for _, rule := range rules {
 		ret = append(ret, *rule)
@@ -171,28 +350,85 @@ func GetRulesOfResource(res string) []Rule {
 
 // getRules returns all the rules。Any changes of rules take effect for isolation module
 // getRules is an internal interface.
-func getRules() []*Rule {
-	rwMux.RLock()
-	defer rwMux.RUnlock()
+func (m *RuleManager) getRules() []*Rule {
+	m.rwMux.RLock()
+	defer m.rwMux.RUnlock()
 
-	return rulesFrom(ruleMap)
+	rules := append(rulesFrom(m.ruleMap), rulesFrom(m.patternRuleMap)...)
+	return append(rules, rulesFrom(m.inactiveRules)...)
 }
 
 // getRulesOfResource returns specific resource's rules。Any changes of rules take effect for isolation module
 // getRulesOfResource is an internal interface.
-func getRulesOfResource(res string) []*Rule {
-	rwMux.RLock()
-	defer rwMux.RUnlock()
+// getRulesOfResource returns the rules of the resource, the Resource of which equals to res.
+// The returned slice is shared and must not be modified, it's never mutated by the rule manager
+// since the rules of a resource are always replaced by a new slice.
+func (m *RuleManager) getRulesOfResource(res string) []*Rule {
+	m.rwMux.RLock()
+	defer m.rwMux.RUnlock()
 
-	resRules, exist := ruleMap[res]
-	if !exist {
-		return nil
+	patternRules := m.patternRuleMap[res]
+	inactiveRules := m.inactiveRules[res]
+	if len(patternRules) == 0 && len(inactiveRules) == 0 {
+		return m.ruleMap[res]
 	}
-	ret := make([]*Rule, 0, len(resRules))
-	for _, r := range resRules {
-		ret = append(ret, r)
+	ret := make([]*Rule, 0, len(m.ruleMap[res])+len(patternRules)+len(inactiveRules))
+	return append(append(append(ret, m.ruleMap[res]...), patternRules...), inactiveRules...)
+}
+
+// getRulesFor returns the rules to check for the resource, including the matched pattern rules.
+// The returned slice is shared and must not be modified.
+func (m *RuleManager) getRulesFor(res string) []*Rule {
+	m.refresher.Check(util.CurrentTimeMillis())
+
+	m.rwMux.RLock()
+	rules := m.ruleMap[res]
+	patterns := m.patterns
+	m.rwMux.RUnlock()
+
+	if patterns == nil {
+		return rules
+	}
+	return patterns.rulesOf(res, rules)
+}
+
+// pinResourceNodes pins the nodes of the resources with the rules and unpins the others, it must be called with
+// rwMux locked. The resources matched by the pattern rules are unknown until the traffic comes, so they're not pinned.
+func (m *RuleManager) pinResourceNodes() {
+	resources := make(map[string]struct{}, len(m.ruleMap))
+	for res := range m.ruleMap {
+		resources[res] = struct{}{}
+	}
+	m.pins.PinOnly(resources)
+}
+
+// pruneRuleStates drops the states (e.g. the reserved concurrency) of the rules which are no longer loaded.
+// The entries in flight still release the dropped reserved concurrency on exit.
+func (m *RuleManager) pruneRuleStates() {
+	loaded := make(map[*Rule]struct{})
+	for _, rule := range m.getRules() {
+		loaded[rule] = struct{}{}
+	}
+	m.reserved.Range(func(key, _ interface{}) bool {
+		if _, ok := loaded[key.(ruleStateKey).rule]; !ok {
+			m.reserved.Delete(key)
+		}
+		return true
+	})
+	m.matched.Range(func(key, _ interface{}) bool {
+		if _, ok := loaded[key.(ruleStateKey).rule]; !ok {
+			m.matched.Delete(key)
+		}
+		return true
+	})
+	for _, states := range []*sync.Map{&m.schedules, &m.conditions} {
+		states.Range(func(key, _ interface{}) bool {
+			if _, ok := loaded[key.(*Rule)]; !ok {
+				states.Delete(key)
+			}
+			return true
+		})
 	}
-	return ret
 }
 
 func rulesFrom(m map[string][]*Rule) []*Rule {
@@ -217,6 +453,16 @@ func logRuleUpdate(m map[string][]*Rule) {
 	} else {
 		logging.Info("[IsolationRuleManager] Isolation rules were loaded", "rules", rs)
 	}
+	publishRuleChange("", rs)
+}
+
+// publishRuleChange publishes the valid rules loaded for the resource (all the resources if res is empty) to the event bus.
+func publishRuleChange(res string, rules []*Rule) {
+	sentinelRules := make([]base.SentinelRule, 0, len(rules))
+	for _, r := range rules {
+		sentinelRules = append(sentinelRules, r)
+	}
+	event.PublishRuleChange(event.ModuleIsolation, res, sentinelRules)
 }
 
 // IsValidRule checks whether the given Rule is valid.
@@ -227,11 +473,44 @@ func IsValidRule(r *Rule) error {
 	if len(r.Resource) == 0 {
 		return errors.New("empty resource of isolation rule")
 	}
+	if r.ResourceMatchStrategy.IsPattern() {
+		if _, err := base.NewResourceMatcher(r.ResourceMatchStrategy, r.Resource); err != nil {
+			return errors.Wrap(err, "invalid resource pattern of isolation rule")
+		}
+	}
 	if r.MetricType != Concurrency {
 		return errors.Errorf("unsupported metric type: %d", r.MetricType)
 	}
 	if r.Threshold == 0 {
 		return errors.New("zero threshold")
 	}
+	if err := base.IsValidEffectivePeriod(r.EffectiveFrom, r.ExpiresAt); err != nil {
+		return err
+	}
+	if _, err := base.NewThresholdSchedule(r.Schedule); err != nil {
+		return errors.Wrap(err, "invalid schedule of isolation rule")
+	}
+	if err := base.IsValidReservations(r.Reservations); err != nil {
+		return errors.Wrap(err, "invalid reservations of isolation rule")
+	}
+	if err := base.IsValidConditions(r.Conditions); err != nil {
+		return errors.Wrap(err, "invalid conditions of isolation rule")
+	}
 	return nil
 }
+
+// thresholdOf returns the threshold of the rule at present, which is overridden by the Schedule of the rule if any.
+func (m *RuleManager) thresholdOf(rule *Rule) uint32 {
+	if len(rule.Schedule) == 0 {
+		return rule.Threshold
+	}
+	v, ok := m.schedules.Load(rule)
+	if !ok {
+		schedule, err := base.NewThresholdSchedule(rule.Schedule)
+		if err != nil {
+			logging.Error(err, "Invalid Schedule of the rule in isolation.RuleManager.thresholdOf()", "rule", rule)
+		}
+		v, _ = m.schedules.LoadOrStore(rule, schedule)
+	}
+	return uint32(v.(*base.ThresholdSchedule).CurrentThreshold(float64(rule.Threshold)))
+}
@@ -16,14 +16,22 @@ package isolation
 
 import (
 	"testing"
+	"time"
 
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/config"
+	"github.com/alibaba/sentinel-golang/core/stat"
 	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/alibaba/sentinel-golang/util"
 	"github.com/stretchr/testify/assert"
 )
 
 func clearData() {
-	ruleMap = make(map[string][]*Rule)
-	currentRules = make(map[string][]*Rule, 0)
+	defaultRuleManager.ruleMap = make(map[string][]*Rule)
+	defaultRuleManager.patternRuleMap = make(map[string][]*Rule)
+	defaultRuleManager.patterns = nil
+	defaultRuleManager.inactiveRules = make(map[string][]*Rule)
+	defaultRuleManager.currentRules = make(map[string][]*Rule, 0)
 }
 
 func TestLoadRules(t *testing.T) {
@@ -46,11 +54,11 @@ func TestLoadRules(t *testing.T) {
 		}
 		_, err := LoadRules([]*Rule{r1, r2, r3})
 		assert.True(t, err == nil)
-		assert.True(t, len(ruleMap) == 2)
-		assert.True(t, len(ruleMap["abc1"]) == 1)
-		assert.True(t, ruleMap["abc1"][0] == r1)
-		assert.True(t, len(ruleMap["abc2"]) == 1)
-		assert.True(t, ruleMap["abc2"][0] == r2)
+		assert.True(t, len(defaultRuleManager.ruleMap) == 2)
+		assert.True(t, len(defaultRuleManager.ruleMap["abc1"]) == 1)
+		assert.True(t, defaultRuleManager.ruleMap["abc1"][0] == r1)
+		assert.True(t, len(defaultRuleManager.ruleMap["abc2"]) == 1)
+		assert.True(t, defaultRuleManager.ruleMap["abc2"][0] == r2)
 
 		clearData()
 	})
@@ -98,12 +106,12 @@ func TestLoadRules(t *testing.T) {
 
 		assert.True(t, ClearRules() == nil)
 
-		assert.True(t, len(ruleMap["abc1"]) == 0)
-		assert.True(t, len(currentRules["abc1"]) == 0)
-		assert.True(t, len(ruleMap["abc2"]) == 0)
-		assert.True(t, len(currentRules["abc2"]) == 0)
-		assert.True(t, len(ruleMap["abc3"]) == 0)
-		assert.True(t, len(currentRules["abc3"]) == 0)
+		assert.True(t, len(defaultRuleManager.ruleMap["abc1"]) == 0)
+		assert.True(t, len(defaultRuleManager.currentRules["abc1"]) == 0)
+		assert.True(t, len(defaultRuleManager.ruleMap["abc2"]) == 0)
+		assert.True(t, len(defaultRuleManager.currentRules["abc2"]) == 0)
+		assert.True(t, len(defaultRuleManager.ruleMap["abc3"]) == 0)
+		assert.True(t, len(defaultRuleManager.currentRules["abc3"]) == 0)
 		clearData()
 	})
 }
@@ -149,8 +157,8 @@ func TestLoadRulesOfResource(t *testing.T) {
 	t.Run("LoadRulesOfResource_clear", func(t *testing.T) {
 		succ, err = LoadRulesOfResource("abc1", []*Rule{})
 		assert.True(t, succ && err == nil)
-		assert.True(t, len(ruleMap["abc1"]) == 0 && len(currentRules["abc1"]) == 0)
-		assert.True(t, len(ruleMap["abc2"]) == 1 && len(currentRules["abc2"]) == 2)
+		assert.True(t, len(defaultRuleManager.ruleMap["abc1"]) == 0 && len(defaultRuleManager.currentRules["abc1"]) == 0)
+		assert.True(t, len(defaultRuleManager.ruleMap["abc2"]) == 1 && len(defaultRuleManager.currentRules["abc2"]) == 2)
 	})
 	clearData()
 }
@@ -179,15 +187,15 @@ func Test_ResourceRuleUpdate(t *testing.T) {
 
 		r111 := r1
 		r111.Threshold = 100
-		err = onResourceRuleUpdate("abc1", []*Rule{r111})
+		err = defaultRuleManager.onResourceRuleUpdate("abc1", []*Rule{r111})
 
 		assert.True(t, err == nil)
-		assert.True(t, len(ruleMap["abc1"]) == 1)
-		assert.True(t, len(currentRules["abc1"]) == 1)
-		assert.True(t, ruleMap["abc1"][0] == r111)
+		assert.True(t, len(defaultRuleManager.ruleMap["abc1"]) == 1)
+		assert.True(t, len(defaultRuleManager.currentRules["abc1"]) == 1)
+		assert.True(t, defaultRuleManager.ruleMap["abc1"][0] == r111)
 
-		assert.True(t, len(ruleMap["abc2"]) == 1)
-		assert.True(t, len(currentRules["abc2"]) == 1)
+		assert.True(t, len(defaultRuleManager.ruleMap["abc2"]) == 1)
+		assert.True(t, len(defaultRuleManager.currentRules["abc2"]) == 1)
 
 		clearData()
 	})
@@ -214,12 +222,123 @@ func Test_ResourceRuleUpdate(t *testing.T) {
 
 		assert.True(t, ClearRulesOfResource("abc1") == nil)
 
-		assert.True(t, len(ruleMap["abc1"]) == 0)
-		assert.True(t, len(currentRules["abc1"]) == 0)
-		assert.True(t, len(ruleMap["abc2"]) == 1)
-		assert.True(t, len(currentRules["abc2"]) == 1)
-		assert.True(t, len(ruleMap["abc3"]) == 0)
-		assert.True(t, len(currentRules["abc3"]) == 1)
+		assert.True(t, len(defaultRuleManager.ruleMap["abc1"]) == 0)
+		assert.True(t, len(defaultRuleManager.currentRules["abc1"]) == 0)
+		assert.True(t, len(defaultRuleManager.ruleMap["abc2"]) == 1)
+		assert.True(t, len(defaultRuleManager.currentRules["abc2"]) == 1)
+		assert.True(t, len(defaultRuleManager.ruleMap["abc3"]) == 0)
+		assert.True(t, len(defaultRuleManager.currentRules["abc3"]) == 1)
 		clearData()
 	})
 }
+
+func TestPatternRules(t *testing.T) {
+	m := NewRuleManager(stat.NewNodeStorage(nil))
+	r1 := &Rule{
+		Resource:              "rpc:",
+		ResourceMatchStrategy: base.ResourceMatchPrefix,
+		MetricType:            Concurrency,
+		Threshold:             10,
+	}
+	r2 := &Rule{
+		Resource:   "rpc:a",
+		MetricType: Concurrency,
+		Threshold:  5,
+	}
+	succ, err := m.LoadRules([]*Rule{r1, r2})
+	assert.True(t, succ && err == nil)
+	assert.Equal(t, 2, len(m.GetRules()))
+	assert.Equal(t, 1, len(m.GetRulesOfResource("rpc:")))
+	assert.Equal(t, 1, len(m.GetRulesOfResource("rpc:a")))
+
+	rulesA := m.getRulesFor("rpc:a")
+	assert.Equal(t, []*Rule{r2, r1}, rulesA)
+	assert.Equal(t, []*Rule{r1}, m.getRulesFor("rpc:b"))
+	assert.Equal(t, 0, len(m.getRulesFor("http:a")))
+	// The resolved rules are cached.
+	assert.True(t, &m.getRulesFor("rpc:a")[0] == &rulesA[0])
+
+	succ, err = m.LoadRulesOfResource("rpc:", nil)
+	assert.True(t, succ && err == nil)
+	assert.Nil(t, m.patterns)
+	assert.Equal(t, []*Rule{r2}, m.getRulesFor("rpc:a"))
+	assert.Equal(t, 0, len(m.getRulesFor("rpc:b")))
+
+	assert.Error(t, IsValidRule(&Rule{
+		Resource:              "rpc:(",
+		ResourceMatchStrategy: base.ResourceMatchRegex,
+		MetricType:            Concurrency,
+		Threshold:             10,
+	}))
+	assert.Error(t, IsValidRule(&Rule{
+		Resource:     "rpc:a",
+		MetricType:   Concurrency,
+		Threshold:    10,
+		Reservations: []*base.Reservation{{Flag: 1, Ratio: 1.5}},
+	}))
+}
+
+func TestRuleManager_PinResourceNodes(t *testing.T) {
+	conf := config.NewDefaultConfig()
+	conf.Sentinel.Stat.Resource.MaxCount = 1
+	nodes := stat.NewNodeStorage(conf)
+	m := NewRuleManager(nodes)
+	nodes.GetOrCreateResourceNode("other", base.ResTypeCommon)
+
+	_, err := m.LoadRules([]*Rule{
+		{Resource: "abc", MetricType: Concurrency, Threshold: 1},
+		{Resource: "rpc:", ResourceMatchStrategy: base.ResourceMatchPrefix, MetricType: Concurrency, Threshold: 1},
+	})
+	assert.Nil(t, err)
+	// The node of the resource with the rules is created regardless of the max amount of resources.
+	assert.Equal(t, map[string]struct{}{"abc": {}}, m.pins.Resources())
+	assert.True(t, nodes.GetResourceNode("abc") == nodes.GetOrCreateResourceNode("abc", base.ResTypeCommon))
+
+	// The resource matched by the pattern rule is accounted to the overflow node, which isn't read by the rule.
+	node := nodes.GetOrCreateResourceNode("rpc:a", base.ResTypeCommon)
+	assert.True(t, node == nodes.OverflowNode())
+	node.IncreaseConcurrency()
+	defer node.DecreaseConcurrency()
+	ctx := &base.EntryContext{
+		Resource: base.NewResourceWrapper("rpc:a", base.ResTypeCommon, base.Inbound),
+		StatNode: node,
+		Input:    &base.SentinelInput{BatchCount: 1},
+	}
+	passed, _, _ := checkPass(m, ctx)
+	assert.True(t, passed)
+
+	_, err = m.LoadRulesOfResource("abc", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(m.pins.Resources()))
+}
+
+func TestScheduledThreshold(t *testing.T) {
+	util.SetClock(util.NewMockClock())
+	defer util.SetClock(util.NewRealClock())
+
+	m := NewRuleManager(stat.NewNodeStorage(nil))
+	r := &Rule{
+		Resource:   "abc",
+		MetricType: Concurrency,
+		Threshold:  10,
+		Schedule:   []*base.ScheduledThreshold{{Start: "01:00", End: "05:00", Threshold: 50}},
+	}
+	succ, err := m.LoadRules([]*Rule{r})
+	assert.True(t, succ && err == nil)
+
+	now := util.CurrentClock().Now().UTC()
+	batchStart := time.Date(now.Year(), now.Month(), now.Day(), 2, 0, 0, 0, time.UTC)
+	if !batchStart.After(now) {
+		batchStart = batchStart.Add(24 * time.Hour)
+	}
+	util.Sleep(batchStart.Sub(now))
+	assert.Equal(t, uint32(50), m.thresholdOf(r))
+	util.Sleep(4 * time.Hour)
+	assert.Equal(t, uint32(10), m.thresholdOf(r))
+
+	// The compiled schedule of the unloaded rule is dropped.
+	succ, err = m.LoadRules(nil)
+	assert.True(t, succ && err == nil)
+	_, ok := m.schedules.Load(r)
+	assert.False(t, ok)
+}
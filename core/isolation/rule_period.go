@@ -0,0 +1,71 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package isolation
+
+import (
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/util"
+)
+
+// EffectivePeriod returns the effective period of the rule, see base.PeriodicRule.
+func (r *Rule) EffectivePeriod() (effectiveFrom, expiresAt uint64) {
+	return r.EffectiveFrom, r.ExpiresAt
+}
+
+// splitInactiveRules splits the rules into the ones taking effect at now and the ones out of their effective period.
+func splitInactiveRules(rules []*Rule, now uint64) (activeRules, inactiveRules []*Rule) {
+	activeRules = make([]*Rule, 0, len(rules))
+	for _, rule := range rules {
+		if base.IsRuleActiveAt(rule, now) {
+			activeRules = append(activeRules, rule)
+		} else {
+			inactiveRules = append(inactiveRules, rule)
+		}
+	}
+	return activeRules, inactiveRules
+}
+
+// refreshableRules adapts the RuleManager to base.RefreshableRules, see base.RuleRefresher.
+type refreshableRules RuleManager
+
+func (r *refreshableRules) PeriodicRules() []base.PeriodicRule {
+	rules := make([]base.PeriodicRule, 0, len(r.currentRules))
+	for _, rulesOfRes := range r.currentRules {
+		for _, rule := range rulesOfRes {
+			if rule != nil {
+				rules = append(rules, rule)
+			}
+		}
+	}
+	return rules
+}
+
+func (r *refreshableRules) ReloadRules() error {
+	m := (*RuleManager)(r)
+	return m.onRuleUpdate(m.currentRules)
+}
+
+// GetRuleStatuses returns the statuses of all the loaded rules, including the ones out of their effective period.
+func GetRuleStatuses() []base.RuleStatus {
+	return defaultRuleManager.GetRuleStatuses()
+}
+
+// GetRuleStatuses returns the statuses of all the loaded rules, including the ones out of their effective period.
+func (m *RuleManager) GetRuleStatuses() []base.RuleStatus {
+	rules := m.GetRules()
+	return base.RuleStatusesAt(len(rules), func(i int) base.PeriodicRule {
+		return &rules[i]
+	}, util.CurrentTimeMillis())
+}
@@ -22,13 +22,22 @@ import (
 
 const (
 	RuleCheckSlotOrder = 3000
+
+	blockMsg = "concurrency exceeds threshold"
 )
 
 var (
-	DefaultSlot = &Slot{}
+	DefaultSlot = NewSlot(defaultRuleManager)
 )
 
 type Slot struct {
+	// manager is the RuleManager to check the rules of, the default RuleManager is used if nil.
+	manager *RuleManager
+}
+
+// NewSlot creates a Slot checking the rules of the given RuleManager.
+func NewSlot(manager *RuleManager) *Slot {
+	return &Slot{manager: manager}
 }
 
 func (s *Slot) Order() uint32 {
@@ -41,34 +50,76 @@ func (s *Slot) Check(ctx *base.EntryContext) *base.TokenResult {
 	if len(resource) == 0 {
 		return result
 	}
-	if passed, rule, snapshot := checkPass(ctx); !passed {
-		msg := "concurrency exceeds threshold"
+	if passed, rule, snapshot := checkPass(managerOrDefault(s.manager), ctx); !passed {
 		if result == nil {
-			result = base.NewTokenResultBlockedWithCause(base.BlockTypeIsolation, msg, rule, snapshot)
+			result = base.NewTokenResultBlockedWithCause(base.BlockTypeIsolation, blockMsg, rule, snapshot)
 		} else {
-			result.ResetToBlockedWithCause(base.BlockTypeIsolation, msg, rule, snapshot)
+			result.ResetToBlockedWithCause(base.BlockTypeIsolation, blockMsg, rule, snapshot)
 		}
 	}
 	return result
 }
 
-func checkPass(ctx *base.EntryContext) (bool, *Rule, uint32) {
+func checkPass(m *RuleManager, ctx *base.EntryContext) (bool, *Rule, uint32) {
 	statNode := ctx.StatNode
 	batchCount := ctx.Input.BatchCount
+	res := ctx.Resource.Name()
 	curCount := uint32(0)
-	for _, rule := range getRulesOfResource(ctx.Resource.Name()) {
-		threshold := rule.Threshold
+	var (
+		toTake        []*reservedConcurrency
+		indexes       []int
+		toTakeMatched []*matchedConcurrency
+	)
+	for _, rule := range m.getRulesFor(res) {
+		if !m.appliesTo(rule, ctx.Input) {
+			continue
+		}
+		threshold := m.thresholdOf(rule)
 		if rule.MetricType == Concurrency {
-			if cur := statNode.CurrentConcurrency(); cur >= 0 {
+			var matched *matchedConcurrency
+			if len(rule.Conditions) > 0 {
+				// The rule with Conditions only limits the concurrency of the matching entries.
+				matched = m.matchedConcurrencyOf(res, rule)
+				curCount = matched.current()
+			} else if statNode == base.StatNode(m.nodes.OverflowNode()) {
+				// Only the resources matched by the pattern rules go without a pinned node, the shared overflow node
+				// accounts the resources beyond the max amount of resources rather than the resource, so the rule is skipped.
+				continue
+			} else if cur := statNode.CurrentConcurrency(); cur >= 0 {
 				curCount = uint32(cur)
 			} else {
 				curCount = 0
 				logging.Error(errors.New("negative concurrency"), "Negative concurrency in isolation.checkPass()", "rule", rule)
 			}
-			if curCount+batchCount > threshold {
+			var reserved *reservedConcurrency
+			index, unused := -1, 0.0
+			if len(rule.Reservations) > 0 {
+				reserved = m.reservedConcurrencyOf(res, rule)
+				index = base.MatchReservation(rule.Reservations, ctx.Input)
+				unused = reserved.unusedReserves(rule, threshold, index)
+			}
+			if float64(curCount+batchCount)+unused > float64(threshold) {
+				if rule.Mode.IsShadow() {
+					ctx.AddShadowBlock(base.NewBlockErrorWithCause(base.BlockTypeIsolation, blockMsg, rule, curCount))
+					continue
+				}
 				return false, rule, curCount
 			}
+			if index >= 0 {
+				toTake = append(toTake, reserved)
+				indexes = append(indexes, index)
+			}
+			if matched != nil {
+				toTakeMatched = append(toTakeMatched, matched)
+			}
 		}
 	}
+	// Take the reserved and the matched concurrency only if all the rules pass.
+	for i, reserved := range toTake {
+		reserved.take(ctx, indexes[i], batchCount)
+	}
+	for _, matched := range toTakeMatched {
+		matched.take(ctx, batchCount)
+	}
 	return true, nil, curCount
 }
@@ -15,6 +15,7 @@
 package metric
 
 import (
+	"io"
 	"sort"
 	"sync"
 	"time"
@@ -36,67 +37,126 @@ var (
 	// The timestamp of the last fetching. The time unit is ms (= second * 1000).
 	lastFetchTime int64 = -1
 	writeChan           = make(chan metricTimeMap, logFlushQueueSize)
-	stopChan            = make(chan struct{})
 
 	metricWriter MetricLogWriter
-	initOnce     sync.Once
+
+	// taskMux guards the lifecycle of the aggregating and writing tasks.
+	taskMux       sync.Mutex
+	taskStarted   bool
+	aggregateStop chan struct{}
+	writeStop     chan struct{}
+	aggregateWg   sync.WaitGroup
+	writeWg       sync.WaitGroup
 )
 
+// InitTask starts the metric log aggregating and writing tasks, it does nothing if the tasks have been started.
 func InitTask() (err error) {
-	initOnce.Do(func() {
-		flushInterval := config.MetricLogFlushIntervalSec()
-		if flushInterval == 0 {
-			return
-		}
+	taskMux.Lock()
+	defer taskMux.Unlock()
 
-		metricWriter, err = NewDefaultMetricLogWriter(config.MetricLogSingleFileMaxSize(), config.MetricLogMaxFileAmount())
-		if err != nil {
-			logging.Error(err, "Failed to initialize the MetricLogWriter in aggregator.InitTask()")
-			return
-		}
+	if taskStarted {
+		return nil
+	}
+	flushInterval := config.MetricLogFlushIntervalSec()
+	if flushInterval == 0 {
+		return nil
+	}
 
-		// Schedule the log flushing task
-		go util.RunWithRecover(writeTaskLoop)
-		// Schedule the log aggregating task
-		ticker := util.NewTicker(time.Duration(flushInterval) * time.Second)
-		go util.RunWithRecover(func() {
-			for {
-				select {
-				case <-ticker.C():
-					doAggregate()
-				case <-stopChan:
-					ticker.Stop()
-					return
-				}
+	metricWriter, err = NewDefaultMetricLogWriter(config.MetricLogSingleFileMaxSize(), config.MetricLogMaxFileAmount())
+	if err != nil {
+		logging.Error(err, "Failed to initialize the MetricLogWriter in aggregator.InitTask()")
+		return err
+	}
+
+	aggregateStop = make(chan struct{})
+	writeStop = make(chan struct{})
+	aggregateStopChan, writeStopChan := aggregateStop, writeStop
+	// Schedule the log flushing task
+	writeWg.Add(1)
+	go util.RunWithRecover(func() {
+		defer writeWg.Done()
+		writeTaskLoop(writeStopChan)
+	})
+	// Schedule the log aggregating task
+	ticker := util.NewTicker(time.Duration(flushInterval) * time.Second)
+	aggregateWg.Add(1)
+	go util.RunWithRecover(func() {
+		defer aggregateWg.Done()
+		for {
+			select {
+			case <-ticker.C():
+				doAggregate()
+			case <-aggregateStopChan:
+				ticker.Stop()
+				return
 			}
-		})
+		}
 	})
+	taskStarted = true
+	return nil
+}
+
+// StopTask stops the aggregating and writing tasks started by InitTask. The pending metric items are written
+// before the metric log writer is closed. InitTask could be called again to restart the tasks after StopTask.
+func StopTask() error {
+	taskMux.Lock()
+	defer taskMux.Unlock()
+
+	if !taskStarted {
+		return nil
+	}
+	// The aggregating task is stopped first, so that it never blocks on the write channel.
+	close(aggregateStop)
+	aggregateWg.Wait()
+	close(writeStop)
+	writeWg.Wait()
+	taskStarted = false
+
+	var err error
+	if c, ok := metricWriter.(io.Closer); ok {
+		err = c.Close()
+	}
+	metricWriter = nil
 	return err
 }
 
-func writeTaskLoop() {
+func writeTaskLoop(stop <-chan struct{}) {
 	for {
 		select {
 		case m := <-writeChan:
-			keys := make([]uint64, 0, len(m))
-			for t := range m {
-				keys = append(keys, t)
-			}
-			// Sort the time
-			sort.Slice(keys, func(i, j int) bool {
-				return keys[i] < keys[j]
-			})
-
-			for _, t := range keys {
-				err := metricWriter.Write(t, m[t])
-				if err != nil {
-					logging.Error(err, "[MetricAggregatorTask] fail tp write metric in aggregator.writeTaskLoop()")
+			writeMetrics(m)
+		case <-stop:
+			// Flush the pending metric items before exiting.
+			for {
+				select {
+				case m := <-writeChan:
+					writeMetrics(m)
+				default:
+					return
 				}
 			}
 		}
 	}
 }
 
+func writeMetrics(m metricTimeMap) {
+	keys := make([]uint64, 0, len(m))
+	for t := range m {
+		keys = append(keys, t)
+	}
+	// Sort the time
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i] < keys[j]
+	})
+
+	for _, t := range keys {
+		err := metricWriter.Write(t, m[t])
+		if err != nil {
+			logging.Error(err, "[MetricAggregatorTask] fail tp write metric in aggregator.writeTaskLoop()")
+		}
+	}
+}
+
 func doAggregate() {
 	curTime := util.CurrentTimeMillis()
 	curTime = curTime - curTime%1000
@@ -308,3 +308,22 @@ func Test_Aggregate(t *testing.T) {
 		assert.True(t, data2.items[0].Resource == "test")
 	})
 }
+
+func TestStopTask(t *testing.T) {
+	// Stop the task possibly started by the other cases.
+	assert.Nil(t, StopTask())
+	assert.Nil(t, StopTask())
+
+	m := &MockMetricLogWriter{make(chan *writeData, 100)}
+	metricWriter = m
+	writeChan <- metricTimeMap{2000: []*base.MetricItem{{Resource: "b"}}, 1000: []*base.MetricItem{{Resource: "a"}}}
+
+	// The pending metric items are flushed in order once stopped.
+	stop := make(chan struct{})
+	close(stop)
+	writeTaskLoop(stop)
+	assert.Equal(t, 2, len(m.dataChan))
+	assert.Equal(t, uint64(1000), (<-m.dataChan).ts)
+	assert.Equal(t, uint64(2000), (<-m.dataChan).ts)
+	metricWriter = nil
+}
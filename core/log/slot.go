@@ -15,17 +15,39 @@
 package log
 
 import (
+	"sync"
+	"sync/atomic"
+
 	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/alibaba/sentinel-golang/util"
 )
 
 const (
 	StatSlotOrder = 2000
+
+	// shadowLogIntervalMs limits the frequency of the shadow block log of each resource and block type.
+	shadowLogIntervalMs = 1000
 )
 
 var (
 	DefaultSlot = &Slot{}
+
+	// shadowLogStates holds the *shadowLogState of each shadowLogKey.
+	shadowLogStates sync.Map
 )
 
+type shadowLogKey struct {
+	resource  string
+	blockType base.BlockType
+}
+
+type shadowLogState struct {
+	lastLogMs uint64
+	// suppressed is the count of the shadow blocks not logged since the last log.
+	suppressed int64
+}
+
 type Slot struct {
 }
 
@@ -33,14 +55,44 @@ func (s *Slot) Order() uint32 {
 	return StatSlotOrder
 }
 
-func (s *Slot) OnEntryPassed(_ *base.EntryContext) {
-
+func (s *Slot) OnEntryPassed(ctx *base.EntryContext) {
+	s.logShadowBlocks(ctx)
 }
 
 func (s *Slot) OnEntryBlocked(ctx *base.EntryContext, blockError *base.BlockError) {
 	// TODO: write sentinel-block.log here
+	s.logShadowBlocks(ctx)
 }
 
 func (s *Slot) OnCompleted(_ *base.EntryContext) {
 
 }
+
+// logShadowBlocks logs the would-be blocks of the rules in shadow mode, tagged with mode=Shadow.
+// At most one entry is logged per resource and block type every shadowLogIntervalMs,
+// the count of the suppressed ones is carried by the next entry.
+func (s *Slot) logShadowBlocks(ctx *base.EntryContext) {
+	blocks := ctx.ShadowBlocks()
+	if len(blocks) == 0 {
+		return
+	}
+	res := ctx.Resource.Name()
+	now := util.CurrentTimeMillis()
+	for _, blockError := range blocks {
+		key := shadowLogKey{resource: res, blockType: blockError.BlockType()}
+		v, ok := shadowLogStates.Load(key)
+		if !ok {
+			v, _ = shadowLogStates.LoadOrStore(key, &shadowLogState{})
+		}
+		state := v.(*shadowLogState)
+		last := atomic.LoadUint64(&state.lastLogMs)
+		if (last != 0 && now < last+shadowLogIntervalMs) || !atomic.CompareAndSwapUint64(&state.lastLogMs, last, now) {
+			atomic.AddInt64(&state.suppressed, 1)
+			continue
+		}
+		logging.Info("[Sentinel] Rule in shadow mode would have blocked the entry", "mode", base.RuleModeShadow.String(),
+			"resource", res, "blockType", blockError.BlockType().String(), "blockMsg", blockError.BlockMsg(),
+			"rule", blockError.TriggeredRule(), "snapshotValue", blockError.TriggeredValue(),
+			"suppressed", atomic.SwapInt64(&state.suppressed, 0))
+	}
+}
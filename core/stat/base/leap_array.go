@@ -249,19 +249,37 @@ func (la *LeapArray) valuesWithTime(now uint64) []*BucketWrap {
 	return ret
 }
 
+// ForEachValue calls fn with every valid BucketWrap, it's the non-allocating version of Values for the hot path.
+func (la *LeapArray) ForEachValue(fn func(*BucketWrap)) {
+	la.forEachConditional(util.CurrentTimeMillis(), func(uint64) bool {
+		return true
+	}, fn)
+}
+
 func (la *LeapArray) ValuesConditional(now uint64, predicate base.TimePredicate) []*BucketWrap {
 	if now <= 0 {
 		return make([]*BucketWrap, 0)
 	}
 	ret := make([]*BucketWrap, 0, la.array.length)
+	la.forEachConditional(now, predicate, func(ww *BucketWrap) {
+		ret = append(ret, ww)
+	})
+	return ret
+}
+
+// forEachConditional calls fn with every valid BucketWrap satisfying the predicate,
+// it's the non-allocating version of ValuesConditional for the hot path.
+func (la *LeapArray) forEachConditional(now uint64, predicate base.TimePredicate, fn func(*BucketWrap)) {
+	if now <= 0 {
+		return
+	}
 	for i := 0; i < la.array.length; i++ {
 		ww := la.array.get(i)
 		if ww == nil || la.isBucketDeprecated(now, ww) || !predicate(atomic.LoadUint64(&ww.BucketStart)) {
 			continue
 		}
-		ret = append(ret, ww)
+		fn(ww)
 	}
-	return ret
 }
 
 // Judge whether the BucketWrap is expired
@@ -24,11 +24,16 @@ import (
 
 // MetricBucket represents the entity to record metrics per minimum time unit (i.e. the bucket time span).
 // Note that all operations of the MetricBucket are required to be thread-safe.
+// The counter and the RT histogram are striped to avoid the contention on a single cache line when
+// a hot resource is accessed by lots of goroutines in parallel, see StripedCounter.
 type MetricBucket struct {
 	// Value of statistic
-	counter        [base.MetricEventTotal]int64
+	counter        StripedCounter
 	minRt          int64
+	maxRt          int64
 	maxConcurrency int32
+	// rtHistogram records the distribution of RT, which is used to estimate the RT percentiles.
+	rtHistogram rtHistogram
 }
 
 func NewMetricBucket() *MetricBucket {
@@ -53,7 +58,7 @@ func (mb *MetricBucket) Add(event base.MetricEvent, count int64) {
 }
 
 func (mb *MetricBucket) addCount(event base.MetricEvent, count int64) {
-	atomic.AddInt64(&mb.counter[event], count)
+	mb.counter.Add(event, count)
 }
 
 // Get current statistic count of the given metric event.
@@ -62,14 +67,14 @@ func (mb *MetricBucket) Get(event base.MetricEvent) int64 {
 		logging.Error(errors.Errorf("Unknown metric event: %v", event), "")
 		return 0
 	}
-	return atomic.LoadInt64(&mb.counter[event])
+	return mb.counter.Sum(event)
 }
 
 func (mb *MetricBucket) reset() {
-	for i := 0; i < int(base.MetricEventTotal); i++ {
-		atomic.StoreInt64(&mb.counter[i], 0)
-	}
+	mb.counter.Reset()
 	atomic.StoreInt64(&mb.minRt, base.DefaultStatisticMaxRt)
+	atomic.StoreInt64(&mb.maxRt, 0)
+	mb.rtHistogram.reset()
 	atomic.StoreInt32(&mb.maxConcurrency, int32(0))
 }
 
@@ -79,12 +84,30 @@ func (mb *MetricBucket) AddRt(rt int64) {
 		// Might not be accurate here.
 		atomic.StoreInt64(&mb.minRt, rt)
 	}
+	for {
+		maxRt := atomic.LoadInt64(&mb.maxRt)
+		if rt <= maxRt || atomic.CompareAndSwapInt64(&mb.maxRt, maxRt, rt) {
+			break
+		}
+	}
+	mb.rtHistogram.add(rt)
 }
 
 func (mb *MetricBucket) MinRt() int64 {
 	return atomic.LoadInt64(&mb.minRt)
 }
 
+func (mb *MetricBucket) MaxRt() int64 {
+	return atomic.LoadInt64(&mb.maxRt)
+}
+
+// RtPercentile returns the estimated RT of the percentile p (in (0, 100]) in current bucket.
+func (mb *MetricBucket) RtPercentile(p float64) int64 {
+	var counts [rtHistogramSize]uint64
+	mb.rtHistogram.addTo(&counts)
+	return rtPercentileOf(&counts, p, mb.MaxRt())
+}
+
 func (mb *MetricBucket) UpdateConcurrency(concurrency int32) {
 	cc := concurrency
 	if cc > atomic.LoadInt32(&mb.maxConcurrency) {
@@ -27,7 +27,7 @@ func Test_metricBucket_MemSize(t *testing.T) {
 	mb := NewMetricBucket()
 	t.Log("mb:", mb)
 	size := unsafe.Sizeof(*mb)
-	if size != 56 {
+	if size != 336 {
 		t.Error("unexpect memory size of MetricBucket")
 	}
 }
@@ -73,6 +73,27 @@ func Test_metricBucket_Normal(t *testing.T) {
 	}
 }
 
+func Test_metricBucket_RtDistribution(t *testing.T) {
+	mb := NewMetricBucket()
+	assert.Equal(t, int64(0), mb.MaxRt())
+	assert.Equal(t, int64(0), mb.RtPercentile(99))
+
+	for i := int64(1); i <= 100; i++ {
+		mb.AddRt(i)
+	}
+	assert.Equal(t, int64(100), mb.MaxRt())
+	assert.Equal(t, int64(1), mb.MinRt())
+	p50 := mb.RtPercentile(50)
+	assert.True(t, p50 >= 50 && p50 <= 50*5/4, "p50: %d", p50)
+	p99 := mb.RtPercentile(99)
+	assert.True(t, p99 >= 99 && p99 <= 100, "p99: %d", p99)
+	assert.Equal(t, int64(100), mb.RtPercentile(100))
+
+	mb.reset()
+	assert.Equal(t, int64(0), mb.MaxRt())
+	assert.Equal(t, int64(0), mb.RtPercentile(50))
+}
+
 func Test_metricBucket_Concurrent(t *testing.T) {
 	mb := NewMetricBucket()
 	wg := &sync.WaitGroup{}
@@ -0,0 +1,172 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package base
+
+import (
+	"math"
+	"math/bits"
+	"sync/atomic"
+	"unsafe"
+)
+
+const (
+	// rtSubBucketBits indicates that every power-of-two range is split into 2^rtSubBucketBits linear sub-buckets,
+	// so the relative error of the recorded RT is no more than 1/2^rtSubBucketBits.
+	rtSubBucketBits  = 2
+	rtSubBucketCount = 1 << rtSubBucketBits
+	// rtLinearCount is the amount of the buckets holding the exact RT in [0, rtLinearCount).
+	rtLinearCount = rtSubBucketCount << 1
+	// rtHistogramSize covers the RT up to 2^17-1 ms, the larger RT is recorded in the last bucket.
+	rtHistogramSize = 64
+	// maxRtHistogramStripeCount is the upper bound of the amount of the striped cells of rtHistogram,
+	// which bounds the memory of a contended histogram to 1KB. It's less than maxStripeCount since
+	// each cell takes 4 cache lines, and the RT is recorded only once per entry.
+	maxRtHistogramStripeCount = 4
+)
+
+// rtHistogramCounts is the counts of every bucket of rtHistogram, the size (256 bytes) is a multiple of cacheLineSize.
+type rtHistogramCounts [rtHistogramSize]uint32
+
+// rtHistogram is a fixed-size and lock-free log-scale histogram of RT (in milliseconds).
+// The RT in [0, 8) is recorded exactly, and every power-of-two range above is split into 4 linear sub-buckets,
+// e.g. [8, 10), [10, 12), [12, 14), [14, 16), [16, 20), ...
+// Like StripedCounter, the updates are spread over the striped cells once contention is detected.
+type rtHistogram struct {
+	counts rtHistogramCounts
+	// cells is the pointer to []rtHistogramCounts, nil until contention is detected.
+	cells unsafe.Pointer
+}
+
+// rtHistogramIndex returns the index of the bucket that the rt belongs to.
+func rtHistogramIndex(rt int64) int {
+	if rt < rtLinearCount {
+		if rt < 0 {
+			return 0
+		}
+		return int(rt)
+	}
+	// exp is the exponent of the highest bit, which is no less than rtSubBucketBits+1.
+	exp := bits.Len64(uint64(rt)) - 1
+	sub := int(rt>>uint(exp-rtSubBucketBits)) & (rtSubBucketCount - 1)
+	idx := rtLinearCount + (exp-rtSubBucketBits-1)*rtSubBucketCount + sub
+	if idx >= rtHistogramSize {
+		return rtHistogramSize - 1
+	}
+	return idx
+}
+
+// rtHistogramUpperBound returns the max RT of the bucket of the index.
+func rtHistogramUpperBound(idx int) int64 {
+	if idx < rtLinearCount {
+		return int64(idx)
+	}
+	if idx >= rtHistogramSize-1 {
+		return math.MaxInt64
+	}
+	exp := (idx-rtLinearCount)/rtSubBucketCount + rtSubBucketBits + 1
+	sub := int64((idx - rtLinearCount) % rtSubBucketCount)
+	return ((rtSubBucketCount+sub+1)<<uint(exp-rtSubBucketBits) - 1)
+}
+
+func (h *rtHistogram) loadCells() []rtHistogramCounts {
+	p := atomic.LoadPointer(&h.cells)
+	if p == nil {
+		return nil
+	}
+	return *(*[]rtHistogramCounts)(p)
+}
+
+// rtHistogramStripeCount returns the amount of the striped cells of rtHistogram to allocate.
+func rtHistogramStripeCount() int {
+	if n := stripeCount(); n < maxRtHistogramStripeCount {
+		return n
+	}
+	return maxRtHistogramStripeCount
+}
+
+func (h *rtHistogram) expand() []rtHistogramCounts {
+	cells := make([]rtHistogramCounts, rtHistogramStripeCount())
+	if atomic.CompareAndSwapPointer(&h.cells, nil, unsafe.Pointer(&cells)) {
+		return cells
+	}
+	return h.loadCells()
+}
+
+func (h *rtHistogram) add(rt int64) {
+	idx := rtHistogramIndex(rt)
+	if cells := h.loadCells(); cells != nil {
+		atomic.AddUint32(&cells[stripeHash()&uint64(len(cells)-1)][idx], 1)
+		return
+	}
+	old := atomic.LoadUint32(&h.counts[idx])
+	if atomic.CompareAndSwapUint32(&h.counts[idx], old, old+1) {
+		return
+	}
+	cells := h.expand()
+	atomic.AddUint32(&cells[stripeHash()&uint64(len(cells)-1)][idx], 1)
+}
+
+// reset sets the counts to zero, the cells are zeroed in place, see StripedCounter.Reset.
+func (h *rtHistogram) reset() {
+	cells := h.loadCells()
+	for i := range h.counts {
+		atomic.StoreUint32(&h.counts[i], 0)
+		for j := range cells {
+			atomic.StoreUint32(&cells[j][i], 0)
+		}
+	}
+}
+
+// addTo accumulates the counts of the histogram to the given counts.
+func (h *rtHistogram) addTo(counts *[rtHistogramSize]uint64) {
+	cells := h.loadCells()
+	for i := range h.counts {
+		counts[i] += uint64(atomic.LoadUint32(&h.counts[i]))
+		for j := range cells {
+			counts[i] += uint64(atomic.LoadUint32(&cells[j][i]))
+		}
+	}
+}
+
+// rtPercentileOf returns the estimated RT of the percentile p (in (0, 100]) according to the histogram counts,
+// which is the upper bound of the bucket that the percentile falls in, and no more than maxRt.
+// It returns 0 if there is no RT recorded.
+func rtPercentileOf(counts *[rtHistogramSize]uint64, p float64, maxRt int64) int64 {
+	var total uint64
+	for _, c := range counts {
+		total += c
+	}
+	if total == 0 {
+		return 0
+	}
+	if p > 100 {
+		p = 100
+	}
+	rank := uint64(math.Ceil(float64(total) * p / 100))
+	if rank == 0 {
+		rank = 1
+	}
+	var seen uint64
+	for i, c := range counts {
+		seen += c
+		if seen >= rank {
+			if ub := rtHistogramUpperBound(i); ub < maxRt {
+				return ub
+			}
+			return maxRt
+		}
+	}
+	return maxRt
+}
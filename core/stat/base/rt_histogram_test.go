@@ -0,0 +1,66 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package base
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_rtHistogramIndex(t *testing.T) {
+	for rt := int64(0); rt < rtLinearCount; rt++ {
+		assert.Equal(t, int(rt), rtHistogramIndex(rt))
+	}
+	assert.Equal(t, 0, rtHistogramIndex(-1))
+	assert.Equal(t, rtHistogramSize-1, rtHistogramIndex(math.MaxInt64))
+
+	// Every RT falls in the bucket whose upper bound is no less than it, and the relative error is bounded.
+	prevIdx := 0
+	for rt := int64(0); rt < 1<<17; rt++ {
+		idx := rtHistogramIndex(rt)
+		assert.True(t, idx == prevIdx || idx == prevIdx+1, "rt: %d", rt)
+		prevIdx = idx
+		ub := rtHistogramUpperBound(idx)
+		assert.True(t, ub >= rt, "rt: %d", rt)
+		if idx < rtHistogramSize-1 && rt > 0 {
+			assert.True(t, float64(ub-rt)/float64(rt) <= 1.0/rtSubBucketCount, "rt: %d", rt)
+		}
+		if idx > 0 {
+			assert.True(t, rtHistogramUpperBound(idx-1) < rt, "rt: %d", rt)
+		}
+	}
+}
+
+func Test_rtPercentileOf(t *testing.T) {
+	h := &rtHistogram{}
+	counts := &[rtHistogramSize]uint64{}
+	h.addTo(counts)
+	assert.Equal(t, int64(0), rtPercentileOf(counts, 99, 0))
+
+	for i := 0; i < 99; i++ {
+		h.add(5)
+	}
+	h.add(1000)
+	counts = &[rtHistogramSize]uint64{}
+	h.addTo(counts)
+	assert.Equal(t, int64(5), rtPercentileOf(counts, 50, 1000))
+	assert.Equal(t, int64(5), rtPercentileOf(counts, 99, 1000))
+	assert.Equal(t, int64(1000), rtPercentileOf(counts, 99.5, 1000))
+	assert.Equal(t, int64(1000), rtPercentileOf(counts, 100, 1000))
+	// The result never exceeds the max RT.
+	assert.Equal(t, int64(900), rtPercentileOf(counts, 100, 900))
+}
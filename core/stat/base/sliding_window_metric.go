@@ -66,21 +66,22 @@ func (m *SlidingWindowMetric) getIntervalInSecond() float64 {
 	return float64(m.intervalInMs) / 1000.0
 }
 
-func (m *SlidingWindowMetric) count(event base.MetricEvent, values []*BucketWrap) int64 {
+// count sums the event of the buckets satisfying the predicate, the buckets are iterated in place without allocation.
+func (m *SlidingWindowMetric) count(event base.MetricEvent, now uint64, predicate base.TimePredicate) int64 {
 	ret := int64(0)
-	for _, ww := range values {
+	m.real.data.forEachConditional(now, predicate, func(ww *BucketWrap) {
 		mb := ww.Value.Load()
 		if mb == nil {
 			logging.Error(errors.New("nil BucketWrap"), "Current bucket value is nil in SlidingWindowMetric.count()")
-			continue
+			return
 		}
 		counter, ok := mb.(*MetricBucket)
 		if !ok {
 			logging.Error(errors.New("type assert failed"), "Fail to do type assert in SlidingWindowMetric.count()", "expectType", "*MetricBucket", "actualType", reflect.TypeOf(mb).Name())
-			continue
+			return
 		}
 		ret += counter.Get(event)
-	}
+	})
 	return ret
 }
 
@@ -89,8 +90,10 @@ func (m *SlidingWindowMetric) GetSum(event base.MetricEvent) int64 {
 }
 
 func (m *SlidingWindowMetric) getSumWithTime(now uint64, event base.MetricEvent) int64 {
-	satisfiedBuckets := m.getSatisfiedBuckets(now)
-	return m.count(event, satisfiedBuckets)
+	start, end := m.getBucketStartRange(now)
+	return m.count(event, now, func(ws uint64) bool {
+		return ws >= start && ws <= end
+	})
 }
 
 func (m *SlidingWindowMetric) GetQPS(event base.MetricEvent) float64 {
@@ -162,6 +165,44 @@ func (m *SlidingWindowMetric) MinRT() float64 {
 	return float64(minRt)
 }
 
+// MaxRT returns the max RT in the sliding window, 0 if there is no request completed.
+func (m *SlidingWindowMetric) MaxRT() float64 {
+	maxRt, _ := m.rtDistribution()
+	return float64(maxRt)
+}
+
+// Percentile returns the estimated RT of the percentile p (in (0, 100], e.g. 99 for P99) in the sliding window,
+// 0 if there is no request completed. The relative error of the estimation is no more than 25%.
+func (m *SlidingWindowMetric) Percentile(p float64) float64 {
+	maxRt, counts := m.rtDistribution()
+	return float64(rtPercentileOf(counts, p, maxRt))
+}
+
+// rtDistribution returns the max RT and the merged RT histogram counts of the sliding window.
+func (m *SlidingWindowMetric) rtDistribution() (int64, *[rtHistogramSize]uint64) {
+	now := util.CurrentTimeMillis()
+	satisfiedBuckets := m.getSatisfiedBuckets(now)
+	maxRt := int64(0)
+	counts := &[rtHistogramSize]uint64{}
+	for _, w := range satisfiedBuckets {
+		mb := w.Value.Load()
+		if mb == nil {
+			logging.Error(errors.New("nil BucketWrap"), "Current bucket value is nil in SlidingWindowMetric.rtDistribution()")
+			continue
+		}
+		counter, ok := mb.(*MetricBucket)
+		if !ok {
+			logging.Error(errors.New("type assert failed"), "Fail to do type assert in SlidingWindowMetric.rtDistribution()", "expectType", "*MetricBucket", "actualType", reflect.TypeOf(mb).Name())
+			continue
+		}
+		if v := counter.MaxRt(); v > maxRt {
+			maxRt = v
+		}
+		counter.rtHistogram.addTo(counts)
+	}
+	return maxRt, counts
+}
+
 func (m *SlidingWindowMetric) MaxConcurrency() int32 {
 	now := util.CurrentTimeMillis()
 	satisfiedBuckets := m.getSatisfiedBuckets(now)
@@ -222,6 +263,8 @@ func (m *SlidingWindowMetric) SecondMetricsOnCondition(predicate base.TimePredic
 func (m *SlidingWindowMetric) metricItemFromBuckets(ts uint64, ws []*BucketWrap) *base.MetricItem {
 	item := &base.MetricItem{Timestamp: ts}
 	var allRt int64 = 0
+	var maxRt int64 = 0
+	rtCounts := &[rtHistogramSize]uint64{}
 	for _, w := range ws {
 		mi := w.Value.Load()
 		if mi == nil {
@@ -242,12 +285,19 @@ func (m *SlidingWindowMetric) metricItemFromBuckets(ts uint64, ws []*BucketWrap)
 			item.Concurrency = mc
 		}
 		allRt += mb.Get(base.MetricEventRt)
+		if v := mb.MaxRt(); v > maxRt {
+			maxRt = v
+		}
+		mb.rtHistogram.addTo(rtCounts)
 	}
 	if item.CompleteQps > 0 {
 		item.AvgRt = uint64(allRt) / item.CompleteQps
 	} else {
 		item.AvgRt = uint64(allRt)
 	}
+	item.P50Rt = uint64(rtPercentileOf(rtCounts, 50, maxRt))
+	item.P99Rt = uint64(rtPercentileOf(rtCounts, 99, maxRt))
+	item.MaxRt = uint64(maxRt)
 	return item
 }
 
@@ -269,6 +319,9 @@ func (m *SlidingWindowMetric) metricItemFromBucket(w *BucketWrap) *base.MetricIt
 		ErrorQps:    uint64(mb.Get(base.MetricEventError)),
 		CompleteQps: uint64(completeQps),
 		Timestamp:   w.BucketStart,
+		P50Rt:       uint64(mb.RtPercentile(50)),
+		P99Rt:       uint64(mb.RtPercentile(99)),
+		MaxRt:       uint64(mb.MaxRt()),
 	}
 	if completeQps > 0 {
 		item.AvgRt = uint64(mb.Get(base.MetricEventRt) / completeQps)
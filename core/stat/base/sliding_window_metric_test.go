@@ -244,12 +244,36 @@ func TestAvgRT(t *testing.T) {
 	assert.True(t, util.Float64Equals(avgRT, 1.0))
 }
 
+func TestPercentileAndMaxRT(t *testing.T) {
+	got, err := NewSlidingWindowMetric(4, 2000, NewBucketLeapArray(SampleCount, IntervalInMs))
+	assert.True(t, err == nil && got != nil)
+	assert.True(t, util.Float64Equals(got.MaxRT(), 0))
+	assert.True(t, util.Float64Equals(got.Percentile(99), 0))
+
+	for i := 0; i < 98; i++ {
+		got.real.AddCount(base.MetricEventRt, 2)
+	}
+	got.real.AddCount(base.MetricEventRt, 300)
+	got.real.AddCount(base.MetricEventRt, 500)
+	assert.True(t, util.Float64Equals(got.MaxRT(), 500))
+	assert.True(t, util.Float64Equals(got.Percentile(50), 2))
+	p99 := got.Percentile(99)
+	assert.True(t, p99 >= 300 && p99 <= 300*5/4, "p99: %f", p99)
+	assert.True(t, util.Float64Equals(got.Percentile(100), 500))
+}
+
 func TestMetricItemFromBuckets(t *testing.T) {
 	got, err := NewSlidingWindowMetric(4, 2000, NewBucketLeapArray(SampleCount, IntervalInMs))
 	assert.True(t, err == nil && got != nil)
 	got.real.AddCount(base.MetricEventPass, 100)
+	got.real.AddCount(base.MetricEventRt, 10)
+	got.real.AddCount(base.MetricEventRt, 100)
 	item := got.metricItemFromBuckets(util.CurrentTimeMillis(), got.real.data.array.data)
 	assert.True(t, item.PassQps == 100)
+	// 10 falls in the histogram bucket [10, 12), the estimation is the upper bound of the bucket.
+	assert.Equal(t, uint64(11), item.P50Rt)
+	assert.Equal(t, uint64(100), item.P99Rt)
+	assert.Equal(t, uint64(100), item.MaxRt)
 }
 
 func TestMetricItemFromBucket(t *testing.T) {
@@ -136,3 +136,18 @@ func BenchmarkBucketLeapArray_Count_Concurrency1000(b *testing.B) {
 		}
 	})
 }
+
+// BenchmarkMetricBucket_Add_Parallel measures the contention of updating a single hot bucket
+// from all the CPUs, compare the results of different -cpu values.
+func BenchmarkMetricBucket_Add_Parallel(b *testing.B) {
+	mb := NewMetricBucket()
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			mb.Add(base.MetricEventPass, 1)
+			mb.Add(base.MetricEventComplete, 1)
+			mb.AddRt(5)
+		}
+	})
+}
@@ -0,0 +1,125 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package base
+
+import (
+	"runtime"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+)
+
+const (
+	// cacheLineSize is the assumed size of the CPU cache line, the striped cells are padded to it
+	// so that the cells updated by different CPUs never share a cache line.
+	cacheLineSize = 64
+	// maxStripeCount is the upper bound of the amount of the striped cells, which bounds the memory of a contended
+	// counter to 1KB. More cells hardly reduce the contention further, since the cells are picked by a hash.
+	maxStripeCount = 16
+
+	counterCellSize = int(base.MetricEventTotal) * 8
+)
+
+// stripeCount returns the amount of the striped cells to allocate, which is the power of two
+// no less than GOMAXPROCS and no more than maxStripeCount.
+func stripeCount() int {
+	procs := runtime.GOMAXPROCS(0)
+	n := 1
+	for n < procs && n < maxStripeCount {
+		n <<= 1
+	}
+	return n
+}
+
+// stripeHash returns a hash of the calling goroutine. There is no goroutine-local storage in Go,
+// so the address of a stack variable is used instead: the goroutines running in parallel have
+// distinct stacks, so they are likely to pick distinct cells.
+// The hash is a hint rather than an identity, the correctness never depends on it.
+func stripeHash() uint64 {
+	var marker byte
+	// Fibonacci hashing, the stack address is shifted to drop the bits varying with the call depth.
+	return (uint64(uintptr(unsafe.Pointer(&marker))>>12) * 0x9E3779B97F4A7C15) >> 32
+}
+
+type counterCell struct {
+	counts [base.MetricEventTotal]int64
+	_      [cacheLineSize - counterCellSize%cacheLineSize]byte
+}
+
+// StripedCounter is a contention-free counter of all the metric events, in the manner of java.util.concurrent.atomic.LongAdder.
+// All the updates go to the base counts until contention is detected (i.e. a CAS on the base counts fails),
+// then the following updates are spread over a set of cache-line padded cells, and the counts are summed on read.
+// The cells are allocated lazily, so the counter updated by a single goroutine costs no more than a plain atomic counter.
+// The zero value is ready to use.
+type StripedCounter struct {
+	// baseCounts is kept at the head of struct to be 64-bit aligned for atomic operations on 32-bit platforms.
+	baseCounts [base.MetricEventTotal]int64
+	// cells is the pointer to []counterCell, nil until contention is detected.
+	cells unsafe.Pointer
+}
+
+func (c *StripedCounter) loadCells() []counterCell {
+	p := atomic.LoadPointer(&c.cells)
+	if p == nil {
+		return nil
+	}
+	return *(*[]counterCell)(p)
+}
+
+// expand allocates the cells if absent, and returns the cells.
+func (c *StripedCounter) expand() []counterCell {
+	cells := make([]counterCell, stripeCount())
+	if atomic.CompareAndSwapPointer(&c.cells, nil, unsafe.Pointer(&cells)) {
+		return cells
+	}
+	return c.loadCells()
+}
+
+// Add adds count to the counts of the event. The caller must guarantee the event is valid.
+func (c *StripedCounter) Add(event base.MetricEvent, count int64) {
+	if cells := c.loadCells(); cells != nil {
+		atomic.AddInt64(&cells[stripeHash()&uint64(len(cells)-1)].counts[event], count)
+		return
+	}
+	old := atomic.LoadInt64(&c.baseCounts[event])
+	if atomic.CompareAndSwapInt64(&c.baseCounts[event], old, old+count) {
+		return
+	}
+	cells := c.expand()
+	atomic.AddInt64(&cells[stripeHash()&uint64(len(cells)-1)].counts[event], count)
+}
+
+// Sum returns the counts of the event. The caller must guarantee the event is valid.
+// The updates concurrent with Sum might or might not be included.
+func (c *StripedCounter) Sum(event base.MetricEvent) int64 {
+	sum := atomic.LoadInt64(&c.baseCounts[event])
+	for i, cells := 0, c.loadCells(); i < len(cells); i++ {
+		sum += atomic.LoadInt64(&cells[i].counts[event])
+	}
+	return sum
+}
+
+// Reset sets the counts of all the events to zero. The cells already allocated are zeroed in place rather than
+// released, so that resetting a contended counter never allocates, and the memory of them is bounded by maxStripeCount.
+func (c *StripedCounter) Reset() {
+	cells := c.loadCells()
+	for e := 0; e < int(base.MetricEventTotal); e++ {
+		atomic.StoreInt64(&c.baseCounts[e], 0)
+		for i := range cells {
+			atomic.StoreInt64(&cells[i].counts[e], 0)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package base
+
+import (
+	"sync"
+	"testing"
+	"unsafe"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_stripeCount(t *testing.T) {
+	n := stripeCount()
+	assert.True(t, n >= 1 && n <= maxStripeCount)
+	assert.Equal(t, 0, n&(n-1), "the stripe count must be a power of two")
+}
+
+func TestStripedCounter_Uncontended(t *testing.T) {
+	c := &StripedCounter{}
+	c.Add(base.MetricEventPass, 3)
+	c.Add(base.MetricEventPass, 2)
+	c.Add(base.MetricEventBlock, 1)
+	assert.Equal(t, int64(5), c.Sum(base.MetricEventPass))
+	assert.Equal(t, int64(1), c.Sum(base.MetricEventBlock))
+	assert.Equal(t, int64(0), c.Sum(base.MetricEventRt))
+	// No contention, no cells.
+	assert.Nil(t, c.loadCells())
+
+	c.Reset()
+	assert.Equal(t, int64(0), c.Sum(base.MetricEventPass))
+	assert.Equal(t, int64(0), c.Sum(base.MetricEventBlock))
+}
+
+func TestStripedCounter_Striped(t *testing.T) {
+	c := &StripedCounter{}
+	c.Add(base.MetricEventPass, 10)
+	// Simulate the contention, the following updates go to the cells.
+	cells := c.expand()
+	assert.Equal(t, stripeCount(), len(cells))
+	assert.Equal(t, cacheLineSize, int(unsafe.Sizeof(counterCell{})))
+
+	const goroutines, times = 16, 10000
+	wg := &sync.WaitGroup{}
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < times; j++ {
+				c.Add(base.MetricEventPass, 1)
+				c.Add(base.MetricEventRt, 2)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int64(10+goroutines*times), c.Sum(base.MetricEventPass))
+	assert.Equal(t, int64(2*goroutines*times), c.Sum(base.MetricEventRt))
+
+	c.Reset()
+	assert.Equal(t, int64(0), c.Sum(base.MetricEventPass))
+	assert.Equal(t, int64(0), c.Sum(base.MetricEventRt))
+	// The cells are zeroed in place, so that resetting allocates nothing.
+	assert.Equal(t, len(cells), len(c.loadCells()))
+	assert.Equal(t, float64(0), testing.AllocsPerRun(100, func() {
+		c.Add(base.MetricEventPass, 1)
+		c.Reset()
+	}))
+}
+
+func Test_rtHistogram_Striped(t *testing.T) {
+	h := &rtHistogram{}
+	h.add(5)
+	cells := h.expand()
+	assert.True(t, len(cells) >= 1 && len(cells) <= maxRtHistogramStripeCount)
+	wg := &sync.WaitGroup{}
+	wg.Add(8)
+	for i := 0; i < 8; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				h.add(5)
+			}
+		}()
+	}
+	wg.Wait()
+	counts := &[rtHistogramSize]uint64{}
+	h.addTo(counts)
+	assert.Equal(t, uint64(8001), counts[rtHistogramIndex(5)])
+
+	h.reset()
+	assert.Equal(t, len(cells), len(h.loadCells()))
+	counts = &[rtHistogramSize]uint64{}
+	h.addTo(counts)
+	assert.Equal(t, uint64(0), counts[rtHistogramIndex(5)])
+}
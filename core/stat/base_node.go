@@ -22,7 +22,18 @@ import (
 	sbase "github.com/alibaba/sentinel-golang/core/stat/base"
 )
 
+// blockTypeCount is the amount of the built-in block types, the blocks of other types are counted as BlockTypeUnknown.
+const blockTypeCount = int(base.BlockTypeHotSpotParamFlow) + 1
+
 type BaseStatNode struct {
+	// totalCounts are the cumulative counts of each metric event since the node was created.
+	// The 64-bit fields are kept at the head of struct to be 64-bit aligned for atomic operations on 32-bit platforms.
+	totalCounts sbase.StripedCounter
+	// totalBlocks are the cumulative block counts of each block type since the node was created.
+	totalBlocks [blockTypeCount]int64
+	// totalShadowBlocks are the cumulative would-be block counts of each block type triggered by the rules in shadow mode.
+	totalShadowBlocks [blockTypeCount]int64
+
 	sampleCount uint32
 	intervalMs  uint32
 
@@ -66,6 +77,51 @@ func (n *BaseStatNode) GetMaxAvg(event base.MetricEvent) float64 {
 
 func (n *BaseStatNode) AddCount(event base.MetricEvent, count int64) {
 	n.arr.AddCount(event, count)
+	if event >= 0 && event < base.MetricEventTotal {
+		n.totalCounts.Add(event, count)
+	}
+}
+
+// TotalCount returns the cumulative count of the event since the node was created,
+// which never decreases and is suitable for exporting as a counter.
+func (n *BaseStatNode) TotalCount(event base.MetricEvent) int64 {
+	if event < 0 || event >= base.MetricEventTotal {
+		return 0
+	}
+	return n.totalCounts.Sum(event)
+}
+
+// AddBlockCount records the blocked requests of the block type.
+func (n *BaseStatNode) AddBlockCount(blockType base.BlockType, count int64) {
+	if int(blockType) >= blockTypeCount {
+		blockType = base.BlockTypeUnknown
+	}
+	atomic.AddInt64(&n.totalBlocks[blockType], count)
+}
+
+// TotalBlockCount returns the cumulative block count of the block type since the node was created.
+func (n *BaseStatNode) TotalBlockCount(blockType base.BlockType) int64 {
+	if int(blockType) >= blockTypeCount {
+		return 0
+	}
+	return atomic.LoadInt64(&n.totalBlocks[blockType])
+}
+
+// AddShadowBlockCount records the requests which would have been blocked by the rules in shadow mode.
+func (n *BaseStatNode) AddShadowBlockCount(blockType base.BlockType, count int64) {
+	if int(blockType) >= blockTypeCount {
+		blockType = base.BlockTypeUnknown
+	}
+	atomic.AddInt64(&n.totalShadowBlocks[blockType], count)
+}
+
+// TotalShadowBlockCount returns the cumulative would-be block count of the block type in shadow mode
+// since the node was created.
+func (n *BaseStatNode) TotalShadowBlockCount(blockType base.BlockType) int64 {
+	if int(blockType) >= blockTypeCount {
+		return 0
+	}
+	return atomic.LoadInt64(&n.totalShadowBlocks[blockType])
 }
 
 func (n *BaseStatNode) UpdateConcurrency(concurrency int32) {
@@ -84,6 +140,16 @@ func (n *BaseStatNode) MinRT() float64 {
 	return float64(n.metric.MinRT())
 }
 
+// MaxRT returns the max RT in the default sliding window.
+func (n *BaseStatNode) MaxRT() float64 {
+	return n.metric.MaxRT()
+}
+
+// Percentile returns the estimated RT of the percentile p (in (0, 100], e.g. 99 for P99) in the default sliding window.
+func (n *BaseStatNode) Percentile(p float64) float64 {
+	return n.metric.Percentile(p)
+}
+
 func (n *BaseStatNode) MaxConcurrency() int32 {
 	return n.metric.MaxConcurrency()
 }
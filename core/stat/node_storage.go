@@ -16,67 +16,301 @@ package stat
 
 import (
 	"sync"
+	"sync/atomic"
 
 	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/config"
 	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/alibaba/sentinel-golang/util"
+)
+
+const (
+	// evictScanIntervalMs limits the frequency of scanning the idle nodes, since the scan is O(n) under the write lock.
+	evictScanIntervalMs = 1000
+	// overflowWarnIntervalMs limits the frequency of the warning once the max amount of resources is reached.
+	overflowWarnIntervalMs = 60000
 )
 
 type ResourceNodeMap map[string]*ResourceNode
 
-var (
-	inboundNode = NewResourceNode(base.TotalInBoundResourceName, base.ResTypeCommon)
+// NodeStorage holds the statistic nodes of resources, as well as the inbound node and the overflow node.
+// The package-level functions (e.g. GetOrCreateResourceNode) operate on the default NodeStorage.
+type NodeStorage struct {
+	// The 64-bit fields are kept at the head of struct to be 64-bit aligned for atomic operations on 32-bit platforms.
+	// overflowCount is the cumulative count of the accesses accounted to the overflow node.
+	overflowCount uint64
+	// evictedCount is the cumulative count of the evicted idle nodes.
+	evictedCount       uint64
+	lastEvictScanMs    uint64
+	lastOverflowWarnMs uint64
 
-	resNodeMap = make(ResourceNodeMap)
-	rnsMux     = new(sync.RWMutex)
-)
+	// conf provides the limit of resources, the global config is used if nil.
+	conf *config.Entity
+
+	inboundNode *ResourceNode
+	// overflowNode accounts the statistics of all the resources beyond the max amount of resources.
+	overflowNode *ResourceNode
+
+	resNodeMap ResourceNodeMap
+	rnsMux     sync.RWMutex
+}
+
+var defaultNodeStorage = NewNodeStorage(nil)
+
+// NewNodeStorage creates an empty NodeStorage limited by the resource stat config of conf.
+// If conf is nil, the global config is used, which is the case of the default NodeStorage.
+func NewNodeStorage(conf *config.Entity) *NodeStorage {
+	return &NodeStorage{
+		conf:         conf,
+		inboundNode:  NewResourceNode(base.TotalInBoundResourceName, base.ResTypeCommon),
+		overflowNode: NewResourceNode(base.OverflowResourceName, base.ResTypeCommon),
+		resNodeMap:   make(ResourceNodeMap),
+	}
+}
+
+// DefaultNodeStorage returns the NodeStorage operated by the package-level functions.
+func DefaultNodeStorage() *NodeStorage {
+	return defaultNodeStorage
+}
+
+func storageOrDefault(s *NodeStorage) *NodeStorage {
+	if s == nil {
+		return defaultNodeStorage
+	}
+	return s
+}
 
 // InboundNode returns the global inbound statistic node.
 func InboundNode() *ResourceNode {
-	return inboundNode
+	return defaultNodeStorage.InboundNode()
+}
+
+// OverflowNode returns the shared node accounting the resources beyond the max amount of resources.
+func OverflowNode() *ResourceNode {
+	return defaultNodeStorage.OverflowNode()
+}
+
+// ResourceOverflowCount returns the cumulative count of the accesses accounted to the overflow node,
+// i.e. the accesses to the new resources rejected by the max amount of resources.
+func ResourceOverflowCount() uint64 {
+	return defaultNodeStorage.ResourceOverflowCount()
+}
+
+// EvictedResourceCount returns the cumulative count of the idle resource nodes evicted.
+func EvictedResourceCount() uint64 {
+	return defaultNodeStorage.EvictedResourceCount()
 }
 
 // ResourceNodeList returns the slice of all existing resource nodes.
+// The overflow node is included once any resource is accounted to it.
 func ResourceNodeList() []*ResourceNode {
-	rnsMux.RLock()
-	defer rnsMux.RUnlock()
+	return defaultNodeStorage.ResourceNodeList()
+}
+
+func GetResourceNode(resource string) *ResourceNode {
+	return defaultNodeStorage.GetResourceNode(resource)
+}
+
+// GetOrCreateResourceNode returns the node of the resource, the node is created if absent.
+// The amount of resources is unbounded unless the max amount of resources is configured, once it reaches
+// config.MaxResourceAmount(), the new resource is handled by config.ResourceOverflowPolicy():
+// either the idle nodes are evicted to make room for it, or the shared overflow node is returned instead.
+func GetOrCreateResourceNode(resource string, resourceType base.ResourceType) *ResourceNode {
+	return defaultNodeStorage.GetOrCreateResourceNode(resource, resourceType)
+}
+
+// GetOrCreatePinnedResourceNode returns the node of the resource like GetOrCreateResourceNode, but the node is
+// created regardless of the max amount of resources and is never evicted until it's unpinned. It's used by the rules
+// holding the node (e.g. the flow rules reusing the statistic of the resource), which must keep sharing the node with
+// the entries. Each call must be paired with an UnpinResourceNode once the node is no longer held.
+func GetOrCreatePinnedResourceNode(resource string, resourceType base.ResourceType) *ResourceNode {
+	return defaultNodeStorage.GetOrCreatePinnedResourceNode(resource, resourceType)
+}
+
+// UnpinResourceNode releases a pin of GetOrCreatePinnedResourceNode, the node could be evicted once all its pins
+// are released.
+func UnpinResourceNode(resource string) {
+	defaultNodeStorage.UnpinResourceNode(resource)
+}
 
-	list := make([]*ResourceNode, 0, len(resNodeMap))
-	for _, v := range resNodeMap {
+func ResetResourceNodeMap() {
+	defaultNodeStorage.Reset()
+}
+
+// InboundNode returns the inbound statistic node of the storage.
+func (s *NodeStorage) InboundNode() *ResourceNode {
+	return s.inboundNode
+}
+
+// OverflowNode returns the shared node accounting the resources beyond the max amount of resources.
+func (s *NodeStorage) OverflowNode() *ResourceNode {
+	return s.overflowNode
+}
+
+// ResourceOverflowCount returns the cumulative count of the accesses accounted to the overflow node.
+func (s *NodeStorage) ResourceOverflowCount() uint64 {
+	return atomic.LoadUint64(&s.overflowCount)
+}
+
+// EvictedResourceCount returns the cumulative count of the idle resource nodes evicted.
+func (s *NodeStorage) EvictedResourceCount() uint64 {
+	return atomic.LoadUint64(&s.evictedCount)
+}
+
+// ResourceNodeList returns the slice of all existing resource nodes.
+// The overflow node is included once any resource is accounted to it.
+func (s *NodeStorage) ResourceNodeList() []*ResourceNode {
+	s.rnsMux.RLock()
+	defer s.rnsMux.RUnlock()
+
+	list := make([]*ResourceNode, 0, len(s.resNodeMap)+1)
+	for _, v := range s.resNodeMap {
 		list = append(list, v)
 	}
+	if atomic.LoadUint64(&s.overflowCount) > 0 {
+		list = append(list, s.overflowNode)
+	}
 	return list
 }
 
-func GetResourceNode(resource string) *ResourceNode {
-	rnsMux.RLock()
-	defer rnsMux.RUnlock()
+func (s *NodeStorage) GetResourceNode(resource string) *ResourceNode {
+	s.rnsMux.RLock()
+	defer s.rnsMux.RUnlock()
 
-	return resNodeMap[resource]
+	return s.resNodeMap[resource]
 }
 
-func GetOrCreateResourceNode(resource string, resourceType base.ResourceType) *ResourceNode {
-	node := GetResourceNode(resource)
+// GetOrCreateResourceNode returns the node of the resource, the node is created if absent.
+// See the package-level GetOrCreateResourceNode for the handling of the max amount of resources.
+func (s *NodeStorage) GetOrCreateResourceNode(resource string, resourceType base.ResourceType) *ResourceNode {
+	now := util.CurrentTimeMillis()
+	s.rnsMux.RLock()
+	node := s.resNodeMap[resource]
+	full := len(s.resNodeMap) >= int(s.MaxResourceAmount())
+	s.rnsMux.RUnlock()
 	if node != nil {
+		node.touch(now)
 		return node
 	}
-	rnsMux.Lock()
-	defer rnsMux.Unlock()
+	// Fast path of the overflow, which avoids competing for the write lock.
+	if full && !s.shouldScanIdleNodes(now) {
+		return s.onOverflow(resource, now)
+	}
+
+	s.rnsMux.Lock()
+	defer s.rnsMux.Unlock()
 
-	node = resNodeMap[resource]
+	node = s.resNodeMap[resource]
 	if node != nil {
 		return node
 	}
-
-	if len(resNodeMap) >= int(base.DefaultMaxResourceAmount) {
+	if len(s.resNodeMap) >= int(s.MaxResourceAmount()) && s.evictIdleNodes(now) == 0 {
+		return s.onOverflow(resource, now)
+	}
+	if len(s.resNodeMap) >= int(base.DefaultMaxResourceAmount) {
 		logging.Warn("[GetOrCreateResourceNode] Resource amount exceeds the threshold", "maxResourceAmount", base.DefaultMaxResourceAmount)
 	}
 	node = NewResourceNode(resource, resourceType)
-	resNodeMap[resource] = node
+	s.resNodeMap[resource] = node
 	return node
 }
 
-func ResetResourceNodeMap() {
-	rnsMux.Lock()
-	defer rnsMux.Unlock()
-	resNodeMap = make(ResourceNodeMap)
+// GetOrCreatePinnedResourceNode returns the node of the resource, the node is created regardless of
+// the max amount of resources and is never evicted until all its pins are released by UnpinResourceNode.
+func (s *NodeStorage) GetOrCreatePinnedResourceNode(resource string, resourceType base.ResourceType) *ResourceNode {
+	s.rnsMux.Lock()
+	defer s.rnsMux.Unlock()
+
+	node := s.resNodeMap[resource]
+	if node == nil {
+		node = NewResourceNode(resource, resourceType)
+		s.resNodeMap[resource] = node
+	}
+	node.pins++
+	return node
+}
+
+// UnpinResourceNode releases a pin of GetOrCreatePinnedResourceNode. The node becomes evictable once all its pins
+// are released, and it's regarded as idle only after the idle timeout since then.
+func (s *NodeStorage) UnpinResourceNode(resource string) {
+	s.rnsMux.Lock()
+	defer s.rnsMux.Unlock()
+
+	node := s.resNodeMap[resource]
+	if node == nil || node.pins == 0 {
+		return
+	}
+	node.pins--
+	if node.pins == 0 {
+		node.touch(util.CurrentTimeMillis())
+	}
+}
+
+// Reset removes all the resource nodes.
+func (s *NodeStorage) Reset() {
+	s.rnsMux.Lock()
+	defer s.rnsMux.Unlock()
+	s.resNodeMap = make(ResourceNodeMap)
+	atomic.StoreUint64(&s.lastEvictScanMs, 0)
+}
+
+// MaxResourceAmount returns the max amount of the resource nodes retained by the storage.
+func (s *NodeStorage) MaxResourceAmount() uint32 {
+	if s.conf == nil {
+		return config.MaxResourceAmount()
+	}
+	return s.conf.MaxResourceAmount()
+}
+
+func (s *NodeStorage) overflowPolicy() config.OverflowPolicy {
+	if s.conf == nil {
+		return config.ResourceOverflowPolicy()
+	}
+	return s.conf.ResourceOverflowPolicy()
+}
+
+func (s *NodeStorage) idleTimeoutMs() uint32 {
+	if s.conf == nil {
+		return config.ResourceIdleTimeoutMs()
+	}
+	return s.conf.ResourceIdleTimeoutMs()
+}
+
+func (s *NodeStorage) shouldScanIdleNodes(now uint64) bool {
+	return s.overflowPolicy() == config.OverflowEvictIdle &&
+		now >= atomic.LoadUint64(&s.lastEvictScanMs)+evictScanIntervalMs
+}
+
+// evictIdleNodes removes all the idle nodes and returns the amount of them, it must be called with the write lock held.
+func (s *NodeStorage) evictIdleNodes(now uint64) int {
+	if !s.shouldScanIdleNodes(now) {
+		return 0
+	}
+	atomic.StoreUint64(&s.lastEvictScanMs, now)
+
+	idleMs := uint64(s.idleTimeoutMs())
+	evicted := 0
+	for res, node := range s.resNodeMap {
+		if node.pins > 0 || !node.isIdle(now, idleMs) {
+			continue
+		}
+		delete(s.resNodeMap, res)
+		evicted++
+	}
+	if evicted > 0 {
+		atomic.AddUint64(&s.evictedCount, uint64(evicted))
+		logging.Info("[GetOrCreateResourceNode] Evicted idle resource nodes", "evicted", evicted, "remaining", len(s.resNodeMap))
+	}
+	return evicted
+}
+
+func (s *NodeStorage) onOverflow(resource string, now uint64) *ResourceNode {
+	count := atomic.AddUint64(&s.overflowCount, 1)
+	lastWarn := atomic.LoadUint64(&s.lastOverflowWarnMs)
+	if (lastWarn == 0 || now >= lastWarn+overflowWarnIntervalMs) && atomic.CompareAndSwapUint64(&s.lastOverflowWarnMs, lastWarn, now) {
+		logging.Warn("[GetOrCreateResourceNode] Resource amount exceeds the threshold, the statistics of new resources are accounted to the overflow node",
+			"maxResourceAmount", s.MaxResourceAmount(), "overflowPolicy", s.overflowPolicy(), "resource", resource,
+			"overflowNode", base.OverflowResourceName, "overflowCount", count)
+	}
+	return s.overflowNode
 }
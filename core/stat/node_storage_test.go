@@ -0,0 +1,124 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stat
+
+import (
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// withResourceStatConfig applies the resource stat config and returns the function restoring the default one.
+func withResourceStatConfig(rc config.ResourceStatConfig) func() {
+	conf := config.NewDefaultConfig()
+	conf.Sentinel.Stat.Resource = rc
+	config.ResetGlobalConfig(conf)
+	ResetResourceNodeMap()
+	return func() {
+		config.ResetGlobalConfig(config.NewDefaultConfig())
+		ResetResourceNodeMap()
+	}
+}
+
+func TestGetOrCreateResourceNode_UnboundedByDefault(t *testing.T) {
+	defer withResourceStatConfig(config.NewDefaultConfig().Sentinel.Stat.Resource)()
+
+	assert.Equal(t, config.UnboundedResourceAmount, config.MaxResourceAmount())
+	overflowBefore := ResourceOverflowCount()
+	for i := 0; i < int(base.DefaultMaxResourceAmount)+1; i++ {
+		node := GetOrCreateResourceNode("res"+strconv.Itoa(i), base.ResTypeCommon)
+		assert.False(t, node == OverflowNode())
+	}
+	assert.NotNil(t, GetResourceNode("res"+strconv.Itoa(int(base.DefaultMaxResourceAmount))))
+	assert.Equal(t, overflowBefore, ResourceOverflowCount())
+}
+
+func TestGetOrCreateResourceNode_OverflowReject(t *testing.T) {
+	defer withResourceStatConfig(config.ResourceStatConfig{MaxCount: 3, OverflowPolicy: config.OverflowReject})()
+
+	for i := 0; i < 3; i++ {
+		node := GetOrCreateResourceNode("res"+strconv.Itoa(i), base.ResTypeCommon)
+		assert.Equal(t, "res"+strconv.Itoa(i), node.ResourceName())
+	}
+	overflowBefore := ResourceOverflowCount()
+	node := GetOrCreateResourceNode("res3", base.ResTypeCommon)
+	assert.True(t, node == OverflowNode())
+	assert.Nil(t, GetResourceNode("res3"))
+	assert.Equal(t, overflowBefore+1, ResourceOverflowCount())
+	// The existing resources are not affected.
+	assert.Equal(t, "res0", GetOrCreateResourceNode("res0", base.ResTypeCommon).ResourceName())
+
+	list := ResourceNodeList()
+	assert.Equal(t, 4, len(list))
+	assert.Contains(t, list, OverflowNode())
+
+	// The pinned nodes are created regardless of the limit.
+	pinned := GetOrCreatePinnedResourceNode("res4", base.ResTypeCommon)
+	assert.True(t, pinned == GetOrCreateResourceNode("res4", base.ResTypeCommon))
+}
+
+func TestGetOrCreateResourceNode_OverflowEvictIdle(t *testing.T) {
+	defer withResourceStatConfig(config.ResourceStatConfig{MaxCount: 3, OverflowPolicy: config.OverflowEvictIdle, IdleTimeoutMs: 1000})()
+
+	idle := GetOrCreateResourceNode("idle", base.ResTypeCommon)
+	busy := GetOrCreateResourceNode("busy", base.ResTypeCommon)
+	pinned := GetOrCreatePinnedResourceNode("pinned", base.ResTypeCommon)
+	for _, n := range []*ResourceNode{idle, busy, pinned} {
+		atomic.StoreUint64(&n.lastAccessMs, 0)
+	}
+	// The node with requests in progress is never idle.
+	busy.IncreaseConcurrency()
+
+	evictedBefore := EvictedResourceCount()
+	node := GetOrCreateResourceNode("new", base.ResTypeCommon)
+	assert.Equal(t, "new", node.ResourceName())
+	assert.Equal(t, evictedBefore+1, EvictedResourceCount())
+	assert.Nil(t, GetResourceNode("idle"))
+	assert.True(t, busy == GetResourceNode("busy"))
+	assert.True(t, pinned == GetResourceNode("pinned"))
+
+	// No idle node to evict, falls back to the overflow node.
+	atomic.StoreUint64(&defaultNodeStorage.lastEvictScanMs, 0)
+	assert.True(t, GetOrCreateResourceNode("another", base.ResTypeCommon) == OverflowNode())
+}
+
+func TestUnpinResourceNode(t *testing.T) {
+	defer withResourceStatConfig(config.ResourceStatConfig{MaxCount: 1, OverflowPolicy: config.OverflowEvictIdle, IdleTimeoutMs: 1000})()
+
+	pinned := GetOrCreatePinnedResourceNode("pinned", base.ResTypeCommon)
+	GetOrCreatePinnedResourceNode("pinned", base.ResTypeCommon)
+	UnpinResourceNode("pinned")
+	atomic.StoreUint64(&pinned.lastAccessMs, 0)
+	// The node is still held by the other pin.
+	assert.True(t, GetOrCreateResourceNode("new", base.ResTypeCommon) == OverflowNode())
+
+	UnpinResourceNode("pinned")
+	// Unpinning the node touches it, so it's not idle right away.
+	atomic.StoreUint64(&defaultNodeStorage.lastEvictScanMs, 0)
+	assert.True(t, GetOrCreateResourceNode("new", base.ResTypeCommon) == OverflowNode())
+
+	atomic.StoreUint64(&pinned.lastAccessMs, 0)
+	atomic.StoreUint64(&defaultNodeStorage.lastEvictScanMs, 0)
+	assert.Equal(t, "new", GetOrCreateResourceNode("new", base.ResTypeCommon).ResourceName())
+	assert.Nil(t, GetResourceNode("pinned"))
+
+	// Unpinning the absent or unpinned nodes is a no-op.
+	UnpinResourceNode("pinned")
+	UnpinResourceNode("new")
+}
@@ -15,26 +15,51 @@
 package stat
 
 import (
+	"sync/atomic"
+
 	"github.com/alibaba/sentinel-golang/core/base"
 	"github.com/alibaba/sentinel-golang/core/config"
+	"github.com/alibaba/sentinel-golang/util"
 )
 
+// touchIntervalMs is the precision of ResourceNode.lastAccessMs, which avoids writing the shared field on every access.
+const touchIntervalMs = 1000
+
 type ResourceNode struct {
+	// lastAccessMs is the last time (roughly) the node was accessed, it's kept at the head of struct
+	// to be 64-bit aligned for atomic operations on 32-bit platforms.
+	lastAccessMs uint64
+
 	BaseStatNode
 
 	resourceName string
 	resourceType base.ResourceType
+	// pins is the amount of the holders of the node, the pinned nodes are never evicted,
+	// see GetOrCreatePinnedResourceNode. It's guarded by the lock of the NodeStorage.
+	pins int
 }
 
 // NewResourceNode creates a new resource node with given name and classification.
 func NewResourceNode(resourceName string, resourceType base.ResourceType) *ResourceNode {
 	return &ResourceNode{
+		lastAccessMs: util.CurrentTimeMillis(),
 		BaseStatNode: *NewBaseStatNode(config.MetricStatisticSampleCount(), config.MetricStatisticIntervalMs()),
 		resourceName: resourceName,
 		resourceType: resourceType,
 	}
 }
 
+func (n *ResourceNode) touch(now uint64) {
+	if now >= atomic.LoadUint64(&n.lastAccessMs)+touchIntervalMs {
+		atomic.StoreUint64(&n.lastAccessMs, now)
+	}
+}
+
+// isIdle returns true if the node has not been accessed for idleMs and there is no request in progress.
+func (n *ResourceNode) isIdle(now uint64, idleMs uint64) bool {
+	return now >= atomic.LoadUint64(&n.lastAccessMs)+idleMs && n.CurrentConcurrency() <= 0
+}
+
 func (n *ResourceNode) ResourceType() base.ResourceType {
 	return n.resourceType
 }
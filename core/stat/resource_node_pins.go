@@ -0,0 +1,97 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stat
+
+import (
+	"sync"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+)
+
+// ResourceNodePins holds the pins of the resource nodes taken by a holder (e.g. a rule manager), the node of
+// a resource is pinned at most once by the holder however many rules of the resource it holds.
+type ResourceNodePins struct {
+	nodes  *NodeStorage
+	pinned map[string]struct{}
+	mux    sync.Mutex
+}
+
+// NewResourceNodePins creates the pins of the nodes in the given NodeStorage, the default one if nil.
+func NewResourceNodePins(nodes *NodeStorage) *ResourceNodePins {
+	return &ResourceNodePins{
+		nodes:  storageOrDefault(nodes),
+		pinned: make(map[string]struct{}),
+	}
+}
+
+// Pin pins the node of the resource if not yet, and returns the node.
+func (p *ResourceNodePins) Pin(res string) *ResourceNode {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	return p.pin(res)
+}
+
+func (p *ResourceNodePins) pin(res string) *ResourceNode {
+	if _, ok := p.pinned[res]; ok {
+		// The node is absent only if the node storage is reset, pin it again then.
+		if node := p.nodes.GetResourceNode(res); node != nil {
+			return node
+		}
+	}
+	p.pinned[res] = struct{}{}
+	return p.nodes.GetOrCreatePinnedResourceNode(res, base.ResTypeCommon)
+}
+
+// Retain unpins the nodes of the resources absent in used.
+func (p *ResourceNodePins) Retain(used map[string]struct{}) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	p.retain(used)
+}
+
+func (p *ResourceNodePins) retain(used map[string]struct{}) {
+	for res := range p.pinned {
+		if _, ok := used[res]; ok {
+			continue
+		}
+		delete(p.pinned, res)
+		p.nodes.UnpinResourceNode(res)
+	}
+}
+
+// PinOnly pins the nodes of the given resources and unpins the others.
+func (p *ResourceNodePins) PinOnly(resources map[string]struct{}) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	for res := range resources {
+		p.pin(res)
+	}
+	p.retain(resources)
+}
+
+// Resources returns the set of the resources whose nodes are pinned.
+func (p *ResourceNodePins) Resources() map[string]struct{} {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	ret := make(map[string]struct{}, len(p.pinned))
+	for res := range p.pinned {
+		ret[res] = struct{}{}
+	}
+	return ret
+}
@@ -23,10 +23,17 @@ const (
 )
 
 var (
-	DefaultResourceNodePrepareSlot = &ResourceNodePrepareSlot{}
+	DefaultResourceNodePrepareSlot = NewResourceNodePrepareSlot(defaultNodeStorage)
 )
 
 type ResourceNodePrepareSlot struct {
+	// storage is the NodeStorage to prepare nodes from, the default NodeStorage is used if nil.
+	storage *NodeStorage
+}
+
+// NewResourceNodePrepareSlot creates a ResourceNodePrepareSlot preparing the resource nodes from the given storage.
+func NewResourceNodePrepareSlot(storage *NodeStorage) *ResourceNodePrepareSlot {
+	return &ResourceNodePrepareSlot{storage: storage}
 }
 
 func (s *ResourceNodePrepareSlot) Order() uint32 {
@@ -34,7 +41,7 @@ func (s *ResourceNodePrepareSlot) Order() uint32 {
 }
 
 func (s *ResourceNodePrepareSlot) Prepare(ctx *base.EntryContext) {
-	node := GetOrCreateResourceNode(ctx.Resource.Name(), ctx.Resource.Classification())
+	node := storageOrDefault(s.storage).GetOrCreateResourceNode(ctx.Resource.Name(), ctx.Resource.Classification())
 	// Set the resource node to the context.
 	ctx.StatNode = node
 }
@@ -16,6 +16,7 @@ package stat
 
 import (
 	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/event"
 	"github.com/alibaba/sentinel-golang/util"
 )
 
@@ -24,10 +25,17 @@ const (
 )
 
 var (
-	DefaultSlot = &Slot{}
+	DefaultSlot = NewSlot(defaultNodeStorage)
 )
 
 type Slot struct {
+	// storage is the NodeStorage holding the inbound node, the default NodeStorage is used if nil.
+	storage *NodeStorage
+}
+
+// NewSlot creates a Slot recording the inbound statistics to the inbound node of the given storage.
+func NewSlot(storage *NodeStorage) *Slot {
+	return &Slot{storage: storage}
 }
 
 func (s *Slot) Order() uint32 {
@@ -36,16 +44,23 @@ func (s *Slot) Order() uint32 {
 
 func (s *Slot) OnEntryPassed(ctx *base.EntryContext) {
 	s.recordPassFor(ctx.StatNode, ctx.Input.BatchCount)
+	s.recordShadowBlocksFor(ctx.StatNode, ctx.Input.BatchCount, ctx.ShadowBlocks())
 	if ctx.Resource.FlowType() == base.Inbound {
-		s.recordPassFor(InboundNode(), ctx.Input.BatchCount)
+		inbound := storageOrDefault(s.storage).InboundNode()
+		s.recordPassFor(inbound, ctx.Input.BatchCount)
+		s.recordShadowBlocksFor(inbound, ctx.Input.BatchCount, ctx.ShadowBlocks())
 	}
 }
 
 func (s *Slot) OnEntryBlocked(ctx *base.EntryContext, blockError *base.BlockError) {
-	s.recordBlockFor(ctx.StatNode, ctx.Input.BatchCount)
+	s.recordBlockFor(ctx.StatNode, ctx.Input.BatchCount, blockError)
+	s.recordShadowBlocksFor(ctx.StatNode, ctx.Input.BatchCount, ctx.ShadowBlocks())
 	if ctx.Resource.FlowType() == base.Inbound {
-		s.recordBlockFor(InboundNode(), ctx.Input.BatchCount)
+		inbound := storageOrDefault(s.storage).InboundNode()
+		s.recordBlockFor(inbound, ctx.Input.BatchCount, blockError)
+		s.recordShadowBlocksFor(inbound, ctx.Input.BatchCount, ctx.ShadowBlocks())
 	}
+	event.PublishBlocked(ctx, blockError)
 }
 
 func (s *Slot) OnCompleted(ctx *base.EntryContext) {
@@ -53,7 +68,7 @@ func (s *Slot) OnCompleted(ctx *base.EntryContext) {
 	ctx.PutRt(rt)
 	s.recordCompleteFor(ctx.StatNode, ctx.Input.BatchCount, rt, ctx.Err())
 	if ctx.Resource.FlowType() == base.Inbound {
-		s.recordCompleteFor(InboundNode(), ctx.Input.BatchCount, rt, ctx.Err())
+		s.recordCompleteFor(storageOrDefault(s.storage).InboundNode(), ctx.Input.BatchCount, rt, ctx.Err())
 	}
 }
 
@@ -65,11 +80,38 @@ func (s *Slot) recordPassFor(sn base.StatNode, count uint32) {
 	sn.AddCount(base.MetricEventPass, int64(count))
 }
 
-func (s *Slot) recordBlockFor(sn base.StatNode, count uint32) {
+func (s *Slot) recordBlockFor(sn base.StatNode, count uint32, blockError *base.BlockError) {
 	if sn == nil {
 		return
 	}
 	sn.AddCount(base.MetricEventBlock, int64(count))
+	if rn, ok := sn.(*ResourceNode); ok && blockError != nil {
+		rn.AddBlockCount(blockError.BlockType(), int64(count))
+	}
+}
+
+// recordShadowBlocksFor records the would-be blocks of the rules in shadow mode, which are not counted as blocked.
+// The entry is counted once per block type even if it's triggered by several rules of the same type.
+func (s *Slot) recordShadowBlocksFor(sn base.StatNode, count uint32, blocks []*base.BlockError) {
+	if len(blocks) == 0 {
+		return
+	}
+	rn, ok := sn.(*ResourceNode)
+	if !ok || rn == nil {
+		return
+	}
+	var recorded [blockTypeCount]bool
+	for _, blockError := range blocks {
+		t := blockError.BlockType()
+		if int(t) >= blockTypeCount {
+			t = base.BlockTypeUnknown
+		}
+		if recorded[t] {
+			continue
+		}
+		recorded[t] = true
+		rn.AddShadowBlockCount(t, int64(count))
+	}
 }
 
 func (s *Slot) recordCompleteFor(sn base.StatNode, count uint32, rt uint64, err error) {
@@ -17,6 +17,9 @@ package system
 import (
 	"encoding/json"
 	"fmt"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/util"
 )
 
 type MetricType uint32
@@ -51,6 +54,24 @@ func (t MetricType) String() string {
 	}
 }
 
+var metricTypeNames = map[string]int64{
+	"load":        int64(Load),
+	"avgrt":       int64(AvgRT),
+	"concurrency": int64(Concurrency),
+	"inboundqps":  int64(InboundQPS),
+	"cpuusage":    int64(CpuUsage),
+}
+
+// UnmarshalJSON accepts either the integer code or the name (e.g. "CpuUsage") of MetricType.
+func (t *MetricType) UnmarshalJSON(data []byte) error {
+	v, err := util.ParseEnumJSON(data, metricTypeNames)
+	if err != nil {
+		return err
+	}
+	*t = MetricType(v)
+	return nil
+}
+
 type AdaptiveStrategy int32
 
 const (
@@ -70,19 +91,42 @@ func (t AdaptiveStrategy) String() string {
 	}
 }
 
+var adaptiveStrategyNames = map[string]int64{
+	"none":       int64(NoAdaptive),
+	"noadaptive": int64(NoAdaptive),
+	"bbr":        int64(BBR),
+}
+
+// UnmarshalJSON accepts either the integer code or the name (e.g. "BBR") of AdaptiveStrategy.
+func (t *AdaptiveStrategy) UnmarshalJSON(data []byte) error {
+	v, err := util.ParseEnumJSON(data, adaptiveStrategyNames)
+	if err != nil {
+		return err
+	}
+	*t = AdaptiveStrategy(v)
+	return nil
+}
+
 type Rule struct {
 	ID           string           `json:"id,omitempty"`
 	MetricType   MetricType       `json:"metricType"`
 	TriggerCount float64          `json:"triggerCount"`
 	Strategy     AdaptiveStrategy `json:"strategy"`
+	// Mode indicates whether the rule rejects the traffic (RuleModeEnforce, by default),
+	// or only records the would-be blocks without rejecting anything (RuleModeShadow).
+	Mode base.RuleMode `json:"mode,omitempty"`
+	// EffectiveFrom and ExpiresAt are the optional effective period of the rule in Unix milliseconds, 0 means unbounded.
+	// The rule takes effect in [EffectiveFrom, ExpiresAt), out of which it's kept loaded but ignored.
+	EffectiveFrom uint64 `json:"effectiveFrom,omitempty"`
+	ExpiresAt     uint64 `json:"expiresAt,omitempty"`
 }
 
 func (r *Rule) String() string {
 	b, err := json.Marshal(r)
 	if err != nil {
 		// Return the fallback string
-		return fmt.Sprintf("Rule{metricType=%s, triggerCount=%.2f, adaptiveStrategy=%s}",
-			r.MetricType, r.TriggerCount, r.Strategy)
+		return fmt.Sprintf("Rule{metricType=%s, triggerCount=%.2f, adaptiveStrategy=%s, mode=%s, effectiveFrom=%d, expiresAt=%d}",
+			r.MetricType, r.TriggerCount, r.Strategy, r.Mode, r.EffectiveFrom, r.ExpiresAt)
 	}
 	return string(b)
 }
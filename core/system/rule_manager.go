@@ -18,6 +18,9 @@ import (
 	"reflect"
 	"sync"
 
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/event"
+	"github.com/alibaba/sentinel-golang/core/stat"
 	"github.com/alibaba/sentinel-golang/logging"
 	"github.com/alibaba/sentinel-golang/util"
 	"github.com/pkg/errors"
@@ -25,26 +28,58 @@ import (
 
 type RuleMap map[MetricType][]*Rule
 
-// const
-var (
-	ruleMap       = make(RuleMap)
-	ruleMapMux    = new(sync.RWMutex)
-	currentRules  = make([]*Rule, 0)
-	updateRuleMux = new(sync.Mutex)
-)
+// RuleManager manages the system rules, which are checked against the inbound node of its NodeStorage.
+// The package-level functions (e.g. LoadRules) operate on the default RuleManager.
+type RuleManager struct {
+	nodes         *stat.NodeStorage
+	ruleMap       RuleMap
+	inactiveRules []*Rule
+	ruleMapMux    sync.RWMutex
+	currentRules  []*Rule
+	updateRuleMux sync.Mutex
+	refresher     *base.RuleRefresher
+}
+
+var defaultRuleManager = NewRuleManager(stat.DefaultNodeStorage())
+
+// NewRuleManager creates an empty RuleManager checking the inbound node of the given NodeStorage,
+// the default NodeStorage is used if nodes is nil.
+func NewRuleManager(nodes *stat.NodeStorage) *RuleManager {
+	if nodes == nil {
+		nodes = stat.DefaultNodeStorage()
+	}
+	m := &RuleManager{
+		nodes:        nodes,
+		ruleMap:      make(RuleMap),
+		currentRules: make([]*Rule, 0),
+	}
+	m.refresher = base.NewRuleRefresher(event.ModuleSystem, &m.updateRuleMux, (*refreshableRules)(m))
+	return m
+}
+
+// DefaultRuleManager returns the RuleManager operated by the package-level functions.
+func DefaultRuleManager() *RuleManager {
+	return defaultRuleManager
+}
+
+func managerOrDefault(m *RuleManager) *RuleManager {
+	if m == nil {
+		return defaultRuleManager
+	}
+	return m
+}
 
 // GetRules returns all the rules based on copy.
 // It doesn't take effect for system module if user changes the rule.
 // GetRules need to compete system module's global lock and the high performance losses of copy,
 // 		reduce or do not call GetRules if possible
 func GetRules() []Rule {
-	rules := make([]*Rule, 0, len(ruleMap))
-	ruleMapMux.RLock()
-	for _, rs := range ruleMap {
-		rules = append(rules, rs...)
-	}
-	ruleMapMux.RUnlock()
+	return defaultRuleManager.GetRules()
+}
 
+// GetRules returns all the rules of the rule manager based on copy.
+func (m *RuleManager) GetRules() []Rule {
+	rules := m.getRules()
 	ret := make([]Rule, 0, len(rules))
 	for _, r := range rules {
 		ret = append(ret, *r)
@@ -54,56 +89,92 @@ func GetRules() []Rule {
 
 // getRules returns all the rules。Any changes of rules take effect for system module
 // getRules is an internal interface.
-func getRules() []*Rule {
-	ruleMapMux.RLock()
-	defer ruleMapMux.RUnlock()
+func (m *RuleManager) getRules() []*Rule {
+	m.ruleMapMux.RLock()
+	defer m.ruleMapMux.RUnlock()
 
 	rules := make([]*Rule, 0, 8)
-	for _, rs := range ruleMap {
+	for _, rs := range m.ruleMap {
 		rules = append(rules, rs...)
 	}
-	return rules
+	return append(rules, m.inactiveRules...)
+}
+
+// getRuleMap returns the current rule map, which must not be modified.
+// The rule map is never mutated by the rule manager since it's always replaced by a new map.
+func (m *RuleManager) getRuleMap() RuleMap {
+	m.refresher.Check(util.CurrentTimeMillis())
+
+	m.ruleMapMux.RLock()
+	defer m.ruleMapMux.RUnlock()
+
+	return m.ruleMap
 }
 
 // LoadRules loads given system rules to the rule manager, while all previous rules will be replaced.
 func LoadRules(rules []*Rule) (bool, error) {
-	updateRuleMux.Lock()
-	defer updateRuleMux.Unlock()
-	isEqual := reflect.DeepEqual(currentRules, rules)
+	return defaultRuleManager.LoadRules(rules)
+}
+
+// LoadRules loads given system rules to the rule manager, while all previous rules will be replaced.
+func (m *RuleManager) LoadRules(rules []*Rule) (bool, error) {
+	m.updateRuleMux.Lock()
+	defer m.updateRuleMux.Unlock()
+	isEqual := reflect.DeepEqual(m.currentRules, rules)
 	if isEqual {
 		logging.Info("[System] Load rules is the same with current rules, so ignore load operation.")
 		return false, nil
 	}
 
-	m := buildRuleMap(rules)
-
-	if err := onRuleUpdate(m); err != nil {
+	if err := m.applyRules(rules); err != nil {
 		logging.Error(err, "Fail to load rules in system.LoadRules()", "rules", rules)
 		return false, err
 	}
-	currentRules = rules
 	return true, nil
 }
 
 // ClearRules clear all the previous rules
 func ClearRules() error {
-	_, err := LoadRules(nil)
+	return defaultRuleManager.ClearRules()
+}
+
+// ClearRules clear all the previous rules of the rule manager.
+func (m *RuleManager) ClearRules() error {
+	_, err := m.LoadRules(nil)
 	return err
 }
 
-func onRuleUpdate(r RuleMap) error {
+// Close stops refreshing the rules in background and clears all the rules of the rule manager,
+// which must not be used any more then.
+func (m *RuleManager) Close() error {
+	m.refresher.Stop()
+	return m.ClearRules()
+}
+
+func (m *RuleManager) onRuleUpdate(r RuleMap) error {
 	start := util.CurrentTimeNano()
-	ruleMapMux.Lock()
-	ruleMap = r
-	ruleMapMux.Unlock()
+	m.ruleMapMux.Lock()
+	m.ruleMap = r
+	m.ruleMapMux.Unlock()
 
 	logging.Debug("[System onRuleUpdate] Time statistic(ns) for updating system rule", "timeCost", util.CurrentTimeNano()-start)
+	logRuleUpdate(r)
+	return nil
+}
+
+func logRuleUpdate(r RuleMap) {
 	if len(r) > 0 {
 		logging.Info("[SystemRuleManager] System rules loaded", "rules", r)
 	} else {
 		logging.Info("[SystemRuleManager] System rules were cleared")
 	}
-	return nil
+	rules := make([]base.SentinelRule, 0, len(r))
+	for _, rs := range r {
+		for _, rule := range rs {
+			rules = append(rules, rule)
+		}
+	}
+	event.PublishRuleChange(event.ModuleSystem, "", rules)
 }
 
 func buildRuleMap(rules []*Rule) RuleMap {
@@ -143,5 +214,8 @@ func IsValidSystemRule(rule *Rule) error {
 	if rule.MetricType == CpuUsage && rule.TriggerCount > 1 {
 		return errors.New("invalid CPU usage, valid range is [0.0, 1.0]")
 	}
+	if err := base.IsValidEffectivePeriod(rule.EffectiveFrom, rule.ExpiresAt); err != nil {
+		return err
+	}
 	return nil
 }
@@ -22,24 +22,24 @@ import (
 
 func TestGetRules(t *testing.T) {
 	t.Run("EmptyRules", func(t *testing.T) {
-		rules := getRules()
+		rules := defaultRuleManager.getRules()
 		assert.Equal(t, 0, len(rules))
 	})
 
 	t.Run("GetUpdatedRules", func(t *testing.T) {
-		defer func() { ruleMap = make(RuleMap) }()
+		defer func() { defaultRuleManager.ruleMap = make(RuleMap) }()
 
 		r := map[MetricType][]*Rule{
 			InboundQPS:  {&Rule{MetricType: InboundQPS, TriggerCount: 1}},
 			Concurrency: {&Rule{MetricType: Concurrency, TriggerCount: 2}},
 		}
-		ruleMap = r
-		rules := getRules()
+		defaultRuleManager.ruleMap = r
+		rules := defaultRuleManager.getRules()
 		assert.Equal(t, 2, len(rules))
 
 		r[InboundQPS] = append(r[InboundQPS], &Rule{MetricType: InboundQPS, TriggerCount: 2})
-		ruleMap = r
-		rules = getRules()
+		defaultRuleManager.ruleMap = r
+		rules = defaultRuleManager.getRules()
 		assert.Equal(t, 3, len(rules))
 	})
 }
@@ -49,11 +49,11 @@ func TestLoadRules(t *testing.T) {
 		isOK, err := LoadRules(nil)
 		assert.Equal(t, true, isOK)
 		assert.Nil(t, err)
-		assert.Equal(t, 0, len(ruleMap))
+		assert.Equal(t, 0, len(defaultRuleManager.ruleMap))
 	})
 
 	t.Run("ValidSystemRule", func(t *testing.T) {
-		defer func() { ruleMap = make(RuleMap) }()
+		defer func() { defaultRuleManager.ruleMap = make(RuleMap) }()
 		sRule := []*Rule{
 			{MetricType: InboundQPS, TriggerCount: 1},
 			{MetricType: Concurrency, TriggerCount: 2},
@@ -61,14 +61,14 @@ func TestLoadRules(t *testing.T) {
 		isOK, err := LoadRules(sRule)
 		assert.Equal(t, true, isOK)
 		assert.Nil(t, err)
-		assert.Equal(t, 2, len(ruleMap))
+		assert.Equal(t, 2, len(defaultRuleManager.ruleMap))
 	})
 }
 
 func TestClearRules(t *testing.T) {
 	t.Run("EmptyOriginRuleMap", func(t *testing.T) {
 		err := ClearRules()
-		assert.Equal(t, 0, len(ruleMap))
+		assert.Equal(t, 0, len(defaultRuleManager.ruleMap))
 		assert.Nil(t, err)
 	})
 
@@ -80,22 +80,22 @@ func TestClearRules(t *testing.T) {
 		isOK, err := LoadRules(r)
 		assert.Equal(t, true, isOK)
 		assert.Nil(t, err)
-		assert.Equal(t, 2, len(ruleMap))
+		assert.Equal(t, 2, len(defaultRuleManager.ruleMap))
 		err = ClearRules()
 		assert.Nil(t, err)
-		assert.Equal(t, 0, len(ruleMap))
+		assert.Equal(t, 0, len(defaultRuleManager.ruleMap))
 	})
 }
 
 func TestOnRuleUpdate(t *testing.T) {
 	t.Run("NilSystemRule", func(t *testing.T) {
-		err := onRuleUpdate(nil)
+		err := defaultRuleManager.onRuleUpdate(nil)
 		assert.NoError(t, err)
-		assert.Equal(t, 0, len(ruleMap))
+		assert.Equal(t, 0, len(defaultRuleManager.ruleMap))
 	})
 
 	t.Run("ValidSystemRule", func(t *testing.T) {
-		defer func() { ruleMap = make(RuleMap) }()
+		defer func() { defaultRuleManager.ruleMap = make(RuleMap) }()
 		rMap := RuleMap{
 			InboundQPS: []*Rule{
 				{MetricType: InboundQPS, TriggerCount: 1},
@@ -104,9 +104,9 @@ func TestOnRuleUpdate(t *testing.T) {
 				{MetricType: Concurrency, TriggerCount: 2},
 			},
 		}
-		err := onRuleUpdate(rMap)
+		err := defaultRuleManager.onRuleUpdate(rMap)
 		assert.NoError(t, err)
-		assert.Equal(t, len(rMap), len(ruleMap))
+		assert.Equal(t, len(rMap), len(defaultRuleManager.ruleMap))
 	})
 }
 
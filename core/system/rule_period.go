@@ -0,0 +1,94 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package system
+
+import (
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/util"
+)
+
+// EffectivePeriod returns the effective period of the rule, see base.PeriodicRule.
+func (r *Rule) EffectivePeriod() (effectiveFrom, expiresAt uint64) {
+	return r.EffectiveFrom, r.ExpiresAt
+}
+
+// splitInactiveRules splits the rules into the ones taking effect at now and the valid ones out of their effective period.
+// Invalid rules are kept in activeRules, so that they're reported and dropped by buildRuleMap.
+func splitInactiveRules(rules []*Rule, now uint64) (activeRules, inactiveRules []*Rule) {
+	activeRules = make([]*Rule, 0, len(rules))
+	for _, rule := range rules {
+		if IsValidSystemRule(rule) != nil || base.IsRuleActiveAt(rule, now) {
+			activeRules = append(activeRules, rule)
+		} else {
+			inactiveRules = append(inactiveRules, rule)
+		}
+	}
+	return activeRules, inactiveRules
+}
+
+// applyRules replaces the current rules with the given rules, see buildRuleUpdate.
+// It must be called with updateRuleMux held.
+func (m *RuleManager) applyRules(rules []*Rule) error {
+	m.buildRuleUpdate(rules).Apply()
+	return nil
+}
+
+// buildRuleUpdate builds the rule map from the rules taking effect at present without applying it,
+// the rules become the current rules and the next refresh is scheduled once it's applied.
+// It must be called with updateRuleMux held.
+func (m *RuleManager) buildRuleUpdate(rules []*Rule) *base.RuleUpdate {
+	now := util.CurrentTimeMillis()
+	activeRules, inactiveRules := splitInactiveRules(rules, now)
+	ruleMap := buildRuleMap(activeRules)
+	return base.NewRuleUpdate(&m.updateRuleMux, &m.ruleMapMux, func() {
+		m.ruleMap = ruleMap
+		m.inactiveRules = inactiveRules
+	}, func() {
+		m.currentRules = rules
+		m.refresher.Schedule(now)
+		logRuleUpdate(ruleMap)
+	})
+}
+
+// refreshableRules adapts the RuleManager to base.RefreshableRules, see base.RuleRefresher.
+type refreshableRules RuleManager
+
+func (r *refreshableRules) PeriodicRules() []base.PeriodicRule {
+	rules := make([]base.PeriodicRule, 0, len(r.currentRules))
+	for _, rule := range r.currentRules {
+		if rule != nil {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+func (r *refreshableRules) ReloadRules() error {
+	m := (*RuleManager)(r)
+	return m.applyRules(m.currentRules)
+}
+
+// GetRuleStatuses returns the statuses of all the loaded rules, including the ones out of their effective period.
+func GetRuleStatuses() []base.RuleStatus {
+	return defaultRuleManager.GetRuleStatuses()
+}
+
+// GetRuleStatuses returns the statuses of all the loaded rules, including the ones out of their effective period.
+func (m *RuleManager) GetRuleStatuses() []base.RuleStatus {
+	rules := m.GetRules()
+	return base.RuleStatusesAt(len(rules), func(i int) base.PeriodicRule {
+		return &rules[i]
+	}, util.CurrentTimeMillis())
+}
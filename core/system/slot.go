@@ -25,10 +25,25 @@ const (
 )
 
 var (
-	DefaultAdaptiveSlot = &AdaptiveSlot{}
+	DefaultAdaptiveSlot = NewAdaptiveSlot(defaultRuleManager)
 )
 
 type AdaptiveSlot struct {
+	// manager is the RuleManager to check the rules of, the default RuleManager is used if nil.
+	manager *RuleManager
+}
+
+// NewAdaptiveSlot creates an AdaptiveSlot checking the rules of the given RuleManager.
+func NewAdaptiveSlot(manager *RuleManager) *AdaptiveSlot {
+	return &AdaptiveSlot{manager: manager}
+}
+
+// ruleManager returns the RuleManager of the slot, it's safe to call on a nil slot.
+func (s *AdaptiveSlot) ruleManager() *RuleManager {
+	if s == nil {
+		return defaultRuleManager
+	}
+	return managerOrDefault(s.manager)
 }
 
 func (s *AdaptiveSlot) Order() uint32 {
@@ -39,19 +54,25 @@ func (s *AdaptiveSlot) Check(ctx *base.EntryContext) *base.TokenResult {
 	if ctx == nil || ctx.Resource == nil || ctx.Resource.FlowType() != base.Inbound {
 		return nil
 	}
-	rules := getRules()
 	result := ctx.RuleCheckResult
-	for _, rule := range rules {
-		passed, msg, snapshotValue := s.doCheckRule(rule)
-		if passed {
-			continue
-		}
-		if result == nil {
-			result = base.NewTokenResultBlockedWithCause(base.BlockTypeSystemFlow, msg, rule, snapshotValue)
-		} else {
-			result.ResetToBlockedWithCause(base.BlockTypeSystemFlow, msg, rule, snapshotValue)
+	// The rule map is iterated in place rather than through getRules(), so that no allocation is needed.
+	for _, rules := range s.ruleManager().getRuleMap() {
+		for _, rule := range rules {
+			passed, msg, snapshotValue := s.doCheckRule(rule)
+			if passed {
+				continue
+			}
+			if rule.Mode.IsShadow() {
+				ctx.AddShadowBlock(base.NewBlockErrorWithCause(base.BlockTypeSystemFlow, msg, rule, snapshotValue))
+				continue
+			}
+			if result == nil {
+				result = base.NewTokenResultBlockedWithCause(base.BlockTypeSystemFlow, msg, rule, snapshotValue)
+			} else {
+				result.ResetToBlockedWithCause(base.BlockTypeSystemFlow, msg, rule, snapshotValue)
+			}
+			return result
 		}
-		return result
 	}
 	return result
 }
@@ -59,24 +80,25 @@ func (s *AdaptiveSlot) Check(ctx *base.EntryContext) *base.TokenResult {
 func (s *AdaptiveSlot) doCheckRule(rule *Rule) (bool, string, float64) {
 	var msg string
 
+	inbound := s.ruleManager().nodes.InboundNode()
 	threshold := rule.TriggerCount
 	switch rule.MetricType {
 	case InboundQPS:
-		qps := stat.InboundNode().GetQPS(base.MetricEventPass)
+		qps := inbound.GetQPS(base.MetricEventPass)
 		res := qps < threshold
 		if !res {
 			msg = "system qps check blocked"
 		}
 		return res, msg, qps
 	case Concurrency:
-		n := float64(stat.InboundNode().CurrentConcurrency())
+		n := float64(inbound.CurrentConcurrency())
 		res := n < threshold
 		if !res {
 			msg = "system concurrency check blocked"
 		}
 		return res, msg, n
 	case AvgRT:
-		rt := stat.InboundNode().AvgRT()
+		rt := inbound.AvgRT()
 		res := rt < threshold
 		if !res {
 			msg = "system avg rt check blocked"
@@ -85,7 +107,7 @@ func (s *AdaptiveSlot) doCheckRule(rule *Rule) (bool, string, float64) {
 	case Load:
 		l := system_metric.CurrentLoad()
 		if l > threshold {
-			if rule.Strategy != BBR || !checkBbrSimple() {
+			if rule.Strategy != BBR || !checkBbrSimple(inbound) {
 				msg = "system load check blocked"
 				return false, msg, l
 			}
@@ -94,7 +116,7 @@ func (s *AdaptiveSlot) doCheckRule(rule *Rule) (bool, string, float64) {
 	case CpuUsage:
 		c := system_metric.CurrentCpuUsage()
 		if c > threshold {
-			if rule.Strategy != BBR || !checkBbrSimple() {
+			if rule.Strategy != BBR || !checkBbrSimple(inbound) {
 				msg = "system cpu usage check blocked"
 				return false, msg, c
 			}
@@ -106,10 +128,10 @@ func (s *AdaptiveSlot) doCheckRule(rule *Rule) (bool, string, float64) {
 	}
 }
 
-func checkBbrSimple() bool {
-	concurrency := stat.InboundNode().CurrentConcurrency()
-	minRt := stat.InboundNode().MinRT()
-	maxComplete := stat.InboundNode().GetMaxAvg(base.MetricEventComplete)
+func checkBbrSimple(inbound *stat.ResourceNode) bool {
+	concurrency := inbound.CurrentConcurrency()
+	minRt := inbound.MinRT()
+	maxComplete := inbound.GetMaxAvg(base.MetricEventComplete)
 	if concurrency > 1 && float64(concurrency) > maxComplete*minRt/1000.0 {
 		return false
 	}
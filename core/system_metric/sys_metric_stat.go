@@ -20,6 +20,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/alibaba/sentinel-golang/core/event"
 	"github.com/alibaba/sentinel-golang/logging"
 	"github.com/alibaba/sentinel-golang/metrics"
 	"github.com/alibaba/sentinel-golang/util"
@@ -39,9 +40,12 @@ var (
 	currentCpuUsage    atomic.Value
 	currentMemoryUsage atomic.Value
 
-	loadStatCollectorOnce   sync.Once
-	memoryStatCollectorOnce sync.Once
-	cpuStatCollectorOnce    sync.Once
+	// collectorMux guards the lifecycle of the collectors.
+	collectorMux         sync.Mutex
+	loadCollectorStarted bool
+	memCollectorStarted  bool
+	cpuCollectorStarted  bool
+	collectorWg          sync.WaitGroup
 
 	CurrentPID         = os.Getpid()
 	currentProcess     atomic.Value
@@ -76,33 +80,64 @@ func getTotalMemorySize() (total uint64) {
 	return stat.Total
 }
 
+// startCollector schedules the collecting task with the given interval until StopCollectors is called,
+// it must be called with collectorMux held.
+func startCollector(intervalMs uint32, collect func()) {
+	ticker := util.NewTicker(time.Duration(intervalMs) * time.Millisecond)
+	stopChan := ssStopChan
+	collectorWg.Add(1)
+	go util.RunWithRecover(func() {
+		defer collectorWg.Done()
+		for {
+			select {
+			case <-ticker.C():
+				collect()
+			case <-stopChan:
+				ticker.Stop()
+				return
+			}
+		}
+	})
+}
+
+// StopCollectors stops all the collectors and resets the collected statistics to the not-retrieved values.
+// The collectors could be initialized again after StopCollectors.
+func StopCollectors() {
+	collectorMux.Lock()
+	defer collectorMux.Unlock()
+
+	close(ssStopChan)
+	collectorWg.Wait()
+	ssStopChan = make(chan struct{})
+	loadCollectorStarted = false
+	memCollectorStarted = false
+	cpuCollectorStarted = false
+
+	currentLoad.Store(NotRetrievedLoadValue)
+	currentCpuUsage.Store(NotRetrievedCpuUsageValue)
+	currentMemoryUsage.Store(NotRetrievedMemoryValue)
+}
+
 func InitMemoryCollector(intervalMs uint32) {
 	if intervalMs == 0 {
 		return
 	}
-	memoryStatCollectorOnce.Do(func() {
-		// Initial memory retrieval.
-		retrieveAndUpdateMemoryStat()
-
-		ticker := util.NewTicker(time.Duration(intervalMs) * time.Millisecond)
-		go util.RunWithRecover(func() {
-			for {
-				select {
-				case <-ticker.C():
-					retrieveAndUpdateMemoryStat()
-				case <-ssStopChan:
-					ticker.Stop()
-					return
-				}
-			}
-		})
-	})
+	collectorMux.Lock()
+	defer collectorMux.Unlock()
+	if memCollectorStarted {
+		return
+	}
+	memCollectorStarted = true
+	// Initial memory retrieval.
+	retrieveAndUpdateMemoryStat()
+	startCollector(intervalMs, retrieveAndUpdateMemoryStat)
 }
 
 func retrieveAndUpdateMemoryStat() {
 	memoryUsedBytes, err := GetProcessMemoryStat()
 	if err != nil {
 		logging.Error(err, "Fail to retrieve and update cpu statistic")
+		publishCollectorError("memory", err)
 		return
 	}
 	metrics.SetProcessMemorySize(memoryUsedBytes)
@@ -136,29 +171,22 @@ func InitCpuCollector(intervalMs uint32) {
 	if intervalMs == 0 {
 		return
 	}
-	cpuStatCollectorOnce.Do(func() {
-		// Initial memory retrieval.
-		retrieveAndUpdateCpuStat()
-
-		ticker := util.NewTicker(time.Duration(intervalMs) * time.Millisecond)
-		go util.RunWithRecover(func() {
-			for {
-				select {
-				case <-ticker.C():
-					retrieveAndUpdateCpuStat()
-				case <-ssStopChan:
-					ticker.Stop()
-					return
-				}
-			}
-		})
-	})
+	collectorMux.Lock()
+	defer collectorMux.Unlock()
+	if cpuCollectorStarted {
+		return
+	}
+	cpuCollectorStarted = true
+	// Initial cpu retrieval.
+	retrieveAndUpdateCpuStat()
+	startCollector(intervalMs, retrieveAndUpdateCpuStat)
 }
 
 func retrieveAndUpdateCpuStat() {
 	cpuPercent, err := getProcessCpuStat()
 	if err != nil {
 		logging.Error(err, "Fail to retrieve and update cpu statistic")
+		publishCollectorError("cpu", err)
 		return
 	}
 	metrics.SetCPURatio(cpuPercent)
@@ -186,29 +214,22 @@ func InitLoadCollector(intervalMs uint32) {
 	if intervalMs == 0 {
 		return
 	}
-	loadStatCollectorOnce.Do(func() {
-		// Initial retrieval.
-		retrieveAndUpdateLoadStat()
-
-		ticker := util.NewTicker(time.Duration(intervalMs) * time.Millisecond)
-		go util.RunWithRecover(func() {
-			for {
-				select {
-				case <-ticker.C():
-					retrieveAndUpdateLoadStat()
-				case <-ssStopChan:
-					ticker.Stop()
-					return
-				}
-			}
-		})
-	})
+	collectorMux.Lock()
+	defer collectorMux.Unlock()
+	if loadCollectorStarted {
+		return
+	}
+	loadCollectorStarted = true
+	// Initial retrieval.
+	retrieveAndUpdateLoadStat()
+	startCollector(intervalMs, retrieveAndUpdateLoadStat)
 }
 
 func retrieveAndUpdateLoadStat() {
 	loadStat, err := load.Avg()
 	if err != nil {
 		logging.Error(err, "[retrieveAndUpdateSystemStat] Failed to retrieve current system load")
+		publishCollectorError("load", err)
 		return
 	}
 	if loadStat != nil {
@@ -216,6 +237,17 @@ func retrieveAndUpdateLoadStat() {
 	}
 }
 
+func publishCollectorError(collector string, err error) {
+	if !event.HasSubscriber(event.TypeSystemCollectorError) {
+		return
+	}
+	event.Publish(&event.SystemCollectorErrorEvent{
+		Time:      util.CurrentTimeMillis(),
+		Collector: collector,
+		Err:       err,
+	})
+}
+
 func CurrentLoad() float64 {
 	r, ok := currentLoad.Load().(float64)
 	if !ok {
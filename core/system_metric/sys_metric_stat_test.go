@@ -47,6 +47,25 @@ func TestCurrentCpuUsage(t *testing.T) {
 	assert.True(t, util.Float64Equals(v, cpuUsage))
 }
 
+func TestStopCollectors(t *testing.T) {
+	InitLoadCollector(100)
+	InitMemoryCollector(100)
+	assert.True(t, loadCollectorStarted)
+	assert.True(t, memCollectorStarted)
+	assert.True(t, CurrentMemoryUsage() > 0)
+
+	StopCollectors()
+	assert.False(t, loadCollectorStarted)
+	assert.False(t, memCollectorStarted)
+	assert.Equal(t, NotRetrievedMemoryValue, CurrentMemoryUsage())
+
+	// The collectors could be initialized again.
+	InitMemoryCollector(100)
+	assert.True(t, memCollectorStarted)
+	assert.True(t, CurrentMemoryUsage() > 0)
+	StopCollectors()
+}
+
 func Test_getProcessCpuStat(t *testing.T) {
 	wg := &sync.WaitGroup{}
 	wg.Add(1)
@@ -0,0 +1,60 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datasource
+
+import (
+	"io"
+	"sync"
+
+	"go.uber.org/multierr"
+)
+
+var (
+	openDataSources    = make(map[io.Closer]struct{})
+	openDataSourcesMux sync.Mutex
+)
+
+// TrackOpen registers the data source to be closed by CloseAll, it's expected to be called once
+// the background tasks of the data source are started.
+func TrackOpen(c io.Closer) {
+	openDataSourcesMux.Lock()
+	defer openDataSourcesMux.Unlock()
+	openDataSources[c] = struct{}{}
+}
+
+// UntrackOpen removes the data source from CloseAll, it's expected to be called on Close.
+func UntrackOpen(c io.Closer) {
+	openDataSourcesMux.Lock()
+	defer openDataSourcesMux.Unlock()
+	delete(openDataSources, c)
+}
+
+// CloseAll closes all the tracked data sources (e.g. on shutdown) and returns the combined errors.
+func CloseAll() error {
+	openDataSourcesMux.Lock()
+	closers := make([]io.Closer, 0, len(openDataSources))
+	for c := range openDataSources {
+		closers = append(closers, c)
+	}
+	openDataSourcesMux.Unlock()
+
+	var err error
+	for _, c := range closers {
+		// Close is expected to untrack the data source, untrack it anyway in case it doesn't.
+		UntrackOpen(c)
+		err = multierr.Append(err, c.Close())
+	}
+	return err
+}
@@ -0,0 +1,54 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datasource
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockCloser struct {
+	closed int
+	err    error
+}
+
+func (c *mockCloser) Close() error {
+	c.closed++
+	UntrackOpen(c)
+	return c.err
+}
+
+func TestCloseAll(t *testing.T) {
+	c1 := &mockCloser{}
+	c2 := &mockCloser{err: errors.New("close failed")}
+	c3 := &mockCloser{}
+	TrackOpen(c1)
+	TrackOpen(c2)
+	TrackOpen(c3)
+	UntrackOpen(c3)
+
+	err := CloseAll()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "close failed")
+	assert.Equal(t, 1, c1.closed)
+	assert.Equal(t, 1, c2.closed)
+	assert.Equal(t, 0, c3.closed)
+
+	// The closed data sources are untracked.
+	assert.Nil(t, CloseAll())
+	assert.Equal(t, 1, c1.closed)
+}
@@ -17,7 +17,11 @@ package datasource
 import (
 	"fmt"
 	"io"
+	"sync"
 
+	"github.com/alibaba/sentinel-golang/core/event"
+	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/alibaba/sentinel-golang/util"
 	"go.uber.org/multierr"
 )
 
@@ -40,11 +44,75 @@ type DataSource interface {
 	io.Closer
 }
 
+// WritableDataSource is the generic interface to describe the datasource that the property could be written back to,
+// so that the property changed at runtime (e.g. rules loaded through code) could be persisted to the source of truth.
+type WritableDataSource interface {
+	// Write encodes the given property and writes it to the data source.
+	Write(data interface{}) error
+	// Close the data source.
+	io.Closer
+}
+
 type Base struct {
 	handlers []PropertyHandler
+
+	// snapshot persists the last successfully handled source, nil if disabled.
+	snapshot *SnapshotCache
+
+	name            string
+	statusMux       sync.RWMutex
+	lastSuccessTime uint64
+	lastErrorTime   uint64
+	lastError       error
+	stale           bool
+}
+
+// Handle updates all the property handlers with src. Once all the handlers succeed,
+// src is persisted to the snapshot cache (if any) and the status is no longer stale.
+func (b *Base) Handle(src []byte) error {
+	err := b.handle(src)
+	b.onHandled(src, err)
+	return err
+}
+
+func (b *Base) onHandled(src []byte, err error) {
+	if err != nil {
+		b.ReportError(err)
+		return
+	}
+	b.statusMux.Lock()
+	b.lastSuccessTime = util.CurrentTimeMillis()
+	b.stale = false
+	b.statusMux.Unlock()
+
+	// The empty source (e.g. the source file was removed) never overwrites the last-known-good snapshot.
+	if b.snapshot == nil || len(src) == 0 {
+		return
+	}
+	if e := b.snapshot.Save(src); e != nil {
+		logging.Warn("[Datasource] Fail to save the snapshot of source", "name", b.name, "err", e.Error())
+	}
+}
+
+// ReportError records the failure of reading or handling the source in the status,
+// and publishes it to the event bus as a DatasourceErrorEvent.
+func (b *Base) ReportError(err error) {
+	if err == nil {
+		return
+	}
+	now := util.CurrentTimeMillis()
+	b.statusMux.Lock()
+	b.lastErrorTime = now
+	b.lastError = err
+	name := b.name
+	b.statusMux.Unlock()
+
+	if event.HasSubscriber(event.TypeDatasourceError) {
+		event.Publish(&event.DatasourceErrorEvent{Time: now, Name: name, Err: err})
+	}
 }
 
-func (b *Base) Handle(src []byte) (err error) {
+func (b *Base) handle(src []byte) (err error) {
 	for _, h := range b.handlers {
 		e := h.Handle(src)
 		if e != nil {
@@ -84,3 +152,14 @@ func (b *Base) RemovePropertyHandler(h PropertyHandler) {
 	}
 	b.handlers = append(b.handlers[:idx], b.handlers[idx+1:]...)
 }
+
+// MarkHandled records src as the latest handled property of all the DefaultPropertyHandler, without updating downstream.
+// It's used when downstream already holds the property, e.g. the source was just written from the current rules.
+func (b *Base) MarkHandled(src []byte) {
+	for _, h := range b.handlers {
+		if dh, ok := h.(*DefaultPropertyHandler); ok {
+			dh.markHandled(src)
+		}
+	}
+	b.onHandled(src, nil)
+}
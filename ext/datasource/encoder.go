@@ -0,0 +1,139 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datasource
+
+import (
+	"encoding/json"
+	"fmt"
+
+	cb "github.com/alibaba/sentinel-golang/core/circuitbreaker"
+	"github.com/alibaba/sentinel-golang/core/flow"
+	"github.com/alibaba/sentinel-golang/core/hotspot"
+	"github.com/alibaba/sentinel-golang/core/isolation"
+	"github.com/alibaba/sentinel-golang/core/system"
+	"gopkg.in/yaml.v2"
+)
+
+// toSerializable converts the property to the form that is accepted by the parsers,
+// e.g. the hotspot rules are converted to HotspotRule, whose specific items are serializable.
+func toSerializable(data interface{}) interface{} {
+	switch v := data.(type) {
+	case []hotspot.Rule:
+		rules := make([]*hotspot.Rule, 0, len(v))
+		for i := range v {
+			rules = append(rules, &v[i])
+		}
+		return toHotspotRules(rules)
+	case []*hotspot.Rule:
+		return toHotspotRules(v)
+	case *RuleBundle:
+		doc := &ruleBundleDocument{
+			FlowRules:           v.FlowRules,
+			CircuitBreakerRules: v.CircuitBreakerRules,
+			IsolationRules:      v.IsolationRules,
+			SystemRules:         v.SystemRules,
+		}
+		if v.HotSpotParamRules != nil {
+			doc.HotSpotParamRules = toHotspotRules(v.HotSpotParamRules)
+		}
+		return doc
+	default:
+		return data
+	}
+}
+
+// JsonEncoder encodes the rules (or *RuleBundle) to the JSON format accepted by the JSON parsers.
+func JsonEncoder(data interface{}) ([]byte, error) {
+	src, err := json.MarshalIndent(toSerializable(data), "", "  ")
+	if err != nil {
+		return nil, NewError(ConvertSourceError, fmt.Sprintf("Fail to encode property to JSON, err: %s", err.Error()))
+	}
+	return src, nil
+}
+
+// YamlEncoder encodes the rules (or *RuleBundle) to the YAML format accepted by the YAML parsers.
+func YamlEncoder(data interface{}) ([]byte, error) {
+	jsonSrc, err := json.Marshal(toSerializable(data))
+	if err != nil {
+		return nil, NewError(ConvertSourceError, fmt.Sprintf("Fail to encode property to YAML, err: %s", err.Error()))
+	}
+	// JSON is a subset of YAML, decode it back to generic values so that the keys keep the JSON tags.
+	var obj interface{}
+	if err = yaml.Unmarshal(jsonSrc, &obj); err != nil {
+		return nil, NewError(ConvertSourceError, fmt.Sprintf("Fail to encode property to YAML, err: %s", err.Error()))
+	}
+	src, err := yaml.Marshal(obj)
+	if err != nil {
+		return nil, NewError(ConvertSourceError, fmt.Sprintf("Fail to encode property to YAML, err: %s", err.Error()))
+	}
+	return src, nil
+}
+
+// FlowRulesSupplier returns the flow rules currently loaded.
+func FlowRulesSupplier() interface{} {
+	return flow.GetRules()
+}
+
+// CircuitBreakerRulesSupplier returns the circuit breaker rules currently loaded.
+func CircuitBreakerRulesSupplier() interface{} {
+	return cb.GetRules()
+}
+
+// HotSpotParamRulesSupplier returns the hotspot param rules currently loaded.
+func HotSpotParamRulesSupplier() interface{} {
+	return hotspot.GetRules()
+}
+
+// IsolationRulesSupplier returns the isolation rules currently loaded.
+func IsolationRulesSupplier() interface{} {
+	return isolation.GetRules()
+}
+
+// SystemRulesSupplier returns the system rules currently loaded.
+func SystemRulesSupplier() interface{} {
+	return system.GetRules()
+}
+
+// RuleBundleSupplier returns the *RuleBundle of the rules of all the rule types currently loaded.
+func RuleBundleSupplier() interface{} {
+	bundle := &RuleBundle{
+		FlowRules:           []*flow.Rule{},
+		CircuitBreakerRules: []*cb.Rule{},
+		HotSpotParamRules:   []*hotspot.Rule{},
+		IsolationRules:      []*isolation.Rule{},
+		SystemRules:         []*system.Rule{},
+	}
+	for _, r := range flow.GetRules() {
+		r := r
+		bundle.FlowRules = append(bundle.FlowRules, &r)
+	}
+	for _, r := range cb.GetRules() {
+		r := r
+		bundle.CircuitBreakerRules = append(bundle.CircuitBreakerRules, &r)
+	}
+	for _, r := range hotspot.GetRules() {
+		r := r
+		bundle.HotSpotParamRules = append(bundle.HotSpotParamRules, &r)
+	}
+	for _, r := range isolation.GetRules() {
+		r := r
+		bundle.IsolationRules = append(bundle.IsolationRules, &r)
+	}
+	for _, r := range system.GetRules() {
+		r := r
+		bundle.SystemRules = append(bundle.SystemRules, &r)
+	}
+	return bundle
+}
@@ -0,0 +1,89 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datasource
+
+import (
+	"testing"
+
+	"github.com/alibaba/sentinel-golang/core/flow"
+	"github.com/alibaba/sentinel-golang/core/hotspot"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncoder(t *testing.T) {
+	t.Run("TestEncoder_FlowRules", func(t *testing.T) {
+		rules := []*flow.Rule{
+			{Resource: "abc", Threshold: 100, ControlBehavior: flow.Throttling, MaxQueueingTimeMs: 10},
+		}
+		for _, c := range []struct {
+			encoder PropertyEncoder
+			parser  PropertyConverter
+		}{
+			{JsonEncoder, FlowRuleJsonArrayParser},
+			{YamlEncoder, FlowRuleYamlArrayParser},
+		} {
+			src, err := c.encoder(rules)
+			assert.Nil(t, err)
+			got, err := c.parser(src)
+			assert.Nil(t, err)
+			assert.Equal(t, rules, got)
+		}
+	})
+
+	t.Run("TestEncoder_HotSpotParamRules", func(t *testing.T) {
+		rules := []hotspot.Rule{{
+			Resource:        "abc",
+			MetricType:      hotspot.QPS,
+			ControlBehavior: hotspot.Reject,
+			Threshold:       50,
+			DurationInSec:   1,
+			SpecificItems:   map[interface{}]int64{9: 5, "a": 1, true: 2, 1.5: 3},
+		}}
+		for _, c := range []struct {
+			encoder PropertyEncoder
+			parser  PropertyConverter
+		}{
+			{JsonEncoder, HotSpotParamRuleJsonArrayParser},
+			{YamlEncoder, HotSpotParamRuleYamlArrayParser},
+		} {
+			src, err := c.encoder(rules)
+			assert.Nil(t, err)
+			got, err := c.parser(src)
+			assert.Nil(t, err)
+			assert.Equal(t, 1, len(got.([]*hotspot.Rule)))
+			assert.Equal(t, rules[0].SpecificItems, got.([]*hotspot.Rule)[0].SpecificItems)
+		}
+	})
+
+	t.Run("TestEncoder_RuleBundle", func(t *testing.T) {
+		clearAllRules()
+		defer clearAllRules()
+		assert.Nil(t, RuleBundleUpdater(&RuleBundle{
+			FlowRules:         []*flow.Rule{{Resource: "abc", Threshold: 100}},
+			HotSpotParamRules: []*hotspot.Rule{{Resource: "abc", MetricType: hotspot.Concurrency, Threshold: 10}},
+		}))
+
+		src, err := YamlEncoder(RuleBundleSupplier())
+		assert.Nil(t, err)
+		got, err := RuleBundleYamlParser(src)
+		assert.Nil(t, err)
+		bundle := got.(*RuleBundle)
+		assert.Equal(t, "abc", bundle.FlowRules[0].Resource)
+		assert.Equal(t, int64(10), bundle.HotSpotParamRules[0].Threshold)
+		// The empty sections are kept, so that loading the bundle restores exactly the same rules.
+		assert.NotNil(t, bundle.SystemRules)
+		assert.Equal(t, 0, len(bundle.SystemRules))
+	})
+}
@@ -15,8 +15,11 @@
 package file
 
 import (
+	"bytes"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/alibaba/sentinel-golang/ext/datasource"
@@ -26,6 +29,12 @@ import (
 	"github.com/pkg/errors"
 )
 
+// k8sDataDirName is the symlink that Kubernetes swaps atomically when a mounted ConfigMap or Secret is updated.
+// A mounted key "rules.json" is a symlink to "..data/rules.json", and "..data" links to a timestamped directory.
+const k8sDataDirName = "..data"
+
+const defaultDebounceInterval = 100 * time.Millisecond
+
 type RefreshableFileDataSource struct {
 	datasource.Base
 	sourceFilePath string
@@ -33,6 +42,17 @@ type RefreshableFileDataSource struct {
 	closeChan      chan struct{}
 	watcher        *fsnotify.Watcher
 	closed         util.AtomicBool
+
+	// watchDir indicates whether to watch the parent directory rather than the file itself,
+	// so that the atomic symlink swaps (e.g. Kubernetes ConfigMap volumes) and rename-based replacements are followed.
+	watchDir bool
+	// debounceInterval is the quiet period to wait for before reloading after a burst of directory events.
+	debounceInterval time.Duration
+
+	// selfWritten is the content last written by the bound WritableFileDataSource,
+	// reading it back is not treated as an update, since the rules managers already hold it.
+	selfWritten []byte
+	writeMux    sync.Mutex
 }
 
 func NewFileDataSource(sourceFilePath string, handlers ...datasource.PropertyHandler) *RefreshableFileDataSource {
@@ -46,6 +66,20 @@ func NewFileDataSource(sourceFilePath string, handlers ...datasource.PropertyHan
 	return ds
 }
 
+// NewDirWatchingFileDataSource creates a RefreshableFileDataSource that watches the parent directory of the source file.
+// It follows the "..data" symlink swaps that Kubernetes performs on mounted ConfigMaps, and reloads once per burst
+// of events after the debounceInterval elapses without further events.
+// If debounceInterval is not positive, 100ms is used.
+func NewDirWatchingFileDataSource(sourceFilePath string, debounceInterval time.Duration, handlers ...datasource.PropertyHandler) *RefreshableFileDataSource {
+	ds := NewFileDataSource(sourceFilePath, handlers...)
+	ds.watchDir = true
+	if debounceInterval <= 0 {
+		debounceInterval = defaultDebounceInterval
+	}
+	ds.debounceInterval = debounceInterval
+	return ds
+}
+
 func (s *RefreshableFileDataSource) ReadSource() ([]byte, error) {
 	f, err := os.Open(s.sourceFilePath)
 	if err != nil {
@@ -64,18 +98,37 @@ func (s *RefreshableFileDataSource) Initialize() error {
 	if !s.isInitialized.CompareAndSet(false, true) {
 		return nil
 	}
+	s.TrackStatus("file:" + s.sourceFilePath)
 
 	err := s.doReadAndUpdate()
 	if err != nil {
 		logging.Error(err, "Fail to execute RefreshableFileDataSource.doReadAndUpdate")
+		if e := s.LoadSnapshot(); e != nil {
+			logging.Error(e, "Fail to load the snapshot of RefreshableFileDataSource", "sourceFilePath", s.sourceFilePath)
+		}
 	}
 
 	w, err := fsnotify.NewWatcher()
 	if err != nil {
+		s.UntrackStatus()
 		return errors.Errorf("Fail to new a watcher instance of fsnotify, err: %+v", err)
 	}
+	if s.watchDir {
+		dir := filepath.Dir(s.sourceFilePath)
+		if err = w.Add(dir); err != nil {
+			_ = w.Close()
+			s.UntrackStatus()
+			return errors.Errorf("Fail add a watcher on directory[%s], err: %+v", dir, err)
+		}
+		s.watcher = w
+		go util.RunWithRecover(s.watchDirectory)
+		datasource.TrackOpen(s)
+		return nil
+	}
 	err = w.Add(s.sourceFilePath)
 	if err != nil {
+		_ = w.Close()
+		s.UntrackStatus()
 		return errors.Errorf("Fail add a watcher on file[%s], err: %+v", s.sourceFilePath, err)
 	}
 	s.watcher = w
@@ -85,6 +138,18 @@ func (s *RefreshableFileDataSource) Initialize() error {
 		for {
 			select {
 			case ev := <-s.watcher.Events:
+				if ev.Op&(fsnotify.Rename|fsnotify.Remove) != 0 && s.sourceFileExists() {
+					// The file was replaced rather than removed, e.g. written atomically through a temp file and rename,
+					// so watch the new file and reload it.
+					_ = s.watcher.Remove(s.sourceFilePath)
+					if e := s.watcher.Add(s.sourceFilePath); e != nil {
+						logging.Error(e, "Failed to add to watcher", "sourceFilePath", s.sourceFilePath)
+					}
+					if err := s.doReadAndUpdate(); err != nil {
+						logging.Error(err, "Fail to execute RefreshableFileDataSource.doReadAndUpdate")
+					}
+					continue
+				}
 				if ev.Op&fsnotify.Rename == fsnotify.Rename {
 					logging.Warn("[RefreshableFileDataSource] The file source was renamed.", "sourceFilePath", s.sourceFilePath)
 					updateErr := s.Handle(nil)
@@ -131,23 +196,147 @@ func (s *RefreshableFileDataSource) Initialize() error {
 			}
 		}
 	})
+	datasource.TrackOpen(s)
 	return nil
 }
 
+// watchDirectory handles the events of the parent directory until the data source is closed.
+func (s *RefreshableFileDataSource) watchDirectory() {
+	defer s.watcher.Close()
+	dir := filepath.Clean(filepath.Dir(s.sourceFilePath))
+	file := filepath.Clean(s.sourceFilePath)
+
+	var debounce *time.Timer
+	var debounceC <-chan time.Time
+	defer func() {
+		if debounce != nil {
+			debounce.Stop()
+		}
+	}()
+	for {
+		select {
+		case ev := <-s.watcher.Events:
+			name := filepath.Clean(ev.Name)
+			if name == dir && (ev.Op&fsnotify.Remove == fsnotify.Remove || ev.Op&fsnotify.Rename == fsnotify.Rename) {
+				logging.Warn("[RefreshableFileDataSource] The watched directory was removed or renamed.", "dir", dir)
+				if !s.rewatchDirectory(dir) {
+					return
+				}
+			} else if name != file && filepath.Base(name) != k8sDataDirName {
+				continue
+			}
+			// Wait for the end of the event burst (e.g. the whole "..data" swap) before reloading.
+			if debounce == nil {
+				debounce = time.NewTimer(s.debounceInterval)
+				debounceC = debounce.C
+			} else {
+				if !debounce.Stop() {
+					select {
+					case <-debounce.C:
+					default:
+					}
+				}
+				debounce.Reset(s.debounceInterval)
+			}
+		case <-debounceC:
+			s.reloadAfterDirChange()
+		case err := <-s.watcher.Errors:
+			logging.Error(err, "Watch err on directory", "dir", dir)
+		case <-s.closeChan:
+			return
+		}
+	}
+}
+
+// rewatchDirectory re-establishes the watch on the directory, returns false if it fails after retries.
+func (s *RefreshableFileDataSource) rewatchDirectory(dir string) bool {
+	_ = s.watcher.Remove(dir)
+	for retryCount := 0; ; retryCount++ {
+		if retryCount > 5 {
+			logging.Error(errors.New("retry failed"), "Fail to retry watch", "dir", dir)
+			s.Close()
+			return false
+		}
+		e := s.watcher.Add(dir)
+		if e == nil {
+			return true
+		}
+		logging.Error(e, "Failed to add to watcher", "dir", dir)
+		select {
+		case <-s.closeChan:
+			return false
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (s *RefreshableFileDataSource) reloadAfterDirChange() {
+	// Stat follows the symlinks, so a dangling "..data/<key>" link is treated as removed.
+	if _, err := os.Stat(s.sourceFilePath); err != nil && os.IsNotExist(err) {
+		logging.Warn("[RefreshableFileDataSource] The file source was removed.", "sourceFilePath", s.sourceFilePath)
+		if updateErr := s.Handle(nil); updateErr != nil {
+			logging.Error(updateErr, "Fail to update nil property")
+		}
+		return
+	}
+	if err := s.doReadAndUpdate(); err != nil {
+		logging.Error(err, "Fail to execute RefreshableFileDataSource.doReadAndUpdate")
+	}
+}
+
+func (s *RefreshableFileDataSource) sourceFileExists() bool {
+	_, err := os.Stat(s.sourceFilePath)
+	return err == nil
+}
+
 func (s *RefreshableFileDataSource) doReadAndUpdate() (err error) {
 	src, err := s.ReadSource()
 	if err != nil {
 		err = errors.Errorf("Fail to read source, err: %+v", err)
+		s.ReportError(err)
 		return err
 	}
+	if s.isSelfWritten(src) {
+		s.MarkHandled(src)
+		return nil
+	}
 	return s.Handle(src)
 }
 
+// NewWritableDataSource creates a WritableFileDataSource that writes to the source file of s.
+// The changes written through it are not loaded again when s observes them.
+func (s *RefreshableFileDataSource) NewWritableDataSource(encoder datasource.PropertyEncoder) *WritableFileDataSource {
+	w := NewWritableFileDataSource(s.sourceFilePath, encoder)
+	w.source = s
+	return w
+}
+
+func (s *RefreshableFileDataSource) setSelfWritten(src []byte) {
+	s.writeMux.Lock()
+	defer s.writeMux.Unlock()
+	s.selfWritten = src
+}
+
+// isSelfWritten checks whether src is the content last written by the bound WritableFileDataSource.
+// Once a different content is read, the file has been changed by others and the record is dropped.
+func (s *RefreshableFileDataSource) isSelfWritten(src []byte) bool {
+	s.writeMux.Lock()
+	defer s.writeMux.Unlock()
+	if s.selfWritten != nil && bytes.Equal(s.selfWritten, src) {
+		return true
+	}
+	s.selfWritten = nil
+	return false
+}
+
 func (s *RefreshableFileDataSource) Close() error {
 	if !s.closed.CompareAndSet(false, true) {
 		return nil
 	}
-	s.closeChan <- struct{}{}
+	s.UntrackStatus()
+	datasource.UntrackOpen(s)
+	// Close the channel rather than sending to it, since Close may be called by the watching goroutine itself.
+	close(s.closeChan)
 	logging.Info("[File] The RefreshableFileDataSource for file had been closed.", "sourceFilePath", s.sourceFilePath)
 	return nil
 }
@@ -15,13 +15,16 @@
 package file
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/alibaba/sentinel-golang/core/system"
 	"github.com/alibaba/sentinel-golang/ext/datasource"
 	"github.com/alibaba/sentinel-golang/util"
 	"github.com/pkg/errors"
@@ -296,3 +299,163 @@ func TestNewFileDataSource_ALL_For_SystemRule(t *testing.T) {
 	})
 
 }
+
+// configMapDir reproduces the layout of a Kubernetes ConfigMap volume:
+//
+//	<dir>/..<ts>/SystemRules.json
+//	<dir>/..data -> ..<ts>
+//	<dir>/SystemRules.json -> ..data/SystemRules.json
+type configMapDir struct {
+	dir     string
+	key     string
+	version int
+}
+
+func newConfigMapDir(t *testing.T, key, content string) *configMapDir {
+	dir, err := ioutil.TempDir("", "sentinel-configmap")
+	if err != nil {
+		t.Fatalf("Fail to create temp dir, err: %+v", err)
+	}
+	c := &configMapDir{dir: dir, key: key}
+	tsDir := c.writeTimestampDir(t, content)
+	if err = os.Symlink(tsDir, filepath.Join(dir, k8sDataDirName)); err != nil {
+		t.Fatalf("Fail to create ..data symlink, err: %+v", err)
+	}
+	if err = os.Symlink(filepath.Join(k8sDataDirName, key), filepath.Join(dir, key)); err != nil {
+		t.Fatalf("Fail to create key symlink, err: %+v", err)
+	}
+	return c
+}
+
+func (c *configMapDir) writeTimestampDir(t *testing.T, content string) string {
+	c.version++
+	tsDir := fmt.Sprintf("..2020_12_01_00_00_0%d.000000000", c.version)
+	if err := os.Mkdir(filepath.Join(c.dir, tsDir), os.ModePerm); err != nil {
+		t.Fatalf("Fail to create timestamp dir, err: %+v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(c.dir, tsDir, c.key), []byte(content), os.ModePerm); err != nil {
+		t.Fatalf("Fail to write file, err: %+v", err)
+	}
+	return tsDir
+}
+
+// update performs the same sequence as the kubelet atomic writer:
+// write a new timestamped dir, link "..data_tmp" to it, rename "..data_tmp" to "..data" and remove the old dir.
+func (c *configMapDir) update(t *testing.T, content string) {
+	oldTsDir, err := os.Readlink(filepath.Join(c.dir, k8sDataDirName))
+	if err != nil {
+		t.Fatalf("Fail to read ..data symlink, err: %+v", err)
+	}
+	tsDir := c.writeTimestampDir(t, content)
+	tmpLink := filepath.Join(c.dir, k8sDataDirName+"_tmp")
+	if err = os.Symlink(tsDir, tmpLink); err != nil {
+		t.Fatalf("Fail to create ..data_tmp symlink, err: %+v", err)
+	}
+	if err = os.Rename(tmpLink, filepath.Join(c.dir, k8sDataDirName)); err != nil {
+		t.Fatalf("Fail to rename ..data_tmp, err: %+v", err)
+	}
+	if err = os.RemoveAll(filepath.Join(c.dir, oldTsDir)); err != nil {
+		t.Fatalf("Fail to remove old timestamp dir, err: %+v", err)
+	}
+}
+
+func TestNewDirWatchingFileDataSource_ConfigMapSwap(t *testing.T) {
+	cm := newConfigMapDir(t, "SystemRules.json", TestSystemRules)
+	defer os.RemoveAll(cm.dir)
+
+	mh1 := &datasource.MockPropertyHandler{}
+	mh1.On("Handle", tmock.Anything).Return(nil)
+
+	ds := NewDirWatchingFileDataSource(filepath.Join(cm.dir, cm.key), 50*time.Millisecond, mh1)
+	err := ds.Initialize()
+	if err != nil {
+		t.Fatalf("Fail to initialize the file data source, err: %+v", err)
+	}
+	mh1.AssertNumberOfCalls(t, "Handle", 1)
+
+	// Each swap emits a burst of events, which should be debounced to one reload.
+	cm.update(t, TestSystemRules+"\n")
+	time.Sleep(500 * time.Millisecond)
+	mh1.AssertNumberOfCalls(t, "Handle", 2)
+	mh1.AssertCalled(t, "Handle", []byte(TestSystemRules+"\n"))
+
+	// The watch survives the swap.
+	cm.update(t, TestSystemRules+"\n\n")
+	time.Sleep(500 * time.Millisecond)
+	mh1.AssertNumberOfCalls(t, "Handle", 3)
+	mh1.AssertCalled(t, "Handle", []byte(TestSystemRules+"\n\n"))
+
+	// The key is removed from the ConfigMap.
+	if err = os.Remove(filepath.Join(cm.dir, cm.key)); err != nil {
+		t.Fatalf("Fail to remove key symlink, err: %+v", err)
+	}
+	time.Sleep(500 * time.Millisecond)
+	mh1.AssertNumberOfCalls(t, "Handle", 4)
+	mh1.AssertCalled(t, "Handle", []byte(nil))
+
+	assert.Nil(t, ds.Close())
+}
+
+func TestNewDirWatchingFileDataSource_IgnoreOtherFiles(t *testing.T) {
+	cm := newConfigMapDir(t, "SystemRules.json", TestSystemRules)
+	defer os.RemoveAll(cm.dir)
+
+	mh1 := &datasource.MockPropertyHandler{}
+	mh1.On("Handle", tmock.Anything).Return(nil)
+
+	ds := NewDirWatchingFileDataSource(filepath.Join(cm.dir, cm.key), 50*time.Millisecond, mh1)
+	err := ds.Initialize()
+	if err != nil {
+		t.Fatalf("Fail to initialize the file data source, err: %+v", err)
+	}
+	err = ioutil.WriteFile(filepath.Join(cm.dir, "other.json"), []byte("[]"), os.ModePerm)
+	if err != nil {
+		t.Fatalf("Fail to write file, err: %+v", err)
+	}
+	time.Sleep(300 * time.Millisecond)
+	mh1.AssertNumberOfCalls(t, "Handle", 1)
+
+	assert.Nil(t, ds.Close())
+}
+
+func TestRefreshableFileDataSource_LoadSnapshotOnFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sentinel-snapshot")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "SystemRules.json")
+	cachePath := filepath.Join(dir, "SystemRules.snapshot.json")
+	assert.Nil(t, ioutil.WriteFile(path, []byte(TestSystemRules), 0644))
+
+	// The first run persists the applied rules.
+	h := datasource.NewDefaultPropertyHandler(datasource.SystemRuleJsonArrayParser, func(data interface{}) error {
+		return nil
+	})
+	ds := NewFileDataSource(path, h)
+	ds.SetSnapshotCache(datasource.NewSnapshotCache(cachePath))
+	assert.Nil(t, ds.Initialize())
+	assert.False(t, ds.Status().Stale)
+	assert.Nil(t, ds.Close())
+
+	// The second run fails to read the source, the snapshot is loaded instead.
+	assert.Nil(t, ioutil.WriteFile(path, []byte("[xxx"), 0644))
+	var loaded interface{}
+	h = datasource.NewDefaultPropertyHandler(datasource.SystemRuleJsonArrayParser, func(data interface{}) error {
+		loaded = data
+		return nil
+	})
+	ds = NewFileDataSource(path, h)
+	ds.SetSnapshotCache(datasource.NewSnapshotCache(cachePath))
+	// The data source is tracked once initialized.
+	for _, status := range datasource.GetStatuses() {
+		assert.NotEqual(t, "file:"+path, status.Name)
+	}
+	assert.Nil(t, ds.Initialize())
+	assert.Equal(t, 3, len(loaded.([]*system.Rule)))
+
+	status := ds.Status()
+	assert.True(t, status.Stale)
+	assert.Equal(t, "file:"+path, status.Name)
+	assert.NotEmpty(t, status.LastError)
+	assert.Contains(t, datasource.GetStatuses(), status)
+	assert.Nil(t, ds.Close())
+}
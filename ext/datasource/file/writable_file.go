@@ -0,0 +1,73 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"sync"
+
+	"github.com/alibaba/sentinel-golang/ext/datasource"
+	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/alibaba/sentinel-golang/util"
+	"github.com/pkg/errors"
+)
+
+// WritableFileDataSource writes the property to a file atomically: the encoded content is written to a temp file
+// in the same directory, and then renamed to the target file, so readers never observe a partially written file.
+type WritableFileDataSource struct {
+	filePath string
+	encoder  datasource.PropertyEncoder
+	mux      sync.Mutex
+	// source is the RefreshableFileDataSource reading the same file, if any.
+	source *RefreshableFileDataSource
+}
+
+func NewWritableFileDataSource(filePath string, encoder datasource.PropertyEncoder) *WritableFileDataSource {
+	return &WritableFileDataSource{
+		filePath: filePath,
+		encoder:  encoder,
+	}
+}
+
+// Write encodes the data and writes it to the file.
+func (w *WritableFileDataSource) Write(data interface{}) error {
+	src, err := w.encoder(data)
+	if err != nil {
+		return errors.Errorf("WritableFileDataSource fail to encode the property, err: %+v", err)
+	}
+
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	// Record the content before writing, since the watcher may observe the change before the rename returns.
+	if w.source != nil {
+		w.source.setSelfWritten(src)
+	}
+	if err = util.WriteFileAtomically(w.filePath, src); err != nil {
+		if w.source != nil {
+			w.source.setSelfWritten(nil)
+		}
+		return errors.Errorf("WritableFileDataSource fail to write file, err: %+v", err)
+	}
+	logging.Info("[File] The property had been written to file.", "filePath", w.filePath)
+	return nil
+}
+
+// WriteFrom writes the current property returned by the supplier, e.g. datasource.FlowRulesSupplier.
+func (w *WritableFileDataSource) WriteFrom(supplier datasource.PropertySupplier) error {
+	return w.Write(supplier())
+}
+
+func (w *WritableFileDataSource) Close() error {
+	return nil
+}
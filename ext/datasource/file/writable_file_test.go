@@ -0,0 +1,93 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alibaba/sentinel-golang/core/system"
+	"github.com/alibaba/sentinel-golang/ext/datasource"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWritableFileDataSource_Write(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sentinel-writable")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "SystemRules.json")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("[]"), 0600))
+
+	rules := []*system.Rule{{MetricType: system.Load, TriggerCount: 0.5, Strategy: system.BBR}}
+	w := NewWritableFileDataSource(path, datasource.JsonEncoder)
+	assert.Nil(t, w.Write(rules))
+
+	src, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	got, err := datasource.SystemRuleJsonArrayParser(src)
+	assert.Nil(t, err)
+	assert.Equal(t, rules, got)
+
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	// No temp file is left.
+	files, err := ioutil.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(files))
+
+	assert.Error(t, NewWritableFileDataSource(filepath.Join(dir, "not-existed", "x.json"), datasource.JsonEncoder).Write(rules))
+}
+
+func TestWritableFileDataSource_NotReloadSelfWritten(t *testing.T) {
+	for _, dirWatching := range []bool{false, true} {
+		dir, err := ioutil.TempDir("", "sentinel-writable")
+		assert.Nil(t, err)
+		path := filepath.Join(dir, "SystemRules.json")
+		assert.Nil(t, ioutil.WriteFile(path, []byte(TestSystemRules), 0644))
+
+		var updates int32
+		h := datasource.NewDefaultPropertyHandler(datasource.SystemRuleJsonArrayParser, func(data interface{}) error {
+			atomic.AddInt32(&updates, 1)
+			return nil
+		})
+		var ds *RefreshableFileDataSource
+		if dirWatching {
+			ds = NewDirWatchingFileDataSource(path, 20*time.Millisecond, h)
+		} else {
+			ds = NewFileDataSource(path, h)
+		}
+		assert.Nil(t, ds.Initialize())
+		assert.Equal(t, int32(1), atomic.LoadInt32(&updates))
+
+		w := ds.NewWritableDataSource(datasource.JsonEncoder)
+		assert.Nil(t, w.Write([]*system.Rule{{MetricType: system.Load, TriggerCount: 0.5, Strategy: system.BBR}}))
+		time.Sleep(200 * time.Millisecond)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&updates), "dirWatching: %v", dirWatching)
+
+		// The file is still watched after being replaced, and the changes of others are loaded.
+		// Truncating the file may be observed as an update as well when watching the file itself.
+		assert.Nil(t, ioutil.WriteFile(path, []byte(TestSystemRules), 0644))
+		time.Sleep(200 * time.Millisecond)
+		assert.True(t, atomic.LoadInt32(&updates) >= 2, "dirWatching: %v", dirWatching)
+
+		assert.Nil(t, ds.Close())
+		_ = os.RemoveAll(dir)
+	}
+}
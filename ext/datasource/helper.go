@@ -23,6 +23,7 @@ import (
 	"github.com/alibaba/sentinel-golang/core/hotspot"
 	"github.com/alibaba/sentinel-golang/core/isolation"
 	"github.com/alibaba/sentinel-golang/core/system"
+	"gopkg.in/yaml.v2"
 )
 
 func checkSrcComplianceJson(src []byte) (bool, error) {
@@ -32,6 +33,48 @@ func checkSrcComplianceJson(src []byte) (bool, error) {
 	return true, nil
 }
 
+// yamlToJson converts the YAML document to JSON, so that the YAML format shares the keys (json tags) with the JSON format.
+func yamlToJson(src []byte) ([]byte, error) {
+	var obj interface{}
+	if err := yaml.Unmarshal(src, &obj); err != nil {
+		return nil, err
+	}
+	return json.Marshal(normalizeYamlValue(obj))
+}
+
+// parseYamlWith converts the YAML source to JSON and then decodes it with the given JSON parser.
+func parseYamlWith(src []byte, jsonParser PropertyConverter, target string) (interface{}, error) {
+	if valid, err := checkSrcComplianceJson(src); !valid {
+		return nil, err
+	}
+
+	jsonSrc, err := yamlToJson(src)
+	if err != nil {
+		desc := fmt.Sprintf("Fail to convert source bytes to %s, err: %s", target, err.Error())
+		return nil, NewError(ConvertSourceError, desc)
+	}
+	return jsonParser(jsonSrc)
+}
+
+// normalizeYamlValue converts the map[interface{}]interface{} decoded by yaml.v2 to map[string]interface{} recursively.
+func normalizeYamlValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			m[fmt.Sprintf("%v", k)] = normalizeYamlValue(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range val {
+			val[i] = normalizeYamlValue(item)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
 // FlowRuleJsonArrayParser provide JSON  as the default serialization for list of flow.Rule
 func FlowRuleJsonArrayParser(src []byte) (interface{}, error) {
 	if valid, err := checkSrcComplianceJson(src); !valid {
@@ -46,6 +89,11 @@ func FlowRuleJsonArrayParser(src []byte) (interface{}, error) {
 	return rules, nil
 }
 
+// FlowRuleYamlArrayParser decodes list of flow.Rule from YAML bytes, the keys are the same as the JSON format.
+func FlowRuleYamlArrayParser(src []byte) (interface{}, error) {
+	return parseYamlWith(src, FlowRuleJsonArrayParser, "[]*flow.Rule")
+}
+
 // FlowRulesUpdater load the newest []flow.Rule to downstream flow component.
 func FlowRulesUpdater(data interface{}) error {
 	if data == nil {
@@ -93,6 +141,11 @@ func SystemRuleJsonArrayParser(src []byte) (interface{}, error) {
 	return rules, nil
 }
 
+// SystemRuleYamlArrayParser decodes list of system.Rule from YAML bytes, the keys are the same as the JSON format.
+func SystemRuleYamlArrayParser(src []byte) (interface{}, error) {
+	return parseYamlWith(src, SystemRuleJsonArrayParser, "[]*system.Rule")
+}
+
 // SystemRulesUpdater load the newest []system.Rule to downstream system component.
 func SystemRulesUpdater(data interface{}) error {
 	if data == nil {
@@ -139,6 +192,11 @@ func CircuitBreakerRuleJsonArrayParser(src []byte) (interface{}, error) {
 	return rules, nil
 }
 
+// CircuitBreakerRuleYamlArrayParser decodes list of circuitbreaker.Rule from YAML bytes, the keys are the same as the JSON format.
+func CircuitBreakerRuleYamlArrayParser(src []byte) (interface{}, error) {
+	return parseYamlWith(src, CircuitBreakerRuleJsonArrayParser, "[]*circuitbreaker.Rule")
+}
+
 // CircuitBreakerRulesUpdater load the newest []cb.Rule to downstream circuit breaker component.
 func CircuitBreakerRulesUpdater(data interface{}) error {
 	if data == nil {
@@ -179,23 +237,12 @@ func HotSpotParamRuleJsonArrayParser(src []byte) (interface{}, error) {
 		desc := fmt.Sprintf("Fail to convert source bytes to []*hotspot.Rule, err: %s", err.Error())
 		return nil, NewError(ConvertSourceError, desc)
 	}
-	rules := make([]*hotspot.Rule, len(hotspotRules))
-	for i, hotspotRule := range hotspotRules {
-		rules[i] = &hotspot.Rule{
-			ID:                hotspotRule.ID,
-			Resource:          hotspotRule.Resource,
-			MetricType:        hotspotRule.MetricType,
-			ControlBehavior:   hotspotRule.ControlBehavior,
-			ParamIndex:        hotspotRule.ParamIndex,
-			Threshold:         hotspotRule.Threshold,
-			MaxQueueingTimeMs: hotspotRule.MaxQueueingTimeMs,
-			BurstCount:        hotspotRule.BurstCount,
-			DurationInSec:     hotspotRule.DurationInSec,
-			ParamsMaxCapacity: hotspotRule.ParamsMaxCapacity,
-			SpecificItems:     parseSpecificItems(hotspotRule.SpecificItems),
-		}
-	}
-	return rules, nil
+	return convertHotspotRules(hotspotRules), nil
+}
+
+// HotSpotParamRuleYamlArrayParser decodes list of param flow rules from YAML bytes, the keys are the same as the JSON format.
+func HotSpotParamRuleYamlArrayParser(src []byte) (interface{}, error) {
+	return parseYamlWith(src, HotSpotParamRuleJsonArrayParser, "[]*hotspot.Rule")
 }
 
 // HotSpotParamRulesUpdater loads the provided hot-spot param rules to downstream rule manager.
@@ -245,6 +292,11 @@ func IsolationRuleJsonArrayParser(src []byte) (interface{}, error) {
 	return rules, nil
 }
 
+// IsolationRuleYamlArrayParser decodes list of isolation.Rule from YAML bytes, the keys are the same as the JSON format.
+func IsolationRuleYamlArrayParser(src []byte) (interface{}, error) {
+	return parseYamlWith(src, IsolationRuleJsonArrayParser, "[]*isolation.Rule")
+}
+
 // IsolationRulesUpdater load the newest []isolation.Rule to downstream system component.
 func IsolationRulesUpdater(data interface{}) error {
 	if data == nil {
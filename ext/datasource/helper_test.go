@@ -602,3 +602,129 @@ func TestIsolationRuleListJsonUpdater(t *testing.T) {
 		assert.True(t, strings.Contains(err.(Error).desc, "Fail to type assert"))
 	})
 }
+
+func TestRuleYamlArrayParser(t *testing.T) {
+	t.Run("TestRuleYamlArrayParser_Nil", func(t *testing.T) {
+		parsers := []PropertyConverter{FlowRuleYamlArrayParser, SystemRuleYamlArrayParser, CircuitBreakerRuleYamlArrayParser,
+			HotSpotParamRuleYamlArrayParser, IsolationRuleYamlArrayParser}
+		for _, parser := range parsers {
+			got, err := parser(nil)
+			assert.True(t, got == nil && err == nil)
+			_, err = parser([]byte("- resource: [abc"))
+			assert.Error(t, err)
+		}
+	})
+
+	t.Run("TestFlowRuleYamlArrayParser", func(t *testing.T) {
+		src := `
+- resource: abc
+  threshold: 100
+  tokenCalculateStrategy: WarmUp
+  controlBehavior: Throttling
+  warmUpPeriodSec: 10
+  maxQueueingTimeMs: 500
+- resource: def
+  threshold: 10
+  relationStrategy: AssociatedResource
+  refResource: abc
+  tokenCalculateStrategy: 0
+  controlBehavior: 0
+`
+		got, err := FlowRuleYamlArrayParser([]byte(src))
+		assert.Nil(t, err)
+		rules := got.([]*flow.Rule)
+		assert.Equal(t, 2, len(rules))
+		assert.Equal(t, &flow.Rule{
+			Resource:               "abc",
+			Threshold:              100,
+			TokenCalculateStrategy: flow.WarmUp,
+			ControlBehavior:        flow.Throttling,
+			WarmUpPeriodSec:        10,
+			MaxQueueingTimeMs:      500,
+		}, rules[0])
+		assert.Equal(t, flow.AssociatedResource, rules[1].RelationStrategy)
+		assert.Equal(t, flow.Reject, rules[1].ControlBehavior)
+	})
+
+	t.Run("TestCircuitBreakerRuleYamlArrayParser", func(t *testing.T) {
+		src := `
+- resource: abc
+  strategy: SlowRequestRatio
+  retryTimeoutMs: 3000
+  minRequestAmount: 10
+  statIntervalMs: 10000
+  maxAllowedRtMs: 50
+  threshold: 0.5
+`
+		got, err := CircuitBreakerRuleYamlArrayParser([]byte(src))
+		assert.Nil(t, err)
+		rules := got.([]*cb.Rule)
+		assert.Equal(t, &cb.Rule{
+			Resource:         "abc",
+			Strategy:         cb.SlowRequestRatio,
+			RetryTimeoutMs:   3000,
+			MinRequestAmount: 10,
+			StatIntervalMs:   10000,
+			MaxAllowedRtMs:   50,
+			Threshold:        0.5,
+		}, rules[0])
+	})
+
+	t.Run("TestHotSpotParamRuleYamlArrayParser", func(t *testing.T) {
+		src := `
+- resource: abc
+  metricType: QPS
+  controlBehavior: Reject
+  paramKey: uid
+  threshold: 100
+  durationInSec: 1
+  specificItems:
+    - valKind: KindInt
+      valStr: 1000
+      threshold: 1
+    - valKind: string
+      valStr: ximu
+      threshold: 2
+    - valKind: KindBool
+      valStr: true
+      threshold: 3
+    - valKind: 3
+      valStr: "1.5"
+      threshold: 4
+`
+		got, err := HotSpotParamRuleYamlArrayParser([]byte(src))
+		assert.Nil(t, err)
+		rules := got.([]*hotspot.Rule)
+		assert.Equal(t, 1, len(rules))
+		assert.Equal(t, hotspot.QPS, rules[0].MetricType)
+		assert.Equal(t, hotspot.Reject, rules[0].ControlBehavior)
+		assert.Equal(t, "uid", rules[0].ParamKey)
+		assert.Equal(t, map[interface{}]int64{1000: 1, "ximu": 2, true: 3, 1.5: 4}, rules[0].SpecificItems)
+	})
+
+	t.Run("TestIsolationRuleYamlArrayParser", func(t *testing.T) {
+		got, err := IsolationRuleYamlArrayParser([]byte("- resource: abc\n  metricType: Concurrency\n  threshold: 10\n"))
+		assert.Nil(t, err)
+		assert.Equal(t, []*isolation.Rule{{Resource: "abc", MetricType: isolation.Concurrency, Threshold: 10}}, got)
+	})
+
+	t.Run("TestSystemRuleYamlArrayParser", func(t *testing.T) {
+		got, err := SystemRuleYamlArrayParser([]byte("- metricType: CpuUsage\n  triggerCount: 0.8\n  strategy: BBR\n"))
+		assert.Nil(t, err)
+		assert.Equal(t, []*system.Rule{{MetricType: system.CpuUsage, TriggerCount: 0.8, Strategy: system.BBR}}, got)
+	})
+}
+
+func TestRuleJsonArrayParserWithEnumNames(t *testing.T) {
+	got, err := CircuitBreakerRuleJsonArrayParser([]byte(`[{"resource": "abc", "strategy": "ErrorCount"}]`))
+	assert.Nil(t, err)
+	assert.Equal(t, cb.ErrorCount, got.([]*cb.Rule)[0].Strategy)
+
+	got, err = HotSpotParamRuleJsonArrayParser([]byte(`[{"resource": "abc", "metricType": "Concurrency", "specificItems": [{"valKind": "KindString", "valStr": "a", "threshold": 1}]}]`))
+	assert.Nil(t, err)
+	assert.Equal(t, hotspot.Concurrency, got.([]*hotspot.Rule)[0].MetricType)
+	assert.Equal(t, map[interface{}]int64{"a": 1}, got.([]*hotspot.Rule)[0].SpecificItems)
+
+	_, err = FlowRuleJsonArrayParser([]byte(`[{"resource": "abc", "controlBehavior": "Unknown"}]`))
+	assert.Error(t, err)
+}
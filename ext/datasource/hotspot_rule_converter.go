@@ -15,11 +15,16 @@
 package datasource
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
 
+	"github.com/alibaba/sentinel-golang/core/base"
 	"github.com/alibaba/sentinel-golang/core/hotspot"
 	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/alibaba/sentinel-golang/util"
 	"github.com/pkg/errors"
 )
 
@@ -28,6 +33,10 @@ type HotspotRule struct {
 	ID string `json:"id,omitempty"`
 	// Resource is the resource name
 	Resource string `json:"resource"`
+	// ResourceMatchStrategy indicates how Resource matches the resource names, Resource is the exact resource name by default.
+	ResourceMatchStrategy base.ResourceMatchStrategy `json:"resourceMatchStrategy,omitempty"`
+	// SharedStat indicates whether all the resources matching the pattern share a single parameter statistic.
+	SharedStat bool `json:"sharedStat,omitempty"`
 	// MetricType indicates the metric type for checking logic.
 	// For Concurrency metric, hotspot module will check the each hot parameter's concurrency,
 	//		if concurrency exceeds the Threshold, reject the traffic directly.
@@ -41,8 +50,13 @@ type HotspotRule struct {
 	// if ParamIndex is great than or equals to zero, ParamIndex means the <ParamIndex>-th parameter
 	// if ParamIndex is the negative, ParamIndex means the reversed <ParamIndex>-th parameter
 	ParamIndex int `json:"paramIndex"`
+	// ParamKey is the key in EntryContext.Input.Attachments map.
+	// ParamKey is mutually exclusive with ParamIndex, ParamKey has the higher priority than ParamIndex
+	ParamKey string `json:"paramKey"`
 	// Threshold is the threshold to trigger rejection
 	Threshold int64 `json:"threshold"`
+	// Schedule overrides Threshold during its time windows
+	Schedule []*base.ScheduledThreshold `json:"schedule,omitempty"`
 	// MaxQueueingTimeMs only takes effect when ControlBehavior is Throttling and MetricType is QPS
 	MaxQueueingTimeMs int64 `json:"maxQueueingTimeMs"`
 	// BurstCount is the silent count
@@ -54,6 +68,14 @@ type HotspotRule struct {
 	// ParamsMaxCapacity is the max capacity of cache statistic
 	ParamsMaxCapacity int64           `json:"paramsMaxCapacity"`
 	SpecificItems     []SpecificValue `json:"specificItems"`
+	// Conditions restrict the rule to the entries matching all of them
+	Conditions []*base.MatchCondition `json:"conditions,omitempty"`
+	// Mode indicates whether the rule rejects the traffic (RuleModeEnforce, by default),
+	// or only records the would-be blocks without rejecting anything (RuleModeShadow).
+	Mode base.RuleMode `json:"mode,omitempty"`
+	// EffectiveFrom and ExpiresAt are the optional effective period of the rule in Unix milliseconds
+	EffectiveFrom uint64 `json:"effectiveFrom,omitempty"`
+	ExpiresAt     uint64 `json:"expiresAt,omitempty"`
 }
 
 // ParamKind represents the Param kind.
@@ -82,6 +104,27 @@ func (t ParamKind) String() string {
 	}
 }
 
+var paramKindNames = map[string]int64{
+	"kindint":     int64(KindInt),
+	"int":         int64(KindInt),
+	"kindstring":  int64(KindString),
+	"string":      int64(KindString),
+	"kindbool":    int64(KindBool),
+	"bool":        int64(KindBool),
+	"kindfloat64": int64(KindFloat64),
+	"float64":     int64(KindFloat64),
+}
+
+// UnmarshalJSON accepts either the integer code or the name (e.g. "KindInt" or "int") of ParamKind.
+func (t *ParamKind) UnmarshalJSON(data []byte) error {
+	v, err := util.ParseEnumJSON(data, paramKindNames)
+	if err != nil {
+		return err
+	}
+	*t = ParamKind(v)
+	return nil
+}
+
 // SpecificValue indicates the specific param, contain the supported param kind and concrete value.
 type SpecificValue struct {
 	ValKind   ParamKind `json:"valKind"`
@@ -89,11 +132,124 @@ type SpecificValue struct {
 	Threshold int64     `json:"threshold"`
 }
 
+// UnmarshalJSON also accepts the non-string literal of ValStr, e.g. `"valStr": 100` or `"valStr": true`,
+// which is what an unquoted scalar in YAML turns into.
+func (s *SpecificValue) UnmarshalJSON(data []byte) error {
+	type specificValue SpecificValue
+	aux := struct {
+		*specificValue
+		ValStr json.RawMessage `json:"valStr"`
+	}{specificValue: (*specificValue)(s)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	raw := bytes.TrimSpace(aux.ValStr)
+	switch {
+	case len(raw) == 0 || bytes.Equal(raw, []byte("null")):
+		s.ValStr = ""
+	case raw[0] == '"':
+		return json.Unmarshal(raw, &s.ValStr)
+	case raw[0] == '{' || raw[0] == '[':
+		return errors.Errorf("invalid valStr of specific item: %s", raw)
+	default:
+		s.ValStr = string(raw)
+	}
+	return nil
+}
+
 func (s *SpecificValue) String() string {
 	return fmt.Sprintf("SpecificValue: [ValKind: %+v, ValStr: %s]", s.ValKind, s.ValStr)
 }
 
-// arseSpecificItems parses the SpecificValue as real value.
+// convertHotspotRules converts the HotspotRule slice to hotspot.Rule slice.
+func convertHotspotRules(hotspotRules []*HotspotRule) []*hotspot.Rule {
+	rules := make([]*hotspot.Rule, len(hotspotRules))
+	for i, hotspotRule := range hotspotRules {
+		rules[i] = &hotspot.Rule{
+			ID:                    hotspotRule.ID,
+			Resource:              hotspotRule.Resource,
+			ResourceMatchStrategy: hotspotRule.ResourceMatchStrategy,
+			SharedStat:            hotspotRule.SharedStat,
+			MetricType:            hotspotRule.MetricType,
+			ControlBehavior:       hotspotRule.ControlBehavior,
+			ParamIndex:            hotspotRule.ParamIndex,
+			ParamKey:              hotspotRule.ParamKey,
+			Threshold:             hotspotRule.Threshold,
+			Schedule:              hotspotRule.Schedule,
+			MaxQueueingTimeMs:     hotspotRule.MaxQueueingTimeMs,
+			BurstCount:            hotspotRule.BurstCount,
+			DurationInSec:         hotspotRule.DurationInSec,
+			ParamsMaxCapacity:     hotspotRule.ParamsMaxCapacity,
+			SpecificItems:         parseSpecificItems(hotspotRule.SpecificItems),
+			Conditions:            hotspotRule.Conditions,
+			Mode:                  hotspotRule.Mode,
+			EffectiveFrom:         hotspotRule.EffectiveFrom,
+			ExpiresAt:             hotspotRule.ExpiresAt,
+		}
+	}
+	return rules
+}
+
+// toHotspotRules converts the hotspot.Rule slice to HotspotRule slice, which is the reverse of convertHotspotRules.
+func toHotspotRules(rules []*hotspot.Rule) []*HotspotRule {
+	hotspotRules := make([]*HotspotRule, len(rules))
+	for i, rule := range rules {
+		hotspotRules[i] = &HotspotRule{
+			ID:                    rule.ID,
+			Resource:              rule.Resource,
+			ResourceMatchStrategy: rule.ResourceMatchStrategy,
+			SharedStat:            rule.SharedStat,
+			MetricType:            rule.MetricType,
+			ControlBehavior:       rule.ControlBehavior,
+			ParamIndex:            rule.ParamIndex,
+			ParamKey:              rule.ParamKey,
+			Threshold:             rule.Threshold,
+			Schedule:              rule.Schedule,
+			MaxQueueingTimeMs:     rule.MaxQueueingTimeMs,
+			BurstCount:            rule.BurstCount,
+			DurationInSec:         rule.DurationInSec,
+			ParamsMaxCapacity:     rule.ParamsMaxCapacity,
+			SpecificItems:         toSpecificValues(rule.SpecificItems),
+			Conditions:            rule.Conditions,
+			Mode:                  rule.Mode,
+			EffectiveFrom:         rule.EffectiveFrom,
+			ExpiresAt:             rule.ExpiresAt,
+		}
+	}
+	return hotspotRules
+}
+
+// toSpecificValues converts the specific items to SpecificValue slice sorted by kind and value,
+// which is the reverse of parseSpecificItems.
+func toSpecificValues(items map[interface{}]int64) []SpecificValue {
+	ret := make([]SpecificValue, 0, len(items))
+	for val, threshold := range items {
+		item := SpecificValue{Threshold: threshold}
+		switch v := val.(type) {
+		case int:
+			item.ValKind, item.ValStr = KindInt, strconv.Itoa(v)
+		case string:
+			item.ValKind, item.ValStr = KindString, v
+		case bool:
+			item.ValKind, item.ValStr = KindBool, strconv.FormatBool(v)
+		case float64:
+			item.ValKind, item.ValStr = KindFloat64, strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			logging.Error(errors.New("Unsupported kind for specific item"), "Failed to convert specific item", "item", val)
+			continue
+		}
+		ret = append(ret, item)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].ValKind != ret[j].ValKind {
+			return ret[i].ValKind < ret[j].ValKind
+		}
+		return ret[i].ValStr < ret[j].ValStr
+	})
+	return ret
+}
+
+// parseSpecificItems parses the SpecificValue as real value.
 func parseSpecificItems(source []SpecificValue) map[interface{}]int64 {
 	ret := make(map[interface{}]int64, len(source))
 	if len(source) == 0 {
@@ -0,0 +1,355 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alibaba/sentinel-golang/ext/datasource"
+	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/alibaba/sentinel-golang/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	headerETag        = "ETag"
+	headerIfNoneMatch = "If-None-Match"
+	// headerPrefer carries the long-polling hint as defined in RFC 7240, e.g. "Prefer: wait=30".
+	headerPrefer = "Prefer"
+
+	defaultRefreshInterval = 10 * time.Second
+	defaultRequestTimeout  = 5 * time.Second
+	defaultMinBackoff      = time.Second
+	defaultMaxBackoff      = time.Minute
+)
+
+type options struct {
+	client          *http.Client
+	header          http.Header
+	refreshInterval time.Duration
+	requestTimeout  time.Duration
+	longPollTimeout time.Duration
+	minBackoff      time.Duration
+	maxBackoff      time.Duration
+	handlers        []datasource.PropertyHandler
+	snapshot        *datasource.SnapshotCache
+}
+
+// Option is the functional option of RefreshableHttpDataSource.
+type Option func(*options)
+
+// WithHttpClient sets the http.Client used to send requests.
+func WithHttpClient(client *http.Client) Option {
+	return func(opts *options) {
+		opts.client = client
+	}
+}
+
+// WithHeader adds an additional header (e.g. authorization) to every request.
+func WithHeader(key, value string) Option {
+	return func(opts *options) {
+		opts.header.Add(key, value)
+	}
+}
+
+// WithRefreshInterval sets the interval between two polls.
+func WithRefreshInterval(interval time.Duration) Option {
+	return func(opts *options) {
+		opts.refreshInterval = interval
+	}
+}
+
+// WithRequestTimeout sets the timeout of a single (non long-polling) request.
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(opts *options) {
+		opts.requestTimeout = timeout
+	}
+}
+
+// WithLongPolling enables long-polling. The server is expected to hold the request
+// until the payload no longer matches the If-None-Match ETag or the timeout elapses,
+// and then respond 304 (unchanged) or 200 (changed). The next request is sent
+// immediately after a response, unless the response came back within half of the timeout,
+// in which case the next request is delayed by the min backoff (see WithBackoff).
+func WithLongPolling(timeout time.Duration) Option {
+	return func(opts *options) {
+		opts.longPollTimeout = timeout
+	}
+}
+
+// WithBackoff sets the bounds of the exponential backoff applied after failures.
+func WithBackoff(min, max time.Duration) Option {
+	return func(opts *options) {
+		opts.minBackoff = min
+		opts.maxBackoff = max
+	}
+}
+
+// WithPropertyHandlers adds the property handlers to the data source.
+func WithPropertyHandlers(handlers ...datasource.PropertyHandler) Option {
+	return func(opts *options) {
+		opts.handlers = append(opts.handlers, handlers...)
+	}
+}
+
+// WithSnapshotCache sets the cache of the last-known-good payload, which is loaded when the first poll fails.
+func WithSnapshotCache(cache *datasource.SnapshotCache) Option {
+	return func(opts *options) {
+		opts.snapshot = cache
+	}
+}
+
+// RefreshableHttpDataSource polls the given URL and updates the property handlers
+// whenever the payload changes.
+// Unchanged payloads are detected through ETag/If-None-Match and skipped.
+// Failures never clear the rules: the last successfully handled rules are kept
+// until the endpoint recovers, and polling backs off with jitter in the meantime.
+type RefreshableHttpDataSource struct {
+	datasource.Base
+	url  string
+	opts *options
+
+	// etag is the ETag of the last successfully handled payload.
+	etag string
+	mux  sync.Mutex
+
+	isInitialized util.AtomicBool
+	closed        util.AtomicBool
+	ctx           context.Context
+	cancel        context.CancelFunc
+	closeWg       sync.WaitGroup
+}
+
+func NewHttpDataSource(url string, opts ...Option) *RefreshableHttpDataSource {
+	o := &options{
+		client:          http.DefaultClient,
+		header:          make(http.Header),
+		refreshInterval: defaultRefreshInterval,
+		requestTimeout:  defaultRequestTimeout,
+		minBackoff:      defaultMinBackoff,
+		maxBackoff:      defaultMaxBackoff,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ds := &RefreshableHttpDataSource{
+		url:    url,
+		opts:   o,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	for _, h := range o.handlers {
+		ds.AddPropertyHandler(h)
+	}
+	ds.SetSnapshotCache(o.snapshot)
+	return ds
+}
+
+// ReadSource fetches the current payload unconditionally.
+func (s *RefreshableHttpDataSource) ReadSource() ([]byte, error) {
+	p, _, err := s.fetch("", 0)
+	if err != nil {
+		return nil, err
+	}
+	return p.body, nil
+}
+
+func (s *RefreshableHttpDataSource) Initialize() error {
+	if s.url == "" {
+		return errors.New("empty url of RefreshableHttpDataSource")
+	}
+	if !s.isInitialized.CompareAndSet(false, true) {
+		return nil
+	}
+	s.TrackStatus("http:" + s.url)
+
+	failures := 0
+	if err := s.doReadAndUpdate(); err != nil {
+		failures++
+		logging.Error(err, "Fail to execute RefreshableHttpDataSource.doReadAndUpdate", "url", s.url)
+		if e := s.LoadSnapshot(); e != nil {
+			logging.Error(e, "Fail to load the snapshot of RefreshableHttpDataSource", "url", s.url)
+		}
+	}
+
+	s.closeWg.Add(1)
+	go util.RunWithRecover(func() {
+		defer s.closeWg.Done()
+		s.pollLoop(failures)
+	})
+	datasource.TrackOpen(s)
+	return nil
+}
+
+func (s *RefreshableHttpDataSource) pollLoop(failures int) {
+	// The first long-polling request is sent immediately.
+	elapsed := s.opts.longPollTimeout
+	for {
+		if !s.waitFor(s.nextDelay(failures, elapsed)) {
+			return
+		}
+		start := time.Now()
+		err := s.doReadAndUpdate()
+		elapsed = time.Since(start)
+		if err != nil {
+			if s.closed.Get() {
+				return
+			}
+			failures++
+			logging.Error(err, "Fail to execute RefreshableHttpDataSource.doReadAndUpdate", "url", s.url, "failures", failures)
+			continue
+		}
+		failures = 0
+	}
+}
+
+// nextDelay returns the delay before the next poll according to the count of consecutive failures
+// and the elapsed time of the last poll.
+func (s *RefreshableHttpDataSource) nextDelay(failures int, elapsed time.Duration) time.Duration {
+	if failures <= 0 {
+		if s.opts.longPollTimeout > 0 {
+			// The server answering well before the timeout might ignore the long-polling hint,
+			// so the next poll is delayed rather than flooding the server.
+			if elapsed < s.opts.longPollTimeout/2 {
+				return s.opts.minBackoff
+			}
+			return 0
+		}
+		return s.opts.refreshInterval
+	}
+	return backoffWithJitter(s.opts.minBackoff, s.opts.maxBackoff, failures)
+}
+
+// backoffWithJitter returns a random duration in [d/2, d), where d = min * 2^(failures-1) capped by max.
+func backoffWithJitter(min, max time.Duration, failures int) time.Duration {
+	d := min
+	for i := 1; i < failures && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	half := int64(d / 2)
+	if half <= 0 {
+		return d
+	}
+	return time.Duration(half + rand.Int63n(half))
+}
+
+// waitFor waits for the given duration, returns false if the data source has been closed.
+func (s *RefreshableHttpDataSource) waitFor(d time.Duration) bool {
+	if d <= 0 {
+		return !s.closed.Get()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-s.ctx.Done():
+		return false
+	}
+}
+
+func (s *RefreshableHttpDataSource) doReadAndUpdate() error {
+	s.mux.Lock()
+	etag := s.etag
+	s.mux.Unlock()
+
+	src, modified, err := s.fetch(etag, s.opts.longPollTimeout)
+	if err != nil {
+		s.ReportError(err)
+		return err
+	}
+	if !modified {
+		return nil
+	}
+	if err = s.Handle(src.body); err != nil {
+		return err
+	}
+	s.mux.Lock()
+	s.etag = src.etag
+	s.mux.Unlock()
+	return nil
+}
+
+type payload struct {
+	body []byte
+	etag string
+}
+
+// fetch sends a GET request. If etag is not empty, the request is conditional and
+// modified is false when the server responds 304 Not Modified.
+func (s *RefreshableHttpDataSource) fetch(etag string, longPollTimeout time.Duration) (p payload, modified bool, err error) {
+	ctx, cancel := context.WithTimeout(s.ctx, s.opts.requestTimeout+longPollTimeout)
+	defer cancel()
+
+	req, err := http.NewRequest(http.MethodGet, s.url, nil)
+	if err != nil {
+		return p, false, errors.Wrap(err, "RefreshableHttpDataSource fail to build request")
+	}
+	req = req.WithContext(ctx)
+	for key, values := range s.opts.header {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+	if etag != "" {
+		req.Header.Set(headerIfNoneMatch, etag)
+	}
+	if longPollTimeout > 0 {
+		req.Header.Set(headerPrefer, "wait="+strconv.FormatInt(int64(longPollTimeout/time.Second), 10))
+	}
+
+	resp, err := s.opts.client.Do(req)
+	if err != nil {
+		return p, false, errors.Errorf("RefreshableHttpDataSource fail to request %s, err: %+v", s.url, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return p, false, errors.Errorf("RefreshableHttpDataSource fail to read response body, err: %+v", err)
+		}
+		return payload{body: body, etag: resp.Header.Get(headerETag)}, true, nil
+	case http.StatusNotModified:
+		return p, false, nil
+	default:
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		return p, false, errors.Errorf("RefreshableHttpDataSource got unexpected status code %d from %s", resp.StatusCode, s.url)
+	}
+}
+
+func (s *RefreshableHttpDataSource) Close() error {
+	if !s.closed.CompareAndSet(false, true) {
+		return nil
+	}
+	s.UntrackStatus()
+	datasource.UntrackOpen(s)
+	s.cancel()
+	s.closeWg.Wait()
+	logging.Info("[Http] The RefreshableHttpDataSource had been closed.", "url", s.url)
+	return nil
+}
@@ -0,0 +1,261 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alibaba/sentinel-golang/ext/datasource"
+	"github.com/stretchr/testify/assert"
+	tmock "github.com/stretchr/testify/mock"
+)
+
+const (
+	TestSystemRules = `[
+    {
+        "id": "0",
+        "metricType": 0,
+        "adaptiveStrategy": 0
+    }
+]`
+	TestSystemRules2 = `[
+    {
+        "id": "1",
+        "metricType": 0,
+        "adaptiveStrategy": 0
+    }
+]`
+)
+
+// ruleServer is a stand-in of a config service supporting ETag and long-polling.
+type ruleServer struct {
+	mux      sync.Mutex
+	body     string
+	version  int
+	down     bool
+	changed  chan struct{}
+	notModed int32
+}
+
+func newRuleServer(body string) *ruleServer {
+	return &ruleServer{
+		body:    body,
+		version: 1,
+		changed: make(chan struct{}),
+	}
+}
+
+func (s *ruleServer) update(body string) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.body = body
+	s.version++
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func (s *ruleServer) setDown(down bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.down = down
+}
+
+func (s *ruleServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.Lock()
+	down, body, etag, changed := s.down, s.body, strconv.Itoa(s.version), s.changed
+	s.mux.Unlock()
+	if down {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if r.Header.Get(headerIfNoneMatch) == etag {
+		if wait := r.Header.Get(headerPrefer); wait != "" {
+			select {
+			case <-changed:
+				s.mux.Lock()
+				body, etag = s.body, strconv.Itoa(s.version)
+				s.mux.Unlock()
+				w.Header().Set(headerETag, etag)
+				_, _ = w.Write([]byte(body))
+				return
+			case <-time.After(200 * time.Millisecond):
+			}
+		}
+		atomic.AddInt32(&s.notModed, 1)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set(headerETag, etag)
+	_, _ = w.Write([]byte(body))
+}
+
+func newMockHandler() *datasource.MockPropertyHandler {
+	h := &datasource.MockPropertyHandler{}
+	h.On("Handle", tmock.Anything).Return(nil)
+	h.On("isPropertyConsistent", tmock.Anything).Return(false)
+	return h
+}
+
+func TestRefreshableHttpDataSource_ReadSource(t *testing.T) {
+	rs := newRuleServer(TestSystemRules)
+	server := httptest.NewServer(rs)
+	defer server.Close()
+
+	ds := NewHttpDataSource(server.URL)
+	src, err := ds.ReadSource()
+	assert.Nil(t, err)
+	assert.Equal(t, TestSystemRules, string(src))
+
+	rs.setDown(true)
+	src, err = ds.ReadSource()
+	assert.Nil(t, src)
+	assert.Error(t, err)
+}
+
+func TestRefreshableHttpDataSource_InitializeEmptyUrl(t *testing.T) {
+	ds := NewHttpDataSource("")
+	// The failed Initialize leaves the data source uninitialized, so the next call fails as well.
+	assert.Error(t, ds.Initialize())
+	assert.Error(t, ds.Initialize())
+	assert.False(t, ds.isInitialized.Get())
+}
+
+func TestRefreshableHttpDataSource_Polling(t *testing.T) {
+	t.Run("Skip_Unchanged_Payload", func(t *testing.T) {
+		rs := newRuleServer(TestSystemRules)
+		server := httptest.NewServer(rs)
+		defer server.Close()
+
+		h := newMockHandler()
+		ds := NewHttpDataSource(server.URL, WithRefreshInterval(20*time.Millisecond), WithPropertyHandlers(h))
+		assert.Nil(t, ds.Initialize())
+		h.AssertNumberOfCalls(t, "Handle", 1)
+
+		time.Sleep(200 * time.Millisecond)
+		h.AssertNumberOfCalls(t, "Handle", 1)
+		assert.True(t, atomic.LoadInt32(&rs.notModed) > 0)
+
+		rs.update(TestSystemRules2)
+		time.Sleep(200 * time.Millisecond)
+		h.AssertNumberOfCalls(t, "Handle", 2)
+		h.AssertCalled(t, "Handle", []byte(TestSystemRules2))
+		assert.Nil(t, ds.Close())
+	})
+
+	t.Run("Keep_Rules_When_Down", func(t *testing.T) {
+		rs := newRuleServer(TestSystemRules)
+		server := httptest.NewServer(rs)
+		defer server.Close()
+
+		h := newMockHandler()
+		ds := NewHttpDataSource(server.URL, WithRefreshInterval(20*time.Millisecond),
+			WithBackoff(10*time.Millisecond, 40*time.Millisecond), WithPropertyHandlers(h))
+		assert.Nil(t, ds.Initialize())
+		h.AssertNumberOfCalls(t, "Handle", 1)
+
+		rs.setDown(true)
+		time.Sleep(200 * time.Millisecond)
+		// The handlers are never called with nil property, so the last rules are kept.
+		h.AssertNumberOfCalls(t, "Handle", 1)
+
+		rs.setDown(false)
+		rs.update(TestSystemRules2)
+		time.Sleep(200 * time.Millisecond)
+		h.AssertNumberOfCalls(t, "Handle", 2)
+		assert.Nil(t, ds.Close())
+	})
+
+	t.Run("Handle_Error_Retry", func(t *testing.T) {
+		rs := newRuleServer(TestSystemRules)
+		server := httptest.NewServer(rs)
+		defer server.Close()
+
+		h := &datasource.MockPropertyHandler{}
+		h.On("Handle", tmock.Anything).Return(datasource.NewError(datasource.HandleSourceError, "mock"))
+		ds := NewHttpDataSource(server.URL, WithRefreshInterval(20*time.Millisecond),
+			WithBackoff(10*time.Millisecond, 20*time.Millisecond), WithPropertyHandlers(h))
+		assert.Nil(t, ds.Initialize())
+		time.Sleep(100 * time.Millisecond)
+		assert.Nil(t, ds.Close())
+		// The failed payload is not acknowledged, so it is delivered again.
+		assert.True(t, len(h.Calls) > 1)
+		assert.Equal(t, int32(0), atomic.LoadInt32(&rs.notModed))
+	})
+}
+
+func TestRefreshableHttpDataSource_LongPolling(t *testing.T) {
+	rs := newRuleServer(TestSystemRules)
+	server := httptest.NewServer(rs)
+	defer server.Close()
+
+	h := newMockHandler()
+	ds := NewHttpDataSource(server.URL, WithRefreshInterval(time.Hour), WithLongPolling(time.Second), WithPropertyHandlers(h))
+	assert.Nil(t, ds.Initialize())
+	h.AssertNumberOfCalls(t, "Handle", 1)
+
+	time.Sleep(50 * time.Millisecond)
+	rs.update(TestSystemRules2)
+	time.Sleep(100 * time.Millisecond)
+	// The change is delivered by the pending long-polling request rather than the (hour-long) refresh interval.
+	h.AssertNumberOfCalls(t, "Handle", 2)
+
+	start := time.Now()
+	assert.Nil(t, ds.Close())
+	assert.True(t, time.Since(start) < time.Second)
+}
+
+func TestRefreshableHttpDataSource_LongPollingIgnored(t *testing.T) {
+	var requests int32
+	// The server ignores the long-polling hint and answers 304 immediately.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if r.Header.Get(headerIfNoneMatch) == "1" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set(headerETag, "1")
+		_, _ = w.Write([]byte(TestSystemRules))
+	}))
+	defer server.Close()
+
+	h := newMockHandler()
+	ds := NewHttpDataSource(server.URL, WithLongPolling(time.Second), WithBackoff(100*time.Millisecond, time.Second),
+		WithPropertyHandlers(h))
+	assert.Nil(t, ds.Initialize())
+	time.Sleep(350 * time.Millisecond)
+	assert.Nil(t, ds.Close())
+
+	h.AssertNumberOfCalls(t, "Handle", 1)
+	// The initial request and at most 3 polls delayed by the min backoff.
+	n := atomic.LoadInt32(&requests)
+	assert.True(t, n >= 2 && n <= 5, "requests: %d", n)
+}
+
+func Test_backoffWithJitter(t *testing.T) {
+	for i := 1; i < 10; i++ {
+		d := backoffWithJitter(100*time.Millisecond, time.Second, i)
+		expected := 100 * time.Millisecond << uint(i-1)
+		if expected > time.Second {
+			expected = time.Second
+		}
+		assert.True(t, d >= expected/2 && d < expected)
+	}
+}
@@ -27,6 +27,12 @@ import (
 // if src is nil or len(src)==0, the return value is (nil,nil)
 type PropertyConverter func(src []byte) (interface{}, error)
 
+// PropertyEncoder func is to encode the specific property to source message bytes, which is the reverse of PropertyConverter.
+type PropertyEncoder func(data interface{}) ([]byte, error)
+
+// PropertySupplier func returns the current property, e.g. the rules currently loaded in the rule manager.
+type PropertySupplier func() interface{}
+
 // PropertyUpdater func is to update the specific properties to downstream.
 // return nil if succeed to update, if not, return the error.
 type PropertyUpdater func(data interface{}) error
@@ -78,6 +84,19 @@ func (h *DefaultPropertyHandler) Handle(src []byte) error {
 	return h.updater(realProperty)
 }
 
+func (h *DefaultPropertyHandler) markHandled(src []byte) {
+	defer func() {
+		if err := recover(); err != nil {
+			logging.Error(errors.Errorf("%+v", err), "Unexpected panic in DefaultPropertyHandler.markHandled()")
+		}
+	}()
+	realProperty, err := h.converter(src)
+	if err != nil {
+		return
+	}
+	h.lastUpdateProperty = realProperty
+}
+
 func NewDefaultPropertyHandler(converter PropertyConverter, updater PropertyUpdater) *DefaultPropertyHandler {
 	return &DefaultPropertyHandler{
 		converter: converter,
@@ -0,0 +1,211 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datasource
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	cb "github.com/alibaba/sentinel-golang/core/circuitbreaker"
+	"github.com/alibaba/sentinel-golang/core/flow"
+	"github.com/alibaba/sentinel-golang/core/hotspot"
+	"github.com/alibaba/sentinel-golang/core/isolation"
+	"github.com/alibaba/sentinel-golang/core/system"
+	"github.com/alibaba/sentinel-golang/logging"
+	"go.uber.org/multierr"
+)
+
+// ruleBundleDocument is the serialized format of RuleBundle.
+type ruleBundleDocument struct {
+	FlowRules           []*flow.Rule      `json:"flowRules"`
+	CircuitBreakerRules []*cb.Rule        `json:"circuitBreakerRules"`
+	HotSpotParamRules   []*HotspotRule    `json:"hotspotRules"`
+	IsolationRules      []*isolation.Rule `json:"isolationRules"`
+	SystemRules         []*system.Rule    `json:"systemRules"`
+}
+
+// RuleBundle carries the rules of all rule types in one document, e.g.
+//
+//	{
+//	  "flowRules": [...],
+//	  "circuitBreakerRules": [...],
+//	  "hotspotRules": [...],
+//	  "isolationRules": [...],
+//	  "systemRules": [...]
+//	}
+//
+// A nil section means the bundle doesn't manage the rule type, and the rules of that type are left untouched;
+// an empty section clears the rules of that type.
+type RuleBundle struct {
+	FlowRules           []*flow.Rule
+	CircuitBreakerRules []*cb.Rule
+	HotSpotParamRules   []*hotspot.Rule
+	IsolationRules      []*isolation.Rule
+	SystemRules         []*system.Rule
+}
+
+func newRuleBundleFromDocument(doc *ruleBundleDocument) *RuleBundle {
+	bundle := &RuleBundle{
+		FlowRules:           doc.FlowRules,
+		CircuitBreakerRules: doc.CircuitBreakerRules,
+		IsolationRules:      doc.IsolationRules,
+		SystemRules:         doc.SystemRules,
+	}
+	if doc.HotSpotParamRules != nil {
+		bundle.HotSpotParamRules = convertHotspotRules(doc.HotSpotParamRules)
+	}
+	return bundle
+}
+
+// RuleBundleJsonParser decodes the RuleBundle from JSON bytes.
+func RuleBundleJsonParser(src []byte) (interface{}, error) {
+	if valid, err := checkSrcComplianceJson(src); !valid {
+		return nil, err
+	}
+
+	doc := &ruleBundleDocument{}
+	if err := json.Unmarshal(src, doc); err != nil {
+		desc := fmt.Sprintf("Fail to convert source bytes to *datasource.RuleBundle, err: %s", err.Error())
+		return nil, NewError(ConvertSourceError, desc)
+	}
+	return newRuleBundleFromDocument(doc), nil
+}
+
+// RuleBundleYamlParser decodes the RuleBundle from YAML bytes, the keys are the same as the JSON format.
+func RuleBundleYamlParser(src []byte) (interface{}, error) {
+	return parseYamlWith(src, RuleBundleJsonParser, "*datasource.RuleBundle")
+}
+
+// Validate checks every rule of every section and returns all the invalid rules.
+func (b *RuleBundle) Validate() error {
+	var err error
+	for i, r := range b.FlowRules {
+		if e := flow.IsValidRule(r); e != nil {
+			err = multierr.Append(err, fmt.Errorf("flowRules[%d]: %s", i, e.Error()))
+		}
+	}
+	for i, r := range b.CircuitBreakerRules {
+		if e := cb.IsValidRule(r); e != nil {
+			err = multierr.Append(err, fmt.Errorf("circuitBreakerRules[%d]: %s", i, e.Error()))
+		}
+	}
+	for i, r := range b.HotSpotParamRules {
+		if e := hotspot.IsValidRule(r); e != nil {
+			err = multierr.Append(err, fmt.Errorf("hotspotRules[%d]: %s", i, e.Error()))
+		}
+	}
+	for i, r := range b.IsolationRules {
+		if e := isolation.IsValidRule(r); e != nil {
+			err = multierr.Append(err, fmt.Errorf("isolationRules[%d]: %s", i, e.Error()))
+		}
+	}
+	for i, r := range b.SystemRules {
+		if e := system.IsValidSystemRule(r); e != nil {
+			err = multierr.Append(err, fmt.Errorf("systemRules[%d]: %s", i, e.Error()))
+		}
+	}
+	return err
+}
+
+// bundleSection prepares one section of the bundle to be applied with the other sections.
+type bundleSection struct {
+	name    string
+	prepare func() (base.PreparedRuleUpdate, error)
+}
+
+// sections returns the sections managed by the bundle in the order the rule managers are locked, which is
+// the same as the rule transaction of api, so that the concurrent updates never deadlock.
+func (b *RuleBundle) sections() []bundleSection {
+	sections := make([]bundleSection, 0, 5)
+	if b.FlowRules != nil {
+		sections = append(sections, bundleSection{name: "flowRules", prepare: func() (base.PreparedRuleUpdate, error) {
+			return flow.PrepareRules(b.FlowRules)
+		}})
+	}
+	if b.IsolationRules != nil {
+		sections = append(sections, bundleSection{name: "isolationRules", prepare: func() (base.PreparedRuleUpdate, error) {
+			return isolation.PrepareRules(b.IsolationRules)
+		}})
+	}
+	if b.HotSpotParamRules != nil {
+		sections = append(sections, bundleSection{name: "hotspotRules", prepare: func() (base.PreparedRuleUpdate, error) {
+			return hotspot.PrepareRules(b.HotSpotParamRules)
+		}})
+	}
+	if b.CircuitBreakerRules != nil {
+		sections = append(sections, bundleSection{name: "circuitBreakerRules", prepare: func() (base.PreparedRuleUpdate, error) {
+			return cb.PrepareRules(b.CircuitBreakerRules)
+		}})
+	}
+	if b.SystemRules != nil {
+		sections = append(sections, bundleSection{name: "systemRules", prepare: func() (base.PreparedRuleUpdate, error) {
+			return system.PrepareRules(b.SystemRules)
+		}})
+	}
+	return sections
+}
+
+// RuleBundleUpdater validates all the sections of the *RuleBundle and then applies them to the rule managers at once,
+// so the traffic never sees a part of the bundle. If any rule is invalid or any section fails to build,
+// no section is applied.
+// A nil bundle clears the rules of all the rule types.
+func RuleBundleUpdater(data interface{}) error {
+	var bundle *RuleBundle
+	if data == nil {
+		bundle = &RuleBundle{
+			FlowRules:           []*flow.Rule{},
+			CircuitBreakerRules: []*cb.Rule{},
+			HotSpotParamRules:   []*hotspot.Rule{},
+			IsolationRules:      []*isolation.Rule{},
+			SystemRules:         []*system.Rule{},
+		}
+	} else if val, ok := data.(*RuleBundle); ok && val != nil {
+		bundle = val
+	} else {
+		return NewError(
+			UpdatePropertyError,
+			fmt.Sprintf("Fail to type assert data to *datasource.RuleBundle, in fact, data: %+v", data),
+		)
+	}
+
+	if err := bundle.Validate(); err != nil {
+		return NewError(
+			UpdatePropertyError,
+			fmt.Sprintf("Invalid rule bundle, none of the rules is loaded: %+v", err),
+		)
+	}
+
+	sections := bundle.sections()
+	updates := make([]base.PreparedRuleUpdate, 0, len(sections))
+	for _, section := range sections {
+		u, err := section.prepare()
+		if err != nil {
+			logging.Error(err, "[RuleBundle] Fail to build rules, none of the sections is loaded", "section", section.name)
+			base.DiscardRuleUpdates(updates...)
+			return NewError(
+				UpdatePropertyError,
+				fmt.Sprintf("Fail to load %s of rule bundle: %+v", section.name, err),
+			)
+		}
+		updates = append(updates, u)
+	}
+	base.ApplyRuleUpdates(updates...)
+	return nil
+}
+
+func NewRuleBundleHandler(converter PropertyConverter) PropertyHandler {
+	return NewDefaultPropertyHandler(converter, RuleBundleUpdater)
+}
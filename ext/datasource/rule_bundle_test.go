@@ -0,0 +1,192 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datasource
+
+import (
+	"strings"
+	"testing"
+
+	cb "github.com/alibaba/sentinel-golang/core/circuitbreaker"
+	"github.com/alibaba/sentinel-golang/core/flow"
+	"github.com/alibaba/sentinel-golang/core/hotspot"
+	"github.com/alibaba/sentinel-golang/core/isolation"
+	"github.com/alibaba/sentinel-golang/core/system"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	ruleBundleJson = `{
+    "flowRules": [
+        {"resource": "abc", "threshold": 100, "tokenCalculateStrategy": 0, "controlBehavior": 0}
+    ],
+    "circuitBreakerRules": [
+        {"resource": "abc", "strategy": 1, "retryTimeoutMs": 3000, "minRequestAmount": 10, "statIntervalMs": 1000, "threshold": 0.5}
+    ],
+    "hotspotRules": [
+        {"resource": "abc", "metricType": 1, "controlBehavior": 0, "paramIndex": 0, "threshold": 50, "durationInSec": 1,
+         "specificItems": [{"valKind": 0, "valStr": "9", "threshold": 5}]}
+    ],
+    "isolationRules": [
+        {"resource": "abc", "metricType": 0, "threshold": 10}
+    ],
+    "systemRules": [
+        {"metricType": 0, "triggerCount": 0.5, "strategy": 1}
+    ]
+}`
+	ruleBundleYaml = `
+flowRules:
+  - resource: abc
+    threshold: 100
+    tokenCalculateStrategy: 0
+    controlBehavior: 0
+circuitBreakerRules:
+  - resource: abc
+    strategy: 1
+    retryTimeoutMs: 3000
+    minRequestAmount: 10
+    statIntervalMs: 1000
+    threshold: 0.5
+hotspotRules:
+  - resource: abc
+    metricType: 1
+    controlBehavior: 0
+    paramIndex: 0
+    threshold: 50
+    durationInSec: 1
+    specificItems:
+      - valKind: 0
+        valStr: "9"
+        threshold: 5
+isolationRules:
+  - resource: abc
+    metricType: 0
+    threshold: 10
+systemRules:
+  - metricType: 0
+    triggerCount: 0.5
+    strategy: 1
+`
+)
+
+func clearAllRules() {
+	_ = flow.ClearRules()
+	_ = cb.ClearRules()
+	_ = hotspot.ClearRules()
+	_ = isolation.ClearRules()
+	_ = system.ClearRules()
+}
+
+func assertBundleLoaded(t *testing.T) {
+	assert.Equal(t, 1, len(flow.GetRules()))
+	assert.Equal(t, 1, len(cb.GetRules()))
+	assert.Equal(t, 1, len(hotspot.GetRules()))
+	assert.Equal(t, int64(5), hotspot.GetRules()[0].SpecificItems[9])
+	assert.Equal(t, 1, len(isolation.GetRules()))
+	assert.Equal(t, 1, len(system.GetRules()))
+}
+
+func TestRuleBundleParser(t *testing.T) {
+	t.Run("TestRuleBundleParser_Nil", func(t *testing.T) {
+		got, err := RuleBundleJsonParser(nil)
+		assert.True(t, got == nil && err == nil)
+		got, err = RuleBundleYamlParser([]byte{})
+		assert.True(t, got == nil && err == nil)
+	})
+
+	t.Run("TestRuleBundleParser_Error", func(t *testing.T) {
+		_, err := RuleBundleJsonParser([]byte("[xxx"))
+		assert.Error(t, err)
+		_, err = RuleBundleYamlParser([]byte("flowRules: [xxx"))
+		assert.Error(t, err)
+	})
+
+	t.Run("TestRuleBundleParser_Normal", func(t *testing.T) {
+		fromJson, err := RuleBundleJsonParser([]byte(ruleBundleJson))
+		assert.Nil(t, err)
+		fromYaml, err := RuleBundleYamlParser([]byte(ruleBundleYaml))
+		assert.Nil(t, err)
+		assert.Equal(t, fromJson, fromYaml)
+
+		bundle := fromJson.(*RuleBundle)
+		assert.Equal(t, 1, len(bundle.FlowRules))
+		assert.Equal(t, cb.ErrorRatio, bundle.CircuitBreakerRules[0].Strategy)
+		assert.Equal(t, int64(5), bundle.HotSpotParamRules[0].SpecificItems[9])
+		assert.Equal(t, uint32(10), bundle.IsolationRules[0].Threshold)
+		assert.Equal(t, system.BBR, bundle.SystemRules[0].Strategy)
+	})
+
+	t.Run("TestRuleBundleParser_Absent_Section", func(t *testing.T) {
+		got, err := RuleBundleJsonParser([]byte(`{"flowRules": []}`))
+		assert.Nil(t, err)
+		bundle := got.(*RuleBundle)
+		assert.NotNil(t, bundle.FlowRules)
+		assert.Nil(t, bundle.CircuitBreakerRules)
+		assert.Nil(t, bundle.HotSpotParamRules)
+	})
+}
+
+func TestRuleBundleUpdater(t *testing.T) {
+	t.Run("TestRuleBundleUpdater_Normal", func(t *testing.T) {
+		clearAllRules()
+		defer clearAllRules()
+
+		h := NewRuleBundleHandler(RuleBundleYamlParser)
+		assert.Nil(t, h.Handle([]byte(ruleBundleYaml)))
+		assertBundleLoaded(t)
+
+		// Absent sections are left untouched, empty sections are cleared.
+		assert.Nil(t, h.Handle([]byte("flowRules: []\n")))
+		assert.Equal(t, 0, len(flow.GetRules()))
+		assert.Equal(t, 1, len(cb.GetRules()))
+		assert.Equal(t, 1, len(system.GetRules()))
+
+		assert.Nil(t, h.Handle(nil))
+		assert.Equal(t, 0, len(cb.GetRules()))
+		assert.Equal(t, 0, len(hotspot.GetRules()))
+		assert.Equal(t, 0, len(isolation.GetRules()))
+		assert.Equal(t, 0, len(system.GetRules()))
+	})
+
+	t.Run("TestRuleBundleUpdater_Invalid", func(t *testing.T) {
+		clearAllRules()
+		defer clearAllRules()
+
+		h := NewRuleBundleHandler(RuleBundleJsonParser)
+		assert.Nil(t, h.Handle([]byte(ruleBundleJson)))
+		assertBundleLoaded(t)
+
+		// The flow section is valid, but the isolation section is not.
+		invalid := `{
+    "flowRules": [
+        {"resource": "abc", "threshold": 10},
+        {"resource": "def", "threshold": 20}
+    ],
+    "isolationRules": [
+        {"resource": "", "metricType": 0, "threshold": 10}
+    ]
+}`
+		err := h.Handle([]byte(invalid))
+		assert.Error(t, err)
+		assert.True(t, strings.Contains(err.Error(), "isolationRules[0]"))
+		// Nothing is loaded.
+		assertBundleLoaded(t)
+		assert.Equal(t, float64(100), flow.GetRules()[0].Threshold)
+	})
+
+	t.Run("TestRuleBundleUpdater_Type_Error", func(t *testing.T) {
+		err := RuleBundleUpdater([]*flow.Rule{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,150 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datasource
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/alibaba/sentinel-golang/util"
+	"github.com/pkg/errors"
+)
+
+// SnapshotCache persists the last-known-good source of a data source to a local file,
+// so that the rules survive the failures of the data source on startup.
+type SnapshotCache struct {
+	filePath string
+}
+
+func NewSnapshotCache(filePath string) *SnapshotCache {
+	return &SnapshotCache{filePath: filePath}
+}
+
+// Save writes src to the cache file atomically.
+func (c *SnapshotCache) Save(src []byte) error {
+	if err := util.CreateDirIfNotExists(filepath.Dir(c.filePath)); err != nil {
+		return errors.Errorf("Fail to create the directory of snapshot cache, err: %+v", err)
+	}
+	if err := util.WriteFileAtomically(c.filePath, src); err != nil {
+		return errors.Errorf("Fail to write snapshot cache file[%s], err: %+v", c.filePath, err)
+	}
+	return nil
+}
+
+// Load reads the source saved in the cache file.
+func (c *SnapshotCache) Load() ([]byte, error) {
+	src, err := ioutil.ReadFile(c.filePath)
+	if err != nil {
+		return nil, errors.Errorf("Fail to read snapshot cache file[%s], err: %+v", c.filePath, err)
+	}
+	return src, nil
+}
+
+// SetSnapshotCache sets the cache that persists the source after every successful Handle.
+// It should be set before the data source is initialized.
+func (b *Base) SetSnapshotCache(c *SnapshotCache) {
+	b.snapshot = c
+}
+
+// LoadSnapshot updates the property handlers with the source saved in the snapshot cache,
+// it's expected to be called when the data source fails to load on initialization.
+// The rules loaded from the snapshot are marked stale until the next successful Handle.
+func (b *Base) LoadSnapshot() error {
+	if b.snapshot == nil {
+		return nil
+	}
+	src, err := b.snapshot.Load()
+	if err != nil {
+		return err
+	}
+	if err = b.handle(src); err != nil {
+		return err
+	}
+	b.statusMux.Lock()
+	b.stale = true
+	b.statusMux.Unlock()
+	logging.Warn("[Datasource] The rules are loaded from the snapshot cache and stale", "name", b.name, "snapshot", b.snapshot.filePath)
+	return nil
+}
+
+// Status describes the health of a data source.
+type Status struct {
+	Name string `json:"name"`
+	// LastSuccessTime is the unix timestamp in milliseconds of the last successful update, 0 if never succeeded.
+	LastSuccessTime uint64 `json:"lastSuccessTime"`
+	// LastErrorTime is the unix timestamp in milliseconds of the last failed update, 0 if never failed.
+	LastErrorTime uint64 `json:"lastErrorTime"`
+	LastError     string `json:"lastError,omitempty"`
+	// Stale indicates that the rules in effect are loaded from the snapshot cache rather than the data source.
+	Stale bool `json:"stale"`
+}
+
+// Status returns the current status of the data source.
+func (b *Base) Status() Status {
+	b.statusMux.RLock()
+	defer b.statusMux.RUnlock()
+	s := Status{
+		Name:            b.name,
+		LastSuccessTime: b.lastSuccessTime,
+		LastErrorTime:   b.lastErrorTime,
+		Stale:           b.stale,
+	}
+	if b.lastError != nil {
+		s.LastError = b.lastError.Error()
+	}
+	return s
+}
+
+var (
+	statusRegistry    = make(map[string]*Base)
+	statusRegistryMux sync.RWMutex
+)
+
+// TrackStatus registers the data source under the name, so that its status is reported by GetStatuses.
+// It's expected to be called on Initialize, and paired with UntrackStatus on Close.
+func (b *Base) TrackStatus(name string) {
+	statusRegistryMux.Lock()
+	defer statusRegistryMux.Unlock()
+	b.statusMux.Lock()
+	b.name = name
+	b.statusMux.Unlock()
+	statusRegistry[name] = b
+}
+
+// UntrackStatus removes the data source from the status registry, it's expected to be called on Close.
+func (b *Base) UntrackStatus() {
+	statusRegistryMux.Lock()
+	defer statusRegistryMux.Unlock()
+	if registered, ok := statusRegistry[b.name]; ok && registered == b {
+		delete(statusRegistry, b.name)
+	}
+}
+
+// GetStatuses returns the status of all the tracked data sources, sorted by name.
+func GetStatuses() []Status {
+	statusRegistryMux.RLock()
+	ret := make([]Status, 0, len(statusRegistry))
+	for _, b := range statusRegistry {
+		ret = append(ret, b.Status())
+	}
+	statusRegistryMux.RUnlock()
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
@@ -0,0 +1,112 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datasource
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alibaba/sentinel-golang/core/system"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBase_Snapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sentinel-snapshot")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	cache := NewSnapshotCache(filepath.Join(dir, "cache", "system-rules.json"))
+	defer system.ClearRules()
+
+	t.Run("TestBase_Snapshot_Save_On_Success", func(t *testing.T) {
+		b := &Base{}
+		b.AddPropertyHandler(NewSystemRulesHandler(SystemRuleJsonArrayParser))
+		b.SetSnapshotCache(cache)
+
+		assert.Nil(t, b.Handle([]byte(systemRule)))
+		src, err := cache.Load()
+		assert.Nil(t, err)
+		assert.Equal(t, systemRule, string(src))
+		status := b.Status()
+		assert.True(t, status.LastSuccessTime > 0)
+		assert.False(t, status.Stale)
+
+		// The failed source is not saved.
+		assert.Error(t, b.Handle([]byte(systemRuleErr)))
+		src, err = cache.Load()
+		assert.Nil(t, err)
+		assert.Equal(t, systemRule, string(src))
+		status = b.Status()
+		assert.True(t, status.LastErrorTime > 0)
+		assert.NotEmpty(t, status.LastError)
+	})
+
+	t.Run("TestBase_Snapshot_Not_Save_Empty", func(t *testing.T) {
+		b := &Base{}
+		b.AddPropertyHandler(NewSystemRulesHandler(SystemRuleJsonArrayParser))
+		b.SetSnapshotCache(cache)
+
+		// e.g. the source file was removed.
+		assert.Nil(t, b.Handle(nil))
+		src, err := cache.Load()
+		assert.Nil(t, err)
+		assert.Equal(t, systemRule, string(src))
+	})
+
+	t.Run("TestBase_Snapshot_Load", func(t *testing.T) {
+		_ = system.ClearRules()
+		b := &Base{}
+		b.AddPropertyHandler(NewSystemRulesHandler(SystemRuleJsonArrayParser))
+		b.SetSnapshotCache(cache)
+
+		assert.Nil(t, b.LoadSnapshot())
+		assert.Equal(t, 1, len(system.GetRules()))
+		status := b.Status()
+		assert.True(t, status.Stale)
+		assert.Equal(t, uint64(0), status.LastSuccessTime)
+
+		assert.Nil(t, b.Handle([]byte(systemRule)))
+		assert.False(t, b.Status().Stale)
+	})
+
+	t.Run("TestBase_Snapshot_Load_Not_Existed", func(t *testing.T) {
+		b := &Base{}
+		b.SetSnapshotCache(NewSnapshotCache(filepath.Join(dir, "not-existed.json")))
+		assert.Error(t, b.LoadSnapshot())
+		assert.False(t, b.Status().Stale)
+
+		// No snapshot cache.
+		assert.Nil(t, (&Base{}).LoadSnapshot())
+	})
+}
+
+func TestGetStatuses(t *testing.T) {
+	b1, b2 := &Base{}, &Base{}
+	b2.TrackStatus("test:b")
+	b1.TrackStatus("test:a")
+	assert.Nil(t, b1.Handle(nil))
+
+	statuses := GetStatuses()
+	assert.Equal(t, 2, len(statuses))
+	assert.Equal(t, "test:a", statuses[0].Name)
+	assert.True(t, statuses[0].LastSuccessTime > 0)
+	assert.Equal(t, "test:b", statuses[1].Name)
+	assert.Equal(t, uint64(0), statuses[1].LastSuccessTime)
+
+	b1.UntrackStatus()
+	b2.UntrackStatus()
+	assert.Equal(t, 0, len(GetStatuses()))
+}
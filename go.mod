@@ -7,6 +7,7 @@ require (
 	github.com/google/uuid v1.1.1
 	github.com/pkg/errors v0.9.1
 	github.com/prometheus/client_golang v1.9.0
+	github.com/prometheus/client_model v0.2.0
 	github.com/shirou/gopsutil/v3 v3.20.12
 	github.com/stretchr/testify v1.6.1
 	go.uber.org/multierr v1.5.0
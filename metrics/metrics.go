@@ -88,11 +88,16 @@ func SetProcessMemorySize(size int64) {
 
 // SetResourceFlowThreshold sets the # of resource threshold
 func SetResourceFlowThreshold(resource string, threshold float64) {
+	ResourceFlowThresholdGauge(resource).Set(threshold)
+}
+
+// ResourceFlowThresholdGauge returns the gauge of the resource threshold,
+// the caller could hold the gauge to avoid resolving it by labels on every update.
+func ResourceFlowThresholdGauge(resource string) prometheus.Gauge {
 	if len(resource) != 0 {
 		resource = "rs:" + resource
 	}
-
-	ResourceFlowThreshold.WithLabelValues(hostName, resource, "threshold").Set(threshold)
+	return ResourceFlowThreshold.WithLabelValues(hostName, resource, "threshold")
 }
 
 func RegisterSentinelMetrics(registry *prometheus.Registry) {
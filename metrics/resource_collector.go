@@ -0,0 +1,171 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/circuitbreaker"
+	"github.com/alibaba/sentinel-golang/core/stat"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultMaxResources is the default limit of resources exported by ResourceCollector.
+const DefaultMaxResources = 1000
+
+var blockTypes = []base.BlockType{
+	base.BlockTypeUnknown,
+	base.BlockTypeFlow,
+	base.BlockTypeIsolation,
+	base.BlockTypeCircuitBreaking,
+	base.BlockTypeSystemFlow,
+	base.BlockTypeHotSpotParamFlow,
+}
+
+var (
+	resourcePassDesc = prometheus.NewDesc("sentinel_resource_pass_total",
+		"total passed requests of resource", []string{"resource"}, nil)
+	resourceBlockDesc = prometheus.NewDesc("sentinel_resource_block_total",
+		"total blocked requests of resource", []string{"resource"}, nil)
+	resourceCompleteDesc = prometheus.NewDesc("sentinel_resource_complete_total",
+		"total completed requests of resource", []string{"resource"}, nil)
+	resourceErrorDesc = prometheus.NewDesc("sentinel_resource_error_total",
+		"total business errors of resource", []string{"resource"}, nil)
+	resourceRtDesc = prometheus.NewDesc("sentinel_resource_rt_milliseconds",
+		"response time of completed requests of resource in milliseconds", []string{"resource"}, nil)
+	resourceConcurrencyDesc = prometheus.NewDesc("sentinel_resource_concurrency",
+		"current concurrency of resource", []string{"resource"}, nil)
+	resourceBlockByTypeDesc = prometheus.NewDesc("sentinel_resource_block_by_type_total",
+		"total blocked requests of resource by block type", []string{"resource", "block_type"}, nil)
+	resourceShadowBlockByTypeDesc = prometheus.NewDesc("sentinel_resource_shadow_block_total",
+		"total requests of resource which would have been blocked by the rules in shadow mode, by block type", []string{"resource", "block_type"}, nil)
+	circuitBreakerStateDesc = prometheus.NewDesc("sentinel_circuit_breaker_state",
+		"current state of circuit breaker, 0: Closed, 1: HalfOpen, 2: Open", []string{"resource", "rule_index", "rule_id", "strategy"}, nil)
+	droppedResourcesDesc = prometheus.NewDesc("sentinel_collector_dropped_resources",
+		"amount of resources not exported because of the cardinality limit", nil, nil)
+	resourceOverflowDesc = prometheus.NewDesc("sentinel_resource_overflow_total",
+		"total accesses of new resources accounted to the overflow node because of the max amount of resources", nil, nil)
+	resourceEvictedDesc = prometheus.NewDesc("sentinel_resource_evicted_total",
+		"total idle resource nodes evicted because of the max amount of resources", nil, nil)
+)
+
+type collectorOptions struct {
+	maxResources int
+	filter       func(resource string) bool
+}
+
+// CollectorOption is the functional option of ResourceCollector.
+type CollectorOption func(*collectorOptions)
+
+// WithMaxResources sets the limit of exported resources, the resources beyond the limit
+// (in the lexicographical order of resource name) are dropped and counted in sentinel_collector_dropped_resources.
+// The circuit breakers of the dropped resources are not exported either. Non-positive value means no limit.
+func WithMaxResources(max int) CollectorOption {
+	return func(opts *collectorOptions) {
+		opts.maxResources = max
+	}
+}
+
+// WithResourceFilter sets the filter of resources, only the resources accepted by the filter are exported.
+func WithResourceFilter(filter func(resource string) bool) CollectorOption {
+	return func(opts *collectorOptions) {
+		opts.filter = filter
+	}
+}
+
+// ResourceCollector is a prometheus.Collector exporting the statistics of resources,
+// which are read from the resource nodes at scrape time.
+type ResourceCollector struct {
+	opts *collectorOptions
+}
+
+func NewResourceCollector(opts ...CollectorOption) *ResourceCollector {
+	o := &collectorOptions{
+		maxResources: DefaultMaxResources,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return &ResourceCollector{opts: o}
+}
+
+func (c *ResourceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- resourcePassDesc
+	ch <- resourceBlockDesc
+	ch <- resourceCompleteDesc
+	ch <- resourceErrorDesc
+	ch <- resourceRtDesc
+	ch <- resourceConcurrencyDesc
+	ch <- resourceBlockByTypeDesc
+	ch <- resourceShadowBlockByTypeDesc
+	ch <- circuitBreakerStateDesc
+	ch <- droppedResourcesDesc
+	ch <- resourceOverflowDesc
+	ch <- resourceEvictedDesc
+}
+
+func (c *ResourceCollector) Collect(ch chan<- prometheus.Metric) {
+	nodes, dropped := c.selectNodes()
+	exported := make(map[string]struct{}, len(nodes))
+	for _, node := range nodes {
+		res := node.ResourceName()
+		exported[res] = struct{}{}
+		ch <- prometheus.MustNewConstMetric(resourcePassDesc, prometheus.CounterValue, float64(node.TotalCount(base.MetricEventPass)), res)
+		ch <- prometheus.MustNewConstMetric(resourceBlockDesc, prometheus.CounterValue, float64(node.TotalCount(base.MetricEventBlock)), res)
+		complete := node.TotalCount(base.MetricEventComplete)
+		ch <- prometheus.MustNewConstMetric(resourceCompleteDesc, prometheus.CounterValue, float64(complete), res)
+		ch <- prometheus.MustNewConstMetric(resourceErrorDesc, prometheus.CounterValue, float64(node.TotalCount(base.MetricEventError)), res)
+		ch <- prometheus.MustNewConstSummary(resourceRtDesc, uint64(complete), float64(node.TotalCount(base.MetricEventRt)), nil, res)
+		ch <- prometheus.MustNewConstMetric(resourceConcurrencyDesc, prometheus.GaugeValue, float64(node.CurrentConcurrency()), res)
+		for _, t := range blockTypes {
+			ch <- prometheus.MustNewConstMetric(resourceBlockByTypeDesc, prometheus.CounterValue, float64(node.TotalBlockCount(t)), res, t.String())
+			ch <- prometheus.MustNewConstMetric(resourceShadowBlockByTypeDesc, prometheus.CounterValue, float64(node.TotalShadowBlockCount(t)), res, t.String())
+		}
+	}
+	for res, cbs := range circuitbreaker.GetBreakers() {
+		if _, ok := exported[res]; !ok {
+			continue
+		}
+		// The rule id is optional, so the index of breaker is used to tell the breakers of the same resource apart.
+		for i, cb := range cbs {
+			rule := cb.BoundRule()
+			ch <- prometheus.MustNewConstMetric(circuitBreakerStateDesc, prometheus.GaugeValue, float64(cb.CurrentState()),
+				res, strconv.Itoa(i), rule.Id, rule.Strategy.String())
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(droppedResourcesDesc, prometheus.GaugeValue, float64(dropped))
+	ch <- prometheus.MustNewConstMetric(resourceOverflowDesc, prometheus.CounterValue, float64(stat.ResourceOverflowCount()))
+	ch <- prometheus.MustNewConstMetric(resourceEvictedDesc, prometheus.CounterValue, float64(stat.EvictedResourceCount()))
+}
+
+// selectNodes returns the resource nodes to export and the amount of the dropped ones.
+func (c *ResourceCollector) selectNodes() ([]*stat.ResourceNode, int) {
+	all := stat.ResourceNodeList()
+	nodes := all[:0]
+	for _, node := range all {
+		if c.opts.filter == nil || c.opts.filter(node.ResourceName()) {
+			nodes = append(nodes, node)
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ResourceName() < nodes[j].ResourceName()
+	})
+	if c.opts.maxResources <= 0 || len(nodes) <= c.opts.maxResources {
+		return nodes, 0
+	}
+	return nodes[:c.opts.maxResources], len(nodes) - c.opts.maxResources
+}
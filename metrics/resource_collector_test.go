@@ -0,0 +1,113 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/circuitbreaker"
+	"github.com/alibaba/sentinel-golang/core/stat"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func gather(t *testing.T, c prometheus.Collector) map[string][]*dto.Metric {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	families, err := registry.Gather()
+	assert.Nil(t, err)
+	ret := make(map[string][]*dto.Metric, len(families))
+	for _, f := range families {
+		ret[f.GetName()] = f.GetMetric()
+	}
+	return ret
+}
+
+func labelsOf(m *dto.Metric) map[string]string {
+	ret := make(map[string]string)
+	for _, l := range m.GetLabel() {
+		ret[l.GetName()] = l.GetValue()
+	}
+	return ret
+}
+
+func TestResourceCollector(t *testing.T) {
+	stat.ResetResourceNodeMap()
+	defer stat.ResetResourceNodeMap()
+	defer circuitbreaker.ClearRules()
+
+	node := stat.GetOrCreateResourceNode("abc", base.ResTypeCommon)
+	node.AddCount(base.MetricEventPass, 10)
+	node.AddCount(base.MetricEventBlock, 3)
+	node.AddBlockCount(base.BlockTypeFlow, 2)
+	node.AddBlockCount(base.BlockTypeCircuitBreaking, 1)
+	node.AddCount(base.MetricEventComplete, 8)
+	node.AddCount(base.MetricEventError, 1)
+	node.AddCount(base.MetricEventRt, 80)
+	node.IncreaseConcurrency()
+	stat.GetOrCreateResourceNode("def", base.ResTypeCommon)
+
+	_, err := circuitbreaker.LoadRules([]*circuitbreaker.Rule{
+		{Resource: "abc", Strategy: circuitbreaker.ErrorCount, RetryTimeoutMs: 1000, MinRequestAmount: 1, StatIntervalMs: 1000, Threshold: 10},
+		{Resource: "abc", Strategy: circuitbreaker.ErrorCount, RetryTimeoutMs: 2000, MinRequestAmount: 1, StatIntervalMs: 1000, Threshold: 10},
+	})
+	assert.Nil(t, err)
+
+	t.Run("TestResourceCollector_Normal", func(t *testing.T) {
+		metrics := gather(t, NewResourceCollector())
+		assert.Equal(t, 2, len(metrics["sentinel_resource_pass_total"]))
+		pass := metrics["sentinel_resource_pass_total"][0]
+		assert.Equal(t, "abc", labelsOf(pass)["resource"])
+		assert.Equal(t, float64(10), pass.GetCounter().GetValue())
+		assert.Equal(t, float64(3), metrics["sentinel_resource_block_total"][0].GetCounter().GetValue())
+		assert.Equal(t, float64(8), metrics["sentinel_resource_complete_total"][0].GetCounter().GetValue())
+		assert.Equal(t, float64(1), metrics["sentinel_resource_error_total"][0].GetCounter().GetValue())
+		rt := metrics["sentinel_resource_rt_milliseconds"][0].GetSummary()
+		assert.Equal(t, uint64(8), rt.GetSampleCount())
+		assert.Equal(t, float64(80), rt.GetSampleSum())
+		assert.Equal(t, float64(1), metrics["sentinel_resource_concurrency"][0].GetGauge().GetValue())
+
+		blocks := make(map[string]float64)
+		for _, m := range metrics["sentinel_resource_block_by_type_total"] {
+			if labelsOf(m)["resource"] == "abc" {
+				blocks[labelsOf(m)["block_type"]] = m.GetCounter().GetValue()
+			}
+		}
+		assert.Equal(t, float64(2), blocks[base.BlockTypeFlow.String()])
+		assert.Equal(t, float64(1), blocks[base.BlockTypeCircuitBreaking.String()])
+		assert.Equal(t, float64(0), blocks[base.BlockTypeSystemFlow.String()])
+
+		states := metrics["sentinel_circuit_breaker_state"]
+		assert.Equal(t, 2, len(states))
+		assert.Equal(t, float64(circuitbreaker.Closed), states[0].GetGauge().GetValue())
+		assert.Equal(t, float64(0), metrics["sentinel_collector_dropped_resources"][0].GetGauge().GetValue())
+	})
+
+	t.Run("TestResourceCollector_Cardinality_Limit", func(t *testing.T) {
+		metrics := gather(t, NewResourceCollector(WithMaxResources(1)))
+		assert.Equal(t, 1, len(metrics["sentinel_resource_pass_total"]))
+		assert.Equal(t, float64(1), metrics["sentinel_collector_dropped_resources"][0].GetGauge().GetValue())
+
+		metrics = gather(t, NewResourceCollector(WithResourceFilter(func(resource string) bool {
+			return resource == "def"
+		})))
+		assert.Equal(t, 1, len(metrics["sentinel_resource_pass_total"]))
+		assert.Equal(t, "def", labelsOf(metrics["sentinel_resource_pass_total"][0])["resource"])
+		// The circuit breakers of the resources not exported are not exported either.
+		assert.Equal(t, 0, len(metrics["sentinel_circuit_breaker_state"]))
+	})
+}
@@ -0,0 +1,85 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The sync.Pool randomly drops the pooled objects with the race detector enabled,
+// so the allocation guards only run without it.
+
+//go:build !race
+// +build !race
+
+package entry
+
+import (
+	"testing"
+
+	sentinel "github.com/alibaba/sentinel-golang/api"
+	"github.com/alibaba/sentinel-golang/core/base"
+	cb "github.com/alibaba/sentinel-golang/core/circuitbreaker"
+	"github.com/alibaba/sentinel-golang/core/flow"
+	"github.com/alibaba/sentinel-golang/core/hotspot"
+	"github.com/alibaba/sentinel-golang/core/isolation"
+	"github.com/alibaba/sentinel-golang/core/system"
+	"github.com/stretchr/testify/assert"
+)
+
+func passEntry(res string) {
+	e, b := sentinel.Entry(res, sentinel.WithTrafficType(base.Inbound))
+	if b == nil {
+		e.Exit()
+	}
+}
+
+// TestEntry_PlainPassPath_ZeroAllocs guards that sentinel.Entry without any option allocates nothing on the pass path.
+func TestEntry_PlainPassPath_ZeroAllocs(t *testing.T) {
+	allocs := testing.AllocsPerRun(1000, func() {
+		e, b := sentinel.Entry("allocs_plain")
+		if b == nil {
+			e.Exit()
+		}
+	})
+	assert.Equal(t, float64(0), allocs)
+}
+
+// TestEntry_PassPath_ZeroAllocs guards that the common pass path of sentinel.Entry allocates nothing.
+func TestEntry_PassPath_ZeroAllocs(t *testing.T) {
+	_, err := flow.LoadRules([]*flow.Rule{{Resource: "allocs_flow", Threshold: 1e9}})
+	assert.Nil(t, err)
+	_, err = cb.LoadRules([]*cb.Rule{
+		{Resource: "allocs_cb", Strategy: cb.SlowRequestRatio, RetryTimeoutMs: 1000, MinRequestAmount: 10,
+			StatIntervalMs: 1000, MaxAllowedRtMs: 100, Threshold: 0.5},
+		{Resource: "allocs_cb", Strategy: cb.ErrorCount, RetryTimeoutMs: 1000, MinRequestAmount: 10,
+			StatIntervalMs: 1000, Threshold: 1e9},
+	})
+	assert.Nil(t, err)
+	_, err = isolation.LoadRules([]*isolation.Rule{{Resource: "allocs_isolation", MetricType: isolation.Concurrency, Threshold: 1e6}})
+	assert.Nil(t, err)
+	_, err = hotspot.LoadRules([]*hotspot.Rule{{Resource: "allocs_hotspot", MetricType: hotspot.QPS, Threshold: 1e9, DurationInSec: 1}})
+	assert.Nil(t, err)
+	_, err = system.LoadRules([]*system.Rule{{MetricType: system.InboundQPS, TriggerCount: 1e9, Strategy: system.NoAdaptive}})
+	assert.Nil(t, err)
+	defer func() {
+		_ = flow.ClearRules()
+		_ = cb.ClearRules()
+		_ = isolation.ClearRules()
+		_ = hotspot.ClearRules()
+		_ = system.ClearRules()
+	}()
+
+	for _, res := range []string{"allocs_no_rule", "allocs_flow", "allocs_cb", "allocs_isolation", "allocs_hotspot"} {
+		allocs := testing.AllocsPerRun(1000, func() {
+			passEntry(res)
+		})
+		assert.Equal(t, float64(0), allocs, "resource: %s", res)
+	}
+}
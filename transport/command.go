@@ -0,0 +1,59 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// CommandRequest is the request of a command.
+type CommandRequest struct {
+	// Parameters contains both the URL query parameters and the form parameters.
+	Parameters url.Values
+}
+
+// Param returns the first value of the given parameter, or empty string if absent.
+func (r *CommandRequest) Param(key string) string {
+	return r.Parameters.Get(key)
+}
+
+// CommandResponse is the response of a command.
+type CommandResponse struct {
+	Success bool
+	Result  string
+	Err     error
+}
+
+func OfSuccess(result string) *CommandResponse {
+	return &CommandResponse{Success: true, Result: result}
+}
+
+func OfFailure(err error) *CommandResponse {
+	if err == nil {
+		err = errors.New("unknown error")
+	}
+	return &CommandResponse{Success: false, Err: err}
+}
+
+// CommandHandler handles the request of a command.
+type CommandHandler func(req *CommandRequest) *CommandResponse
+
+type command struct {
+	name    string
+	desc    string
+	handler CommandHandler
+}
@@ -0,0 +1,251 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alibaba/sentinel-golang/core/log/metric"
+	"github.com/alibaba/sentinel-golang/ext/datasource"
+	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/alibaba/sentinel-golang/util"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultPort is the default port of command center, which is the same as Sentinel Java.
+	DefaultPort = 8719
+
+	defaultHeartbeatInterval = 10 * time.Second
+	defaultShutdownTimeout   = 3 * time.Second
+)
+
+type options struct {
+	port              int
+	dashboards        []string
+	heartbeatInterval time.Duration
+	heartbeatIp       string
+	client            *http.Client
+	searcher          metric.MetricSearcher
+	writableSources   map[string]datasource.WritableDataSource
+}
+
+// Option is the functional option of CommandCenter.
+type Option func(*options)
+
+// WithPort sets the port the command center listens on, 0 means a random port.
+func WithPort(port int) Option {
+	return func(opts *options) {
+		opts.port = port
+	}
+}
+
+// WithDashboard sets the addresses ("host:port") of the dashboard to send heartbeats to.
+// If there are several addresses, the next one is tried once a heartbeat fails.
+func WithDashboard(addresses ...string) Option {
+	return func(opts *options) {
+		opts.dashboards = append(opts.dashboards, addresses...)
+	}
+}
+
+// WithHeartbeatInterval sets the interval between two heartbeats.
+func WithHeartbeatInterval(interval time.Duration) Option {
+	return func(opts *options) {
+		opts.heartbeatInterval = interval
+	}
+}
+
+// WithHeartbeatIp sets the IP reported to the dashboard, by default the first non-loopback IPv4 address is used.
+func WithHeartbeatIp(ip string) Option {
+	return func(opts *options) {
+		opts.heartbeatIp = ip
+	}
+}
+
+// WithHttpClient sets the http.Client used to send heartbeats.
+func WithHttpClient(client *http.Client) Option {
+	return func(opts *options) {
+		opts.client = client
+	}
+}
+
+// WithMetricSearcher sets the searcher of the metric command,
+// by default the metric logs of current application are searched.
+func WithMetricSearcher(searcher metric.MetricSearcher) Option {
+	return func(opts *options) {
+		opts.searcher = searcher
+	}
+}
+
+// WithWritableDataSource registers the WritableDataSource of the rule type (e.g. RuleTypeFlow),
+// the rules set through the setRules command are written to it in the native format after being loaded.
+// The isolation rules set as the thread-grade flow rules are written to the one of RuleTypeIsolation.
+func WithWritableDataSource(ruleType string, ds datasource.WritableDataSource) Option {
+	return func(opts *options) {
+		opts.writableSources[ruleType] = ds
+	}
+}
+
+// CommandCenter serves the commands over HTTP, the command name is the path of request, e.g. "/getRules".
+type CommandCenter struct {
+	opts *options
+
+	commands    map[string]*command
+	commandsMux sync.RWMutex
+
+	searcherMux sync.Mutex
+
+	server    *http.Server
+	port      int
+	heartbeat *heartbeatSender
+	started   util.AtomicBool
+	closed    util.AtomicBool
+}
+
+// NewCommandCenter creates a CommandCenter with the built-in commands registered.
+func NewCommandCenter(opts ...Option) *CommandCenter {
+	o := &options{
+		port:              DefaultPort,
+		heartbeatInterval: defaultHeartbeatInterval,
+		client:            &http.Client{Timeout: 3 * time.Second},
+		writableSources:   make(map[string]datasource.WritableDataSource),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	cc := &CommandCenter{
+		opts:     o,
+		commands: make(map[string]*command),
+	}
+	cc.registerBuiltinCommands()
+	return cc
+}
+
+// RegisterCommand registers the handler of the command, the handler of the existing command with the same name is replaced.
+func (c *CommandCenter) RegisterCommand(name, desc string, handler CommandHandler) {
+	if name == "" || handler == nil {
+		return
+	}
+	c.commandsMux.Lock()
+	defer c.commandsMux.Unlock()
+	c.commands[name] = &command{name: name, desc: desc, handler: handler}
+}
+
+func (c *CommandCenter) getCommand(name string) *command {
+	c.commandsMux.RLock()
+	defer c.commandsMux.RUnlock()
+	return c.commands[name]
+}
+
+// commandList returns all the registered commands sorted by name.
+func (c *CommandCenter) commandList() []*command {
+	c.commandsMux.RLock()
+	ret := make([]*command, 0, len(c.commands))
+	for _, cmd := range c.commands {
+		ret = append(ret, cmd)
+	}
+	c.commandsMux.RUnlock()
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].name < ret[j].name
+	})
+	return ret
+}
+
+func (c *CommandCenter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.Trim(r.URL.Path, "/")
+	cmd := c.getCommand(name)
+	if cmd == nil {
+		http.Error(w, "Unknown command `"+name+"`", http.StatusBadRequest)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid parameter: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := c.handleCommand(cmd, &CommandRequest{Parameters: r.Form})
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if !resp.Success {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(resp.Err.Error()))
+		return
+	}
+	_, _ = w.Write([]byte(resp.Result))
+}
+
+func (c *CommandCenter) handleCommand(cmd *command, req *CommandRequest) (resp *CommandResponse) {
+	defer func() {
+		if r := recover(); r != nil {
+			err := errors.Errorf("%+v", r)
+			logging.Error(err, "Unexpected panic in CommandCenter.handleCommand()", "command", cmd.name)
+			resp = OfFailure(err)
+		}
+	}()
+	resp = cmd.handler(req)
+	if resp == nil {
+		resp = OfSuccess("")
+	}
+	return resp
+}
+
+// Start starts the HTTP server of command center, and the heartbeat if the dashboard is configured.
+func (c *CommandCenter) Start() error {
+	if !c.started.CompareAndSet(false, true) {
+		return nil
+	}
+	ln, err := net.Listen("tcp", net.JoinHostPort("", strconv.Itoa(c.opts.port)))
+	if err != nil {
+		return errors.Errorf("CommandCenter fail to listen on port %d, err: %+v", c.opts.port, err)
+	}
+	c.port = ln.Addr().(*net.TCPAddr).Port
+	c.server = &http.Server{Handler: c}
+	go util.RunWithRecover(func() {
+		if err := c.server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			logging.Error(err, "CommandCenter server stopped unexpectedly", "port", c.port)
+		}
+	})
+	logging.Info("[CommandCenter] Command center started", "port", c.port)
+
+	if len(c.opts.dashboards) > 0 {
+		c.heartbeat = newHeartbeatSender(c.opts, c.port)
+		c.heartbeat.start()
+	}
+	return nil
+}
+
+// Port returns the port the command center listens on, it's available after started.
+func (c *CommandCenter) Port() int {
+	return c.port
+}
+
+// Close stops the heartbeat and the HTTP server.
+func (c *CommandCenter) Close() error {
+	if !c.started.Get() || !c.closed.CompareAndSet(false, true) {
+		return nil
+	}
+	if c.heartbeat != nil {
+		c.heartbeat.stop()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultShutdownTimeout)
+	defer cancel()
+	return c.server.Shutdown(ctx)
+}
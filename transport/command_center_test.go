@@ -0,0 +1,301 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/config"
+	"github.com/alibaba/sentinel-golang/core/flow"
+	"github.com/alibaba/sentinel-golang/core/isolation"
+	"github.com/alibaba/sentinel-golang/core/stat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockMetricSearcher struct {
+	mock.Mock
+}
+
+func (m *mockMetricSearcher) FindByTimeAndResource(beginTimeMs uint64, endTimeMs uint64, resource string) ([]*base.MetricItem, error) {
+	args := m.Called(beginTimeMs, endTimeMs, resource)
+	return args.Get(0).([]*base.MetricItem), args.Error(1)
+}
+
+func (m *mockMetricSearcher) FindFromTimeWithMaxLines(beginTimeMs uint64, maxLines uint32) ([]*base.MetricItem, error) {
+	args := m.Called(beginTimeMs, maxLines)
+	return args.Get(0).([]*base.MetricItem), args.Error(1)
+}
+
+type mockWritableDataSource struct {
+	mock.Mock
+}
+
+func (m *mockWritableDataSource) Write(data interface{}) error {
+	args := m.Called(data)
+	return args.Error(0)
+}
+
+func (m *mockWritableDataSource) Close() error {
+	return nil
+}
+
+func doCommand(t *testing.T, server *httptest.Server, name string, params url.Values) (int, string) {
+	resp, err := http.PostForm(server.URL+"/"+name, params)
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.Nil(t, err)
+	return resp.StatusCode, string(body)
+}
+
+func TestCommandCenter_Basic(t *testing.T) {
+	cc := NewCommandCenter()
+	server := httptest.NewServer(cc)
+	defer server.Close()
+
+	code, body := doCommand(t, server, "version", nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, config.SentinelVersion, body)
+	// The version is resolved from config.SentinelVersion rather than a copy of it.
+	version := config.SentinelVersion
+	config.SentinelVersion = "9.9.9-test"
+	code, body = doCommand(t, server, "version", nil)
+	config.SentinelVersion = version
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "9.9.9-test", body)
+
+	code, body = doCommand(t, server, "notExisted", nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.True(t, strings.Contains(body, "notExisted"))
+
+	cc.RegisterCommand("panic", "always panic", func(req *CommandRequest) *CommandResponse {
+		panic("test")
+	})
+	code, _ = doCommand(t, server, "panic", nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, body = doCommand(t, server, "api", nil)
+	assert.Equal(t, http.StatusOK, code)
+	var apis []map[string]string
+	assert.Nil(t, json.Unmarshal([]byte(body), &apis))
+	assert.Equal(t, len(cc.commandList()), len(apis))
+	assert.Equal(t, "/api", apis[0]["url"])
+}
+
+func TestCommandCenter_Rules(t *testing.T) {
+	defer flow.ClearRules()
+	defer isolation.ClearRules()
+	ds := &mockWritableDataSource{}
+	ds.On("Write", mock.Anything).Return(nil)
+	cc := NewCommandCenter(WithWritableDataSource(RuleTypeFlow, ds))
+	server := httptest.NewServer(cc)
+	defer server.Close()
+
+	// The payload sent by Sentinel Dashboard.
+	data := `[{"clusterMode":false,"controlBehavior":0,"count":100.0,"grade":1,"limitApp":"default","resource":"abc","strategy":0},` +
+		`{"clusterMode":false,"controlBehavior":0,"count":10.0,"grade":0,"limitApp":"default","resource":"def","strategy":0}]`
+	code, body := doCommand(t, server, "setRules", url.Values{"type": {RuleTypeFlow}, "data": {data}})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "success", body)
+	assert.Equal(t, 1, len(flow.GetRules()))
+	assert.Equal(t, 1, len(isolation.GetRules()))
+	ds.AssertNumberOfCalls(t, "Write", 1)
+	ds.AssertCalled(t, "Write", flow.GetRules())
+
+	code, body = doCommand(t, server, "getRules", url.Values{"type": {RuleTypeFlow}})
+	assert.Equal(t, http.StatusOK, code)
+	var rules []map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(body), &rules))
+	assert.Equal(t, 2, len(rules))
+	assert.Equal(t, "abc", rules[0]["resource"])
+	assert.Equal(t, float64(100), rules[0]["count"])
+	assert.Equal(t, float64(1), rules[0]["grade"])
+	assert.Equal(t, "default", rules[0]["limitApp"])
+	assert.Equal(t, "def", rules[1]["resource"])
+	assert.Equal(t, float64(0), rules[1]["grade"])
+
+	code, _ = doCommand(t, server, "getRules", url.Values{"type": {"authority"}})
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = doCommand(t, server, "getRules", url.Values{"type": {RuleTypeIsolation}})
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = doCommand(t, server, "setRules", url.Values{"type": {RuleTypeFlow}, "data": {"[xxx"}})
+	assert.Equal(t, http.StatusBadRequest, code)
+	// The invalid rule is rejected by the rule manager.
+	code, _ = doCommand(t, server, "setRules", url.Values{"type": {RuleTypeFlow}, "data": {`[{"resource":"","grade":1,"count":1.0}]`}})
+	assert.Equal(t, http.StatusBadRequest, code)
+	// The rule of other applications is not supported.
+	code, _ = doCommand(t, server, "setRules", url.Values{"type": {RuleTypeFlow}, "data": {`[{"resource":"abc","limitApp":"other","grade":1,"count":1.0}]`}})
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, 1, len(flow.GetRules()))
+	assert.Equal(t, 1, len(isolation.GetRules()))
+	ds.AssertNumberOfCalls(t, "Write", 1)
+
+	code, body = doCommand(t, server, "getParamFlowRules", nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "[]", body)
+}
+
+func TestCommandCenter_RuleStatuses(t *testing.T) {
+	defer flow.ClearRules()
+	server := httptest.NewServer(NewCommandCenter())
+	defer server.Close()
+
+	_, err := flow.LoadRules([]*flow.Rule{
+		{Resource: "abc", Threshold: 100},
+		{Resource: "def", Threshold: 100, ExpiresAt: 1},
+	})
+	assert.Nil(t, err)
+
+	code, body := doCommand(t, server, "getRuleStatuses", url.Values{"type": {RuleTypeFlow}})
+	assert.Equal(t, http.StatusOK, code)
+	var statuses []struct {
+		Rule  flow.Rule `json:"rule"`
+		State string    `json:"state"`
+	}
+	assert.Nil(t, json.Unmarshal([]byte(body), &statuses))
+	assert.Equal(t, 2, len(statuses))
+	states := make(map[string]string)
+	for _, s := range statuses {
+		states[s.Rule.Resource] = s.State
+	}
+	assert.Equal(t, map[string]string{"abc": "Active", "def": "Expired"}, states)
+
+	code, _ = doCommand(t, server, "getRuleStatuses", url.Values{"type": {"authority"}})
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestCommandCenter_Metric(t *testing.T) {
+	searcher := &mockMetricSearcher{}
+	item := &base.MetricItem{Resource: "abc", Timestamp: 1000, PassQps: 10, BlockQps: 1}
+	line, _ := item.ToThinString()
+	searcher.On("FindByTimeAndResource", uint64(1000), uint64(2000), "abc").Return([]*base.MetricItem{item}, nil)
+	searcher.On("FindFromTimeWithMaxLines", uint64(1000), uint32(maxMetricMaxLines)).Return([]*base.MetricItem{item, item}, nil)
+	cc := NewCommandCenter(WithMetricSearcher(searcher))
+	server := httptest.NewServer(cc)
+	defer server.Close()
+
+	code, body := doCommand(t, server, "metric", url.Values{"startTime": {"1000"}, "endTime": {"2000"}, "identity": {"abc"}})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, line+"\n", body)
+
+	code, body = doCommand(t, server, "metric", url.Values{"startTime": {"1000"}, "maxLines": {"100000"}})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, line+"\n"+line+"\n", body)
+
+	code, _ = doCommand(t, server, "metric", url.Values{"endTime": {"2000"}})
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = doCommand(t, server, "metric", url.Values{"startTime": {"abc"}})
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestCommandCenter_Nodes(t *testing.T) {
+	stat.ResetResourceNodeMap()
+	defer stat.ResetResourceNodeMap()
+	stat.GetOrCreateResourceNode("def", base.ResTypeCommon)
+	node := stat.GetOrCreateResourceNode("abc", base.ResTypeCommon)
+	node.AddCount(base.MetricEventPass, 1)
+	node.IncreaseConcurrency()
+
+	cc := NewCommandCenter()
+	server := httptest.NewServer(cc)
+	defer server.Close()
+
+	code, body := doCommand(t, server, "clusterNode", nil)
+	assert.Equal(t, http.StatusOK, code)
+	var vos []*nodeVo
+	assert.Nil(t, json.Unmarshal([]byte(body), &vos))
+	assert.Equal(t, 2, len(vos))
+	assert.Equal(t, "abc", vos[0].Resource)
+	assert.Equal(t, int32(1), vos[0].ThreadNum)
+	assert.Equal(t, "def", vos[1].Resource)
+
+	code, body = doCommand(t, server, "jsonTree", nil)
+	assert.Equal(t, http.StatusOK, code)
+	vos = nil
+	assert.Nil(t, json.Unmarshal([]byte(body), &vos))
+	assert.Equal(t, 3, len(vos))
+	assert.Equal(t, machineRootName, vos[0].Id)
+	assert.Equal(t, machineRootName, vos[1].ParentId)
+	assert.Equal(t, machineRootName, vos[2].ParentId)
+}
+
+// dashboard is a stand-in of Sentinel Dashboard recording the heartbeats.
+type dashboard struct {
+	mux        sync.Mutex
+	heartbeats []url.Values
+}
+
+func (d *dashboard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != heartbeatPath || r.ParseForm() != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	d.mux.Lock()
+	d.heartbeats = append(d.heartbeats, r.PostForm)
+	d.mux.Unlock()
+	_, _ = w.Write([]byte("success"))
+}
+
+func (d *dashboard) received() []url.Values {
+	d.mux.Lock()
+	defer d.mux.Unlock()
+	return append([]url.Values{}, d.heartbeats...)
+}
+
+func TestCommandCenter_Heartbeat(t *testing.T) {
+	d := &dashboard{}
+	dashboardServer := httptest.NewServer(d)
+	defer dashboardServer.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	defer down.Close()
+
+	cc := NewCommandCenter(WithPort(0), WithDashboard(down.URL, strings.TrimPrefix(dashboardServer.URL, "http://")),
+		WithHeartbeatInterval(20*time.Millisecond), WithHeartbeatIp("10.0.0.1"))
+	assert.Nil(t, cc.Start())
+	time.Sleep(200 * time.Millisecond)
+
+	heartbeats := d.received()
+	// The first heartbeat is sent to the unavailable dashboard, and the following ones are sent to the next one.
+	assert.True(t, len(heartbeats) > 1)
+	hb := heartbeats[0]
+	assert.Equal(t, config.AppName(), hb.Get("app"))
+	assert.Equal(t, config.SentinelVersion, hb.Get("v"))
+	assert.Equal(t, "10.0.0.1", hb.Get("ip"))
+	assert.Equal(t, strconv.Itoa(cc.Port()), hb.Get("port"))
+	assert.NotEmpty(t, hb.Get("version"))
+
+	// The command center is reachable on the reported port.
+	resp, err := http.Get("http://127.0.0.1:" + hb.Get("port") + "/version")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	_ = resp.Body.Close()
+
+	// No more heartbeat is sent after closed.
+	assert.Nil(t, cc.Close())
+	count := len(d.received())
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, count, len(d.received()))
+}
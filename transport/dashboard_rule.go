@@ -0,0 +1,601 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/alibaba/sentinel-golang/api"
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/circuitbreaker"
+	"github.com/alibaba/sentinel-golang/core/config"
+	"github.com/alibaba/sentinel-golang/core/flow"
+	"github.com/alibaba/sentinel-golang/core/hotspot"
+	"github.com/alibaba/sentinel-golang/core/isolation"
+	"github.com/alibaba/sentinel-golang/core/system"
+	"github.com/pkg/errors"
+)
+
+// The rules are exchanged with Sentinel Dashboard in the JSON of the rule classes of Sentinel (Java), e.g. FlowRule.
+// The thread-grade flow rules are mapped to the isolation rules, and the rules using the features not known by
+// the Dashboard (e.g. pattern resources, conditions or effective periods) are neither exposed to the Dashboard
+// nor replaced by it.
+
+const (
+	limitAppDefault = "default"
+
+	flowGradeThread = 0
+	flowGradeQps    = 1
+
+	flowStrategyDirect = 0
+	flowStrategyRelate = 1
+
+	flowControlDefault           = 0
+	flowControlWarmUp            = 1
+	flowControlRateLimiter       = 2
+	flowControlWarmUpRateLimiter = 3
+
+	degradeGradeRt             = 0
+	degradeGradeExceptionRatio = 1
+	degradeGradeExceptionCount = 2
+
+	paramFlowControlDefault     = 0
+	paramFlowControlRateLimiter = 2
+
+	systemRuleNotSet = -1
+)
+
+// dashboardFlowRule is the FlowRule of Sentinel (Java).
+type dashboardFlowRule struct {
+	Resource          string  `json:"resource"`
+	LimitApp          string  `json:"limitApp"`
+	Grade             int32   `json:"grade"`
+	Count             float64 `json:"count"`
+	Strategy          int32   `json:"strategy"`
+	RefResource       string  `json:"refResource,omitempty"`
+	ControlBehavior   int32   `json:"controlBehavior"`
+	WarmUpPeriodSec   uint32  `json:"warmUpPeriodSec"`
+	MaxQueueingTimeMs uint32  `json:"maxQueueingTimeMs"`
+	ClusterMode       bool    `json:"clusterMode"`
+}
+
+// dashboardDegradeRule is the DegradeRule of Sentinel (Java).
+type dashboardDegradeRule struct {
+	Resource           string  `json:"resource"`
+	LimitApp           string  `json:"limitApp"`
+	Grade              int32   `json:"grade"`
+	Count              float64 `json:"count"`
+	TimeWindow         uint32  `json:"timeWindow"`
+	MinRequestAmount   uint64  `json:"minRequestAmount"`
+	SlowRatioThreshold float64 `json:"slowRatioThreshold"`
+	StatIntervalMs     uint32  `json:"statIntervalMs"`
+}
+
+// dashboardSystemRule is the SystemRule of Sentinel (Java), the thresholds not set are -1.
+type dashboardSystemRule struct {
+	HighestSystemLoad float64 `json:"highestSystemLoad"`
+	HighestCpuUsage   float64 `json:"highestCpuUsage"`
+	Qps               float64 `json:"qps"`
+	AvgRt             float64 `json:"avgRt"`
+	MaxThread         float64 `json:"maxThread"`
+}
+
+// dashboardParamFlowItem is the ParamFlowItem of Sentinel (Java).
+type dashboardParamFlowItem struct {
+	Object    string `json:"object"`
+	Count     int64  `json:"count"`
+	ClassType string `json:"classType"`
+}
+
+// dashboardParamFlowRule is the ParamFlowRule of Sentinel (Java).
+type dashboardParamFlowRule struct {
+	Resource          string                    `json:"resource"`
+	LimitApp          string                    `json:"limitApp"`
+	Grade             int32                     `json:"grade"`
+	ParamIdx          int                       `json:"paramIdx"`
+	Count             float64                   `json:"count"`
+	ControlBehavior   int32                     `json:"controlBehavior"`
+	MaxQueueingTimeMs int64                     `json:"maxQueueingTimeMs"`
+	BurstCount        int64                     `json:"burstCount"`
+	DurationInSec     int64                     `json:"durationInSec"`
+	ParamFlowItemList []*dashboardParamFlowItem `json:"paramFlowItemList"`
+	ClusterMode       bool                      `json:"clusterMode"`
+}
+
+// checkLimitApp checks the common fields of the Dashboard rules which are not supported.
+func checkLimitApp(limitApp string, clusterMode bool) error {
+	if limitApp != "" && limitApp != limitAppDefault {
+		return errors.Errorf("unsupported limitApp: %s", limitApp)
+	}
+	if clusterMode {
+		return errors.New("unsupported cluster mode")
+	}
+	return nil
+}
+
+// isPlainRule checks whether the rule uses none of the features not known by the Dashboard.
+func isPlainRule(matchStrategy base.ResourceMatchStrategy, conditions []*base.MatchCondition, mode base.RuleMode, effectiveFrom, expiresAt uint64) bool {
+	return matchStrategy == base.ResourceMatchExact && len(conditions) == 0 && mode == base.RuleModeEnforce &&
+		effectiveFrom == 0 && expiresAt == 0
+}
+
+func toDashboardFlowRule(r *flow.Rule) (*dashboardFlowRule, bool) {
+	if !isPlainRule(r.ResourceMatchStrategy, r.Conditions, r.Mode, r.EffectiveFrom, r.ExpiresAt) || r.SharedStat ||
+		len(r.Schedule) > 0 || len(r.Reservations) > 0 || len(r.FairShareWeights) > 0 || r.FairShareKey != "" ||
+		(r.StatIntervalInMs != 0 && r.StatIntervalInMs != 1000) ||
+		(r.WarmUpColdFactor != 0 && r.WarmUpColdFactor != config.DefaultWarmUpColdFactor) {
+		return nil, false
+	}
+	d := &dashboardFlowRule{
+		Resource:          r.Resource,
+		LimitApp:          limitAppDefault,
+		Grade:             flowGradeQps,
+		Count:             r.Threshold,
+		Strategy:          flowStrategyDirect,
+		WarmUpPeriodSec:   r.WarmUpPeriodSec,
+		MaxQueueingTimeMs: r.MaxQueueingTimeMs,
+	}
+	if r.RelationStrategy == flow.AssociatedResource {
+		d.Strategy, d.RefResource = flowStrategyRelate, r.RefResource
+	}
+	switch {
+	case r.TokenCalculateStrategy == flow.Direct && r.ControlBehavior == flow.Reject:
+		d.ControlBehavior = flowControlDefault
+	case r.TokenCalculateStrategy == flow.WarmUp && r.ControlBehavior == flow.Reject:
+		d.ControlBehavior = flowControlWarmUp
+	case r.TokenCalculateStrategy == flow.Direct && r.ControlBehavior == flow.Throttling:
+		d.ControlBehavior = flowControlRateLimiter
+	case r.TokenCalculateStrategy == flow.WarmUp && r.ControlBehavior == flow.Throttling:
+		d.ControlBehavior = flowControlWarmUpRateLimiter
+	default:
+		return nil, false
+	}
+	return d, true
+}
+
+// toDashboardThreadFlowRule converts the isolation rule to the thread-grade flow rule.
+func toDashboardThreadFlowRule(r *isolation.Rule) (*dashboardFlowRule, bool) {
+	if !isPlainRule(r.ResourceMatchStrategy, r.Conditions, r.Mode, r.EffectiveFrom, r.ExpiresAt) ||
+		r.MetricType != isolation.Concurrency || len(r.Schedule) > 0 || len(r.Reservations) > 0 {
+		return nil, false
+	}
+	return &dashboardFlowRule{
+		Resource: r.Resource,
+		LimitApp: limitAppDefault,
+		Grade:    flowGradeThread,
+		Count:    float64(r.Threshold),
+		Strategy: flowStrategyDirect,
+	}, true
+}
+
+// fromDashboardFlowRule converts the Dashboard flow rule to either the flow rule or the isolation rule (thread grade).
+func fromDashboardFlowRule(d *dashboardFlowRule) (*flow.Rule, *isolation.Rule, error) {
+	if err := checkLimitApp(d.LimitApp, d.ClusterMode); err != nil {
+		return nil, nil, err
+	}
+	if d.Grade == flowGradeThread {
+		if d.Strategy != flowStrategyDirect || d.ControlBehavior != flowControlDefault {
+			return nil, nil, errors.New("the thread grade only supports the direct strategy and the default control behavior")
+		}
+		if d.Count < 0 || d.Count > math.MaxUint32 {
+			return nil, nil, errors.Errorf("invalid count of thread grade: %v", d.Count)
+		}
+		return nil, &isolation.Rule{
+			Resource:   d.Resource,
+			MetricType: isolation.Concurrency,
+			Threshold:  uint32(d.Count),
+		}, nil
+	}
+	if d.Grade != flowGradeQps {
+		return nil, nil, errors.Errorf("unsupported grade: %d", d.Grade)
+	}
+	r := &flow.Rule{
+		Resource:          d.Resource,
+		Threshold:         d.Count,
+		WarmUpPeriodSec:   d.WarmUpPeriodSec,
+		MaxQueueingTimeMs: d.MaxQueueingTimeMs,
+		StatIntervalInMs:  1000,
+	}
+	switch d.Strategy {
+	case flowStrategyDirect:
+		r.RelationStrategy = flow.CurrentResource
+	case flowStrategyRelate:
+		r.RelationStrategy, r.RefResource = flow.AssociatedResource, d.RefResource
+	default:
+		return nil, nil, errors.Errorf("unsupported strategy: %d", d.Strategy)
+	}
+	switch d.ControlBehavior {
+	case flowControlDefault:
+		r.TokenCalculateStrategy, r.ControlBehavior = flow.Direct, flow.Reject
+	case flowControlWarmUp:
+		r.TokenCalculateStrategy, r.ControlBehavior = flow.WarmUp, flow.Reject
+	case flowControlRateLimiter:
+		r.TokenCalculateStrategy, r.ControlBehavior = flow.Direct, flow.Throttling
+	case flowControlWarmUpRateLimiter:
+		r.TokenCalculateStrategy, r.ControlBehavior = flow.WarmUp, flow.Throttling
+	default:
+		return nil, nil, errors.Errorf("unsupported control behavior: %d", d.ControlBehavior)
+	}
+	return r, nil, nil
+}
+
+func toDashboardDegradeRule(r *circuitbreaker.Rule) (*dashboardDegradeRule, bool) {
+	if !isPlainRule(r.ResourceMatchStrategy, r.Conditions, r.Mode, r.EffectiveFrom, r.ExpiresAt) || r.SharedStat ||
+		r.StatSlidingWindowBucketCount > 1 || r.RetryTimeoutMs%1000 != 0 {
+		return nil, false
+	}
+	d := &dashboardDegradeRule{
+		Resource:         r.Resource,
+		LimitApp:         limitAppDefault,
+		Count:            r.Threshold,
+		TimeWindow:       r.RetryTimeoutMs / 1000,
+		MinRequestAmount: r.MinRequestAmount,
+		StatIntervalMs:   r.StatIntervalMs,
+	}
+	switch r.Strategy {
+	case circuitbreaker.SlowRequestRatio:
+		d.Grade, d.Count, d.SlowRatioThreshold = degradeGradeRt, float64(r.MaxAllowedRtMs), r.Threshold
+	case circuitbreaker.ErrorRatio:
+		d.Grade = degradeGradeExceptionRatio
+	case circuitbreaker.ErrorCount:
+		d.Grade = degradeGradeExceptionCount
+	default:
+		return nil, false
+	}
+	return d, true
+}
+
+func fromDashboardDegradeRule(d *dashboardDegradeRule) (*circuitbreaker.Rule, error) {
+	if err := checkLimitApp(d.LimitApp, false); err != nil {
+		return nil, err
+	}
+	r := &circuitbreaker.Rule{
+		Resource:         d.Resource,
+		RetryTimeoutMs:   d.TimeWindow * 1000,
+		MinRequestAmount: d.MinRequestAmount,
+		StatIntervalMs:   d.StatIntervalMs,
+		Threshold:        d.Count,
+	}
+	if r.StatIntervalMs == 0 {
+		r.StatIntervalMs = 1000
+	}
+	switch d.Grade {
+	case degradeGradeRt:
+		if d.Count < 0 {
+			return nil, errors.Errorf("invalid count of RT grade: %v", d.Count)
+		}
+		r.Strategy, r.MaxAllowedRtMs, r.Threshold = circuitbreaker.SlowRequestRatio, uint64(d.Count), d.SlowRatioThreshold
+	case degradeGradeExceptionRatio:
+		r.Strategy = circuitbreaker.ErrorRatio
+	case degradeGradeExceptionCount:
+		r.Strategy = circuitbreaker.ErrorCount
+	default:
+		return nil, errors.Errorf("unsupported grade: %d", d.Grade)
+	}
+	return r, nil
+}
+
+// toDashboardSystemRule converts the system rule to the Dashboard system rule setting the threshold of its metric only.
+func toDashboardSystemRule(r *system.Rule) (*dashboardSystemRule, bool) {
+	if r.Mode != base.RuleModeEnforce || r.EffectiveFrom != 0 || r.ExpiresAt != 0 {
+		return nil, false
+	}
+	// Only the load is checked with BBR in Sentinel (Java).
+	if (r.MetricType == system.Load) != (r.Strategy == system.BBR) {
+		return nil, false
+	}
+	d := &dashboardSystemRule{
+		HighestSystemLoad: systemRuleNotSet,
+		HighestCpuUsage:   systemRuleNotSet,
+		Qps:               systemRuleNotSet,
+		AvgRt:             systemRuleNotSet,
+		MaxThread:         systemRuleNotSet,
+	}
+	switch r.MetricType {
+	case system.Load:
+		d.HighestSystemLoad = r.TriggerCount
+	case system.CpuUsage:
+		d.HighestCpuUsage = r.TriggerCount
+	case system.InboundQPS:
+		d.Qps = r.TriggerCount
+	case system.AvgRT:
+		d.AvgRt = r.TriggerCount
+	case system.Concurrency:
+		d.MaxThread = r.TriggerCount
+	default:
+		return nil, false
+	}
+	return d, true
+}
+
+// fromDashboardSystemRule converts the Dashboard system rule to a system rule for each threshold set.
+func fromDashboardSystemRule(d *dashboardSystemRule) []*system.Rule {
+	rules := make([]*system.Rule, 0, 1)
+	add := func(metricType system.MetricType, threshold float64, strategy system.AdaptiveStrategy) {
+		if threshold >= 0 {
+			rules = append(rules, &system.Rule{MetricType: metricType, TriggerCount: threshold, Strategy: strategy})
+		}
+	}
+	add(system.Load, d.HighestSystemLoad, system.BBR)
+	add(system.CpuUsage, d.HighestCpuUsage, system.NoAdaptive)
+	add(system.InboundQPS, d.Qps, system.NoAdaptive)
+	add(system.AvgRT, d.AvgRt, system.NoAdaptive)
+	add(system.Concurrency, d.MaxThread, system.NoAdaptive)
+	return rules
+}
+
+func toDashboardParamFlowRule(r *hotspot.Rule) (*dashboardParamFlowRule, bool) {
+	if !isPlainRule(r.ResourceMatchStrategy, r.Conditions, r.Mode, r.EffectiveFrom, r.ExpiresAt) || r.SharedStat ||
+		r.ParamKey != "" || len(r.Schedule) > 0 || r.ParamsMaxCapacity != 0 {
+		return nil, false
+	}
+	d := &dashboardParamFlowRule{
+		Resource:          r.Resource,
+		LimitApp:          limitAppDefault,
+		Grade:             int32(r.MetricType),
+		ParamIdx:          r.ParamIndex,
+		Count:             float64(r.Threshold),
+		MaxQueueingTimeMs: r.MaxQueueingTimeMs,
+		BurstCount:        r.BurstCount,
+		DurationInSec:     r.DurationInSec,
+		ParamFlowItemList: make([]*dashboardParamFlowItem, 0, len(r.SpecificItems)),
+	}
+	switch r.ControlBehavior {
+	case hotspot.Reject:
+		d.ControlBehavior = paramFlowControlDefault
+	case hotspot.Throttling:
+		d.ControlBehavior = paramFlowControlRateLimiter
+	default:
+		return nil, false
+	}
+	for val, threshold := range r.SpecificItems {
+		item := &dashboardParamFlowItem{Count: threshold}
+		switch v := val.(type) {
+		case int:
+			item.Object, item.ClassType = strconv.Itoa(v), "int"
+		case float64:
+			item.Object, item.ClassType = strconv.FormatFloat(v, 'f', -1, 64), "double"
+		case string:
+			item.Object, item.ClassType = v, "java.lang.String"
+		case bool:
+			item.Object, item.ClassType = strconv.FormatBool(v), "boolean"
+		default:
+			return nil, false
+		}
+		d.ParamFlowItemList = append(d.ParamFlowItemList, item)
+	}
+	sort.Slice(d.ParamFlowItemList, func(i, j int) bool {
+		return d.ParamFlowItemList[i].Object < d.ParamFlowItemList[j].Object
+	})
+	return d, true
+}
+
+func fromDashboardParamFlowRule(d *dashboardParamFlowRule) (*hotspot.Rule, error) {
+	if err := checkLimitApp(d.LimitApp, d.ClusterMode); err != nil {
+		return nil, err
+	}
+	r := &hotspot.Rule{
+		Resource:          d.Resource,
+		MetricType:        hotspot.MetricType(d.Grade),
+		ParamIndex:        d.ParamIdx,
+		Threshold:         int64(d.Count),
+		MaxQueueingTimeMs: d.MaxQueueingTimeMs,
+		BurstCount:        d.BurstCount,
+		DurationInSec:     d.DurationInSec,
+		SpecificItems:     make(map[interface{}]int64, len(d.ParamFlowItemList)),
+	}
+	if r.DurationInSec == 0 {
+		r.DurationInSec = 1
+	}
+	switch d.ControlBehavior {
+	case paramFlowControlDefault:
+		r.ControlBehavior = hotspot.Reject
+	case paramFlowControlRateLimiter:
+		r.ControlBehavior = hotspot.Throttling
+	default:
+		return nil, errors.Errorf("unsupported control behavior: %d", d.ControlBehavior)
+	}
+	for _, item := range d.ParamFlowItemList {
+		if item == nil {
+			continue
+		}
+		var (
+			val interface{}
+			err error
+		)
+		switch item.ClassType {
+		case "int", "long", "short", "byte", "java.lang.Integer", "java.lang.Long":
+			val, err = strconv.Atoi(item.Object)
+		case "double", "float", "java.lang.Double", "java.lang.Float":
+			val, err = strconv.ParseFloat(item.Object, 64)
+		case "boolean", "java.lang.Boolean":
+			val, err = strconv.ParseBool(item.Object)
+		case "java.lang.String", "String", "char":
+			val = item.Object
+		default:
+			err = errors.Errorf("unsupported class type: %s", item.ClassType)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid param flow item %s", item.Object)
+		}
+		r.SpecificItems[val] = item.Count
+	}
+	return r, nil
+}
+
+// dashboardFlowRules returns the flow rules and the isolation rules in the Dashboard format.
+func dashboardFlowRules() []*dashboardFlowRule {
+	flowRules, isolationRules := flow.GetRules(), isolation.GetRules()
+	ret := make([]*dashboardFlowRule, 0, len(flowRules)+len(isolationRules))
+	for i := range flowRules {
+		if d, ok := toDashboardFlowRule(&flowRules[i]); ok {
+			ret = append(ret, d)
+		}
+	}
+	for i := range isolationRules {
+		if d, ok := toDashboardThreadFlowRule(&isolationRules[i]); ok {
+			ret = append(ret, d)
+		}
+	}
+	return ret
+}
+
+// setDashboardFlowRules replaces the flow rules and the isolation rules known by the Dashboard together.
+func setDashboardFlowRules(data []byte) error {
+	var rules []*dashboardFlowRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return err
+	}
+	flowRules, isolationRules := make([]*flow.Rule, 0, len(rules)), make([]*isolation.Rule, 0)
+	for i, d := range rules {
+		if d == nil {
+			continue
+		}
+		f, iso, err := fromDashboardFlowRule(d)
+		if err != nil {
+			return errors.Wrapf(err, "invalid flow rule #%d", i)
+		}
+		if f != nil {
+			flowRules = append(flowRules, f)
+		} else {
+			isolationRules = append(isolationRules, iso)
+		}
+	}
+	for _, r := range flow.GetRules() {
+		if _, ok := toDashboardFlowRule(&r); !ok {
+			kept := r
+			flowRules = append(flowRules, &kept)
+		}
+	}
+	for _, r := range isolation.GetRules() {
+		if _, ok := toDashboardThreadFlowRule(&r); !ok {
+			kept := r
+			isolationRules = append(isolationRules, &kept)
+		}
+	}
+	return api.NewRuleTransaction().LoadFlowRules(flowRules).LoadIsolationRules(isolationRules).Commit()
+}
+
+func dashboardDegradeRules() []*dashboardDegradeRule {
+	rules := circuitbreaker.GetRules()
+	ret := make([]*dashboardDegradeRule, 0, len(rules))
+	for i := range rules {
+		if d, ok := toDashboardDegradeRule(&rules[i]); ok {
+			ret = append(ret, d)
+		}
+	}
+	return ret
+}
+
+func setDashboardDegradeRules(data []byte) error {
+	var rules []*dashboardDegradeRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return err
+	}
+	cbRules := make([]*circuitbreaker.Rule, 0, len(rules))
+	for i, d := range rules {
+		if d == nil {
+			continue
+		}
+		r, err := fromDashboardDegradeRule(d)
+		if err != nil {
+			return errors.Wrapf(err, "invalid degrade rule #%d", i)
+		}
+		cbRules = append(cbRules, r)
+	}
+	for _, r := range circuitbreaker.GetRules() {
+		if _, ok := toDashboardDegradeRule(&r); !ok {
+			kept := r
+			cbRules = append(cbRules, &kept)
+		}
+	}
+	return api.NewRuleTransaction().LoadCircuitBreakerRules(cbRules).Commit()
+}
+
+func dashboardParamFlowRules() []*dashboardParamFlowRule {
+	rules := hotspot.GetRules()
+	ret := make([]*dashboardParamFlowRule, 0, len(rules))
+	for i := range rules {
+		if d, ok := toDashboardParamFlowRule(&rules[i]); ok {
+			ret = append(ret, d)
+		}
+	}
+	return ret
+}
+
+func setDashboardParamFlowRules(data []byte) error {
+	var rules []*dashboardParamFlowRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return err
+	}
+	hotspotRules := make([]*hotspot.Rule, 0, len(rules))
+	for i, d := range rules {
+		if d == nil {
+			continue
+		}
+		r, err := fromDashboardParamFlowRule(d)
+		if err != nil {
+			return errors.Wrapf(err, "invalid param flow rule #%d", i)
+		}
+		hotspotRules = append(hotspotRules, r)
+	}
+	for _, r := range hotspot.GetRules() {
+		if _, ok := toDashboardParamFlowRule(&r); !ok {
+			kept := r
+			hotspotRules = append(hotspotRules, &kept)
+		}
+	}
+	return api.NewRuleTransaction().LoadHotspotRules(hotspotRules).Commit()
+}
+
+func dashboardSystemRules() []*dashboardSystemRule {
+	rules := system.GetRules()
+	ret := make([]*dashboardSystemRule, 0, len(rules))
+	for i := range rules {
+		if d, ok := toDashboardSystemRule(&rules[i]); ok {
+			ret = append(ret, d)
+		}
+	}
+	return ret
+}
+
+func setDashboardSystemRules(data []byte) error {
+	var rules []*dashboardSystemRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return err
+	}
+	systemRules := make([]*system.Rule, 0, len(rules))
+	for _, d := range rules {
+		if d != nil {
+			systemRules = append(systemRules, fromDashboardSystemRule(d)...)
+		}
+	}
+	for i, r := range systemRules {
+		if err := system.IsValidSystemRule(r); err != nil {
+			return errors.Wrapf(err, "invalid system rule #%d", i)
+		}
+	}
+	for _, r := range system.GetRules() {
+		if _, ok := toDashboardSystemRule(&r); !ok {
+			kept := r
+			systemRules = append(systemRules, &kept)
+		}
+	}
+	_, err := system.LoadRules(systemRules)
+	return err
+}
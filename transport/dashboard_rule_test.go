@@ -0,0 +1,166 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"testing"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/circuitbreaker"
+	"github.com/alibaba/sentinel-golang/core/flow"
+	"github.com/alibaba/sentinel-golang/core/hotspot"
+	"github.com/alibaba/sentinel-golang/core/isolation"
+	"github.com/alibaba/sentinel-golang/core/system"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetDashboardFlowRules(t *testing.T) {
+	defer flow.ClearRules()
+	defer isolation.ClearRules()
+
+	_, err := flow.LoadRules([]*flow.Rule{
+		{Resource: "abc", Threshold: 1},
+		{Resource: "/api/", ResourceMatchStrategy: base.ResourceMatchPrefix, Threshold: 10},
+	})
+	assert.Nil(t, err)
+
+	data := `[{"clusterMode":false,"controlBehavior":3,"count":20.0,"grade":1,"limitApp":"default","maxQueueingTimeMs":500,` +
+		`"resource":"abc","strategy":0,"warmUpPeriodSec":10},` +
+		`{"clusterMode":false,"controlBehavior":0,"count":5.0,"grade":1,"limitApp":"default","refResource":"abc","resource":"def","strategy":1},` +
+		`{"clusterMode":false,"controlBehavior":0,"count":8.0,"grade":0,"limitApp":"default","resource":"ghi","strategy":0}]`
+	assert.Nil(t, setDashboardFlowRules([]byte(data)))
+
+	rules := flow.GetRules()
+	assert.Equal(t, 3, len(rules))
+	byResource := make(map[string]flow.Rule)
+	for _, r := range rules {
+		byResource[r.Resource] = r
+	}
+	abc := byResource["abc"]
+	assert.Equal(t, flow.WarmUp, abc.TokenCalculateStrategy)
+	assert.Equal(t, flow.Throttling, abc.ControlBehavior)
+	assert.Equal(t, float64(20), abc.Threshold)
+	assert.Equal(t, uint32(10), abc.WarmUpPeriodSec)
+	assert.Equal(t, uint32(500), abc.MaxQueueingTimeMs)
+	assert.Equal(t, flow.AssociatedResource, byResource["def"].RelationStrategy)
+	assert.Equal(t, "abc", byResource["def"].RefResource)
+	// The pattern rule is not known by the Dashboard, so it's kept.
+	assert.Equal(t, base.ResourceMatchPrefix, byResource["/api/"].ResourceMatchStrategy)
+
+	isolationRules := isolation.GetRules()
+	assert.Equal(t, 1, len(isolationRules))
+	assert.Equal(t, "ghi", isolationRules[0].Resource)
+	assert.Equal(t, uint32(8), isolationRules[0].Threshold)
+
+	ret := dashboardFlowRules()
+	assert.Equal(t, 3, len(ret))
+	for _, d := range ret {
+		assert.Equal(t, "default", d.LimitApp)
+		assert.NotEqual(t, "/api/", d.Resource)
+	}
+
+	// The chain strategy and the cluster mode are not supported, and the rules are not changed.
+	assert.NotNil(t, setDashboardFlowRules([]byte(`[{"resource":"abc","limitApp":"default","grade":1,"count":1.0,"strategy":2}]`)))
+	assert.NotNil(t, setDashboardFlowRules([]byte(`[{"resource":"abc","limitApp":"default","grade":1,"count":1.0,"clusterMode":true}]`)))
+	assert.Equal(t, 3, len(flow.GetRules()))
+	assert.Equal(t, 1, len(isolation.GetRules()))
+}
+
+func TestSetDashboardDegradeRules(t *testing.T) {
+	defer circuitbreaker.ClearRules()
+
+	data := `[{"count":500.0,"grade":0,"limitApp":"default","minRequestAmount":5,"resource":"abc","slowRatioThreshold":0.5,` +
+		`"statIntervalMs":1000,"timeWindow":10},` +
+		`{"count":0.2,"grade":1,"limitApp":"default","minRequestAmount":5,"resource":"def","statIntervalMs":1000,"timeWindow":5},` +
+		`{"count":10.0,"grade":2,"limitApp":"default","minRequestAmount":5,"resource":"ghi","timeWindow":5}]`
+	assert.Nil(t, setDashboardDegradeRules([]byte(data)))
+
+	rules := circuitbreaker.GetRules()
+	assert.Equal(t, 3, len(rules))
+	byResource := make(map[string]circuitbreaker.Rule)
+	for _, r := range rules {
+		byResource[r.Resource] = r
+	}
+	abc := byResource["abc"]
+	assert.Equal(t, circuitbreaker.SlowRequestRatio, abc.Strategy)
+	assert.Equal(t, uint64(500), abc.MaxAllowedRtMs)
+	assert.Equal(t, 0.5, abc.Threshold)
+	assert.Equal(t, uint32(10000), abc.RetryTimeoutMs)
+	assert.Equal(t, circuitbreaker.ErrorRatio, byResource["def"].Strategy)
+	assert.Equal(t, circuitbreaker.ErrorCount, byResource["ghi"].Strategy)
+	assert.Equal(t, uint32(1000), byResource["ghi"].StatIntervalMs)
+
+	ret := dashboardDegradeRules()
+	assert.Equal(t, 3, len(ret))
+	for _, d := range ret {
+		if d.Resource == "abc" {
+			assert.Equal(t, int32(0), d.Grade)
+			assert.Equal(t, float64(500), d.Count)
+			assert.Equal(t, 0.5, d.SlowRatioThreshold)
+			assert.Equal(t, uint32(10), d.TimeWindow)
+		}
+	}
+}
+
+func TestSetDashboardParamFlowRules(t *testing.T) {
+	defer hotspot.ClearRules()
+
+	data := `[{"burstCount":0,"clusterMode":false,"controlBehavior":0,"count":10.0,"durationInSec":1,"grade":1,"limitApp":"default",` +
+		`"maxQueueingTimeMs":0,"paramFlowItemList":[{"classType":"java.lang.String","count":1,"object":"a"},` +
+		`{"classType":"int","count":2,"object":"100"}],"paramIdx":0,"resource":"abc"}]`
+	assert.Nil(t, setDashboardParamFlowRules([]byte(data)))
+
+	rules := hotspot.GetRules()
+	assert.Equal(t, 1, len(rules))
+	assert.Equal(t, hotspot.QPS, rules[0].MetricType)
+	assert.Equal(t, hotspot.Reject, rules[0].ControlBehavior)
+	assert.Equal(t, int64(10), rules[0].Threshold)
+	assert.Equal(t, map[interface{}]int64{"a": 1, 100: 2}, rules[0].SpecificItems)
+
+	ret := dashboardParamFlowRules()
+	assert.Equal(t, 1, len(ret))
+	assert.Equal(t, []*dashboardParamFlowItem{
+		{Object: "100", Count: 2, ClassType: "int"},
+		{Object: "a", Count: 1, ClassType: "java.lang.String"},
+	}, ret[0].ParamFlowItemList)
+
+	assert.NotNil(t, setDashboardParamFlowRules([]byte(`[{"resource":"abc","limitApp":"default","grade":1,"count":1.0,`+
+		`"paramFlowItemList":[{"classType":"int","count":1,"object":"a"}]}]`)))
+	assert.Equal(t, 1, len(hotspot.GetRules()))
+}
+
+func TestSetDashboardSystemRules(t *testing.T) {
+	defer system.ClearRules()
+
+	data := `[{"avgRt":-1,"highestCpuUsage":0.8,"highestSystemLoad":2.5,"maxThread":-1,"qps":-1.0}]`
+	assert.Nil(t, setDashboardSystemRules([]byte(data)))
+
+	rules := system.GetRules()
+	assert.Equal(t, 2, len(rules))
+	byType := make(map[system.MetricType]system.Rule)
+	for _, r := range rules {
+		byType[r.MetricType] = r
+	}
+	assert.Equal(t, 2.5, byType[system.Load].TriggerCount)
+	assert.Equal(t, system.BBR, byType[system.Load].Strategy)
+	assert.Equal(t, 0.8, byType[system.CpuUsage].TriggerCount)
+
+	ret := dashboardSystemRules()
+	assert.Equal(t, 2, len(ret))
+	for _, d := range ret {
+		assert.Equal(t, float64(-1), d.Qps)
+		assert.True(t, d.HighestSystemLoad == 2.5 || d.HighestCpuUsage == 0.8)
+	}
+}
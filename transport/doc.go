@@ -0,0 +1,42 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package transport provides the command center that exposes the Sentinel commands over HTTP,
+// which is compatible with the command API (sentinel-transport) used by Sentinel Dashboard.
+//
+// The built-in commands are:
+//
+//	getRules?type=flow|degrade|system|param-flow
+//	setRules?type=flow|degrade|system|param-flow&data=<rules JSON>
+//	getRuleStatuses?type=flow|degrade|system|param-flow|isolation
+//	metric?startTime=<ms>&endTime=<ms>&identity=<resource>&maxLines=<n>
+//	clusterNode
+//	jsonTree
+//	version
+//	api
+//
+// The rules of getRules and setRules are in the JSON of the rule classes of Sentinel (Java), e.g. FlowRule, as
+// Sentinel Dashboard does. The thread-grade flow rules are loaded as the isolation rules, and the rules using
+// the features not known by the Dashboard (e.g. pattern resources) are neither returned nor replaced by these commands.
+//
+// The command center also sends heartbeats to the dashboard periodically, so that the machine is discovered by the dashboard.
+//
+// Sample code:
+//
+//	cc := transport.NewCommandCenter(transport.WithPort(8719), transport.WithDashboard("127.0.0.1:8080"))
+//	if err := cc.Start(); err != nil {
+//		// handle error
+//	}
+//	defer cc.Close()
+package transport
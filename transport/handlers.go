@@ -0,0 +1,326 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alibaba/sentinel-golang/core/base"
+	"github.com/alibaba/sentinel-golang/core/circuitbreaker"
+	"github.com/alibaba/sentinel-golang/core/config"
+	"github.com/alibaba/sentinel-golang/core/flow"
+	"github.com/alibaba/sentinel-golang/core/hotspot"
+	"github.com/alibaba/sentinel-golang/core/isolation"
+	"github.com/alibaba/sentinel-golang/core/log/metric"
+	"github.com/alibaba/sentinel-golang/core/stat"
+	"github.com/alibaba/sentinel-golang/core/system"
+	"github.com/alibaba/sentinel-golang/ext/datasource"
+	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/alibaba/sentinel-golang/util"
+	"github.com/pkg/errors"
+)
+
+// The rule types of getRules and setRules command, which are the same as Sentinel Dashboard.
+// The flow type covers both the flow rules and the isolation rules, which are the thread-grade flow rules of the Dashboard.
+const (
+	RuleTypeFlow      = "flow"
+	RuleTypeDegrade   = "degrade"
+	RuleTypeSystem    = "system"
+	RuleTypeParamFlow = "param-flow"
+	// RuleTypeIsolation is only used by getRuleStatuses and WithWritableDataSource,
+	// the isolation rules are exchanged with the Dashboard as the flow rules.
+	RuleTypeIsolation = "isolation"
+)
+
+const (
+	defaultMetricMaxLines = 6000
+	maxMetricMaxLines     = 12000
+
+	machineRootName = "machine-root"
+)
+
+type ruleTypeAccessor struct {
+	// rules returns the rules in the format of Sentinel Dashboard.
+	rules func() interface{}
+	// setRules loads the rules in the format of Sentinel Dashboard, it fails without any change on any invalid rule.
+	setRules func(data []byte) error
+	// written is the rule types whose rules are written to the writable data sources after setRules.
+	written []string
+}
+
+var ruleTypeAccessors = map[string]*ruleTypeAccessor{
+	RuleTypeFlow: {func() interface{} { return dashboardFlowRules() }, setDashboardFlowRules,
+		[]string{RuleTypeFlow, RuleTypeIsolation}},
+	RuleTypeDegrade: {func() interface{} { return dashboardDegradeRules() }, setDashboardDegradeRules,
+		[]string{RuleTypeDegrade}},
+	RuleTypeSystem: {func() interface{} { return dashboardSystemRules() }, setDashboardSystemRules,
+		[]string{RuleTypeSystem}},
+	RuleTypeParamFlow: {func() interface{} { return dashboardParamFlowRules() }, setDashboardParamFlowRules,
+		[]string{RuleTypeParamFlow}},
+}
+
+// ruleSuppliers supplies the rules of each type in the native format, which are written to the writable data sources.
+var ruleSuppliers = map[string]datasource.PropertySupplier{
+	RuleTypeFlow:      datasource.FlowRulesSupplier,
+	RuleTypeDegrade:   datasource.CircuitBreakerRulesSupplier,
+	RuleTypeSystem:    datasource.SystemRulesSupplier,
+	RuleTypeParamFlow: datasource.HotSpotParamRulesSupplier,
+	RuleTypeIsolation: datasource.IsolationRulesSupplier,
+}
+
+// ruleStatuses returns the statuses of the loaded rules of each type, including the ones out of their effective period.
+var ruleStatuses = map[string]func() []base.RuleStatus{
+	RuleTypeFlow:      flow.GetRuleStatuses,
+	RuleTypeDegrade:   circuitbreaker.GetRuleStatuses,
+	RuleTypeSystem:    system.GetRuleStatuses,
+	RuleTypeParamFlow: hotspot.GetRuleStatuses,
+	RuleTypeIsolation: isolation.GetRuleStatuses,
+}
+
+func (c *CommandCenter) registerBuiltinCommands() {
+	c.RegisterCommand("version", "get sentinel version", c.handleVersion)
+	c.RegisterCommand("api", "get all available command handlers", c.handleApi)
+	c.RegisterCommand("getRules", "get all active rules by type, request param: type={ruleType}", c.handleGetRules)
+	c.RegisterCommand("getRuleStatuses", "get all loaded rules with their states (Active, Pending or Expired) by type, request param: type={ruleType}", c.handleGetRuleStatuses)
+	c.RegisterCommand("setRules", "modify the rules, accept param: type={ruleType}&data={ruleJson}", c.handleSetRules)
+	c.RegisterCommand("getParamFlowRules", "get all active parameter flow rules", func(req *CommandRequest) *CommandResponse {
+		return c.getRules(RuleTypeParamFlow)
+	})
+	c.RegisterCommand("setParamFlowRules", "set parameter flow rules, accept param: data={paramFlowRuleJson}", func(req *CommandRequest) *CommandResponse {
+		return c.setRules(RuleTypeParamFlow, req.Param("data"))
+	})
+	c.RegisterCommand("metric", "get and aggregate metrics, accept param: startTime={startTime}&endTime={endTime}&maxLines={maxLines}&identity={resourceName}", c.handleMetric)
+	c.RegisterCommand("clusterNode", "get all clusterNode VO", c.handleClusterNode)
+	c.RegisterCommand("jsonTree", "get tree node VO start from root node", c.handleJsonTree)
+}
+
+func (c *CommandCenter) handleVersion(_ *CommandRequest) *CommandResponse {
+	return OfSuccess(config.SentinelVersion)
+}
+
+func (c *CommandCenter) handleApi(_ *CommandRequest) *CommandResponse {
+	type apiVo struct {
+		Url  string `json:"url"`
+		Desc string `json:"desc"`
+	}
+	commands := c.commandList()
+	vos := make([]apiVo, 0, len(commands))
+	for _, cmd := range commands {
+		vos = append(vos, apiVo{Url: "/" + cmd.name, Desc: cmd.desc})
+	}
+	return jsonResponse(vos)
+}
+
+func (c *CommandCenter) handleGetRules(req *CommandRequest) *CommandResponse {
+	return c.getRules(req.Param("type"))
+}
+
+func (c *CommandCenter) getRules(ruleType string) *CommandResponse {
+	accessor, ok := ruleTypeAccessors[ruleType]
+	if !ok {
+		return OfFailure(errors.Errorf("invalid rule type: %s", ruleType))
+	}
+	src, err := datasource.JsonEncoder(accessor.rules())
+	if err != nil {
+		return OfFailure(err)
+	}
+	return OfSuccess(string(src))
+}
+
+func (c *CommandCenter) handleGetRuleStatuses(req *CommandRequest) *CommandResponse {
+	statuses, ok := ruleStatuses[req.Param("type")]
+	if !ok {
+		return OfFailure(errors.Errorf("invalid rule type: %s", req.Param("type")))
+	}
+	return jsonResponse(statuses())
+}
+
+func (c *CommandCenter) handleSetRules(req *CommandRequest) *CommandResponse {
+	return c.setRules(req.Param("type"), req.Param("data"))
+}
+
+func (c *CommandCenter) setRules(ruleType, data string) *CommandResponse {
+	accessor, ok := ruleTypeAccessors[ruleType]
+	if !ok {
+		return OfFailure(errors.Errorf("invalid rule type: %s", ruleType))
+	}
+	if err := accessor.setRules([]byte(data)); err != nil {
+		return OfFailure(errors.Errorf("load rules error: %+v", err))
+	}
+	logging.Info("[CommandCenter] Rules were set through command", "type", ruleType)
+
+	for _, t := range accessor.written {
+		ds, ok := c.opts.writableSources[t]
+		if !ok {
+			continue
+		}
+		if err := ds.Write(ruleSuppliers[t]()); err != nil {
+			logging.Error(err, "Fail to write rules to the writable data source", "type", t)
+			return OfFailure(errors.Errorf("write data source failed: %+v", err))
+		}
+	}
+	return OfSuccess("success")
+}
+
+func (c *CommandCenter) metricSearcher() (metric.MetricSearcher, error) {
+	c.searcherMux.Lock()
+	defer c.searcherMux.Unlock()
+	if c.opts.searcher != nil {
+		return c.opts.searcher, nil
+	}
+	baseDir := config.LogBaseDir()
+	if baseDir == "" {
+		baseDir = config.GetDefaultLogDir()
+	}
+	searcher, err := metric.NewDefaultMetricSearcher(baseDir, metric.FormMetricFileName(config.AppName(), config.LogUsePid()))
+	if err != nil {
+		return nil, err
+	}
+	c.opts.searcher = searcher
+	return searcher, nil
+}
+
+func parseUintParam(req *CommandRequest, key string, defaultValue uint64) (uint64, error) {
+	v := req.Param(key)
+	if v == "" {
+		return defaultValue, nil
+	}
+	ret, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return 0, errors.Errorf("invalid %s: %s", key, v)
+	}
+	return ret, nil
+}
+
+func (c *CommandCenter) handleMetric(req *CommandRequest) *CommandResponse {
+	if req.Param("startTime") == "" {
+		return OfFailure(errors.New("startTime is required"))
+	}
+	startTime, err := parseUintParam(req, "startTime", 0)
+	if err != nil {
+		return OfFailure(err)
+	}
+	endTime, err := parseUintParam(req, "endTime", 0)
+	if err != nil {
+		return OfFailure(err)
+	}
+	maxLines, err := parseUintParam(req, "maxLines", defaultMetricMaxLines)
+	if err != nil {
+		return OfFailure(err)
+	}
+	if maxLines > maxMetricMaxLines {
+		maxLines = maxMetricMaxLines
+	}
+
+	searcher, err := c.metricSearcher()
+	if err != nil {
+		return OfFailure(err)
+	}
+	var items []*base.MetricItem
+	if endTime > 0 {
+		items, err = searcher.FindByTimeAndResource(startTime, endTime, req.Param("identity"))
+	} else {
+		items, err = searcher.FindFromTimeWithMaxLines(startTime, uint32(maxLines))
+	}
+	if err != nil {
+		return OfFailure(errors.Errorf("fail to search metrics: %+v", err))
+	}
+
+	b := strings.Builder{}
+	for _, item := range items {
+		line, err := item.ToThinString()
+		if err != nil {
+			continue
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return OfSuccess(b.String())
+}
+
+// nodeVo is the view of statistic node, which is the same as the NodeVo of Sentinel Dashboard.
+type nodeVo struct {
+	Id           string  `json:"id"`
+	ParentId     string  `json:"parentId,omitempty"`
+	Resource     string  `json:"resource"`
+	ThreadNum    int32   `json:"threadNum"`
+	PassQps      int64   `json:"passQps"`
+	BlockQps     int64   `json:"blockQps"`
+	TotalQps     int64   `json:"totalQps"`
+	AverageRt    int64   `json:"averageRt"`
+	SuccessQps   int64   `json:"successQps"`
+	ExceptionQps int64   `json:"exceptionQps"`
+	Timestamp    uint64  `json:"timestamp"`
+	MinRt        float64 `json:"minRt"`
+}
+
+func newNodeVo(id, parentId, resource string, node *stat.BaseStatNode) *nodeVo {
+	pass := int64(node.GetQPS(base.MetricEventPass))
+	block := int64(node.GetQPS(base.MetricEventBlock))
+	return &nodeVo{
+		Id:           id,
+		ParentId:     parentId,
+		Resource:     resource,
+		ThreadNum:    node.CurrentConcurrency(),
+		PassQps:      pass,
+		BlockQps:     block,
+		TotalQps:     pass + block,
+		AverageRt:    int64(node.AvgRT()),
+		SuccessQps:   int64(node.GetQPS(base.MetricEventComplete)),
+		ExceptionQps: int64(node.GetQPS(base.MetricEventError)),
+		Timestamp:    util.CurrentTimeMillis(),
+		MinRt:        node.MinRT(),
+	}
+}
+
+func sortedResourceNodes() []*stat.ResourceNode {
+	nodes := stat.ResourceNodeList()
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ResourceName() < nodes[j].ResourceName()
+	})
+	return nodes
+}
+
+func (c *CommandCenter) handleClusterNode(_ *CommandRequest) *CommandResponse {
+	nodes := sortedResourceNodes()
+	vos := make([]*nodeVo, 0, len(nodes))
+	for _, n := range nodes {
+		vos = append(vos, newNodeVo(n.ResourceName(), "", n.ResourceName(), &n.BaseStatNode))
+	}
+	return jsonResponse(vos)
+}
+
+// handleJsonTree returns the flattened node tree, whose root is the machine root node (holding the inbound statistics)
+// and the children are the resource nodes.
+func (c *CommandCenter) handleJsonTree(_ *CommandRequest) *CommandResponse {
+	nodes := sortedResourceNodes()
+	vos := make([]*nodeVo, 0, len(nodes)+1)
+	vos = append(vos, newNodeVo(machineRootName, "", machineRootName, &stat.InboundNode().BaseStatNode))
+	for _, n := range nodes {
+		vos = append(vos, newNodeVo(n.ResourceName(), machineRootName, n.ResourceName(), &n.BaseStatNode))
+	}
+	return jsonResponse(vos)
+}
+
+func jsonResponse(v interface{}) *CommandResponse {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return OfFailure(errors.Errorf("fail to encode result: %+v", err))
+	}
+	return OfSuccess(string(data))
+}
@@ -0,0 +1,135 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alibaba/sentinel-golang/core/config"
+	"github.com/alibaba/sentinel-golang/logging"
+	"github.com/alibaba/sentinel-golang/util"
+	"github.com/pkg/errors"
+)
+
+// heartbeatPath is the API of Sentinel Dashboard to register the machine.
+const heartbeatPath = "/registry/machine"
+
+// heartbeatSender registers current machine to the dashboard periodically.
+// Once a heartbeat fails, the next dashboard address is used for the following heartbeats.
+type heartbeatSender struct {
+	opts     *options
+	port     int
+	hostname string
+	ip       string
+	// addrIdx is the index of the dashboard address currently in use.
+	addrIdx int
+
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+}
+
+func newHeartbeatSender(opts *options, port int) *heartbeatSender {
+	hostname, _ := os.Hostname()
+	ip := opts.heartbeatIp
+	if ip == "" {
+		ip = localIp()
+	}
+	return &heartbeatSender{
+		opts:     opts,
+		port:     port,
+		hostname: hostname,
+		ip:       ip,
+		stopChan: make(chan struct{}),
+	}
+}
+
+func (s *heartbeatSender) start() {
+	s.wg.Add(1)
+	go util.RunWithRecover(func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.opts.heartbeatInterval)
+		defer ticker.Stop()
+		for {
+			if err := s.sendHeartbeat(); err != nil {
+				logging.Warn("[CommandCenter] Fail to send heartbeat to dashboard", "err", err.Error())
+			}
+			select {
+			case <-ticker.C:
+			case <-s.stopChan:
+				return
+			}
+		}
+	})
+}
+
+func (s *heartbeatSender) stop() {
+	close(s.stopChan)
+	s.wg.Wait()
+}
+
+// heartbeatParams returns the parameters of heartbeat, which are the same as Sentinel Java.
+func (s *heartbeatSender) heartbeatParams() url.Values {
+	params := url.Values{}
+	params.Set("app", config.AppName())
+	params.Set("app_type", strconv.Itoa(int(config.AppType())))
+	params.Set("v", config.SentinelVersion)
+	params.Set("version", strconv.FormatUint(util.CurrentTimeMillis(), 10))
+	params.Set("hostname", s.hostname)
+	params.Set("ip", s.ip)
+	params.Set("port", strconv.Itoa(s.port))
+	params.Set("pid", strconv.Itoa(os.Getpid()))
+	return params
+}
+
+func (s *heartbeatSender) sendHeartbeat() error {
+	addr := s.opts.dashboards[s.addrIdx]
+	if !strings.HasPrefix(addr, "http://") && !strings.HasPrefix(addr, "https://") {
+		addr = "http://" + addr
+	}
+	resp, err := s.opts.client.PostForm(strings.TrimSuffix(addr, "/")+heartbeatPath, s.heartbeatParams())
+	if err == nil {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		_ = resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			return nil
+		}
+		err = errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	s.addrIdx = (s.addrIdx + 1) % len(s.opts.dashboards)
+	return errors.Errorf("fail to send heartbeat to %s, err: %+v", addr, err)
+}
+
+// localIp returns the first non-loopback IPv4 address of current machine.
+func localIp() string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return ""
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+			return ipNet.IP.String()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,50 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ParseEnumJSON parses the JSON value of an enum, which could be either the integer code
+// or one of the human-friendly names. The keys of names must be lower case, and the names
+// are matched case-insensitively.
+func ParseEnumJSON(data []byte, names map[string]int64) (int64, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		var name string
+		if err := json.Unmarshal(data, &name); err != nil {
+			return 0, err
+		}
+		if v, ok := names[strings.ToLower(strings.TrimSpace(name))]; ok {
+			return v, nil
+		}
+		// Also accept the quoted integer code.
+		if v, err := strconv.ParseInt(name, 10, 64); err == nil {
+			return v, nil
+		}
+		return 0, errors.Errorf("unknown enum name: %s", name)
+	}
+	var v int64
+	if err := json.Unmarshal(data, &v); err != nil {
+		return 0, err
+	}
+	return v, nil
+}
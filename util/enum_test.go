@@ -0,0 +1,53 @@
+// Copyright 1999-2020 Alibaba Group Holding Ltd.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEnumJSON(t *testing.T) {
+	names := map[string]int64{
+		"reject":     0,
+		"throttling": 1,
+	}
+	tests := []struct {
+		name    string
+		data    string
+		want    int64
+		wantErr bool
+	}{
+		{"code", `1`, 1, false},
+		{"undefined code", `5`, 5, false},
+		{"name", `"Throttling"`, 1, false},
+		{"lower case name", `"reject"`, 0, false},
+		{"quoted code", `"1"`, 1, false},
+		{"unknown name", `"WarmUp"`, 0, true},
+		{"bad type", `true`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseEnumJSON([]byte(tt.data), names)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
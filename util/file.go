@@ -16,10 +16,15 @@ package util
 
 import (
 	"errors"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 )
 
+const defaultFileMode os.FileMode = 0644
+
 func FilePosition(file *os.File) (int64, error) {
 	if file == nil {
 		return 0, errors.New("null fd when retrieving file position")
@@ -47,3 +52,49 @@ func CreateDirIfNotExists(dirname string) error {
 	}
 	return nil
 }
+
+// WriteFileAtomically writes src to a temp file in the same directory and then renames it to filePath,
+// so that the readers never observe a partially written file. If filePath is a symlink, its target is replaced.
+func WriteFileAtomically(filePath string, src []byte) (err error) {
+	// Replace the target rather than the symlink pointing to it.
+	if target, e := filepath.EvalSymlinks(filePath); e == nil {
+		filePath = target
+	}
+	mode := defaultFileMode
+	if info, e := os.Stat(filePath); e == nil {
+		mode = info.Mode().Perm()
+	}
+
+	dir, name := filepath.Split(filePath)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := ioutil.TempFile(dir, "."+name+".tmp")
+	if err != nil {
+		return fmt.Errorf("fail to create temp file, err: %+v", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(src); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("fail to write temp file, err: %+v", err)
+	}
+	if err = tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("fail to sync temp file, err: %+v", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("fail to close temp file, err: %+v", err)
+	}
+	if err = os.Chmod(tmp.Name(), mode); err != nil {
+		return fmt.Errorf("fail to chmod temp file, err: %+v", err)
+	}
+	if err = os.Rename(tmp.Name(), filePath); err != nil {
+		return fmt.Errorf("fail to rename temp file to %s, err: %+v", filePath, err)
+	}
+	return nil
+}
@@ -177,6 +177,23 @@ func TestMockTicker(t *testing.T) {
 	assert.Equal(t, ticked, 3)
 }
 
+func TestStartStopTimeTicker(t *testing.T) {
+	StartTimeTicker()
+	// Starting again is a no-op.
+	StartTimeTicker()
+	assert.True(t, CurrentTimeMillsWithTicker() > 0)
+
+	StopTimeTicker()
+	assert.Equal(t, uint64(0), CurrentTimeMillsWithTicker())
+	assert.True(t, CurrentTimeMillis() > 0)
+	// Stopping again is a no-op.
+	StopTimeTicker()
+
+	StartTimeTicker()
+	assert.True(t, CurrentTimeMillsWithTicker() > 0)
+	StopTimeTicker()
+}
+
 func BenchmarkCurrentTimeInMs(b *testing.B) {
 	StartTimeTicker()
 	currentTimeMillisDirect := func() uint64 {
@@ -15,18 +15,41 @@
 package util
 
 import (
+	"sync"
 	"sync/atomic"
 	"time"
 )
 
-var nowInMs = uint64(0)
+var (
+	nowInMs = uint64(0)
+
+	tickerMux      sync.Mutex
+	tickerStopChan chan struct{}
+	tickerWg       sync.WaitGroup
+)
 
 // StartTimeTicker starts a background task that caches current timestamp per millisecond,
 // which may provide better performance in high-concurrency scenarios.
+// It does nothing if the task has been started.
 func StartTimeTicker() {
+	tickerMux.Lock()
+	defer tickerMux.Unlock()
+	if tickerStopChan != nil {
+		return
+	}
+
 	atomic.StoreUint64(&nowInMs, uint64(time.Now().UnixNano())/UnixTimeUnitOffset)
+	stopChan := make(chan struct{})
+	tickerStopChan = stopChan
+	tickerWg.Add(1)
 	go func() {
+		defer tickerWg.Done()
 		for {
+			select {
+			case <-stopChan:
+				return
+			default:
+			}
 			now := uint64(time.Now().UnixNano()) / UnixTimeUnitOffset
 			atomic.StoreUint64(&nowInMs, now)
 			time.Sleep(time.Millisecond)
@@ -34,6 +57,21 @@ func StartTimeTicker() {
 	}()
 }
 
+// StopTimeTicker stops the task started by StartTimeTicker,
+// the current timestamp is no longer cached afterwards.
+func StopTimeTicker() {
+	tickerMux.Lock()
+	defer tickerMux.Unlock()
+	if tickerStopChan == nil {
+		return
+	}
+
+	close(tickerStopChan)
+	tickerWg.Wait()
+	tickerStopChan = nil
+	atomic.StoreUint64(&nowInMs, 0)
+}
+
 func CurrentTimeMillsWithTicker() uint64 {
 	return atomic.LoadUint64(&nowInMs)
 }